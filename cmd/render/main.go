@@ -0,0 +1,163 @@
+// Renders saved scenes as PNG or SVG thumbnails, without a browser
+//
+// Usage: render [flags] scene.json...
+package main
+
+import (
+	"flag"
+	"fmt"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/justinclift/wasmGraph4/pkg/render"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+// The size of a thumbnail, in pixels
+type size struct {
+	w, h int
+}
+
+func main() {
+	sizes := flag.String("size", "320x240", "thumbnail sizes, as a comma separated list like 160x120,640x480")
+	formats := flag.String("format", "png", "output formats, as a comma separated list of png and svg")
+	out := flag.String("out", ".", "directory to write the thumbnails to")
+	themeName := flag.String("theme", "light", "colour theme, light or dark")
+	pathLabels := flag.Bool("labels", false, "label curves along their paths (SVG only)")
+	layout := flag.Bool("layout", true, "move or hide point labels which would overlap (SVG only)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "Usage: %s [flags] scene.json...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	render.LabelLayout = *layout
+
+	szs, err := parseSizes(*sizes)
+	if err != nil {
+		fail(err)
+	}
+	var pngOut, svgOut bool
+	for _, f := range strings.Split(*formats, ",") {
+		switch strings.ToLower(strings.TrimSpace(f)) {
+		case "png":
+			pngOut = true
+		case "svg":
+			svgOut = true
+		default:
+			fail(fmt.Errorf("unknown format '%s'", f))
+		}
+	}
+	th, ok := findTheme(*themeName)
+	if !ok {
+		fail(fmt.Errorf("unknown theme '%s'", *themeName))
+	}
+
+	// Carry on with the other scenes when one can't be rendered, but still report the failure at the end
+	failed := false
+	for _, f := range flag.Args() {
+		data, err := ioutil.ReadFile(f)
+		if err == nil {
+			var s *scene.File
+			if s, err = scene.Parse(data); err == nil {
+				err = renderScene(s, strings.TrimSuffix(filepath.Base(f), filepath.Ext(f)), *out, szs, th, pngOut,
+					svgOut, *pathLabels)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", f, err)
+			failed = true
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// Prints an error and exits
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(2)
+}
+
+// Returns the theme with the given name
+func findTheme(name string) (render.Theme, bool) {
+	for _, t := range render.Themes {
+		if t.Name == strings.ToLower(name) {
+			return t, true
+		}
+	}
+	return render.Theme{}, false
+}
+
+// Parses a list of sizes such as "160x120,640x480"
+func parseSizes(s string) (szs []size, err error) {
+	for _, p := range strings.Split(s, ",") {
+		wh := strings.Split(strings.TrimSpace(p), "x")
+		if len(wh) != 2 {
+			return nil, fmt.Errorf("expected a size like 320x240, not '%s'", p)
+		}
+		var sz size
+		if sz.w, err = strconv.Atoi(wh[0]); err == nil {
+			sz.h, err = strconv.Atoi(wh[1])
+		}
+		if err != nil || sz.w <= 0 || sz.h <= 0 {
+			return nil, fmt.Errorf("expected a size like 320x240, not '%s'", p)
+		}
+		szs = append(szs, sz)
+	}
+	return
+}
+
+// Writes the thumbnails of a scene, one for each size and format.  They're named after the scene, with the size on
+// the end, e.g. "scene-320x240.png".  Parts of the scene which can't be plotted are reported, but don't stop it
+// being rendered
+func renderScene(s *scene.File, name string, dir string, szs []size, th render.Theme, pngOut bool, svgOut bool,
+	pathLabels bool) error {
+	// Light the scene the way it was saved.  Each scene starts from the default, so one scene's light doesn't carry
+	// over to the next
+	render.Light = render.DefaultLight
+	if len(s.Light) == 3 {
+		if err := render.SetLight(s.Light[0], s.Light[1], s.Light[2]); err != nil {
+			return err
+		}
+	}
+	for i, sz := range szs {
+		// Lay the graph out the way the page does, with the origin in the middle and 30 units across the short side
+		w, h := float64(sz.w), float64(sz.h)
+		unit := math.Min(w, h) / 30
+		objs, err := s.Build(scene.DefaultMinX, scene.DefaultMaxX, scene.DefaultStep, unit)
+		if err != nil && i == 0 {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err) // The same problems come up for every size
+		}
+		base := filepath.Join(dir, fmt.Sprintf("%s-%dx%d", name, sz.w, sz.h))
+		if svgOut {
+			svg := render.SVG(objs, s.ViewMatrix(), th, w, h, w/2, h/2, unit, pathLabels, true)
+			if err = ioutil.WriteFile(base+".svg", []byte(svg), 0644); err != nil {
+				return err
+			}
+		}
+		if pngOut {
+			f, err := os.Create(base + ".png")
+			if err != nil {
+				return err
+			}
+			err = png.Encode(f, render.Image(objs, s.ViewMatrix(), th, w, h, w/2, h/2, unit))
+			if cErr := f.Close(); err == nil {
+				err = cErr
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+const (
+	activityMax   = 1000            // Most entries kept in the activity log, the oldest being dropped first
+	activityShown = 10              // Number of the most recent entries shown in the info panel
+	activityMerge = 2 * time.Second // Repeats of the same entry within this long of each other are counted, not added
+)
+
+// Something the user did, or a change to the scene
+type activityEntry struct {
+	Time  time.Time
+	Kind  string // "key", "mouse", "api", or "scene"
+	Text  string
+	Count int `json:",omitempty"` // Number of times it happened in a row, when more than once
+}
+
+var (
+	activity []activityEntry // Oldest first
+)
+
+// Lines for the Activity section of the info panel, showing the most recent entries, newest first
+func activityLines() (l []panelLine) {
+	l = append(l, panelLine{text: fmt.Sprintf("%d entries.  Save as JSON", len(activity)), colour: theme.Link,
+		action: saveActivity})
+	for i := len(activity) - 1; i >= 0 && i >= len(activity)-activityShown; i-- {
+		a := activity[i]
+		text := fmt.Sprintf("%s  %s", a.Time.Format("15:04:05"), a.Text)
+		if a.Count > 1 {
+			text += fmt.Sprintf(" (x%d)", a.Count)
+		}
+		l = append(l, panelLine{text: text, font: "12px sans-serif", indent: 10})
+	}
+	return
+}
+
+// Adds an entry to the activity log.  One the same as the last, straight after it, counts as a repeat of that instead
+func logActivity(kind string, format string, a ...interface{}) {
+	now := time.Now()
+	text := fmt.Sprintf(format, a...)
+	if n := len(activity); n > 0 {
+		last := &activity[n-1]
+		if last.Kind == kind && last.Text == text && now.Sub(last.Time) < activityMerge {
+			last.Time = now
+			if last.Count == 0 {
+				last.Count = 1
+			}
+			last.Count++
+			return
+		}
+	}
+	if len(activity) >= activityMax {
+		activity = append(activity[:0], activity[1:]...)
+	}
+	activity = append(activity, activityEntry{Time: now, Kind: kind, Text: text})
+}
+
+// Offers the activity log as a JSON file download, for reviewing a session or attaching to a bug report
+func saveActivity() {
+	data, err := json.MarshalIndent(activity, "", "  ")
+	if err != nil {
+		opText = fmt.Sprintf("Couldn't save the activity log: %v", err)
+		return
+	}
+	downloadFile("wasmGraph-activity.json", "application/json", string(data))
+}
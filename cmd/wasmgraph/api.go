@@ -0,0 +1,941 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/render"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+// The callbacks registered for the javascript API, kept so they can be released
+var apiCalls []js.Callback
+
+// Exposes a "wasmGraph" object on the javascript global object, so host pages can drive the visualisation
+// programmatically.  eg:
+//
+//	wasmGraph.addObject('{"Name": "tri", "C": "red", "P": [{"X": 0, "Y": 0}, {"X": 1, "Y": 0}, {"X": 0, "Y": 1}],
+//		"S": [[0, 1, 2]]}')
+//	wasmGraph.rotate(0, 45, 0)
+//	wasmGraph.clear()
+func registerAPI() {
+	api := js.Global().Get("Object").New()
+	apiFunc(api, "addDerived", apiAddDerived)
+	apiFunc(api, "addDistribution", apiAddDistribution)
+	apiFunc(api, "addEquation", apiAddEquation)
+	apiFunc(api, "addMarker", apiAddMarker)
+	apiFunc(api, "addObject", apiAddObject)
+	apiFunc(api, "arcball", apiArcball)
+	apiFunc(api, "checkRendering", apiCheckRendering)
+	apiFunc(api, "clear", apiClear)
+	apiFunc(api, "compare", apiCompare)
+	apiFunc(api, "compute", apiCompute)
+	apiFunc(api, "cursor", apiCursor)
+	apiFunc(api, "domain", apiDomain)
+	apiFunc(api, "downsample", apiDownsample)
+	apiFunc(api, "filterTag", apiFilterTag)
+	apiFunc(api, "fit", apiFit)
+	apiFunc(api, "gallery", apiGallery)
+	apiFunc(api, "generate", apiGenerate)
+	apiFunc(api, "group", apiGroup)
+	apiFunc(api, "importExpressions", apiImportExpressions)
+	apiFunc(api, "integrate", apiIntegrate)
+	apiFunc(api, "labelLayout", apiLabelLayout)
+	apiFunc(api, "light", apiLight)
+	apiFunc(api, "link", apiLink)
+	apiFunc(api, "loadScene", apiLoadScene)
+	apiFunc(api, "loadTimeline", apiLoadTimeline)
+	apiFunc(api, "locale", apiLocale)
+	apiFunc(api, "monteCarlo", apiMonteCarlo)
+	apiFunc(api, "onDraw", apiOnDraw)
+	apiFunc(api, "pauseTimeline", apiPauseTimeline)
+	apiFunc(api, "playTimeline", apiPlayTimeline)
+	apiFunc(api, "preset", apiPreset)
+	apiFunc(api, "removeDerived", apiRemoveDerived)
+	apiFunc(api, "removeDistribution", apiRemoveDistribution)
+	apiFunc(api, "removeEquation", apiRemoveEquation)
+	apiFunc(api, "removeMarker", apiRemoveMarker)
+	apiFunc(api, "removeObject", apiRemoveObject)
+	apiFunc(api, "renderMode", apiRenderMode)
+	apiFunc(api, "renderScale", apiRenderScale)
+	apiFunc(api, "replaceObject", apiReplaceObject)
+	apiFunc(api, "rotate", apiRotate)
+	apiFunc(api, "saveActivity", apiSaveActivity)
+	apiFunc(api, "saveHTML", apiSaveHTML)
+	apiFunc(api, "sample", apiSample)
+	apiFunc(api, "saveScene", apiSaveScene)
+	apiFunc(api, "scale", apiScale)
+	apiFunc(api, "seekTimeline", apiSeekTimeline)
+	apiFunc(api, "setParam", apiSetParam)
+	apiFunc(api, "setSpeed", apiSetSpeed)
+	apiFunc(api, "setTransform", apiSetTransform)
+	apiFunc(api, "setVisible", apiSetVisible)
+	apiFunc(api, "shareScene", apiShareScene)
+	apiFunc(api, "spriteSheet", apiSpriteSheet)
+	apiFunc(api, "stopTimeline", apiStopTimeline)
+	apiFunc(api, "texElement", apiTeXElement)
+	apiFunc(api, "translate", apiTranslate)
+	apiFunc(api, "ungroup", apiUngroup)
+	apiFunc(api, "view", apiView)
+	initProjection(api)
+	js.Global().Set("wasmGraph", api)
+}
+
+// Releases the javascript API callbacks
+func releaseAPI() {
+	for _, c := range apiCalls {
+		c.Release()
+	}
+	js.Global().Set("wasmGraph", js.Undefined())
+}
+
+// Adds a function to the javascript API object.  API calls count as activity, so the full frame rate resumes.  Scene
+// changes queued by earlier calls are made first, so each call sees the ones before it
+func apiFunc(api js.Value, name string, fn func(args []js.Value)) {
+	c := js.NewCallback(func(args []js.Value) {
+		markActivity()
+		applySceneChanges()
+		logActivity("api", "wasmGraph.%s(%s)", name, apiArgsText(args))
+		fn(args)
+	})
+	apiCalls = append(apiCalls, c)
+	api.Set(name, c)
+}
+
+// Returns a short description of an API call's arguments, for the activity log.  Functions and objects (such as
+// elements) are only named, and long strings are cut short
+func apiArgsText(args []js.Value) string {
+	var l []string
+	for _, a := range args {
+		switch a.Type() {
+		case js.TypeString:
+			s := a.String()
+			if len(s) > 40 {
+				s = s[:40] + "…"
+			}
+			l = append(l, strconv.Quote(s))
+		case js.TypeFunction:
+			l = append(l, "function")
+		case js.TypeObject:
+			l = append(l, "object")
+		default:
+			l = append(l, a.String())
+		}
+	}
+	return strings.Join(l, ", ")
+}
+
+// Reports a problem with an API call on the javascript console
+func apiError(name string, err error) {
+	logError("wasmGraph.%s: %v", name, err)
+}
+
+// Returns the first n arguments as numbers, or an error if any are missing or aren't numbers
+func floatArgs(args []js.Value, n int) ([]float64, error) {
+	if len(args) < n {
+		return nil, fmt.Errorf("expected %d arguments, but got %d", n, len(args))
+	}
+	var f []float64
+	for i := 0; i < n; i++ {
+		if args[i].Type() != js.TypeNumber {
+			return nil, fmt.Errorf("argument %d isn't a number", i+1)
+		}
+		f = append(f, args[i].Float())
+	}
+	return f, nil
+}
+
+// Returns the name of the object or group an operation is for, from an optional argument.  Empty means everything
+func targetArg(args []js.Value, i int) (string, error) {
+	if len(args) <= i || args[i].Type() != js.TypeString {
+		return "", nil
+	}
+	t := args[i].String()
+	if t == "" || sceneRoot.Find(t) == nil {
+		return "", fmt.Errorf("there's no object or group called '%s'", t)
+	}
+	return t, nil
+}
+
+// wasmGraph.addDerived(curve) - works out a new curve from existing ones, such as "c1 = f1 - f2" or "abs(f1')".  The
+// curves are resampled on a common grid across the range of x they share
+func apiAddDerived(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("addDerived", fmt.Errorf("expected a derived curve string"))
+		return
+	}
+	_, err := addDerived(args[0].String())
+	if err != nil {
+		apiError("addDerived", err)
+	}
+}
+
+// wasmGraph.addDistribution(distribution) - plots a probability distribution such as "normal(0, 1)", optionally
+// shading a range and showing its probability, eg "binomial(10, 0.5); 3..6"
+func apiAddDistribution(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("addDistribution", fmt.Errorf("expected a distribution string"))
+		return
+	}
+	_, err := addDistribution(args[0].String())
+	if err != nil {
+		apiError("addDistribution", err)
+	}
+}
+
+// wasmGraph.addEquation(equation) - plots an equation such as "y = x^2", along with its derivative
+func apiAddEquation(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("addEquation", fmt.Errorf("expected an equation string"))
+		return
+	}
+	_, err := addEquation(args[0].String())
+	if err != nil {
+		apiError("addEquation", err)
+	}
+}
+
+// wasmGraph.addMarker(name, x, y, z, content) - keeps an HTML element over the point (x, y, z) on the graph, moving
+// it as the graph is rotated, zoomed, and panned.  content is either an element from the page, or a string of HTML to
+// fill a new one with.  The elements have the "wasmGraph-marker" class, for styling
+func apiAddMarker(args []js.Value) {
+	if len(args) < 5 || args[0].Type() != js.TypeString {
+		apiError("addMarker", fmt.Errorf("expected a name, the x, y, and z co-ordinates, and an element or HTML"))
+		return
+	}
+	f, err := floatArgs(args[1:], 3)
+	if err == nil {
+		err = addMarker(args[0].String(), f[0], f[1], f[2], args[4])
+	}
+	if err != nil {
+		apiError("addMarker", err)
+	}
+}
+
+// wasmGraph.addObject(json) - adds an object, given as a JSON string, to the world space.  It appears from the next
+// frame, though later API calls see it straight away
+func apiAddObject(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("addObject", fmt.Errorf("expected a JSON string describing the object"))
+		return
+	}
+	var ob Object
+	err := json.Unmarshal([]byte(args[0].String()), &ob)
+	if err != nil {
+		apiError("addObject", err)
+		return
+	}
+	if err = AddObject(ob); err != nil {
+		apiError("addObject", err)
+	}
+}
+
+// wasmGraph.arcball(on) - turns arcball rotation on or off.  While it's on, dragging the graph with the left mouse
+// button turns it as if it were a ball under the pointer
+func apiArcball(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeBoolean {
+		apiError("arcball", fmt.Errorf("expected true or false"))
+		return
+	}
+	arcballOn = args[0].Bool()
+}
+
+// wasmGraph.checkRendering() - draws the next frame through both the canvas and SVG backends, reporting any
+// differences between them on the javascript console
+func apiCheckRendering(args []js.Value) {
+	checkBackends = true
+}
+
+// wasmGraph.clear() - removes everything except the axes
+func apiClear(args []js.Value) {
+	clearObjects()
+}
+
+// wasmGraph.compare(name) - plots a numerical derivative of an equation next to its symbolic one, shading the error
+// between them.  Calling it with no arguments removes the comparison again
+func apiCompare(args []js.Value) {
+	if len(args) == 0 {
+		clearComparison()
+		return
+	}
+	if args[0].Type() != js.TypeString {
+		apiError("compare", fmt.Errorf("expected the name of an equation"))
+		return
+	}
+	err := compareDerivative(args[0].String())
+	if err != nil {
+		apiError("compare", err)
+	}
+}
+
+// wasmGraph.compute(task, params, callback) - runs one of the compute worker's tasks, "fft", "fit", "isosurface", or
+// "sample", on an object of named parameters, then calls callback(result, error).  The result holds Float64Arrays,
+// such as "coeffs" and "y" for a polynomial fit of "x" and "y"
+func apiCompute(args []js.Value) {
+	if len(args) < 3 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeObject ||
+		args[2].Type() != js.TypeFunction {
+		apiError("compute", fmt.Errorf("expected a task name, an object of parameters, and a callback"))
+		return
+	}
+	callback := args[2]
+	req := js.Global().Get("Object").Call("assign", js.ValueOf(map[string]interface{}{}), args[1])
+	offload(args[0].String(), req, func(reply js.Value) {
+		if e := reply.Get("error"); e != js.Undefined() {
+			callback.Invoke(js.Null(), e)
+			return
+		}
+		callback.Invoke(reply, js.Null())
+	})
+}
+
+// wasmGraph.cursor(x) - shows the cursor line pinned at the given x, with where each curve crosses it.  Handy for
+// keeping it in step with other plots on the page.  Calling it with no arguments hides it again
+func apiCursor(args []js.Value) {
+	if len(args) == 0 {
+		cursorOn, cursorPinned = false, false
+		return
+	}
+	f, err := floatArgs(args, 1)
+	if err != nil {
+		apiError("cursor", err)
+		return
+	}
+	pinCursor(f[0])
+}
+
+// wasmGraph.domain(min, max, step) - sets the range of x the equations are plotted over, and the step along x they're
+// sampled at.  The step is optional, with 0 or leaving it out sampling them adaptively
+func apiDomain(args []js.Value) {
+	f, err := floatArgs(args, 2)
+	st := 0.0
+	if err == nil && len(args) > 2 && args[2] != js.Undefined() {
+		if args[2].Type() != js.TypeNumber {
+			err = fmt.Errorf("the step isn't a number")
+		} else {
+			st = args[2].Float()
+		}
+	}
+	if err == nil {
+		err = setDomain(f[0], f[1], st)
+	}
+	if err != nil {
+		apiError("domain", err)
+	}
+}
+
+// wasmGraph.downsample(name, mode) - sets how an imported data series is reduced for drawing: "envelope" (the
+// default) draws the mean with a shaded band out to the lowest and highest points, "minmax" keeps the lowest and
+// highest points for each pixel across, "lttb" keeps the points which change the shape most, "nth" keeps every Nth
+// point, and "none" draws them all
+func apiDownsample(args []js.Value) {
+	if len(args) < 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString {
+		apiError("downsample", fmt.Errorf("expected the name of a data series and a downsampling mode"))
+		return
+	}
+	if err := setDownsample(args[0].String(), args[1].String()); err != nil {
+		apiError("downsample", err)
+	}
+}
+
+// wasmGraph.filterTag(tag, style) - hides, recolours, or restyles the lines of the objects with a tag, eg
+// wasmGraph.filterTag("derivatives", {hidden: true}), wasmGraph.filterTag("imported", {colour: "grey"}), or
+// wasmGraph.filterTag("derivatives", {dash: [6, 4], width: 1}).  Leaving out the style shows them normally again
+func apiFilterTag(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("filterTag",
+			fmt.Errorf("expected a tag, and optionally an object with hidden, colour, width, and dash"))
+		return
+	}
+	var s tagStyle
+	if len(args) > 1 && args[1].Type() == js.TypeObject {
+		h := args[1].Get("hidden")
+		s.hidden = h.Type() == js.TypeBoolean && h.Bool()
+		if c := args[1].Get("colour"); c.Type() == js.TypeString {
+			s.colour = c.String()
+		}
+		if w := args[1].Get("width"); w.Type() == js.TypeNumber {
+			s.width = w.Float()
+		}
+		if d := args[1].Get("dash"); d.Type() == js.TypeObject {
+			s.dash = []float64{}
+			for i := 0; i < d.Length(); i++ {
+				s.dash = append(s.dash, d.Index(i).Float())
+			}
+		}
+	}
+	if err := scene.CheckLineStyle(s.width, 0, s.dash); err != nil {
+		apiError("filterTag", err)
+		return
+	}
+	setTagStyle(args[0].String(), s)
+}
+
+// wasmGraph.fit() - moves and zooms the graph so everything except the axes fills the graph area, such as after loading
+// data far from the origin
+func apiFit(args []js.Value) {
+	fitToView()
+}
+
+// wasmGraph.gallery(name) - replaces the graph with one from the gallery, such as "Damped oscillation" or "Saddle"
+func apiGallery(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("gallery", fmt.Errorf("expected the name of a graph in the gallery"))
+		return
+	}
+	if err := openGallery(args[0].String()); err != nil {
+		apiError("gallery", err)
+	}
+}
+
+// wasmGraph.generate(type, params, callback) - adds an object made by one of the generators, such as "surface",
+// "field", "histogram", or "isosurface", from an object of named parameters.  The optional callback is called with the
+// new object's name, so it can be removed or changed later.  Isosurfaces are worked out in the compute worker, so are
+// added (and the callback called) once it's finished
+func apiGenerate(args []js.Value) {
+	if len(args) < 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeObject {
+		apiError("generate", fmt.Errorf("expected a generator name and an object of parameters"))
+		return
+	}
+	done := func(name string, err error) {
+		if err != nil {
+			apiError("generate", err)
+			return
+		}
+		if len(args) > 2 && args[2].Type() == js.TypeFunction {
+			args[2].Invoke(name)
+		}
+	}
+	if strings.ToLower(args[0].String()) == "isosurface" {
+		addIsosurface(jsParams{V: args[1]}, done)
+		return
+	}
+	done(addGenerated(args[0].String(), jsParams{V: args[1]}))
+}
+
+// wasmGraph.group(name, members) - gathers the named objects (or groups) into a new group, so setTransform can move
+// them together.  eg wasmGraph.group("frame", ["axes", "f1"])
+func apiGroup(args []js.Value) {
+	if len(args) < 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeObject {
+		apiError("group", fmt.Errorf("expected a group name and an array of object names"))
+		return
+	}
+	var members []string
+	for i := 0; i < args[1].Length(); i++ {
+		members = append(members, args[1].Index(i).String())
+	}
+	if err := groupObjects(args[0].String(), members); err != nil {
+		apiError("group", err)
+	}
+}
+
+// wasmGraph.importExpressions(text) - plots each line of a list of expressions, as exported by Desmos or GeoGebra, as
+// its own equation
+func apiImportExpressions(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("importExpressions", fmt.Errorf("expected the expressions as a string, one per line"))
+		return
+	}
+	_, problems := importExpressions(args[0].String())
+	for _, p := range problems {
+		apiError("importExpressions", fmt.Errorf("%s", p))
+	}
+}
+
+// wasmGraph.integrate(name, a, b) - shades the area under an equation from x = a to x = b, and shows its integral.
+// Calling it with no arguments removes the shaded area again
+func apiIntegrate(args []js.Value) {
+	if len(args) == 0 {
+		clearIntegral()
+		return
+	}
+	if args[0].Type() != js.TypeString {
+		apiError("integrate", fmt.Errorf("expected the name of an equation"))
+		return
+	}
+	f, err := floatArgs(args[1:], 2)
+	if err == nil {
+		err = showIntegral(args[0].String(), f[0], f[1])
+	}
+	if err != nil {
+		apiError("integrate", err)
+	}
+}
+
+// wasmGraph.labelLayout(on) - turns the label layout on or off.  When it's on, point labels are moved or hidden so they
+// don't overlap each other, and moved off the curves where they can be
+func apiLabelLayout(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeBoolean {
+		apiError("labelLayout", fmt.Errorf("expected true or false"))
+		return
+	}
+	render.LabelLayout = args[0].Bool()
+}
+
+// wasmGraph.light(x, y, z) - sets the direction the light shining on shaded surfaces comes from, relative to the
+// viewer, with x to the right, y up, and z towards them.  No arguments puts it back to the default
+func apiLight(args []js.Value) {
+	if len(args) == 0 {
+		render.Light = render.DefaultLight
+		return
+	}
+	f, err := floatArgs(args, 3)
+	if err == nil {
+		err = render.SetLight(f[0], f[1], f[2])
+	}
+	if err != nil {
+		apiError("light", err)
+	}
+}
+
+// wasmGraph.link(mode) - links the view with other plots on the page, or in other tabs, so moving one moves them all.
+// "zoompan" follows their zoom and panning, "all" their rotation too, and "off" unlinks this plot
+func apiLink(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("link", fmt.Errorf("expected \"off\", \"zoompan\", or \"all\""))
+		return
+	}
+	if err := setLink(args[0].String()); err != nil {
+		apiError("link", err)
+	}
+}
+
+// wasmGraph.loadScene(json) - replaces everything plotted with a scene saved by saveScene.  Scenes saved by older
+// versions are upgraded as they load, as are plain object JSON (or lists of objects) as accepted by addObject
+func apiLoadScene(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("loadScene", fmt.Errorf("expected a JSON string describing the scene"))
+		return
+	}
+	s, err := scene.Parse([]byte(args[0].String()))
+	if err == nil {
+		err = loadScene(s)
+	}
+	if err != nil {
+		apiError("loadScene", err)
+	}
+}
+
+// wasmGraph.loadTimeline(json) - loads a keyframe timeline, paused at its start, replacing any earlier one.  Each
+// keyframe is an operation starting "At" milliseconds in and taking "Ms" milliseconds, eg
+// '{"Loop": true, "Keyframes": [{"At": 0, "Op": "rotate", "Ms": 1000, "Y": 90}]}'
+func apiLoadTimeline(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("loadTimeline", fmt.Errorf("expected a JSON string describing the timeline"))
+		return
+	}
+	keys, loop, err := parseTimeline([]byte(args[0].String()))
+	if err != nil {
+		apiError("loadTimeline", err)
+		return
+	}
+	loadTimeline(keys, loop)
+}
+
+// wasmGraph.locale(code) - sets how numbers are shown, from a language code such as "en" or "de".  Numbers typed in
+// can use either a dot or a comma for the decimal separator whatever the locale
+func apiLocale(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("locale", fmt.Errorf("expected a language code"))
+		return
+	}
+	if err := setLocale(args[0].String()); err != nil {
+		apiError("locale", err)
+	}
+}
+
+// wasmGraph.monteCarlo(name, a, b) - starts a Monte Carlo demo estimating the area under an equation from x = a to
+// x = b.  Calling it with no arguments stops the demo
+func apiMonteCarlo(args []js.Value) {
+	if len(args) == 0 {
+		clearMonteCarlo()
+		return
+	}
+	if args[0].Type() != js.TypeString {
+		apiError("monteCarlo", fmt.Errorf("expected the name of an equation"))
+		return
+	}
+	f, err := floatArgs(args[1:], 2)
+	if err == nil {
+		err = startMonteCarlo(args[0].String(), f[0], f[1])
+	}
+	if err != nil {
+		apiError("monteCarlo", err)
+	}
+}
+
+// wasmGraph.onDraw(callback) - calls callback(ctx, view) each frame, after the graph is drawn, so the page can draw
+// its own overlays.  ctx is the canvas 2D context clipped to the graph area, and view has the projection: "matrix"
+// (the world matrix, row by row), "centerX", "centerY", "step", "zoom", the graph area's "left", "top", "width", and
+// "height", and the frame "time".  Calling it with no arguments removes the callback
+func apiOnDraw(args []js.Value) {
+	if len(args) == 0 || args[0] == js.Null() {
+		drawHook = js.Undefined()
+		return
+	}
+	if args[0].Type() != js.TypeFunction {
+		apiError("onDraw", fmt.Errorf("expected a function"))
+		return
+	}
+	if safeMode {
+		apiError("onDraw", fmt.Errorf("draw hooks are off in safe mode"))
+		return
+	}
+	drawHook = args[0]
+}
+
+// wasmGraph.pauseTimeline() - pauses the timeline where it is
+func apiPauseTimeline(args []js.Value) {
+	pauseTimeline()
+}
+
+// wasmGraph.playTimeline() - plays the timeline from where it is, or from the start if it's finished
+func apiPlayTimeline(args []js.Value) {
+	if tl == nil {
+		apiError("playTimeline", fmt.Errorf("there's no timeline loaded"))
+		return
+	}
+	playTimeline()
+}
+
+// wasmGraph.preset(name) - plays a named animation preset, such as "turntable", "wobble", or "zoom pulse"
+func apiPreset(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("preset", fmt.Errorf("expected the name of an animation preset"))
+		return
+	}
+	err := playPreset(args[0].String())
+	if err != nil {
+		apiError("preset", err)
+	}
+}
+
+// wasmGraph.removeDerived(name) - removes a derived curve, given its name (eg "c1")
+func apiRemoveDerived(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("removeDerived", fmt.Errorf("expected the name of a derived curve"))
+		return
+	}
+	err := removeDerived(args[0].String())
+	if err != nil {
+		apiError("removeDerived", err)
+	}
+}
+
+// wasmGraph.removeDistribution(name) - removes a plotted distribution, given its name (eg "d1")
+func apiRemoveDistribution(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("removeDistribution", fmt.Errorf("expected the name of a distribution"))
+		return
+	}
+	err := removeDistribution(args[0].String())
+	if err != nil {
+		apiError("removeDistribution", err)
+	}
+}
+
+// wasmGraph.removeEquation(name) - removes a plotted equation, given its name (eg "f2")
+func apiRemoveEquation(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("removeEquation", fmt.Errorf("expected the name of an equation"))
+		return
+	}
+	err := removeEquation(args[0].String())
+	if err != nil {
+		apiError("removeEquation", err)
+	}
+}
+
+// wasmGraph.removeMarker(name) - removes an HTML marker again
+func apiRemoveMarker(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("removeMarker", fmt.Errorf("expected the name of a marker"))
+		return
+	}
+	if !removeMarker(args[0].String()) {
+		apiError("removeMarker", fmt.Errorf("no marker named '%s'", args[0].String()))
+	}
+}
+
+// wasmGraph.removeObject(name) - removes an object or group, along with anything grouped under it
+func apiRemoveObject(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("removeObject", fmt.Errorf("expected the name of an object"))
+		return
+	}
+	if err := RemoveObject(args[0].String()); err != nil {
+		apiError("removeObject", err)
+	}
+}
+
+// wasmGraph.renderMode(mode) - draws everything but the axes as "points", "wireframe" edges, or "solid" filled
+// surfaces.  No argument (or "") goes back to each object's own Mode
+func apiRenderMode(args []js.Value) {
+	mode := ""
+	if len(args) > 0 && args[0].Type() == js.TypeString {
+		mode = args[0].String()
+	}
+	if err := setRenderMode(mode); err != nil {
+		apiError("renderMode", err)
+	}
+}
+
+// wasmGraph.renderScale(percent) - sets the render resolution, from 50% to 200% of the screen's own.  Lower is quicker
+// to draw, but blurrier
+func apiRenderScale(args []js.Value) {
+	f, err := floatArgs(args, 1)
+	if err == nil {
+		err = setRenderScale(f[0])
+	}
+	if err != nil {
+		apiError("renderScale", err)
+	}
+}
+
+// wasmGraph.replaceObject(json) - replaces the object with the same name, keeping its transform and anything grouped
+// under it, or adds it if there isn't one.  The object is given as JSON, as for addObject
+func apiReplaceObject(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("replaceObject", fmt.Errorf("expected a JSON string describing the object"))
+		return
+	}
+	var ob Object
+	err := json.Unmarshal([]byte(args[0].String()), &ob)
+	if err == nil {
+		err = ReplaceObject(ob)
+	}
+	if err != nil {
+		apiError("replaceObject", err)
+	}
+}
+
+// wasmGraph.rotate(x, y, z, target) - rotates the world space by the given number of degrees around each axis.  Given
+// the name of an object or group as target, just it is rotated
+func apiRotate(args []js.Value) {
+	f, err := floatArgs(args, 3)
+	var target string
+	if err == nil {
+		target, err = targetArg(args, 3)
+	}
+	if err != nil {
+		apiError("rotate", err)
+		return
+	}
+	queueOperation(Operation{op: ROTATE, t: 50, f: 12, X: f[0], Y: f[1], Z: f[2], target: target})
+}
+
+// wasmGraph.sample(equation, min, max, n, callback) - samples an equation at n evenly spaced values of x from min to
+// max (or of t, for parametric curves) in the compute worker, then calls callback(result, error).  The result has
+// the co-ordinates as "x", "y", and for parametric curves "z" Float64Arrays, with NaN where the equation isn't defined
+func apiSample(args []js.Value) {
+	if len(args) < 5 || args[0].Type() != js.TypeString || args[4].Type() != js.TypeFunction {
+		apiError("sample", fmt.Errorf("expected an equation string, min, max, the number of samples, and a callback"))
+		return
+	}
+	f, err := floatArgs(args[1:], 3)
+	if err != nil {
+		apiError("sample", err)
+		return
+	}
+	callback := args[4]
+	params := map[string]interface{}{"src": args[0].String(), "min": f[0], "max": f[1], "n": f[2]}
+	offload("sample", js.ValueOf(params), func(reply js.Value) {
+		if e := reply.Get("error"); e != js.Undefined() {
+			callback.Invoke(js.Null(), e)
+			return
+		}
+		callback.Invoke(reply, js.Null())
+	})
+}
+
+// wasmGraph.saveActivity() - saves the activity log, of what's been done and changed since the page loaded, as a JSON
+// file
+func apiSaveActivity(args []js.Value) {
+	saveActivity()
+}
+
+// wasmGraph.saveHTML() - saves the current scene as a single HTML page with the app bundled in, which opens offline
+func apiSaveHTML(args []js.Value) {
+	if err := saveHTML(); err != nil {
+		apiError("saveHTML", err)
+	}
+}
+
+// wasmGraph.saveScene() - saves the equations, distributions, objects, and view as a JSON file
+func apiSaveScene(args []js.Value) {
+	data, err := json.MarshalIndent(saveScene(), "", "  ")
+	if err != nil {
+		apiError("saveScene", err)
+		return
+	}
+	downloadFile("wasmGraph-scene.json", "application/json", string(data))
+}
+
+// wasmGraph.scale(x, y, z, target) - scales the world space by the given factors, or just the object or group named
+// by target
+func apiScale(args []js.Value) {
+	f, err := floatArgs(args, 3)
+	var target string
+	if err == nil {
+		target, err = targetArg(args, 3)
+	}
+	if err != nil {
+		apiError("scale", err)
+		return
+	}
+	queueOperation(Operation{op: SCALE, t: 50, f: 12, X: f[0], Y: f[1], Z: f[2], target: target})
+}
+
+// wasmGraph.seekTimeline(ms) - moves the graph to the given number of milliseconds into the timeline
+func apiSeekTimeline(args []js.Value) {
+	f, err := floatArgs(args, 1)
+	if err == nil && tl == nil {
+		err = fmt.Errorf("there's no timeline loaded")
+	}
+	if err != nil {
+		apiError("seekTimeline", err)
+		return
+	}
+	seekTimeline(f[0])
+}
+
+// wasmGraph.setParam(equation, name, value) - sets one of an equation's parameters, such as the a in y = a·x³ + b,
+// plotting the equation again with it
+func apiSetParam(args []js.Value) {
+	if len(args) < 3 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeString ||
+		args[2].Type() != js.TypeNumber {
+		apiError("setParam", fmt.Errorf("expected the name of an equation, the name of a parameter, and a number"))
+		return
+	}
+	e, ok := findEquation(args[0].String())
+	if !ok {
+		apiError("setParam", fmt.Errorf("there's no equation called '%s'", args[0].String()))
+		return
+	}
+	if err := setParam(e, args[1].String(), args[2].Float()); err != nil {
+		apiError("setParam", err)
+	}
+}
+
+// wasmGraph.setSpeed(multiplier) - speeds up (above 1) or slows down (below 1) every animated operation, from 0.125
+// to 8 times its normal speed
+func apiSetSpeed(args []js.Value) {
+	f, err := floatArgs(args, 1)
+	if err == nil {
+		err = setAnimSpeed(f[0])
+	}
+	if err != nil {
+		apiError("setSpeed", err)
+	}
+}
+
+// wasmGraph.setTransform(name, matrix) - sets the transform of an object or group within its parent, as 16 numbers of
+// a 4x4 matrix in row order.  Anything grouped under it moves along with it.  The tick marks are under the axes, so
+// setTransform("axes", ...) moves both, and setTransform("ticks", ...) just the tick marks
+func apiSetTransform(args []js.Value) {
+	if len(args) < 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeObject || args[1].Length() != 16 {
+		apiError("setTransform", fmt.Errorf("expected a name and an array of 16 numbers"))
+		return
+	}
+	m := make(matrix, 16)
+	for i := range m {
+		m[i] = args[1].Index(i).Float()
+	}
+	if err := setObjectTransform(args[0].String(), m); err != nil {
+		apiError("setTransform", err)
+	}
+}
+
+// wasmGraph.setVisible(name, visible) - hides or shows an object without removing it.  "grid" is the background grid,
+// and "gridxy", "gridxz", and "gridyz" the grid planes
+func apiSetVisible(args []js.Value) {
+	if len(args) < 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeBoolean {
+		apiError("setVisible", fmt.Errorf("expected a name and true or false"))
+		return
+	}
+	if err := setVisible(args[0].String(), args[1].Bool()); err != nil {
+		apiError("setVisible", err)
+	}
+}
+
+// wasmGraph.shareScene() - puts a share link for the current scene in the address bar, and on the javascript console
+func apiShareScene(args []js.Value) {
+	link, err := sceneLink()
+	if err != nil {
+		apiError("shareScene", err)
+		return
+	}
+	js.Global().Get("history").Call("replaceState", js.Null(), "", link)
+	js.Global().Get("console").Call("log", link)
+}
+
+// wasmGraph.spriteSheet(frames) - saves a PNG sprite sheet of the graph rotated 360° around the Y axis
+func apiSpriteSheet(args []js.Value) {
+	f, err := floatArgs(args, 1)
+	if err != nil {
+		apiError("spriteSheet", err)
+		return
+	}
+	err = saveSpriteSheet(int(f[0]))
+	if err != nil {
+		apiError("spriteSheet", err)
+	}
+}
+
+// wasmGraph.stopTimeline() - unloads the timeline, leaving the graph where it is
+func apiStopTimeline(args []js.Value) {
+	stopTimeline()
+}
+
+// wasmGraph.texElement(element) - keeps the element filled with the equations and their derivatives, each in its own
+// div with the "wasmGraph-equation" class.  They're typeset by KaTeX when the page has loaded it, with proper
+// fractions, and as plain text otherwise.  No arguments stops
+func apiTeXElement(args []js.Value) {
+	el := js.Undefined()
+	if len(args) > 0 {
+		el = args[0]
+	}
+	if err := setTeXElement(el); err != nil {
+		apiError("texElement", err)
+	}
+}
+
+// wasmGraph.translate(x, y, z, target) - moves the world space by the given amounts, or just the object or group
+// named by target
+func apiTranslate(args []js.Value) {
+	f, err := floatArgs(args, 3)
+	var target string
+	if err == nil {
+		target, err = targetArg(args, 3)
+	}
+	if err != nil {
+		apiError("translate", err)
+		return
+	}
+	queueOperation(Operation{op: TRANSLATE, t: 50, f: 12, X: f[0], Y: f[1], Z: f[2], target: target})
+}
+
+// wasmGraph.ungroup(name) - removes a group made by group, keeping the objects in it where they are
+func apiUngroup(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("ungroup", fmt.Errorf("expected the name of a group"))
+		return
+	}
+	if err := ungroupObjects(args[0].String()); err != nil {
+		apiError("ungroup", err)
+	}
+}
+
+// wasmGraph.view(name) - turns the graph to a standard view: "front", "top", "side", or "isometric"
+func apiView(args []js.Value) {
+	if len(args) < 1 || args[0].Type() != js.TypeString {
+		apiError("view", fmt.Errorf("expected the name of a view"))
+		return
+	}
+	err := showView(args[0].String())
+	if err != nil {
+		apiError("view", err)
+	}
+}
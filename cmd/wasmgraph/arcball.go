@@ -0,0 +1,115 @@
+package main
+
+import (
+	"math"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+)
+
+const (
+	arcballClick = 3 // How far the mouse can move, in pixels, before a press counts as a drag rather than a click
+)
+
+var (
+	arcballOn          bool                            // Set when dragging the graph with the left button rotates it
+	arcballing         bool                            // True while the left button is held down for a drag
+	arcballMoved       bool                            // Set once the drag has gone far enough not to be a click
+	arcballX, arcballY float64                         // Where the drag started, for telling clicks from drags
+	arcballFrom        [3]float64                      // Point on the virtual sphere the drag was last at
+	arcballTurn        = geometry.IdentityQuaternion() // Rotation built up during an operation, not yet sent
+)
+
+// Maps a point on the screen onto the virtual sphere filling the graph area, centred on the middle where rotations
+// happen around.  Points outside the sphere go to its edge.  Screen Y runs downwards, so it's flipped to match the
+// graph
+func arcballPoint(x float64, y float64) [3]float64 {
+	r := math.Min(graphWidth, graphHeight) / 2
+	if r <= 0 {
+		return [3]float64{0, 0, 1}
+	}
+	nx, ny := (x-centerX)/r, (centerY-y)/r
+	d := nx*nx + ny*ny
+	if d > 1 {
+		l := math.Sqrt(d)
+		return [3]float64{nx / l, ny / l, 0}
+	}
+	return [3]float64{nx, ny, math.Sqrt(1 - d)}
+}
+
+// Rotates the graph to follow the mouse during an arcball drag.  The rotation is around the axis at right angles to
+// both the last and the new points on the sphere, so the graph turns the way it's dragged.  Rotation builds up while
+// an operation is in progress, and is sent once it's done
+func arcballTo(x float64, y float64) {
+	if !arcballing {
+		return
+	}
+	if !arcballMoved && math.Hypot(x-arcballX, y-arcballY) < arcballClick {
+		return
+	}
+	arcballMoved = true
+	to := arcballPoint(x, y)
+	a, b := arcballFrom, to
+	ax, ay, az := a[1]*b[2]-a[2]*b[1], a[2]*b[0]-a[0]*b[2], a[0]*b[1]-a[1]*b[0]
+	dot := a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+	deg := math.Atan2(math.Sqrt(ax*ax+ay*ay+az*az), dot) * 180 / math.Pi
+	arcballTurn = arcballTurn.Then(geometry.AxisQuaternion(ax, ay, az, deg))
+	arcballFrom = to
+	if !renderActive.Load() && !presetActive.Load() {
+		sendArcball()
+	}
+}
+
+// Finishes an arcball drag, sending any rotation still waiting.  A press which didn't move far selects the point under
+// it instead, as it would with arcball rotation off
+func endArcball() {
+	if !arcballing {
+		return
+	}
+	arcballing = false
+	if !arcballMoved {
+		selectAt(arcballX, arcballY)
+		return
+	}
+	sendArcball()
+	logActivity("mouse", "Rotated by dragging")
+}
+
+// Lines for the info panel, showing how the mouse rotates the graph.  Clicking switches between the two ways
+func rotationLines() []panelLine {
+	text := "Rotation: axis steps, with the keys"
+	if arcballOn {
+		text = "Rotation: arcball, drag the graph"
+	}
+	return []panelLine{{text: text, colour: theme.Link, action: toggleArcball}}
+}
+
+// Sends the rotation built up so far as a single step operation
+func sendArcball() {
+	x, y, z := arcballTurn.AxisAngle()
+	arcballTurn = geometry.IdentityQuaternion()
+	if x*x+y*y+z*z < 1e-12 {
+		return
+	}
+	queueOperation(Operation{op: ROTATEAXIS, t: 0, f: 1, X: x, Y: y, Z: z})
+}
+
+// Starts an arcball drag when the left button is pressed on the graph, if arcball rotation is on.  Returns false
+// otherwise
+func startArcball(x float64, y float64) bool {
+	if !arcballOn {
+		return false
+	}
+	arcballing, arcballMoved = true, false
+	arcballX, arcballY = x, y
+	arcballFrom = arcballPoint(x, y)
+	arcballTurn = geometry.IdentityQuaternion()
+	return true
+}
+
+// Switches between rotating the graph by dragging it as an arcball, and only in steps around the axes with the keys
+func toggleArcball() {
+	arcballOn = !arcballOn
+	if arcballOn {
+		boxMode = false
+	}
+}
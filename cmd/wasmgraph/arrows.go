@@ -0,0 +1,65 @@
+package main
+
+import (
+	"math"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+)
+
+const (
+	arrowHeadSize = 8 // Length of arrow heads, in pixels
+)
+
+// A vector arrow, in graph co-ordinates.  Arrows are drawn over the graph each frame, transformed along with everything
+// else
+type arrow struct {
+	x, y, z    float64 // Where the arrow starts
+	dx, dy, dz float64 // The vector
+	colour     string
+	label      string
+}
+
+var (
+	arrows []arrow
+)
+
+// Draws the vector arrows
+func drawArrows(left float64, top float64) {
+	if len(arrows) == 0 {
+		return
+	}
+	ctx.Save()
+	ctx.BeginPath()
+	ctx.Rect(left, top, graphWidth-left, graphHeight-top)
+	ctx.Clip()
+	ctx.SetLineWidth(2)
+	ctx.SetLineDash()
+	ctx.SetFont("bold 12px sans-serif")
+	ctx.SetTextAlign("left")
+	for _, a := range arrows {
+		x1, y1 := geometry.Project(worldMatrix, centerX, centerY, step, a.x, a.y, a.z)
+		x2, y2 := geometry.Project(worldMatrix, centerX, centerY, step, a.x+a.dx, a.y+a.dy, a.z+a.dz)
+		ctx.SetStrokeStyle(a.colour)
+		ctx.SetFillStyle(a.colour)
+		ctx.BeginPath()
+		ctx.MoveTo(x1, y1)
+		ctx.LineTo(x2, y2)
+		ctx.Stroke()
+
+		// Arrow head, pointing along the arrow as it appears on screen
+		if l := math.Hypot(x2-x1, y2-y1); l > 0 {
+			ux, uy := (x2-x1)/l, (y2-y1)/l
+			s := math.Min(arrowHeadSize, l)
+			ctx.BeginPath()
+			ctx.MoveTo(x2, y2)
+			ctx.LineTo(x2-ux*s-uy*s/2, y2-uy*s+ux*s/2)
+			ctx.LineTo(x2-ux*s+uy*s/2, y2-uy*s-ux*s/2)
+			ctx.ClosePath()
+			ctx.Fill()
+		}
+		if a.label != "" {
+			ctx.FillText(a.label, x2+4, y2-4)
+		}
+	}
+	ctx.Restore()
+}
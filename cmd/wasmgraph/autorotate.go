@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"syscall/js"
+	"time"
+)
+
+const (
+	autoRotateParam  = "autorotate"    // Query parameter which starts the graph spinning, eg "index.html?autorotate"
+	autoRotateResume = 5 * time.Second // How long after the user last did something before the spinning carries on
+	autoRotateStep   = 5.0             // Degrees turned by each of the queued rotations
+	autoRotateTime   = 500             // Milliseconds each of them takes, so it turns 10 degrees a second
+)
+
+var (
+	autoRotate bool      // True while the graph spins by itself, as a demo
+	lastInput  time.Time // When the last mouse, touch, or keyboard event was handled
+)
+
+// Lines for the Operation section, saying when auto-rotate is on and whether it's waiting for the user
+func autoRotateLines() []panelLine {
+	if !autoRotate {
+		return nil
+	}
+	text := "Auto-rotate: spinning"
+	if time.Since(lastInput) < autoRotateResume {
+		text = "Auto-rotate: paused while you work"
+	}
+	return []panelLine{{text: text, colour: theme.Link, action: toggleAutoRotate}}
+}
+
+// Keeps the graph turning around the Y axis while auto-rotate is on, one small rotation after another.  The next is
+// queued as soon as the last has started, so they follow on without a pause.  It waits while the user is doing
+// something, or while a preset or the self-test has the queue.  Called each frame
+func stepAutoRotate() {
+	if !autoRotate || time.Since(lastInput) < autoRotateResume || presetActive.Load() || selfTestRunning {
+		return
+	}
+	opMu.Lock()
+	waiting := len(pending)
+	opMu.Unlock()
+	if waiting == 0 {
+		queueOperation(Operation{op: ROTATE, t: autoRotateTime, f: 5, Y: autoRotateStep})
+	}
+}
+
+// Starts or stops the graph spinning by itself
+func toggleAutoRotate() {
+	autoRotate = !autoRotate
+	lastInput = time.Time{} // Start straight away, rather than waiting for the key press to count as idle time
+}
+
+// Returns true if the page was loaded with the auto-rotate query parameter
+func wantAutoRotate() bool {
+	q, err := url.ParseQuery(strings.TrimPrefix(js.Global().Get("location").Get("search").String(), "?"))
+	if err != nil {
+		return false
+	}
+	_, ok := q[autoRotateParam]
+	return ok
+}
@@ -0,0 +1,209 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+// A point picked by the selection box
+type boxPoint struct {
+	object string // Name of the object
+	point  int    // Index of the point in the object
+}
+
+// Statistics of the points picked by the selection box, in graph co-ordinates
+type boxStats struct {
+	n                      int
+	meanX, meanY           float64
+	minX, minY, maxX, maxY float64
+	slope, intercept, r2   float64
+	fit                    bool // Set when there's a best fit line, which needs at least two different values of x
+}
+
+var (
+	boxMode      bool       // Set when dragging the graph with the left button draws a selection box
+	boxing       bool       // True while the selection box is being dragged out
+	boxX0, boxY0 float64    // Where the selection box was started
+	boxX1, boxY1 float64    // Where the selection box has been dragged to
+	boxPicked    []boxPoint // The points picked by the last selection box, highlighted until cleared
+	boxSummary   *boxStats  // Statistics of the picked points, worked out when they're picked
+)
+
+// Works out the statistics of the picked points, using their graph co-ordinates so they don't change as the graph is
+// rotated or zoomed.  The best fit line is the least squares one, with y depending on x
+func boxStatistics(pts []Point) *boxStats {
+	s := &boxStats{n: len(pts), minX: math.Inf(1), minY: math.Inf(1), maxX: math.Inf(-1), maxY: math.Inf(-1)}
+	if len(pts) == 0 {
+		return s
+	}
+	for _, p := range pts {
+		s.meanX += p.X
+		s.meanY += p.Y
+		s.minX, s.maxX = math.Min(s.minX, p.X), math.Max(s.maxX, p.X)
+		s.minY, s.maxY = math.Min(s.minY, p.Y), math.Max(s.maxY, p.Y)
+	}
+	s.meanX /= float64(len(pts))
+	s.meanY /= float64(len(pts))
+	var sxx, sxy, syy float64
+	for _, p := range pts {
+		dx, dy := p.X-s.meanX, p.Y-s.meanY
+		sxx += dx * dx
+		sxy += dx * dy
+		syy += dy * dy
+	}
+	if sxx > 0 {
+		s.fit = true
+		s.slope = sxy / sxx
+		s.intercept = s.meanY - s.slope*s.meanX
+		s.r2 = 1
+		if syy > 0 {
+			s.r2 = sxy * sxy / (sxx * syy)
+		}
+	}
+	return s
+}
+
+// Lines for the Selection section, with the statistics of the points picked by the selection box
+func boxLines() (l []panelLine) {
+	if boxSummary == nil {
+		return nil
+	}
+	s := boxSummary
+	l = append(l, panelLine{text: fmt.Sprintf("%d points selected   ✕", s.n), action: clearBox})
+	if s.n == 0 {
+		return
+	}
+	f := scene.FormatCoord
+	l = append(l, panelLine{text: "Mean: " + scene.FormatPoint(s.meanX, s.meanY), indent: 15})
+	l = append(l, panelLine{text: fmt.Sprintf("x: %s to %s", f(s.minX), f(s.maxX)), indent: 15})
+	l = append(l, panelLine{text: fmt.Sprintf("y: %s to %s", f(s.minY), f(s.maxY)), indent: 15})
+	if s.fit {
+		l = append(l, panelLine{text: fmt.Sprintf("Best fit: y = %sx + %s", f(s.slope), f(s.intercept)), indent: 15})
+		l = append(l, panelLine{text: fmt.Sprintf("R²: %s", f(s.r2)), indent: 15})
+	}
+	return
+}
+
+// Lines for the Operation section, showing whether dragging the graph draws a selection box.  Clicking switches it
+// on or off
+func boxModeLines() []panelLine {
+	text := "Box select: off"
+	if boxMode {
+		text = "Box select: on, drag over points"
+	}
+	return []panelLine{{text: text, colour: theme.Link, action: toggleBoxMode}}
+}
+
+// Drags out the selection box
+func boxTo(x float64, y float64) {
+	if boxing {
+		boxX1, boxY1 = x, y
+	}
+}
+
+// Clears the points picked by the selection box
+func clearBox() {
+	boxPicked, boxSummary = nil, nil
+}
+
+// Draws the selection box while it's being dragged out, and highlights the points it picked, along with their best
+// fit line
+func drawBox(left float64, top float64) {
+	if !boxing && boxSummary == nil {
+		return
+	}
+	ctx.Save()
+	ctx.BeginPath()
+	ctx.Rect(left, top, graphWidth-left, graphHeight-top)
+	ctx.Clip()
+	ctx.SetLineWidth(1)
+	ctx.SetStrokeStyle(theme.Alert)
+	if boxing {
+		ctx.SetLineDash(4, 3)
+		ctx.StrokeRect(math.Min(boxX0, boxX1), math.Min(boxY0, boxY1), math.Abs(boxX1-boxX0),
+			math.Abs(boxY1-boxY0))
+	}
+	ctx.SetLineDash()
+	ctx.SetLineWidth(2)
+	ctx.BeginPath()
+	for _, b := range boxPicked {
+		o, ok := findObject(b.object)
+		if !ok || b.point >= len(o.P) {
+			continue // The object has gone, or has been regenerated with fewer points
+		}
+		px, py := centerX+(o.P[b.point].X*step), centerY+((o.P[b.point].Y*step)*-1)
+		ctx.MoveTo(px+5, py)
+		ctx.Ellipse(px, py, 5, 5, 0, 0, 2*math.Pi)
+	}
+	ctx.Stroke()
+
+	// The best fit line, across the range of x of the picked points
+	if s := boxSummary; s != nil && s.fit {
+		x1, y1 := geometry.Project(worldMatrix, centerX, centerY, step, s.minX, s.slope*s.minX+s.intercept, 0)
+		x2, y2 := geometry.Project(worldMatrix, centerX, centerY, step, s.maxX, s.slope*s.maxX+s.intercept, 0)
+		ctx.SetLineDash(6, 4)
+		ctx.BeginPath()
+		ctx.MoveTo(x1, y1)
+		ctx.LineTo(x2, y2)
+		ctx.Stroke()
+	}
+	ctx.Restore()
+}
+
+// Finishes dragging out the selection box, picking the points inside it.  A press which didn't move far selects the
+// point under it instead, as it would with box selection off
+func endBox() {
+	if !boxing {
+		return
+	}
+	boxing = false
+	if math.Hypot(boxX1-boxX0, boxY1-boxY0) < arcballClick {
+		selectAt(boxX0, boxY0)
+		return
+	}
+	x0, x1 := math.Min(boxX0, boxX1), math.Max(boxX0, boxX1)
+	y0, y1 := math.Min(boxY0, boxY1), math.Max(boxY0, boxY1)
+	inv, ok := geometry.Invert(worldMatrix)
+	if !ok {
+		return
+	}
+	boxPicked = nil
+	var pts []Point
+	for _, o := range world.Objects() {
+		if isAxesPart(o) || o.Hidden {
+			continue
+		}
+		for i, p := range o.P {
+			px, py := centerX+(p.X*step), centerY+((p.Y*step)*-1)
+			if px >= x0 && px <= x1 && py >= y0 && py <= y1 {
+				boxPicked = append(boxPicked, boxPoint{object: o.Name, point: i})
+				pts = append(pts, scene.Transform(inv, p))
+			}
+		}
+	}
+	boxSummary = boxStatistics(pts)
+	logActivity("mouse", "Box selected %d points", len(pts))
+}
+
+// Starts dragging out a selection box when the left button is pressed on the graph, if box selection is on.  Returns
+// false otherwise
+func startBox(x float64, y float64) bool {
+	if !boxMode {
+		return false
+	}
+	boxing = true
+	boxX0, boxY0, boxX1, boxY1 = x, y, x, y
+	return true
+}
+
+// Switches dragging the graph with the left button between drawing a selection box and its usual behaviour.  Box
+// selection and arcball rotation both use the left button, so turning one on turns the other off
+func toggleBoxMode() {
+	boxMode = !boxMode
+	if boxMode {
+		arcballOn = false
+	}
+}
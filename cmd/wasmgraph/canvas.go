@@ -0,0 +1,160 @@
+package main
+
+import (
+	"syscall/js"
+)
+
+// Draws on the page's canvas, through its 2D context
+type canvasRenderer struct {
+	ctx js.Value
+}
+
+// Draws an arc of a circle, as part of the current path
+func (c *canvasRenderer) Arc(x float64, y float64, r float64, start float64, end float64) {
+	c.ctx.Call("arc", x, y, r, start, end)
+}
+
+// Starts a new path
+func (c *canvasRenderer) BeginPath() {
+	c.ctx.Call("beginPath")
+}
+
+// Clips further drawing to the current path
+func (c *canvasRenderer) Clip() {
+	c.ctx.Call("clip")
+}
+
+// Closes the current subpath back to its start
+func (c *canvasRenderer) ClosePath() {
+	c.ctx.Call("closePath")
+}
+
+// Adds an ellipse to the current path
+func (c *canvasRenderer) Ellipse(x float64, y float64, rx float64, ry float64, rotation float64, start float64,
+	end float64) {
+	c.ctx.Call("ellipse", x, y, rx, ry, rotation, start, end)
+}
+
+// Fills the current path
+func (c *canvasRenderer) Fill() {
+	c.ctx.Call("fill")
+}
+
+// Fills a rectangle
+func (c *canvasRenderer) FillRect(x float64, y float64, w float64, h float64) {
+	c.ctx.Call("fillRect", x, y, w, h)
+}
+
+// Draws filled text
+func (c *canvasRenderer) FillText(text string, x float64, y float64) {
+	c.ctx.Call("fillText", text, x, y)
+}
+
+// Continues the current subpath to a point
+func (c *canvasRenderer) LineTo(x float64, y float64) {
+	c.ctx.Call("lineTo", x, y)
+}
+
+// Returns the width the text takes up in the current font
+func (c *canvasRenderer) MeasureText(text string) float64 {
+	return c.ctx.Call("measureText", text).Get("width").Float()
+}
+
+// Starts a new subpath at a point
+func (c *canvasRenderer) MoveTo(x float64, y float64) {
+	c.ctx.Call("moveTo", x, y)
+}
+
+// Adds a rectangle to the current path
+func (c *canvasRenderer) Rect(x float64, y float64, w float64, h float64) {
+	c.ctx.Call("rect", x, y, w, h)
+}
+
+// Puts back the drawing state from the matching Save
+func (c *canvasRenderer) Restore() {
+	c.ctx.Call("restore")
+}
+
+// Rotates further drawing, by an angle in radians
+func (c *canvasRenderer) Rotate(angle float64) {
+	c.ctx.Call("rotate", angle)
+}
+
+// Puts aside the drawing state, for Restore to bring back
+func (c *canvasRenderer) Save() {
+	c.ctx.Call("save")
+}
+
+// Sets the colour shapes and text are filled with
+func (c *canvasRenderer) SetFillStyle(style string) {
+	c.ctx.Set("fillStyle", style)
+}
+
+// Sets the font text is drawn in, as a CSS font
+func (c *canvasRenderer) SetFont(font string) {
+	c.ctx.Set("font", font)
+}
+
+// Sets how opaque further drawing is, from 0 to 1
+func (c *canvasRenderer) SetGlobalAlpha(alpha float64) {
+	c.ctx.Set("globalAlpha", alpha)
+}
+
+// Sets the dash pattern for lines, with none for solid lines
+func (c *canvasRenderer) SetLineDash(segments ...float64) {
+	d := make([]interface{}, len(segments))
+	for i, s := range segments {
+		d[i] = s
+	}
+	c.ctx.Call("setLineDash", d)
+}
+
+// Sets how the corners of lines are joined
+func (c *canvasRenderer) SetLineJoin(join string) {
+	c.ctx.Set("lineJoin", join)
+}
+
+// Sets the width of lines
+func (c *canvasRenderer) SetLineWidth(width float64) {
+	c.ctx.Set("lineWidth", width)
+}
+
+// Sets the colour lines are drawn in
+func (c *canvasRenderer) SetStrokeStyle(style string) {
+	c.ctx.Set("strokeStyle", style)
+}
+
+// Sets how text lines up with the point it's drawn at, across
+func (c *canvasRenderer) SetTextAlign(align string) {
+	c.ctx.Set("textAlign", align)
+}
+
+// Sets how text lines up with the point it's drawn at, up and down
+func (c *canvasRenderer) SetTextBaseline(baseline string) {
+	c.ctx.Set("textBaseline", baseline)
+}
+
+// Replaces the transform applied to further drawing
+func (c *canvasRenderer) SetTransform(a float64, b float64, cc float64, d float64, e float64, f float64) {
+	c.ctx.Call("setTransform", a, b, cc, d, e, f)
+}
+
+// Strokes the current path
+func (c *canvasRenderer) Stroke() {
+	c.ctx.Call("stroke")
+}
+
+// Outlines a rectangle
+func (c *canvasRenderer) StrokeRect(x float64, y float64, w float64, h float64) {
+	c.ctx.Call("strokeRect", x, y, w, h)
+}
+
+// Draws outlined text
+func (c *canvasRenderer) StrokeText(text string, x float64, y float64) {
+	c.ctx.Call("strokeText", text, x, y)
+}
+
+// Moves further drawing by an offset
+func (c *canvasRenderer) Translate(x float64, y float64) {
+	c.ctx.Call("translate", x, y)
+}
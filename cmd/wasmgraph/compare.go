@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	numericColour = "darkorange"           // Colour of the numerical derivative
+	errorColour   = "rgba(255, 0, 0, 0.3)" // Colour of the shaded error between the derivatives
+)
+
+// A comparison of an equations' symbolic derivative with a numerically worked out one
+type comparison struct {
+	eq      string  // Name of the equation
+	maxErr  float64 // Largest difference between the two derivatives
+	maxErrX float64 // Where the largest difference is
+}
+
+var (
+	compared *comparison // The comparison currently shown, if any
+)
+
+// Removes the comparison overlay
+func clearComparison() {
+	if compared == nil {
+		return
+	}
+	removeObjects(compared.eq+"' numeric", compared.eq+"' error")
+	compared = nil
+}
+
+// Plots the numerical derivative of an equation alongside its symbolic one, shading the difference between them.
+// The numerical derivative uses the forward difference over the same step the curves are sampled at, as that's the
+// simplest method and its error is easy to see
+func compareDerivative(name string) error {
+	e, ok := findEquation(name)
+	if !ok {
+		return fmt.Errorf("there's no equation called '%s'", name)
+	}
+	if e.parametric {
+		return fmt.Errorf("parametric curves don't have a derivative to compare")
+	}
+	clearComparison()
+
+	num := Object{Name: e.name + "' numeric", C: numericColour, DrawOrder: len(equations)*2 + 2,
+		Equation: fmt.Sprintf("forward difference of %s, h = %v", e.name, pointStep), Tags: []string{"derivatives"}}
+	band := Object{Name: e.name + "' error", C: errorColour, Equation: "symbolic - numeric",
+		Tags: []string{"annotations"}}
+	c := &comparison{eq: e.name}
+	var exact []Point
+	vars := map[string]float64{}
+	for x := graphMinX; x <= graphMaxX+pointStep/2; x += pointStep {
+		vars["x"] = x
+		d := e.deriv.Eval(vars)
+		n := numericDerivative(e.expr, x, pointStep)
+		if !scene.Finite(d) || !scene.Finite(n) {
+			continue
+		}
+		num.P = append(num.P, Point{X: x, Y: n})
+		exact = append(exact, Point{X: x, Y: d})
+		if err := math.Abs(d - n); err > c.maxErr {
+			c.maxErr, c.maxErrX = err, x
+		}
+	}
+	if len(num.P) == 0 {
+		return fmt.Errorf("the derivative of %s isn't defined over the plotted range", e.name)
+	}
+
+	// The error band goes along the symbolic derivative, then back along the numerical one
+	band.P = append(band.P, exact...)
+	for i := len(num.P) - 1; i >= 0; i-- {
+		band.P = append(band.P, Point{X: num.P[i].X, Y: num.P[i].Y})
+	}
+	var s Surface
+	for i := range band.P {
+		s = append(s, i)
+	}
+	band.S = []Surface{s}
+	replaceObject(band)
+	replaceObject(num)
+	compared = c
+	return nil
+}
+
+// Lines for the info panel, describing the derivative comparison being shown
+func comparisonLines() (l []panelLine) {
+	if compared == nil {
+		return
+	}
+	l = append(l, panelLine{text: fmt.Sprintf("%s' vs numeric: max error %s at x = %s   ✕", compared.eq,
+		scene.FormatCoord(compared.maxErr), scene.FormatCoord(compared.maxErrX)), swatch: errorColour,
+		action: clearComparison})
+	return
+}
+
+// Works out the derivative of an expression of x numerically, using the forward difference with step h
+func numericDerivative(n expr.Node, x float64, h float64) float64 {
+	return (n.Eval(map[string]float64{"x": x + h}) - n.Eval(map[string]float64{"x": x})) / h
+}
+
+// Turns the derivative comparison off, or on for the most recently added equation
+func toggleComparison() {
+	if compared != nil {
+		clearComparison()
+		return
+	}
+	for i := len(equations) - 1; i >= 0; i-- {
+		if !equations[i].parametric {
+			compareDerivative(equations[i].name)
+			return
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math"
+	"sort"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+)
+
+const (
+	compassRadius = 32 // Length of the compass axes, in pixels
+	compassMargin = 18 // Gap between the compass and the corner of the graph area, in pixels
+	compassHit    = 10 // Half the size of the clickable area at the end of each axis, in pixels
+)
+
+// An axis drawn on the compass, and the standard view looking straight down it
+type compassAxis struct {
+	label  string
+	colour string
+	view   string
+}
+
+var (
+	compassAxes = []compassAxis{
+		{label: "X", colour: "red", view: "side"},
+		{label: "Y", colour: "green", view: "top"},
+		{label: "Z", colour: "blue", view: "front"},
+	}
+)
+
+// Draws the compass in the bottom right corner of the graph area, showing which way the +X, +Y, and +Z directions
+// point.  Only the rotation is shown, not the zoom or any movement.  Clicking the end of an axis turns the graph to
+// look straight down it.  The axes pointing away from the viewer are drawn first and fainter, so the nearer ones are
+// on top
+func drawCompass() {
+	cx, cy := graphWidth-compassMargin-compassRadius, graphHeight-compassMargin-compassRadius
+	r := geometry.MatrixQuaternion(worldMatrix).Matrix()
+	idx := []int{0, 1, 2}
+	sort.Slice(idx, func(i, j int) bool { return r[8+idx[i]] < r[8+idx[j]] })
+
+	ctx.Save()
+	ctx.BeginPath()
+	ctx.Arc(cx, cy, compassRadius+compassHit, 0, 2*math.Pi)
+	ctx.SetGlobalAlpha(0.8)
+	ctx.SetFillStyle(theme.Background)
+	ctx.Fill()
+	ctx.SetGlobalAlpha(1)
+	ctx.SetStrokeStyle(theme.Muted)
+	ctx.SetLineWidth(1)
+	ctx.SetLineDash()
+	ctx.Stroke()
+	ctx.SetFont("bold 11px sans-serif")
+	ctx.SetTextAlign("center")
+	ctx.SetTextBaseline("middle")
+	ctx.SetLineWidth(2)
+	for _, i := range idx {
+		a := compassAxes[i]
+		x, y := cx+r[i]*compassRadius, cy-r[4+i]*compassRadius
+		if r[8+i] < 0 {
+			ctx.SetGlobalAlpha(0.45)
+		} else {
+			ctx.SetGlobalAlpha(1)
+		}
+		ctx.SetStrokeStyle(a.colour)
+		ctx.SetFillStyle(a.colour)
+		ctx.BeginPath()
+		ctx.MoveTo(cx, cy)
+		ctx.LineTo(x, y)
+		ctx.Stroke()
+		ctx.BeginPath()
+		ctx.Arc(x, y, 7, 0, 2*math.Pi)
+		ctx.Fill()
+		ctx.SetFillStyle(theme.Background)
+		ctx.FillText(a.label, x, y+1)
+		view := a.view
+		addHotspot(x-compassHit, y-compassHit, 2*compassHit, 2*compassHit, func() {
+			for _, v := range cameraViews {
+				if v.name == view {
+					turnToView(v)
+				}
+			}
+		})
+	}
+	ctx.Restore()
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/render"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	configFile = "config.json" // Deployment settings, looked for next to the app when the page starts
+)
+
+// Settings for a deployment, so a school or department can set things up their way without building the app again.
+// Everything is optional, and anything left out stays as it is
+type deployConfig struct {
+	Theme   string   // Name of the theme to start with, "light" or "dark"
+	Locale  string   // Language code for how numbers are written, instead of the browser's language
+	Tools   []string // The only tools turned on, by name.  When empty, they all are
+	Gallery string   // URL of a JSON list of extra gallery entries, in the same form as the built in ones
+	Title   string   // Name shown at the top of the info panel and as the page title
+	About   string   // A line shown under the title
+	Link    string   // Where clicking the title goes
+}
+
+var (
+	config deployConfig
+
+	configCall      js.Callback // Receives the response when asking for the config file
+	configTextCall  js.Callback // Receives the text of the config file, once the browser has read it
+	galleryCall     js.Callback // Receives the response when asking for the gallery library
+	galleryTextCall js.Callback // Receives the text of the gallery library
+	configErrCall   js.Callback // Called when either couldn't be fetched
+
+	// The tools which can be turned off for a deployment, and the keys which use them
+	configTools = map[string][]string{
+		"autorotate":    {"."},
+		"comparison":    {"c", "C"},
+		"cursor":        {"h", "H"},
+		"derived":       {"="},
+		"distributions": {"b", "B"},
+		"drag":          {"g", "G"},
+		"equations":     {"e", "E", "Delete"},
+		"export":        {"v", "V"},
+		"extrema":       {"q", "Q"},
+		"integral":      {"i", "I"},
+		"intersections": {"f", "F"},
+		"montecarlo":    {"k", "K"},
+		"parametric":    {"p", "P"},
+		"presets":       {"t", "T", "o", "O", "u", "U"},
+		"projectile":    {"j", "J"},
+		"recording":     {"r", "R"},
+		"roots":         {"x", "X"},
+		"sprites":       {"n", "N"},
+		"timeline":      {"y", "Y"},
+	}
+
+	// The info panel sections which are tools of their own, and are left out when turned off
+	configSections = map[string]string{
+		"activity": "Activity",
+		"gallery":  "Gallery",
+	}
+)
+
+// Puts the deployment settings into effect.  The theme, locale, and tools take effect straight away, while any gallery
+// library is fetched in the background
+func applyConfig(data string) error {
+	var c deployConfig
+	if err := json.Unmarshal([]byte(data), &c); err != nil {
+		return fmt.Errorf("it couldn't be read: %v", err)
+	}
+	for _, t := range c.Tools {
+		if _, ok := configTools[t]; !ok && configSections[t] == "" {
+			return fmt.Errorf("there's no tool called '%s'", t)
+		}
+	}
+	if c.Theme != "" {
+		found := false
+		for _, t := range render.Themes {
+			if strings.EqualFold(t.Name, c.Theme) {
+				theme, found = t, true
+			}
+		}
+		if !found {
+			return fmt.Errorf("there's no theme called '%s'", c.Theme)
+		}
+		styleSliders()
+	}
+	if c.Locale != "" {
+		if err := setLocale(c.Locale); err != nil {
+			return err
+		}
+	}
+	config = c
+
+	// Leave out the panel sections for tools which are turned off
+	var sections []*panelSection
+	for _, s := range panelSections {
+		keep := true
+		for t, title := range configSections {
+			keep = keep && (s.title != title || toolOn(t))
+		}
+		if keep {
+			sections = append(sections, s)
+		}
+	}
+	panelSections = sections
+
+	// The branding goes in a section of its own, at the top of the panel
+	if c.Title != "" {
+		doc.Set("title", c.Title)
+		panelSections = append([]*panelSection{{title: c.Title, lines: brandLines}}, panelSections...)
+	}
+	if c.Gallery != "" {
+		js.Global().Call("fetch", c.Gallery).Call("then", galleryCall, configErrCall)
+	}
+	logActivity("scene", "Applied the deployment settings from %s", configFile)
+	return nil
+}
+
+// Lines for the branding section at the top of the info panel
+func brandLines() (l []panelLine) {
+	if config.About != "" {
+		l = append(l, panelLine{text: config.About, font: "12px sans-serif", colour: theme.Muted})
+	}
+	if config.Link != "" {
+		l = append(l, panelLine{text: config.Link, font: "12px sans-serif", colour: theme.Link, action: func() {
+			if w := js.Global().Call("open", config.Link); w == js.Null() {
+				doc.Set("location", config.Link)
+			}
+		}})
+	}
+	return
+}
+
+// Puts the deployment settings into effect once the browser has read them.  They arrive after the page has started,
+// so the next frame is drawn to show them
+func configLoaded(args []js.Value) {
+	if err := applyConfig(args[0].String()); err != nil {
+		logError("Couldn't use %s: %v", configFile, err)
+	}
+	markActivity()
+}
+
+// Adds the gallery entries from the deployment settings once the browser has read them, drawing the next frame to
+// show them in the info panel
+func galleryLoaded(args []js.Value) {
+	if _, err := scene.AddGallery([]byte(args[0].String())); err != nil {
+		logError("Couldn't add to the gallery from %s: %v", config.Gallery, err)
+	}
+	markActivity()
+}
+
+// Sets up the callbacks for loading the deployment settings, and asks for the settings file
+func initConfig() {
+	configTextCall = js.NewCallback(configLoaded)
+	configCall = responseCallback(configTextCall, false)
+	galleryTextCall = js.NewCallback(galleryLoaded)
+	galleryCall = responseCallback(galleryTextCall, true)
+	configErrCall = js.NewCallback(func(args []js.Value) {
+		logError("Couldn't fetch the deployment settings: %s",
+			args[0].Call("toString").String())
+	})
+	js.Global().Call("fetch", configFile).Call("then", configCall, configErrCall)
+}
+
+// Returns true unless the deployment settings turn off the tool a key is for
+func keyAllowed(key string) bool {
+	for t, keys := range configTools {
+		for _, k := range keys {
+			if k == key {
+				return toolOn(t)
+			}
+		}
+	}
+	return true
+}
+
+// Releases the callbacks for loading the deployment settings
+func releaseConfig() {
+	configCall.Release()
+	configTextCall.Release()
+	galleryCall.Release()
+	galleryTextCall.Release()
+	configErrCall.Release()
+}
+
+// Returns a callback which reads the text of a fetched file, then hands it on.  A missing file is only reported when
+// it's been asked for, as most deployments won't have a config file at all
+func responseCallback(text js.Callback, report bool) js.Callback {
+	return js.NewCallback(func(args []js.Value) {
+		r := args[0]
+		if r.Get("ok").Bool() {
+			r.Call("text").Call("then", text, configErrCall)
+		} else if report {
+			logError("Couldn't fetch %s: %s %s", r.Get("url").String(),
+				r.Get("status").Call("toString").String(), r.Get("statusText").String())
+		}
+	})
+}
+
+// Returns true if a tool is turned on, which they all are unless the deployment settings list the ones wanted
+func toolOn(name string) bool {
+	if len(config.Tools) == 0 {
+		return true
+	}
+	for _, t := range config.Tools {
+		if t == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	csvChunkSize = 1 << 20 // Bytes of a CSV file read and parsed at a time
+)
+
+// A CSV file being imported a chunk at a time.  The first column is X, and each of the others is plotted as its own
+// data series against it.  A file with just one column is plotted against the row number
+type csvImport struct {
+	file    js.Value
+	name    string
+	size    int      // File size, in bytes
+	offset  int      // How much of the file has been read
+	rest    []byte   // The start of a line cut off at the end of the last chunk
+	sep     string   // Field separator, worked out from the first line
+	series  []string // Names of the objects for each series, once the first line has been read
+	single  bool     // Set when there's just one column, which is plotted against the row number
+	line    int      // Number of lines read so far
+	rows    int      // Rows imported so far
+	skipped []int    // Line numbers of the rows which couldn't be read
+}
+
+var (
+	csvImp     *csvImport  // The import in progress, if any
+	csvReading *csvImport  // The import whose next chunk is being read by the browser
+	csvQueue   []js.Value  // Files waiting their turn to be imported
+	csvSeries  int         // Number of series imported so far, used for picking their colours
+	csvCall    js.Callback // Receives each chunk of the file, once the browser has read it
+	csvErrCall js.Callback // Called when the browser couldn't read a chunk
+)
+
+// Stops the import in progress, removing what it had imported so far along with any files waiting their turn
+func cancelCSV() {
+	if csvImp == nil {
+		return
+	}
+	removeSeries(csvImp.series...)
+	csvImp = nil
+	csvQueue = nil
+}
+
+// Imports a chunk of the file, then asks for the next one.  Rows cut off at the end of the chunk are finished off
+// with the start of the next
+func csvChunk(args []js.Value) {
+	markActivity()
+	imp := csvReading
+	csvReading = nil
+	if imp != csvImp {
+		nextCSV() // Cancelled while the chunk was being read
+		return
+	}
+	chunk := make([]byte, args[0].Get("byteLength").Int())
+	ta := js.TypedArrayOf(chunk)
+	ta.Call("set", js.Global().Get("Uint8Array").New(args[0]))
+	ta.Release()
+	imp.offset += len(chunk)
+	data := append(imp.rest, chunk...)
+	imp.rest = nil
+	if imp.offset < imp.size {
+		i := bytes.LastIndexByte(data, '\n')
+		imp.rest = append([]byte(nil), data[i+1:]...)
+		data = data[:i+1]
+	}
+	imp.parse(data)
+	if imp.offset < imp.size {
+		readCSVChunk()
+		return
+	}
+	finishCSV()
+}
+
+// Draws the progress of the import along the bottom of the graph area, with a button for cancelling it
+func drawCSVProgress(left float64, top float64) {
+	if csvImp == nil {
+		return
+	}
+	frac := 1.0
+	if csvImp.size > 0 {
+		frac = float64(csvImp.offset) / float64(csvImp.size)
+	}
+	x, y := left+10, graphHeight-50
+	w, h := math.Min(360, graphWidth-left-20), 30.0
+	ctx.Save()
+	ctx.SetFillStyle(theme.Background)
+	ctx.SetStrokeStyle(theme.Foreground)
+	ctx.SetLineWidth(1)
+	ctx.SetLineDash()
+	ctx.FillRect(x, y, w, h)
+	ctx.StrokeRect(x, y, w, h)
+	ctx.SetFillStyle(theme.Link)
+	ctx.FillRect(x+4, y+h-8, (w-8)*frac, 4)
+	ctx.SetFont("12px sans-serif")
+	ctx.SetFillStyle(theme.Text)
+	ctx.SetTextAlign("left")
+	ctx.FillText(fmt.Sprintf("Importing %s: %.0f%%, %d rows", csvImp.name, frac*100, csvImp.rows), x+6,
+		y+15)
+	ctx.SetFillStyle(theme.Alert)
+	ctx.SetTextAlign("right")
+	ctx.FillText("Cancel ✕", x+w-6, y+15)
+	ctx.Restore()
+	addHotspot(x+w-70, y, 70, h, cancelCSV)
+}
+
+// Reports a chunk of the file which the browser couldn't read, and gives up on the file
+func csvReadError(args []js.Value) {
+	imp := csvReading
+	csvReading = nil
+	if imp == nil || imp != csvImp {
+		nextCSV()
+		return
+	}
+	js.Global().Call("alert", fmt.Sprintf("Couldn't read %s: %s", imp.name, args[0].String()))
+	removeSeries(imp.series...)
+	csvImp = nil
+	nextCSV()
+}
+
+// Finishes off the import, reporting any rows which couldn't be read, then starts on the next file waiting
+func finishCSV() {
+	imp := csvImp
+	csvImp = nil
+	if len(imp.skipped) > 0 {
+		lines := imp.skipped
+		more := ""
+		if len(lines) > 10 {
+			lines, more = lines[:10], fmt.Sprintf(" and %d more", len(imp.skipped)-10)
+		}
+		js.Global().Call("alert", fmt.Sprintf("Imported %d rows from %s.  These lines couldn't be read: %s%s",
+			imp.rows, imp.name, strings.Trim(fmt.Sprint(lines), "[]"), more))
+	}
+	nextCSV()
+}
+
+// Reports whether a dropped file looks like a CSV file
+func isCSV(file js.Value) bool {
+	name := strings.ToLower(file.Get("name").String())
+	return strings.HasSuffix(name, ".csv") || strings.HasSuffix(name, ".tsv") ||
+		file.Get("type").String() == "text/csv"
+}
+
+// Starts importing the next file waiting, if there is one
+func nextCSV() {
+	if len(csvQueue) == 0 || csvImp != nil || csvReading != nil {
+		return
+	}
+	f := csvQueue[0]
+	csvQueue = csvQueue[1:]
+	csvImp = &csvImport{file: f, name: f.Get("name").String(), size: f.Get("size").Int()}
+	readCSVChunk()
+}
+
+// Parses whole lines of the file, adding their points to the series
+func (imp *csvImport) parse(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	pts := make([][]Point, len(imp.series))
+	for _, l := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		imp.line++
+		l = strings.TrimSpace(l)
+		if l == "" {
+			continue
+		}
+		if imp.series == nil {
+			header := imp.start(l)
+			pts = make([][]Point, len(imp.series))
+			if header {
+				continue
+			}
+		}
+		f := imp.fields(l)
+		x, ys := float64(imp.rows+1), f
+		if !imp.single {
+			var err error
+			if x, err = scene.ParseNumber(f[0]); err != nil {
+				imp.skipped = append(imp.skipped, imp.line)
+				continue
+			}
+			ys = f[1:]
+		}
+		imp.rows++
+		for i, s := range ys {
+			if i >= len(pts) {
+				break
+			}
+			if y, err := scene.ParseNumber(s); err == nil {
+				pts[i] = append(pts[i], Point{X: x, Y: y})
+			}
+		}
+	}
+	for i, p := range pts {
+		appendSeries(imp.series[i], p)
+	}
+}
+
+// Returns the fields of a line, without any quotes around them
+func (imp *csvImport) fields(l string) []string {
+	f := strings.Split(l, imp.sep)
+	for i := range f {
+		f[i] = strings.Trim(strings.TrimSpace(f[i]), `"`)
+	}
+	return f
+}
+
+// Works out the separator from the first line, and adds an empty object for each series.  They're named after the
+// file and the column headings, if there are any.  Returns true if the first line is the column headings
+func (imp *csvImport) start(first string) bool {
+	imp.sep = ","
+	for _, s := range []string{"\t", ";"} {
+		if strings.Count(first, s) > strings.Count(first, imp.sep) {
+			imp.sep = s
+		}
+	}
+	f := imp.fields(first)
+	header := !csvNumber(f[0])
+	imp.single = len(f) == 1
+	cols := f[1:]
+	if imp.single {
+		cols = f
+	}
+	imp.series = []string{}
+	for i, c := range cols {
+		name := fmt.Sprintf("column %d", len(f)-len(cols)+i+1)
+		if header && c != "" {
+			name = c
+		}
+		name = imp.name + ": " + name
+		csvSeries++
+		addSeries(Object{Name: name, C: scene.Palette[(csvSeries-1)%len(scene.Palette)][0],
+			DrawOrder: 100 + csvSeries, Equation: "imported from " + imp.name, Tags: []string{"imported"},
+			Meta: map[string]string{"file": imp.name, "column": c}})
+		imp.series = append(imp.series, name)
+	}
+	return header
+}
+
+// Reports whether a CSV field is a number.  Files separated by semicolons or tabs can use commas for the decimal
+// separator, as spreadsheets set to European languages save them
+func csvNumber(s string) bool {
+	_, err := scene.ParseNumber(s)
+	return err == nil
+}
+
+// Asks the browser for the next chunk of the file being imported
+func readCSVChunk() {
+	end := csvImp.offset + csvChunkSize
+	if end > csvImp.size {
+		end = csvImp.size
+	}
+	csvReading = csvImp
+	csvImp.file.Call("slice", csvImp.offset, end).Call("arrayBuffer").Call("then", csvCall, csvErrCall)
+}
+
+// Adds CSV files to the queue to be imported, starting on them if nothing else is being imported
+func startCSV(file js.Value) {
+	csvQueue = append(csvQueue, file)
+	nextCSV()
+}
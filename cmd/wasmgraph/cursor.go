@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	cursorReach      = 10 // How far the cursor line runs above and below the X axis in graph units, as the Y axis does
+	cursorLineHeight = 15 // Height of each line of the cursor's readout
+)
+
+// Where a curve crosses the cursor
+type cursorValue struct {
+	name   string
+	colour string
+	y      float64
+}
+
+var (
+	cursorOn     bool    // Set while the cursor is shown
+	cursorPinX   float64 // The X the cursor is pinned at, when cursorPinned is set
+	cursorPinned bool    // Set when the cursor's been put at a fixed X through the API, rather than following the mouse
+)
+
+// Returns the X the cursor is at, either where it's pinned or the graph X under the mouse
+func cursorX(left float64, top float64) (float64, bool) {
+	if cursorPinned {
+		return cursorPinX, true
+	}
+	if inPanel(mouseX, mouseY) || mouseX < left || mouseY < top || mouseX > graphWidth || mouseY > graphHeight {
+		return 0, false
+	}
+	x, _, ok := geometry.Unproject(worldMatrix, centerX, centerY, step, mouseX, mouseY)
+	return x, ok
+}
+
+// Returns where each equation, its derivative, and each data series cross the cursor.  Data series use their nearest
+// drawn point
+func cursorValues(x float64) (v []cursorValue) {
+	vars := map[string]float64{"x": x}
+	for _, e := range equations {
+		if e.parametric {
+			continue
+		}
+		if y := e.expr.Eval(vars); scene.Finite(y) {
+			v = append(v, cursorValue{name: e.name, colour: e.colour, y: y})
+		}
+		if y := e.deriv.Eval(vars); scene.Finite(y) {
+			v = append(v, cursorValue{name: e.name + "'", colour: e.dColour, y: y})
+		}
+	}
+	inv, ok := geometry.Invert(worldMatrix)
+	if !ok {
+		return
+	}
+	for _, s := range series {
+		o, found := findObject(s.obj.Name)
+		if !found {
+			continue
+		}
+		best, by := math.Inf(1), 0.0
+		for _, p := range o.P {
+			g := scene.Transform(inv, p)
+			if d := math.Abs(g.X - x); d < best {
+				best, by = d, g.Y
+			}
+		}
+		if !math.IsInf(best, 1) {
+			v = append(v, cursorValue{name: s.obj.Name, colour: s.obj.C, y: by})
+		}
+	}
+	return
+}
+
+// Draws the cursor: a vertical line at the same X through every curve, with a dot where each crosses it and a readout
+// of their values.  So the function, its derivative, and any data line up visually at the same X
+func drawCursor(left float64, top float64) {
+	if !cursorOn {
+		return
+	}
+	x, ok := cursorX(left, top)
+	if !ok {
+		return
+	}
+	ctx.Save()
+	ctx.BeginPath()
+	ctx.Rect(left, top, graphWidth-left, graphHeight-top)
+	ctx.Clip()
+	x1, y1 := geometry.Project(worldMatrix, centerX, centerY, step, x, -cursorReach, 0)
+	x2, y2 := geometry.Project(worldMatrix, centerX, centerY, step, x, cursorReach, 0)
+	ctx.SetStrokeStyle(theme.Foreground)
+	ctx.SetLineWidth(1)
+	ctx.SetLineDash(2, 3)
+	ctx.BeginPath()
+	ctx.MoveTo(x1, y1)
+	ctx.LineTo(x2, y2)
+	ctx.Stroke()
+	ctx.SetLineDash()
+
+	// A dot where each curve crosses
+	vals := cursorValues(x)
+	for _, v := range vals {
+		px, py := geometry.Project(worldMatrix, centerX, centerY, step, x, v.y, 0)
+		ctx.SetFillStyle(v.colour)
+		ctx.BeginPath()
+		ctx.Ellipse(px, py, 3.5, 3.5, 0, 0, 2*math.Pi)
+		ctx.Fill()
+	}
+
+	// Then the readout along the top of the graph, beside the line unless that would run off the right hand side
+	lines := []string{"x = " + scene.FormatCoord(x)}
+	for _, v := range vals {
+		lines = append(lines, fmt.Sprintf("%s: %s", v.name, scene.FormatCoord(v.y)))
+	}
+	ctx.SetFont("12px sans-serif")
+	ctx.SetTextAlign("left")
+	ctx.SetTextBaseline("top")
+	w := 0.0
+	for _, l := range lines {
+		w = math.Max(w, ctx.MeasureText(l))
+	}
+	w += tooltipPadding * 2
+	h := float64(len(lines))*cursorLineHeight + tooltipPadding*2
+	rx, ry := (x1+x2)/2+8, top+8
+	if rx+w > graphWidth {
+		rx = (x1+x2)/2 - 8 - w
+	}
+	ctx.SetFillStyle(theme.Background)
+	ctx.SetStrokeStyle(theme.Foreground)
+	ctx.FillRect(rx, ry, w, h)
+	ctx.StrokeRect(rx, ry, w, h)
+	for i, l := range lines {
+		ctx.SetFillStyle(theme.Text)
+		if i > 0 {
+			ctx.SetFillStyle(vals[i-1].colour)
+		}
+		ctx.FillText(l, rx+tooltipPadding, ry+tooltipPadding+float64(i)*cursorLineHeight)
+	}
+	ctx.Restore()
+}
+
+// Shows the cursor pinned at the given X, so other plots on the page can keep it in step with their own
+func pinCursor(x float64) {
+	cursorOn, cursorPinned, cursorPinX = true, true, x
+}
+
+// Shows or hides the cursor following the mouse
+func toggleCursor() {
+	cursorOn = !cursorOn
+	cursorPinned = false
+}
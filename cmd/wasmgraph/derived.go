@@ -0,0 +1,168 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+// A curve worked out from other curves, such as the difference between two equations or a data series and the
+// equation fitted to it
+type derivedCurve struct {
+	name   string
+	src    string // The curve as "name = expression"
+	colour string
+	order  int       // Draw order of its object
+	n      expr.Node // The expression, with the curves it uses as placeholders
+	refs   []string  // Names of the curves it uses, in the order of their placeholders
+	pts    []Point   // The sampled points, in graph co-ordinates, so other derived curves can use it in turn
+	err    error     // Why it couldn't be worked out last time, or nil if it could
+}
+
+var (
+	derived      []*derivedCurve
+	derivedCount int // Number of derived curves added so far, used for naming new ones
+)
+
+// Parses a derived curve such as "c3 = f1 - f2" or "abs(f1')", works it out from the curves it uses, and adds it to
+// the list of derived curves.  It's given the next free name, eg "c1", when it isn't named
+func addDerived(src string) (*derivedCurve, error) {
+	sources := curveSources()
+	var names []string
+	for n := range sources {
+		names = append(names, n)
+	}
+	name, n, refs, err := scene.ParseDerived(src, names)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		name = fmt.Sprintf("c%d", derivedCount+1)
+	}
+	if _, ok := findObject(name); ok || name == "grid" {
+		return nil, fmt.Errorf("there's already something called '%s'", name)
+	}
+	derivedCount++
+	d := &derivedCurve{name: name, colour: scene.DerivedColours[(derivedCount-1)%len(scene.DerivedColours)],
+		order: scene.DerivedOrder + derivedCount, n: n, refs: refs}
+	d.src = name + " = " + strings.TrimSpace(src[strings.Index(src, "=")+1:])
+	if err = plotDerived(d); err != nil {
+		return nil, err
+	}
+	derived = append(derived, d)
+	logActivity("scene", "Added %s", d.src)
+	return d, nil
+}
+
+// Returns the curves derived curves can be worked out from, by name.  Equations and their derivatives are worked out
+// exactly, data series use all of their points rather than just the ones drawn, and anything else drawn as a line,
+// such as a distribution or an object added through the API, uses its points
+func curveSources() map[string]scene.Source {
+	sources := map[string]scene.Source{}
+	for _, e := range equations {
+		if e.parametric {
+			continue
+		}
+		minX, maxX, _ := e.sampling()
+		sources[e.name] = scene.ExprSource(e.expr, minX, maxX)
+		sources[e.name+"'"] = scene.ExprSource(e.deriv, minX, maxX)
+	}
+	for _, s := range series {
+		sources[s.obj.Name] = scene.PointSource(s.raw)
+	}
+	for _, d := range derived {
+		if d.err == nil {
+			sources[d.name] = scene.PointSource(d.pts)
+		}
+	}
+	for _, o := range sceneRoot.Flatten(geometry.Identity()) {
+		if _, ok := findDerived(o.Name); ok {
+			continue
+		}
+		if _, ok := sources[o.Name]; ok || isAxesPart(o) || len(o.S) > 0 || len(o.P) < 2 {
+			continue
+		}
+		sources[o.Name] = scene.PointSource(o.P)
+	}
+	return sources
+}
+
+// Lines for the Equations section, listing the derived curves.  Those which can't be worked out any more, because a
+// curve they use has gone, say why
+func derivedLines() (l []panelLine) {
+	for _, j := range derived {
+		d := j
+		l = append(l, panelLine{text: d.src + "   ✕", font: "bold 12px sans-serif", swatch: d.colour,
+			action: func() { removeDerived(d.name) }})
+		if d.err != nil {
+			l = append(l, panelLine{text: d.err.Error(), font: "12px sans-serif", colour: theme.Alert, indent: 15})
+		}
+	}
+	return
+}
+
+// Returns the derived curve with the given name
+func findDerived(name string) (*derivedCurve, bool) {
+	for _, d := range derived {
+		if d.name == name {
+			return d, true
+		}
+	}
+	return nil, false
+}
+
+// Works out a derived curve from the curves it uses as they are now, replacing its object.  If it can't be, the
+// object is removed until it can
+func plotDerived(d *derivedCurve) error {
+	o, err := scene.SampleDerived(d.n, d.refs, curveSources(), graphMinX, graphMaxX)
+	d.err = err
+	if err != nil {
+		d.pts = nil
+		removeObjects(d.name)
+		return err
+	}
+	d.pts = o.P
+	o = scene.NameCurve(o, d.name, d.colour, d.src, d.order)
+	o.Tags = []string{"derived"}
+	replaceObject(o)
+	return nil
+}
+
+// Asks the user for a new curve to work out from the existing ones
+func promptDerived() {
+	val := js.Global().Call("prompt", "Curve to work out from the others (e.g. c1 = f1 - f2, abs(f1'), or "+
+		"c2 = f1 * f2 / 2):", "")
+	if val == js.Null() || val == js.Undefined() || strings.TrimSpace(val.String()) == "" {
+		return
+	}
+	if _, err := addDerived(val.String()); err != nil {
+		js.Global().Call("alert", fmt.Sprintf("Couldn't work out that curve: %v", err))
+	}
+}
+
+// Removes a derived curve.  Any others using it can't be worked out until it's added again
+func removeDerived(name string) error {
+	for i, d := range derived {
+		if d.name != name {
+			continue
+		}
+		derived = append(derived[:i], derived[i+1:]...)
+		removeObjects(d.name)
+		replotDerived()
+		logActivity("scene", "Removed %s", name)
+		return nil
+	}
+	return fmt.Errorf("there's no derived curve called '%s'", name)
+}
+
+// Works out every derived curve again, after the curves they use have changed.  They're done in the order they were
+// added, so those using earlier ones see their new points
+func replotDerived() {
+	for _, d := range derived {
+		plotDerived(d)
+	}
+}
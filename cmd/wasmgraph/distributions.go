@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+var (
+	dists     []*scene.DistPlot
+	distCount int // Number of distributions added so far, used for naming new ones
+)
+
+// Parses a distribution such as "normal(0, 1)" or "binomial(10, 0.5); 3..6", plots it, and adds it to the list of
+// distributions.  The optional range after the semicolon is shaded, with its probability shown in the info panel
+func addDistribution(src string) (*scene.DistPlot, error) {
+	d, err := scene.ParseDistribution(src)
+	if err != nil {
+		return nil, err
+	}
+	distCount++
+	d.Name = fmt.Sprintf("d%d", distCount)
+	d.Colour = scene.DistColours[(distCount-1)%len(scene.DistColours)]
+	dists = append(dists, d)
+	plotDistribution(d)
+	logActivity("scene", "Added %s: %s", d.Name, src)
+	return d, nil
+}
+
+// Lines for the info panel, listing the plotted distributions and their tail probabilities
+func distributionLines() (l []panelLine) {
+	for _, j := range dists {
+		d := j
+		l = append(l, panelLine{text: d.Name + ":  " + d.Describe() + "   ✕", font: "bold 12px sans-serif",
+			swatch: d.Colour, action: func() { removeDistribution(d.Name) }})
+		if d.Tail {
+			l = append(l, panelLine{text: fmt.Sprintf("P(%s ≤ X ≤ %s) = %s", scene.FormatCoord(d.A),
+				scene.FormatCoord(d.B), scene.FormatCoord(d.Prob)), font: "12px sans-serif",
+				swatch: scene.DistTailColour, indent: 15})
+		}
+	}
+	return
+}
+
+// Generates the objects for a distribution and its tail region, replacing any earlier ones
+func plotDistribution(d *scene.DistPlot) {
+	o, t := d.Objects()
+	replaceObject(o)
+	removeObjects(t.Name)
+	if len(t.P) > 0 {
+		replaceObject(t)
+	}
+}
+
+// Asks the user for a distribution to plot
+func promptDistribution() {
+	val := js.Global().Call("prompt", "Distribution to plot, optionally with a range to shade "+
+		"(e.g. normal(0, 1); 1.96..4, binomial(10, 0.5), poisson(3), chisq(4)):", "normal(0, 1); 1..4")
+	if val == js.Null() || val == js.Undefined() || strings.TrimSpace(val.String()) == "" {
+		return
+	}
+	_, err := addDistribution(val.String())
+	if err != nil {
+		js.Global().Call("alert", fmt.Sprintf("Couldn't plot that distribution: %v", err))
+	}
+}
+
+// Removes a distribution and its tail region from the graph
+func removeDistribution(name string) error {
+	for i, d := range dists {
+		if d.Name != name {
+			continue
+		}
+		dists = append(dists[:i], dists[i+1:]...)
+		removeObjects(d.Name, d.Name+" tail")
+		replotDerived()
+		logActivity("scene", "Removed %s", name)
+		return nil
+	}
+	return fmt.Errorf("there's no distribution called '%s'", name)
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+var (
+	graphStep float64 // Step along x equations are sampled at, unless they have their own, or 0 to sample adaptively
+)
+
+// Checks a range of x and step could be used for plotting the equations
+func checkDomain(minX float64, maxX float64, st float64) error {
+	if !scene.Finite(minX) || !scene.Finite(maxX) || minX >= maxX {
+		return fmt.Errorf("the start of the range must be less than the end")
+	}
+	if st < 0 || !scene.Finite(st) {
+		return fmt.Errorf("the step must be a positive number")
+	}
+	if st > 0 && (maxX-minX)/st+1 > curveMaxPoints {
+		return fmt.Errorf("that would be more than %d points, so please use a larger step", curveMaxPoints)
+	}
+	return nil
+}
+
+// Lines for the Equations section showing the range of x the equations are plotted over, and how closely they're
+// sampled.  Each can be clicked to change it
+func domainLines() []panelLine {
+	st := "adaptive"
+	if graphStep > 0 {
+		st = scene.FormatNumber(graphStep)
+	}
+	return []panelLine{
+		{text: fmt.Sprintf("Plotted for x: %s to %s", scene.FormatCoord(graphMinX), scene.FormatCoord(graphMaxX)),
+			colour: theme.Link, action: promptDomain},
+		{text: "Step: " + st, colour: theme.Link, action: promptDomainStep},
+	}
+}
+
+// Asks the user for the range of x to plot the equations over
+func promptDomain() {
+	def := scene.FormatCoord(graphMinX) + ".." + scene.FormatCoord(graphMaxX)
+	val := js.Global().Call("prompt", "Range of x to plot the equations over (e.g. -5..5):", def)
+	if val == js.Null() || val == js.Undefined() || strings.TrimSpace(val.String()) == "" {
+		return
+	}
+	a, b, err := scene.ParseRange(val.String())
+	if err == nil {
+		err = setDomain(a, b, graphStep)
+	}
+	if err != nil {
+		js.Global().Call("alert", fmt.Sprintf("Couldn't change the range: %v", err))
+	}
+}
+
+// Asks the user for the step along x to sample the equations at
+func promptDomainStep() {
+	def := ""
+	if graphStep > 0 {
+		def = scene.FormatNumber(graphStep)
+	}
+	val := js.Global().Call("prompt", fmt.Sprintf("Step along x between the points of the equations, or empty to "+
+		"sample them adaptively (e.g. %s):", scene.FormatNumber(pointStep)), def)
+	if val == js.Null() || val == js.Undefined() {
+		return
+	}
+	var err error
+	st := 0.0
+	if strings.TrimSpace(val.String()) != "" {
+		st, err = scene.ParseNumber(val.String())
+	}
+	if err == nil {
+		err = setDomain(graphMinX, graphMaxX, st)
+	}
+	if err != nil {
+		js.Global().Call("alert", fmt.Sprintf("Couldn't change the step: %v", err))
+	}
+}
+
+// Changes the range of x the equations are plotted over, and the step they're sampled at (0 sampling them
+// adaptively), then plots them all again.  Equations with their own range or step keep them
+func setDomain(minX float64, maxX float64, st float64) error {
+	if err := checkDomain(minX, maxX, st); err != nil {
+		return err
+	}
+	if minX == graphMinX && maxX == graphMaxX && st == graphStep {
+		return nil
+	}
+	graphMinX, graphMaxX, graphStep = minX, maxX, st
+	for i, e := range equations {
+		plotEquation(e, i+1)
+	}
+	if showIntersections {
+		plotIntersections()
+	}
+	replotDerived()
+	logActivity("scene", "Plotting for x from %s to %s", scene.FormatCoord(minX), scene.FormatCoord(maxX))
+	return nil
+}
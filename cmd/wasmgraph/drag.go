@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	dragColour = "mediumvioletred"
+	dragRadius = 5 // Radius of the draggable point, in pixels
+)
+
+// A point which can be dragged along an equation curve (or its derivative curve)
+type curveMarker struct {
+	eq *equation
+	d  int // 1 when on the derivative curve
+	x  float64
+}
+
+var (
+	dragMarker *curveMarker // The draggable point, if one has been placed
+	dragging   bool         // Whether the draggable point is being dragged right now
+)
+
+// Returns the curve the draggable point is on, along with the expression for it
+func dragCurve() (n expr.Node, o Object, ok bool) {
+	m := dragMarker
+	name := m.eq.name
+	n = m.eq.expr
+	if m.d == 1 {
+		name += "'"
+		n = m.eq.deriv
+	}
+	o, ok = findObject(name)
+	return
+}
+
+// Lines for the info panel, showing where the draggable point is
+func dragLines() (l []panelLine) {
+	if dragMarker == nil {
+		return
+	}
+	n, o, ok := dragCurve()
+	if !ok {
+		return
+	}
+	x := dragMarker.x
+	vars := map[string]float64{"x": x}
+	slope := n.Deriv("x").Eval(vars)
+	l = append(l, panelLine{text: fmt.Sprintf("Point on %s: %s   ✕", o.Name, scene.FormatPoint(x, n.Eval(vars))),
+		swatch: dragColour, action: removeDragMarker})
+	l = append(l, panelLine{text: fmt.Sprintf("Slope %s, angle %s°", scene.FormatCoord(slope),
+		scene.FormatCoord(math.Atan(slope)*180/math.Pi)), indent: 15})
+	return
+}
+
+// Moves the draggable point to the spot on its curve nearest the given screen co-ordinates
+func dragTo(sx float64, sy float64) {
+	if !dragging || dragMarker == nil {
+		return
+	}
+	_, o, ok := dragCurve()
+	if !ok {
+		return
+	}
+	inv, ok := geometry.Invert(worldMatrix)
+	if !ok {
+		return
+	}
+	if x, _, near := nearestOnCurve(o, sx, sy, inv); near {
+		dragMarker.x = x
+	}
+}
+
+// Draws the draggable point, along with its tangent and normal lines and a readout of the point and slope
+func drawDragMarker(left float64, top float64) {
+	if dragMarker == nil {
+		return
+	}
+	n, _, ok := dragCurve()
+	if !ok {
+		return
+	}
+	vars := map[string]float64{"x": dragMarker.x}
+	y := n.Eval(vars)
+	slope := n.Deriv("x").Eval(vars)
+	if !scene.Finite(y) || !scene.Finite(slope) {
+		return
+	}
+	ctx.Save()
+	ctx.BeginPath()
+	ctx.Rect(left, top, graphWidth-left, graphHeight-top)
+	ctx.Clip()
+	px, py := drawTangentLines(dragMarker.x, y, slope, true, dragColour)
+	ctx.SetFillStyle(dragColour)
+	ctx.SetStrokeStyle(theme.Foreground)
+	ctx.BeginPath()
+	ctx.Ellipse(px, py, dragRadius, dragRadius, 0, 0, 2*math.Pi)
+	ctx.Fill()
+	ctx.Stroke()
+
+	// Readout beside the point
+	ctx.SetFont("12px sans-serif")
+	ctx.SetTextAlign("left")
+	ctx.SetFillStyle(theme.Text)
+	ctx.FillText(scene.FormatPoint(dragMarker.x, y), px+10, py-20)
+	ctx.FillText(fmt.Sprintf("slope %s, angle %s°", scene.FormatCoord(slope),
+		scene.FormatCoord(math.Atan(slope)*180/math.Pi)), px+10, py-6)
+	ctx.Restore()
+}
+
+// Mouse handler finishing any drag or pan in progress
+func mouseUpHandler(args []js.Value) {
+	markActivity()
+	dragging = false
+	endPan()
+	endArcball()
+	endBox()
+}
+
+// Places a draggable point on the most recently added equation curve, or removes it if it's already there
+func placeDragMarker() {
+	if dragMarker != nil {
+		removeDragMarker()
+		return
+	}
+	for i := len(equations) - 1; i >= 0; i-- {
+		if e := equations[i]; !e.parametric {
+			dragMarker = &curveMarker{eq: e, x: (graphMinX + graphMaxX) / 2}
+			return
+		}
+	}
+}
+
+// Removes the draggable point
+func removeDragMarker() {
+	dragMarker = nil
+	dragging = false
+}
+
+// Starts dragging the draggable point, if the given screen co-ordinates are on it
+func startDrag(sx float64, sy float64) bool {
+	if dragMarker == nil {
+		return false
+	}
+	n, _, ok := dragCurve()
+	if !ok {
+		return false
+	}
+	y := n.Eval(map[string]float64{"x": dragMarker.x})
+	px, py := geometry.Project(worldMatrix, centerX, centerY, step, dragMarker.x, y, 0)
+	if math.Hypot(px-sx, py-sy) > hoverRadius {
+		return false
+	}
+	dragging = true
+	return true
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+// A user supplied equation, plotted along with its derivative
+type equation struct {
+	name    string    // Short name, used for the object names, eg "f1"
+	src     string    // The equation as entered by the user
+	expr    expr.Node // The parsed right hand side of the equation
+	deriv   expr.Node // The first order derivative
+	colour  string
+	dColour string
+
+	parametric bool              // Set for space curves, given as x, y, and z functions of t
+	curve      *scene.Parametric // The co-ordinates and range of t of a parametric curve
+
+	minX, maxX float64 // Range of x the equation is plotted over, when it has its own rather than the graph's
+	step       float64 // Distance along x between the sampled points, or 0 for graphStep
+	samples    int     // Number of points a parametric curve is sampled at
+	scale      float64 // Pixels per unit the curve was last adaptively sampled for
+
+	family  expr.Node          // The right hand side before the parameters are filled in, for equations with any
+	params  map[string]float64 // Current values of the parameters, such as the a and b in y = a·x³ + b
+	sliders []*slider          // Sliders for adjusting the parameters
+
+	roots   []float64  // Roots within the plotted range, when they're being marked
+	extrema []extremum // Extrema and inflection points within the plotted range, when they're being marked
+}
+
+var (
+	equations []*equation
+	eqCount   int // Number of equations added so far, used for naming new ones
+
+	// The range of X values the equations are plotted over
+	graphMinX = scene.DefaultMinX
+	graphMaxX = scene.DefaultMaxX
+)
+
+// Parses an equation, plots it and its derivative, and adds it to the list of equations.  Parametric curves are
+// recognised too, and plotted on their own
+func addEquation(src string) (*equation, error) {
+	newFunc := newEquation
+	if scene.IsParametric(src) {
+		newFunc = newParametric
+	}
+	e, err := newFunc(src)
+	if err != nil {
+		return nil, err
+	}
+	eqCount++
+	e.name = fmt.Sprintf("f%d", eqCount)
+	e.samples = scene.ParametricSamples
+	c := scene.Palette[(eqCount-1)%len(scene.Palette)]
+	e.colour, e.dColour = c[0], c[1]
+	equations = append(equations, e)
+	plotEquation(e, len(equations))
+	addParamSliders(e)
+	if showIntersections {
+		plotIntersections()
+	}
+	logActivity("scene", "Added %s: %s", e.name, e.src)
+	return e, nil
+}
+
+// Returns the equation with the given name
+func findEquation(name string) (*equation, bool) {
+	for _, e := range equations {
+		if e.name == name {
+			return e, true
+		}
+	}
+	return nil, false
+}
+
+// Returns the equation typeset for display, as in "y = a·x³ + b".  Parametric curves are shown as they were entered
+func (e *equation) formatted() string {
+	switch {
+	case e.parametric:
+		return e.src
+	case e.family != nil:
+		return scene.FormatEquation(e.family)
+	}
+	return scene.FormatEquation(e.expr)
+}
+
+// Parses an equation such as "y = x^2", "f(x) = sin(x)", or just "x^2".  It can have parameters too, as in
+// "y = a·x³ + b", which start off at their default value
+func newEquation(src string) (*equation, error) {
+	src, n, params, err := scene.ParseFamily(src)
+	if err != nil {
+		return nil, err
+	}
+	e := &equation{src: src, expr: n, deriv: n.Deriv("x")}
+	if len(params) > 0 {
+		e.family, e.params = n, map[string]float64{}
+		for _, p := range params {
+			e.params[p] = scene.ParamDefault
+		}
+		fillParams(e)
+	}
+	return e, nil
+}
+
+// Generates the objects for an equation and its derivative, replacing any earlier ones
+func plotEquation(e *equation, num int) {
+	var curve Object
+	minX, maxX, st := e.sampling()
+	if e.parametric {
+		curve = e.curve.Sample(e.samples)
+	} else {
+		curve = sampleEquation(e, e.expr, minX, maxX, st)
+	}
+	curve = scene.NameCurve(curve, e.name, e.colour, e.formatted(), num*2)
+	curve.Tags = []string{"equations"}
+	if slopeColours && !e.parametric {
+		curve = slopeValues(e, curve)
+	}
+	replaceObject(curve)
+	if showRoots {
+		plotRoots(e)
+	}
+	if showExtrema {
+		plotExtrema(e)
+	}
+	if e.parametric {
+		return
+	}
+
+	d := sampleEquation(e, e.deriv, minX, maxX, st)
+	d = scene.NameCurve(d, e.name+"'", e.dColour, scene.FormatEquation(e.deriv), num*2+1)
+	d.Tags = []string{"derivatives"}
+	replaceObject(d)
+
+	// TODO: Generate points for the 2nd order derivative?
+}
+
+// Asks the user for a new equation to plot
+func promptEquation() {
+	val := js.Global().Call("prompt",
+		"Equation to plot (e.g. y = x^2 - 2, y = sin(2x), or x = cos(t); y = sin(t); t = 0..2pi):", "")
+	if val == js.Null() || val == js.Undefined() || strings.TrimSpace(val.String()) == "" {
+		return
+	}
+	_, err := addEquation(val.String())
+	if err != nil {
+		js.Global().Call("alert", fmt.Sprintf("Couldn't plot that equation: %v", err))
+	}
+}
+
+// Asks the user which equation to remove, defaulting to the most recent one
+func promptRemoveEquation() {
+	if len(equations) == 0 {
+		return
+	}
+	val := js.Global().Call("prompt", "Name of the equation to remove:", equations[len(equations)-1].name)
+	if val == js.Null() || val == js.Undefined() {
+		return
+	}
+	err := removeEquation(strings.TrimSpace(val.String()))
+	if err != nil {
+		js.Global().Call("alert", err.Error())
+	}
+}
+
+// Removes an equation and its derivative from the graph
+func removeEquation(name string) error {
+	for i, e := range equations {
+		if e.name != name {
+			continue
+		}
+		equations = append(equations[:i], equations[i+1:]...)
+		removeParamSliders(e)
+		removeObjects(e.name, e.name+"'", e.name+" roots")
+		for _, k := range extremaKinds {
+			removeObjects(e.name + " " + k.object)
+		}
+		if area != nil && area.eq == e.name {
+			clearIntegral()
+		}
+		if compared != nil && compared.eq == e.name {
+			clearComparison()
+		}
+		if mc != nil && mc.eq == e {
+			clearMonteCarlo()
+		}
+		if dragMarker != nil && dragMarker.eq == e {
+			removeDragMarker()
+		}
+		plotIntersections()
+		replotDerived()
+		logActivity("scene", "Removed %s", name)
+		return nil
+	}
+	return fmt.Errorf("there's no equation called '%s'", name)
+}
@@ -0,0 +1,247 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	explainWidth    = 40  // Characters per line of the explanation in the info panel, as for the help text
+	explainMaxList  = 5   // Most roots, extrema, or asymptotes listed by position before just counting them
+	explainPoleSize = 1e6 // How large a function needs to get at a sign change for it to count as a vertical asymptote
+)
+
+// The explanation of an equation, kept until the equation or the plotted range changes
+type explanation struct {
+	e        *equation
+	min, max float64
+	lines    []string
+}
+
+var (
+	explained explanation
+
+	// Names for polynomials of low degree
+	degreeNames = []string{"constant", "linear", "quadratic", "cubic", "quartic", "quintic"}
+)
+
+// Describes how a function behaves as x heads off towards +∞ (sign 1) or -∞ (sign -1).  It's sampled further and
+// further out, to see whether it settles down to a value, keeps going up or down, or neither
+func endBehaviour(n expr.Node, sign float64) string {
+	vars := map[string]float64{}
+	var v []float64
+	for _, x := range []float64{1e2, 3e2, 1e3, 3e3, 1e4, 3e4, 1e5, 3e5, 1e6} {
+		vars["x"] = sign * x
+		v = append(v, n.Eval(vars))
+	}
+	last, prev := v[len(v)-1], v[len(v)-2]
+	rising, falling := true, true
+	for i := 1; i < len(v); i++ {
+		rising = rising && v[i] > v[i-1]
+		falling = falling && v[i] < v[i-1]
+	}
+	switch {
+	case math.IsInf(last, 1) || (scene.Finite(last) && rising && math.Abs(last-prev) >= 1e-4*(1+math.Abs(last))):
+		return "rises towards ∞"
+	case math.IsInf(last, -1) || (scene.Finite(last) && falling &&
+		math.Abs(last-prev) >= 1e-4*(1+math.Abs(last))):
+		return "falls towards -∞"
+	case !scene.Finite(last):
+		return "isn't defined"
+	case math.Abs(last-prev) < 1e-4*(1+math.Abs(last)):
+		return "levels off towards y = " + scene.FormatCoord(last)
+	}
+	return "keeps oscillating without settling down"
+}
+
+// Returns a plain English description of an equation: whether it's a polynomial and of what degree, its roots and
+// turning points in the plotted range, any asymptotes, and what it does far out to either side
+func explainEquation(e *equation, minX float64, maxX float64) (s []string) {
+	s = append(s, fmt.Sprintf("%s: %s.", e.name, e.src))
+	if deg, ok := expr.Degree(e.expr, "x"); ok {
+		name := fmt.Sprintf("of degree %d", deg)
+		if deg < len(degreeNames) {
+			name = fmt.Sprintf("%s (degree %d)", degreeNames[deg], deg)
+		}
+		s = append(s, fmt.Sprintf("It's a polynomial, %s.", name))
+	} else {
+		s = append(s, "It isn't a polynomial.")
+	}
+	span := fmt.Sprintf("between x = %s and %s", scene.FormatCoord(minX), scene.FormatCoord(maxX))
+
+	// Roots
+	roots := findRoots(e.expr, e.deriv, minX, maxX)
+	switch {
+	case len(roots) == 0:
+		s = append(s, fmt.Sprintf("It doesn't cross zero %s.", span))
+	case len(roots) > explainMaxList:
+		s = append(s, fmt.Sprintf("It crosses zero %d times %s.", len(roots), span))
+	default:
+		var r []string
+		for _, x := range roots {
+			r = append(r, scene.FormatCoord(x))
+		}
+		s = append(s, fmt.Sprintf("It crosses zero at x = %s.", listText(r)))
+	}
+
+	// Turning points and inflections
+	var turns, bends []string
+	for _, x := range findExtrema(e, minX, maxX) {
+		p := scene.FormatPoint(x.x, x.y)
+		switch x.kind {
+		case "max":
+			turns = append(turns, "a maximum at "+p)
+		case "min":
+			turns = append(turns, "a minimum at "+p)
+		default:
+			bends = append(bends, p)
+		}
+	}
+	switch {
+	case len(turns) == 0:
+		s = append(s, fmt.Sprintf("It has no turning points %s.", span))
+	case len(turns) > explainMaxList:
+		s = append(s, fmt.Sprintf("It has %d turning points %s.", len(turns), span))
+	default:
+		s = append(s, fmt.Sprintf("It has %s.", listText(turns)))
+	}
+	if len(bends) > 0 && len(bends) <= explainMaxList {
+		s = append(s, fmt.Sprintf("Its curvature changes at %s.", listText(bends)))
+	}
+
+	// Asymptotes, then what happens far out on either side
+	poles := findPoles(e.expr, minX, maxX)
+	switch {
+	case len(poles) > explainMaxList:
+		s = append(s, fmt.Sprintf("It has %d vertical asymptotes %s.", len(poles), span))
+	case len(poles) == 1:
+		s = append(s, fmt.Sprintf("It has a vertical asymptote at x = %s.", scene.FormatCoord(poles[0])))
+	case len(poles) > 0:
+		var p []string
+		for _, x := range poles {
+			p = append(p, "x = "+scene.FormatCoord(x))
+		}
+		s = append(s, fmt.Sprintf("It has vertical asymptotes at %s.", listText(p)))
+	}
+	left, right := endBehaviour(e.expr, -1), endBehaviour(e.expr, 1)
+	if left == right {
+		s = append(s, fmt.Sprintf("Out to either side it %s.", left))
+	} else {
+		s = append(s, fmt.Sprintf("To the left it %s, and to the right it %s.", left, right))
+	}
+	return
+}
+
+// Lines for the Explanation section of the info panel, describing the most recently added equation.  The description
+// is only worked out again when the equation or plotted range changes
+func explainLines() (l []panelLine) {
+	var e *equation
+	for i := len(equations) - 1; i >= 0; i-- {
+		if !equations[i].parametric {
+			e = equations[i]
+			break
+		}
+	}
+	if e == nil {
+		return []panelLine{{text: "Add an equation to have it explained."}}
+	}
+	if explained.e != e || explained.min != graphMinX || explained.max != graphMaxX {
+		explained = explanation{e: e, min: graphMinX, max: graphMaxX}
+		for _, sentence := range explainEquation(e, graphMinX, graphMaxX) {
+			explained.lines = append(explained.lines, wrapWords(sentence, explainWidth)...)
+		}
+	}
+	for i, t := range explained.lines {
+		line := panelLine{text: t}
+		if i == 0 {
+			line.swatch = e.colour
+		}
+		l = append(l, line)
+	}
+	return
+}
+
+// Finds the vertical asymptotes of an expression of x between minX and maxX.  These are where it shoots off to ±∞,
+// either changing sign as it does (like 1/x) or landing exactly on the pole when scanning across, so it can't be
+// worked out there
+func findPoles(n expr.Node, minX float64, maxX float64) (poles []float64) {
+	vars := map[string]float64{}
+	f := func(x float64) float64 {
+		vars["x"] = x
+		return n.Eval(vars)
+	}
+	h := (maxX - minX) / rootScanSteps
+	xs, ys := make([]float64, rootScanSteps+1), make([]float64, rootScanSteps+1)
+	for i := range xs {
+		xs[i] = minX + float64(i)*h
+		ys[i] = f(xs[i])
+	}
+	for i := 0; i < rootScanSteps; i++ {
+		// Landed on the pole, with the function getting larger either side of it
+		if i >= 2 && i+2 < len(ys) && !scene.Finite(ys[i]) && scene.Finite(ys[i-1]) && scene.Finite(ys[i+1]) &&
+			math.Abs(ys[i-1]) > math.Abs(ys[i-2]) && math.Abs(ys[i+1]) > math.Abs(ys[i+2]) {
+			poles = append(poles, xs[i])
+			continue
+		}
+
+		// Changed sign, and didn't do it by passing through zero
+		a, b, fa := xs[i], xs[i+1], ys[i]
+		if !scene.Finite(fa) || !scene.Finite(ys[i+1]) || fa*ys[i+1] >= 0 {
+			continue
+		}
+		for j := 0; j < 100 && b-a > 1e-15; j++ {
+			m := (a + b) / 2
+			fm := f(m)
+			if !scene.Finite(fm) {
+				a, b = m, m
+				break
+			}
+			if fa*fm <= 0 {
+				b = m
+			} else {
+				a, fa = m, fm
+			}
+		}
+		m := (a + b) / 2
+		if v := f(m); !scene.Finite(v) || math.Abs(v) > explainPoleSize {
+			poles = append(poles, m)
+		}
+	}
+	return
+}
+
+// Joins a list in English, eg "1, 2, and 3"
+func listText(l []string) string {
+	switch len(l) {
+	case 0:
+		return ""
+	case 1:
+		return l[0]
+	case 2:
+		return l[0] + " and " + l[1]
+	}
+	return strings.Join(l[:len(l)-1], ", ") + ", and " + l[len(l)-1]
+}
+
+// Splits text into lines of at most the given number of characters, breaking between words
+func wrapWords(text string, width int) (lines []string) {
+	line := ""
+	for _, w := range strings.Fields(text) {
+		if line != "" && len([]rune(line))+1+len([]rune(w)) > width {
+			lines = append(lines, line)
+			line = ""
+		}
+		if line != "" {
+			line += " "
+		}
+		line += w
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/render"
+)
+
+// Offers the given content to the user as a file download
+func downloadFile(name string, mimeType string, content string) {
+	blob := js.Global().Get("Blob").New([]interface{}{content}, map[string]interface{}{"type": mimeType})
+	downloadBlob(name, blob)
+}
+
+// Offers an existing javascript Blob to the user as a file download
+func downloadBlob(name string, blob js.Value) {
+	url := js.Global().Get("URL").Call("createObjectURL", blob)
+	a := doc.Call("createElement", "a")
+	a.Set("href", url)
+	a.Set("download", name)
+	doc.Get("body").Call("appendChild", a)
+	a.Call("click")
+	doc.Get("body").Call("removeChild", a)
+	js.Global().Get("URL").Call("revokeObjectURL", url)
+}
+
+// Exports the current graph area as an SVG file
+func saveSVG() {
+	svg := render.SVG(world.Objects(), worldMatrix, theme, graphWidth, graphHeight, centerX, centerY, step, pathLabels,
+		showGrid)
+	downloadFile("wasmGraph.svg", "image/svg+xml", svg)
+	logInfo("Exported SVG, %v bytes", len(svg))
+}
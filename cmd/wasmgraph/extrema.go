@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+// A local maximum, minimum, or inflection point of an equation
+type extremum struct {
+	kind string // "max", "min", or "inflection"
+	x, y float64
+}
+
+var (
+	showExtrema bool // Whether the extrema and inflection points of the equations are marked
+
+	// Marker shapes, and the names of the objects using them, for each kind of extremum
+	extremaKinds = []struct {
+		kind, object, marker string
+	}{
+		{"max", "maxima", "up"},
+		{"min", "minima", "down"},
+		{"inflection", "inflections", "diamond"},
+	}
+)
+
+// Finds the local maxima, minima, and inflection points of an equation between minX and maxX.  These are the roots
+// of the first and second derivatives, checking the derivatives change sign either side so things like the flat part
+// of x^3 aren't counted as extrema
+func findExtrema(e *equation, minX float64, maxX float64) (l []extremum) {
+	d2 := e.deriv.Deriv("x")
+	d3 := d2.Deriv("x")
+	vars := map[string]float64{}
+	eval := func(n expr.Node, x float64) float64 {
+		vars["x"] = x
+		return n.Eval(vars)
+	}
+	const delta = 1e-5
+	for _, x := range findRoots(e.deriv, d2, minX, maxX) {
+		before, after := eval(e.deriv, x-delta), eval(e.deriv, x+delta)
+		switch {
+		case before > 0 && after < 0:
+			l = append(l, extremum{kind: "max", x: x, y: eval(e.expr, x)})
+		case before < 0 && after > 0:
+			l = append(l, extremum{kind: "min", x: x, y: eval(e.expr, x)})
+		}
+	}
+	for _, x := range findRoots(d2, d3, minX, maxX) {
+		if eval(d2, x-delta)*eval(d2, x+delta) < 0 {
+			l = append(l, extremum{kind: "inflection", x: x, y: eval(e.expr, x)})
+		}
+	}
+	return
+}
+
+// Lines for the info panel, listing the extrema and inflection points of each equation
+func extremaLines() (l []panelLine) {
+	if !showExtrema {
+		return
+	}
+	for _, e := range equations {
+		if e.parametric {
+			continue
+		}
+		var p []string
+		for _, x := range e.extrema {
+			p = append(p, x.kind+" "+scene.FormatPoint(x.x, x.y))
+		}
+		if len(p) == 0 {
+			p = append(p, "none")
+		}
+		l = append(l, panelLine{text: fmt.Sprintf("Extrema of %s:  %s", e.name, strings.Join(p, ", ")),
+			swatch: e.colour})
+	}
+	return
+}
+
+// Marks the extrema and inflection points of an equation, replacing any earlier markers
+func plotExtrema(e *equation) {
+	for _, k := range extremaKinds {
+		removeObjects(e.name + " " + k.object)
+	}
+	if e.parametric {
+		return
+	}
+	minX, maxX, _ := e.sampling()
+	e.extrema = findExtrema(e, minX, maxX)
+	for _, k := range extremaKinds {
+		o := Object{Name: e.name + " " + k.object, C: e.colour, Scatter: true, Marker: k.marker,
+			LabelTemplate: " (%x, %y)", LabelFont: "12px sans-serif", Equation: k.object + " of " + e.name,
+			Tags: []string{"annotations"}}
+		for _, x := range e.extrema {
+			if x.kind == k.kind {
+				o.P = append(o.P, Point{X: x.x, Y: x.y, LabelAlign: "left"})
+			}
+		}
+		if len(o.P) > 0 {
+			replaceObject(o)
+		}
+	}
+}
+
+// Turns the marking of extrema and inflection points on or off
+func toggleExtrema() {
+	showExtrema = !showExtrema
+	for _, e := range equations {
+		if showExtrema {
+			plotExtrema(e)
+		} else {
+			for _, k := range extremaKinds {
+				removeObjects(e.name + " " + k.object)
+			}
+		}
+	}
+}
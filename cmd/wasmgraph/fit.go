@@ -0,0 +1,48 @@
+package main
+
+import (
+	"math"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	fitMargin = 0.9 // Fraction of the graph area's width or height the scene is fitted into
+)
+
+// Moves and zooms the graph so everything except the axes fills the graph area, once any operations in progress
+// have finished.  The scene's centre is moved to the middle of the graph area, then the zoom changed around it
+func fitToView() {
+	whenIdle(func() {
+		b, ok := scene.Extent(world.Objects())
+		if !ok {
+			opText = "Nothing to fit to the view"
+			return
+		}
+
+		// The middle of the graph area, in world co-ordinates
+		left, top := 5.0, 5.0
+		midX := ((left+graphWidth)/2 - centerX) / step
+		midY := -((top+graphHeight)/2 - centerY) / step
+
+		// Zoom so the larger side fits, leaving the zoom alone for single points
+		ratio := 1.0
+		w, h := (b.Max.X-b.Min.X)*step, (b.Max.Y-b.Min.Y)*step
+		if w > 0 || h > 0 {
+			ratio = math.Inf(1)
+			if w > 0 {
+				ratio = (graphWidth - left) * fitMargin / w
+			}
+			if h > 0 {
+				ratio = math.Min(ratio, (graphHeight-top)*fitMargin/h)
+			}
+		}
+
+		// Scaling happens around the origin, so move the centre to where it'll end up in the middle once scaled
+		c := b.Centre()
+		queueOperation(Operation{op: TRANSLATE, t: 50, f: 12, X: midX/ratio - c.X, Y: midY/ratio - c.Y})
+		if math.Abs(ratio-1) > 1e-9 {
+			queueOperation(scaleOp(ratio, 50, 12))
+		}
+	})
+}
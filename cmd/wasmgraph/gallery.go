@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+// Lines for the Gallery section, listing the ready made graphs under their categories.  Clicking one opens it
+func galleryLines() (l []panelLine) {
+	g, err := scene.Gallery()
+	if err != nil {
+		return []panelLine{{text: err.Error(), colour: theme.Alert}}
+	}
+	category := ""
+	for _, j := range g {
+		e := j
+		if e.Category != category {
+			category = e.Category
+			l = append(l, panelLine{text: category, font: "bold 12px sans-serif"})
+		}
+		l = append(l, panelLine{text: e.Name, colour: theme.Link, indent: 15, action: func() {
+			if err := openGallery(e.Name); err != nil {
+				opText = fmt.Sprintf("Couldn't open %s: %v", e.Name, err)
+			}
+		}})
+		l = append(l, panelLine{text: e.About, font: "12px sans-serif", colour: theme.Muted, indent: 15})
+	}
+	return
+}
+
+// Replaces everything on the graph with the named graph from the gallery: its equations over its range of x, its
+// surfaces, and its labels, then turns to its view at its zoom level
+func openGallery(name string) error {
+	e, err := scene.FindGallery(name)
+	if err != nil {
+		return err
+	}
+	minX, maxX := scene.DefaultMinX, scene.DefaultMaxX
+	if len(e.Domain) == 2 {
+		minX, maxX = e.Domain[0], e.Domain[1]
+	}
+	if err = checkDomain(minX, maxX, 0); err != nil {
+		return err
+	}
+	clearObjects()
+	setDomain(minX, maxX, 0)
+	var problems []string
+	for _, src := range e.Equations {
+		if _, err := addEquation(src); err != nil {
+			problems = append(problems, fmt.Sprintf("equation '%s': %v", src, err))
+		}
+	}
+	for _, p := range e.Surfaces {
+		if _, err := addGenerated("surface", p); err != nil {
+			problems = append(problems, fmt.Sprintf("surface '%s': %v", p.String("src"), err))
+		}
+	}
+	for i, lb := range e.Labels {
+		text := fmt.Sprintf(`<span style="font: 12px sans-serif; color: %s">%s</span>`, theme.Text,
+			html.EscapeString(lb.Text))
+		addMarker(fmt.Sprintf("gallery%d", i+1), lb.X, lb.Y, lb.Z, js.ValueOf(text))
+	}
+	for _, v := range cameraViews {
+		if strings.EqualFold(v.name, e.View) {
+			turnToView(v)
+		}
+	}
+	z := e.Zoom
+	if z <= 0 {
+		z = 1
+	}
+	setZoom(z)
+	logActivity("scene", "Opened %s from the gallery", e.Name)
+	if len(problems) > 0 {
+		return fmt.Errorf("some of it couldn't be plotted: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
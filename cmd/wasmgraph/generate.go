@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+var (
+	genCount int // Number of objects made by generators so far, for naming and colouring them
+)
+
+// Makes an object with the named generator, such as "surface", "field", or "histogram", and adds it to the world
+// space.  It's named after the generator and given the next palette colour.  Returns the name it was given
+func addGenerated(kind string, p scene.Params) (string, error) {
+	o, err := scene.Generate(kind, p)
+	if err != nil {
+		return "", err
+	}
+	return addGeneratedObject(kind, o)
+}
+
+// Adds an object made by a generator to the world space, named after the generator and given the next palette colour.
+// Returns the name it was given
+func addGeneratedObject(kind string, o Object) (string, error) {
+	genCount++
+	o.Name = fmt.Sprintf("%s%d", kind, genCount)
+	if o.C == "" {
+		o.C = scene.Palette[(genCount-1)%len(scene.Palette)][0]
+	}
+	if o.DrawOrder == 0 {
+		o.DrawOrder = 50 + genCount
+	}
+	o.Tags = append(o.Tags, "generated")
+	if err := scene.Validate(o); err != nil {
+		return "", err
+	}
+	if err := addObject(o); err != nil {
+		return "", err
+	}
+	return o.Name, nil
+}
+
+// Works out an isosurface in the compute worker, as a fine grid can take seconds, then adds it to the world space like
+// the other generated objects.  The parameters are checked on the page first, so mistakes are reported straight away.
+// done is called with the new object's name, or what went wrong
+func addIsosurface(p jsParams, done func(name string, err error)) {
+	if _, _, _, _, _, err := scene.IsosurfaceParams(p); err != nil {
+		done("", err)
+		return
+	}
+	req := js.Global().Get("Object").Call("assign", js.ValueOf(map[string]interface{}{}), p.V)
+	offload("isosurface", req, func(reply js.Value) {
+		if e := reply.Get("error"); e != js.Undefined() {
+			done("", fmt.Errorf("%s", e.String()))
+			return
+		}
+		r := jsParams{V: reply}
+		o, err := scene.Triangles(r.Floats("x"), r.Floats("y"), r.Floats("z"))
+		if err != nil {
+			done("", err)
+			return
+		}
+		done(addGeneratedObject("isosurface", o))
+	})
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"syscall/js"
+)
+
+var (
+	drawHook = js.Undefined() // Javascript function the host page wants called each frame, or undefined for none
+)
+
+// Calls the host page's draw hook, if it's set one, so it can draw its own overlays on the graph area.  It's given the
+// canvas 2D context, clipped to the graph area, and the projection object.  While the render worker has the canvas,
+// it's given a stand-in context instead, whose calls are passed on to the worker.  A hook which throws an exception is
+// removed, so it doesn't take the rest of the frame down with it every time
+func drawHookOverlay(left float64, top float64) {
+	var c js.Value
+	switch r := ctx.(type) {
+	case *canvasRenderer:
+		c = r.ctx
+	case *offscreenRenderer:
+		var start int
+		c, start = r.hookContext()
+		defer r.hookDone(start)
+	}
+	if c == js.Undefined() || drawHook == js.Undefined() {
+		return
+	}
+	ctx.Save()
+	defer ctx.Restore()
+	defer func() {
+		if r := recover(); r != nil {
+			drawHook = js.Undefined()
+			logError("wasmGraph draw hook removed after it failed: %v", r)
+		}
+	}()
+	ctx.BeginPath()
+	ctx.Rect(left, top, graphWidth-left, graphHeight-top)
+	ctx.Clip()
+	drawHook.Invoke(c, projection)
+}
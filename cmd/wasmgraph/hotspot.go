@@ -0,0 +1,27 @@
+package main
+
+// A clickable area of the canvas, registered while rendering a frame
+type hotspot struct {
+	x, y, w, h float64
+	action     func()
+}
+
+// The hotspots registered by the most recently rendered frame
+var hotspots []hotspot
+
+// Registers a clickable area for the frame being rendered
+func addHotspot(x float64, y float64, w float64, h float64, action func()) {
+	hotspots = append(hotspots, hotspot{x: x, y: y, w: w, h: h, action: action})
+}
+
+// Runs the action of the top most hotspot containing the given point.  Returns true if one was hit
+func hitHotspot(x float64, y float64) bool {
+	for i := len(hotspots) - 1; i >= 0; i-- {
+		h := hotspots[i]
+		if x >= h.x && x <= h.x+h.w && y >= h.y && y <= h.y+h.h {
+			h.action()
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	htmlExec = "wasm_exec.js" // The Go wasm support script, bundled into exported pages
+	htmlWasm = "main.wasm"    // The app itself, bundled into exported pages
+)
+
+// Javascript fetching the support script and the app, then filling them into the page template.  The app is base64
+// encoded, so it fits in a script.  done is called with a Blob of the finished page, or null and what went wrong
+const htmlJS = `
+return function(template, exec, wasm, done) {
+	function ok(r) {
+		if (!r.ok) {
+			throw new Error(r.url + ": " + r.status + " " + r.statusText);
+		}
+		return r;
+	}
+	Promise.all([
+		fetch(exec).then(ok).then(function(r) { return r.text(); }),
+		fetch(wasm).then(ok).then(function(r) { return r.blob(); }).then(function(b) {
+			return new Promise(function(resolve, reject) {
+				var f = new FileReader();
+				f.onload = function() { resolve(f.result.slice(f.result.indexOf(",") + 1)); };
+				f.onerror = function() { reject(f.error); };
+				f.readAsDataURL(b);
+			});
+		})
+	]).then(function(parts) {
+		var page = template.replace("{{exec}}", function() { return parts[0].replace(/<\/script/gi, "<\\/script"); })
+			.replace("{{wasm}}", function() { return parts[1]; });
+		done(new Blob([page], {type: "text/html"}));
+	}).catch(function(err) {
+		done(null, String(err));
+	});
+};
+`
+
+// The exported page.  It's the same as index.html, except the support script, app, and scene are all inside it, so
+// it opens offline
+const htmlPage = `<html>
+<head>
+    <title>%s</title>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+    <script>{{exec}}</script>
+    <script>
+        var wasmGraphScene = %s;
+        const go = new Go();
+        const wasm = atob("{{wasm}}");
+        const bytes = new Uint8Array(wasm.length);
+        for (let i = 0; i < wasm.length; i++) {
+            bytes[i] = wasm.charCodeAt(i);
+        }
+        WebAssembly.instantiate(bytes, go.importObject).then( res=> {
+            go.run(res.instance)
+        })
+    </script>
+    <style>
+        body,pre { margin:0;padding:0; }
+        #mycanvas {
+            position:fixed;
+            opacity:1.0;
+            width: 100%%;
+            height:100%%;
+            top:0;right:0;bottom:0;left:0;
+            border: 1px solid black;
+            touch-action: none;
+        }
+        .wasmGraph-marker {
+            transform: translate(-50%%, -100%%);
+        }
+    </style>
+</head>
+<body>
+<canvas id="mycanvas">Your browser doesn't appear to support the canvas tag.</canvas>
+</body>
+</html>
+`
+
+var (
+	htmlBuild = js.Undefined() // The javascript building exported pages, created when first needed
+	htmlCall  js.Callback
+)
+
+// Loads the scene bundled into an exported page, if this is one
+func loadEmbeddedScene() {
+	data := js.Global().Get("wasmGraphScene")
+	if data.Type() != js.TypeObject {
+		return
+	}
+	s, err := scene.Parse([]byte(js.Global().Get("JSON").Call("stringify", data).String()))
+	if err == nil {
+		err = loadScene(s)
+	}
+	if err != nil {
+		logError("Couldn't load the scene in the page: %v", err)
+	}
+}
+
+// Offers the current scene as a single HTML file, with the app bundled in, so it can be passed around and opened
+// without a web server.  The app has to be fetched again to bundle it, which the browser does in the background
+func saveHTML() error {
+	// Marshalling escapes < and >, so the JSON can't end the script early.  Braces are only ever doubled up inside
+	// strings, where they're escaped so they can't be mistaken for the placeholders
+	data, err := json.Marshal(saveScene())
+	if err != nil {
+		return err
+	}
+	sceneJSON := strings.Replace(string(data), "{{", `{\u007b`, -1)
+	title := "wasmGraph"
+	if len(equations) > 0 {
+		var srcs []string
+		for _, e := range equations {
+			srcs = append(srcs, e.src)
+		}
+		esc := strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "{", "&#123;")
+		title += " - " + esc.Replace(strings.Join(srcs, ", "))
+	}
+	if htmlBuild == js.Undefined() {
+		htmlBuild = js.Global().Get("Function").New(htmlJS).Invoke()
+	}
+	htmlCall.Release()
+	htmlCall = js.NewCallback(func(args []js.Value) {
+		if args[0] == js.Null() {
+			opText = fmt.Sprintf("Couldn't export the page: %s", args[1].String())
+			return
+		}
+		downloadBlob("wasmGraph.html", args[0])
+		logInfo("Exported HTML, %v bytes", args[0].Get("size").Int())
+	})
+	htmlBuild.Invoke(fmt.Sprintf(htmlPage, title, sceneJSON), htmlExec, htmlWasm, htmlCall)
+	return nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"syscall/js"
+	"time"
+)
+
+const (
+	idleAfter = 10 * time.Second // How long without input or animation before dropping the frame rate
+	idleFPS   = 2                // Frame rate used while idle
+)
+
+var (
+	lastActivity = time.Now()
+	needsRedraw  = true      // Set when something's changed since the last frame was drawn
+	idling       bool        // True while rendering at the idle frame rate
+	idleTimer    js.Value    // The pending setTimeout() while idle
+	idleTimerSet bool        // True while idleTimer hasn't fired yet
+	idleCall     js.Callback // Called by setTimeout() while idle, to request the next frame
+)
+
+// Sets up the callback used for the idle frame rate
+func initIdle() {
+	idleCall = js.NewCallback(func(args []js.Value) {
+		idleTimerSet = false
+		js.Global().Call("requestAnimationFrame", rCall)
+	})
+}
+
+// Returns true while something is moving or changing on its own, such as an operation, the projectile, or
+// auto-rotate, so every frame needs drawing
+func animating() bool {
+	return renderActive.Load() || interp != nil || recording || (mc != nil && mc.n < mcPoints) || projectile != nil ||
+		(tl != nil && tl.playing) || csvImp != nil || selfTestRunning || autoRotate
+}
+
+// Returns true if the next frame needs drawing.  Frames are skipped while nothing has changed since the last one, so a
+// still graph doesn't cost anything to show.  A draw hook from the host page gets every frame, as there's no telling
+// what it's showing
+func frameNeeded() bool {
+	return needsRedraw || animating() || drawHook != js.Undefined()
+}
+
+// Returns true when nothing has happened for long enough to drop to the idle frame rate
+func isIdle() bool {
+	return time.Since(lastActivity) > idleAfter && !animating()
+}
+
+// Records that the user did something (or an animation step happened), so the next frame is drawn.  Resumes the full
+// frame rate straight away if we were idle
+func markActivity() {
+	lastActivity = time.Now()
+	needsRedraw = true
+	if idling && idleTimerSet {
+		js.Global().Call("clearTimeout", idleTimer)
+		idleTimerSet = false
+		js.Global().Call("requestAnimationFrame", rCall)
+	}
+	idling = false
+}
+
+// Releases the idle callback
+func releaseIdle() {
+	idleCall.Release()
+}
+
+// Schedules the next frame render call, at a much lower rate when idle
+func scheduleFrame() {
+	if isIdle() {
+		if !idling {
+			needsRedraw = true // To show the paused indicator
+		}
+		idling = true
+		idleTimer = js.Global().Call("setTimeout", idleCall, 1000/idleFPS)
+		idleTimerSet = true
+		return
+	}
+	idling = false
+	js.Global().Call("requestAnimationFrame", rCall)
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"syscall/js"
+	"testing"
+
+	"github.com/justinclift/wasmGraph4/pkg/compute"
+	"go.uber.org/atomic"
+)
+
+// Puts the frame renderer in the state it's in once a still graph has been drawn, with nothing changed since
+func settle(t *testing.T) {
+	if renderActive == nil {
+		renderActive = atomic.NewBool(false)
+	}
+	needsRedraw = false
+	if frameNeeded() {
+		t.Fatal("a frame is due with nothing changed")
+	}
+}
+
+func TestWorkerReplyDrawsFrame(t *testing.T) {
+	// A stand-in for the compute worker, which keeps the tasks posted to it rather than working them out
+	posted := make(chan js.Value, 1)
+	post := js.NewCallback(func(args []js.Value) { posted <- args[0] })
+	defer post.Release()
+	worker = js.Global().Get("Object").New()
+	worker.Set("postMessage", post)
+	defer func() { worker = js.Undefined() }()
+
+	settle(t)
+	var name string
+	var err error
+	p := map[string]interface{}{"src": "x^2 + y^2 + z^2 = 4", "min": -3, "max": 3, "n": 8}
+	addIsosurface(jsParams{V: js.ValueOf(p)}, func(n string, e error) { name, err = n, e })
+	req := <-posted
+	if frameNeeded() {
+		t.Fatal("a frame is due before the worker has replied")
+	}
+
+	// Reply the way the worker does, with no input in between
+	reply := map[string]interface{}{"id": req.Get("id").Int()}
+	res, e := compute.Run(req.Get("task").String(), jsParams{V: req})
+	if e != nil {
+		t.Fatal(e)
+	}
+	for k, v := range res {
+		reply[k] = compute.Float64Array(v)
+	}
+	workerReply([]js.Value{js.ValueOf(map[string]interface{}{"data": reply})})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := findObject(name); !ok {
+		t.Fatalf("the isosurface %q isn't in the world space", name)
+	}
+	if !frameNeeded() {
+		t.Error("the isosurface from the worker isn't drawn until the next input")
+	}
+	removeObjects(name)
+}
+
+func TestConfigLoadedDrawsFrame(t *testing.T) {
+	doc = js.Global().Get("Object").New()
+	defer func() { doc = js.Value{} }()
+	old := theme
+	defer func() { theme = old }()
+
+	settle(t)
+	configLoaded([]js.Value{js.ValueOf(`{"Theme": "dark", "Title": "Maths"}`)})
+	if theme.Name != "dark" {
+		t.Fatalf("the theme is %q, want dark", theme.Name)
+	}
+	if !frameNeeded() {
+		t.Error("the deployment settings aren't drawn until the next input")
+	}
+}
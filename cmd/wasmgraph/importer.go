@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+)
+
+var (
+	dragOverCall js.Callback // Lets files be dropped on the page
+	dropCall     js.Callback // Imports dropped files
+	importCall   js.Callback // Imports the text of a dropped file, once the browser has read it
+
+	// LaTeX commands which are the same as our function names and constants, once the backslash is removed
+	latexNames = []string{"sinh", "cosh", "tanh", "sin", "cos", "tan", "exp", "ln", "log", "pi"}
+
+	// Other LaTeX, as exported by Desmos, and what it means to the expression parser
+	latexReplacer = strings.NewReplacer(
+		`\left(`, "(", `\right)`, ")", `\left[`, "(", `\right]`, ")", `\left|`, "|", `\right|`, "|",
+		`\left\{`, "(", `\right\}`, ")", `\cdot`, "*", `\times`, "*", `\div`, "/",
+		`\arcsin`, "asin", `\arccos`, "acos", `\arctan`, "atan", `\operatorname{abs}`, "abs",
+		`\operatorname`, "", `\mathrm`, "", `\,`, " ", `\ `, " ", `\:`, " ", `\;`, " ", `\!`, "",
+	)
+)
+
+// Finds the brace group starting at s[i], returning its contents and the index just after it
+func braceGroup(s string, i int) (string, int, bool) {
+	if i >= len(s) || s[i] != '{' {
+		return "", i, false
+	}
+	depth := 0
+	for j := i; j < len(s); j++ {
+		switch s[j] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return s[i+1 : j], j + 1, true
+			}
+		}
+	}
+	return "", i, false
+}
+
+// Plots each line of a list of expressions (as exported by Desmos or GeoGebra) as its own equation, with the usual
+// automatic colours.  Blank lines and comments are skipped.  Returns the number of equations added, and a description
+// of each line which couldn't be plotted
+func importExpressions(text string) (added int, problems []string) {
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") || strings.HasPrefix(line, "%") {
+			continue
+		}
+		if _, err := addEquation(importLine(line)); err != nil {
+			problems = append(problems, fmt.Sprintf("line %d (%s): %v", i+1, line, err))
+			continue
+		}
+		added++
+	}
+	return
+}
+
+// Imports the files dropped on the page.  CSV files are plotted as data, anything else is taken as a list of
+// expressions
+func dropHandler(event js.Value) {
+	markActivity()
+	files := event.Get("dataTransfer").Get("files")
+	for i := 0; i < files.Length(); i++ {
+		f := files.Index(i)
+		if isCSV(f) {
+			startCSV(f) // CSV files can be huge, so they're read a chunk at a time
+			continue
+		}
+		f.Call("text").Call("then", importCall)
+	}
+}
+
+// Sets up the handlers for importing expression lists and CSV files dropped on the page
+func initImport() {
+	dragOverCall = js.NewEventCallback(js.PreventDefault, func(event js.Value) {})
+	dropCall = js.NewEventCallback(js.PreventDefault, dropHandler)
+	importCall = js.NewCallback(func(args []js.Value) {
+		n, problems := importExpressions(args[0].String())
+		if len(problems) > 0 {
+			js.Global().Call("alert", fmt.Sprintf("Imported %d equations.  These lines couldn't be plotted:\n%s", n,
+				strings.Join(problems, "\n")))
+		}
+	})
+	csvCall = js.NewCallback(csvChunk)
+	csvErrCall = js.NewCallback(csvReadError)
+	doc.Call("addEventListener", "dragover", dragOverCall)
+	doc.Call("addEventListener", "drop", dropCall)
+}
+
+// Converts an imported line into the form addEquation expects.  GeoGebra style names ("g: y = 2x") are dropped, and
+// Desmos style LaTeX is turned into plain expressions
+func importLine(line string) string {
+	if i := strings.Index(line, ":"); i > 0 && isIdentifier(strings.TrimSpace(line[:i])) {
+		line = line[i+1:]
+	}
+	if !strings.Contains(line, `\`) {
+		return strings.TrimSpace(line)
+	}
+	return strings.TrimSpace(latexToExpr(line))
+}
+
+// Returns true if the string is a plain name, such as "f" or "g_1"
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if !(c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9')) {
+			return false
+		}
+	}
+	return true
+}
+
+// Converts the subset of LaTeX used for plain expressions into something the expression parser understands.  Brace
+// groups are already treated as brackets by the parser, so "x^{2}" just works
+func latexToExpr(s string) string {
+	s = latexReplacer.Replace(s)
+
+	// Fractions and roots take brace group arguments
+	var b strings.Builder
+	for i := 0; i < len(s); {
+		switch {
+		case strings.HasPrefix(s[i:], `\frac`):
+			num, j, ok1 := braceGroup(s, i+5)
+			den, k, ok2 := braceGroup(s, j)
+			if ok1 && ok2 {
+				b.WriteString("((" + latexToExpr(num) + ")/(" + latexToExpr(den) + "))")
+				i = k
+				continue
+			}
+		case strings.HasPrefix(s[i:], `\sqrt[`):
+			if j := strings.Index(s[i:], "]"); j > 0 {
+				n := s[i+6 : i+j]
+				if arg, k, ok := braceGroup(s, i+j+1); ok {
+					b.WriteString("((" + latexToExpr(arg) + ")^(1/(" + latexToExpr(n) + ")))")
+					i = k
+					continue
+				}
+			}
+		case strings.HasPrefix(s[i:], `\sqrt`):
+			b.WriteString("sqrt")
+			i += 5
+			continue
+		case s[i] == '\\':
+			matched := false
+			for _, n := range latexNames {
+				if strings.HasPrefix(s[i+1:], n) {
+					b.WriteString(n)
+					i += len(n) + 1
+					matched = true
+					break
+				}
+			}
+			if matched {
+				continue
+			}
+		}
+		b.WriteByte(s[i])
+		i++
+	}
+	return b.String()
+}
+
+// Releases the import handlers
+func releaseImport() {
+	doc.Call("removeEventListener", "dragover", dragOverCall)
+	doc.Call("removeEventListener", "drop", dropCall)
+	dragOverCall.Release()
+	dropCall.Release()
+	importCall.Release()
+	csvCall.Release()
+	csvErrCall.Release()
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"syscall/js"
+	"time"
+)
+
+const (
+	// Returns a keydown listener passing only the shortcut keys the browser has behaviours of its own for on to cb, so
+	// the rest of the keys keep theirs
+	shortcutKeysJS = `return function(e) {
+	var k = e.key.toLowerCase();
+	if (k === 's' && (e.ctrlKey || e.metaKey)) {
+		cb(e);
+	} else if (k === 'l' && e.ctrlKey && e.shiftKey) {
+		cb(e);
+	}
+};`
+)
+
+var (
+	inputCalls []js.Callback // The event listener callbacks, for releasing
+)
+
+// Listens for the mouse and keyboard events on the page.  They're handled as soon as they arrive, as drawing the frames
+// happens in the render worker when the browser has one, rather than holding them up
+func initInput() {
+	listen := func(kind string, handler func(args []js.Value)) {
+		c := inputCallback(handler)
+		inputCalls = append(inputCalls, c)
+		doc.Call("addEventListener", kind, c)
+	}
+	listen("mousedown", clickHandler)
+	listen("keydown", keypressHandler)
+	listen("mousemove", moveHandler)
+	listen("mouseup", mouseUpHandler)
+	listen("wheel", wheelHandler)
+
+	// The browser's own behaviour for the shortcut keys, like saving the page for Ctrl+S or opening the downloads for
+	// Ctrl+Shift+L, needs stopping before the event returns, so can't wait for the (asynchronous) key handler
+	pd := js.NewEventCallback(js.PreventDefault, func(event js.Value) {})
+	inputCalls = append(inputCalls, pd)
+	doc.Call("addEventListener", "keydown", js.Global().Get("Function").New("cb", shortcutKeysJS).Invoke(pd))
+}
+
+// Returns a callback which handles an event with its handler, then wakes the renderer if it's idling so the change is
+// drawn
+func inputCallback(handler func(args []js.Value)) js.Callback {
+	return js.NewCallback(func(args []js.Value) {
+		lastInput = time.Now()
+		handler(args)
+		markActivity()
+	})
+}
+
+// Stops listening for input events, and releases their callbacks
+func releaseInput() {
+	for _, c := range inputCalls {
+		c.Release()
+	}
+	inputCalls = nil
+}
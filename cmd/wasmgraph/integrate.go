@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+	"github.com/justinclift/wasmGraph4/pkg/render"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	integralSteps  = 1000         // Number of Simpson's rule intervals used for definite integrals.  Must be even
+	integralPoints = 200          // Number of points along the curve for the shaded area
+	integralColour = "dodgerblue" // Colour of the shaded area
+	integralAlpha  = 0.3          // Its opacity, so the grid and axes show through it
+)
+
+// A definite integral of an equation, shown as a shaded area under its curve
+type integral struct {
+	eq    string // Name of the equation
+	a, b  float64
+	value float64
+}
+
+var (
+	area *integral // The integral currently shown, if any
+)
+
+// Removes the shaded area and its integral
+func clearIntegral() {
+	area = nil
+	removeObjects("area")
+}
+
+// Computes the definite integral of an expression of x between a and b, using Simpson's rule.  Returns an error if
+// the expression isn't defined everywhere in between
+func integrate(n expr.Node, a float64, b float64) (float64, error) {
+	h := (b - a) / integralSteps
+	vars := map[string]float64{}
+	sum := 0.0
+	for i := 0; i <= integralSteps; i++ {
+		vars["x"] = a + float64(i)*h
+		y := n.Eval(vars)
+		if !scene.Finite(y) {
+			return 0, fmt.Errorf("the equation isn't defined at x = %s", scene.FormatCoord(vars["x"]))
+		}
+		switch {
+		case i == 0 || i == integralSteps:
+			sum += y
+		case i%2 == 1:
+			sum += 4 * y
+		default:
+			sum += 2 * y
+		}
+	}
+	return sum * h / 3, nil
+}
+
+// Lines for the info panel, describing the integral being shown
+func integralLines() (l []panelLine) {
+	if area == nil {
+		return
+	}
+	l = append(l, panelLine{text: fmt.Sprintf("∫ %s from %s to %s = %s   ✕", area.eq, scene.FormatCoord(area.a),
+		scene.FormatCoord(area.b), scene.FormatCoord(area.value)),
+		swatch: render.Translucent(integralColour, integralAlpha), action: clearIntegral})
+	return
+}
+
+// Asks the user for an equation and the x values to integrate it between
+func promptIntegral() {
+	def := "-1..1"
+	for i := len(equations) - 1; i >= 0; i-- {
+		if !equations[i].parametric {
+			def = equations[i].name + ": " + def
+			break
+		}
+	}
+	val := js.Global().Call("prompt", "Equation to integrate, and the range of x (e.g. f1: -1..2):", def)
+	if val == js.Null() || val == js.Undefined() || strings.TrimSpace(val.String()) == "" {
+		return
+	}
+	s := val.String()
+	i := strings.Index(s, ":")
+	if i < 0 {
+		js.Global().Call("alert", "Please give the equation name, then a colon, then the range of x")
+		return
+	}
+	a, b, err := scene.ParseRange(s[i+1:])
+	if err == nil {
+		err = showIntegral(strings.TrimSpace(s[:i]), a, b)
+	}
+	if err != nil {
+		js.Global().Call("alert", fmt.Sprintf("Couldn't integrate that: %v", err))
+	}
+}
+
+// Integrates an equation from one x value to another, shading the area between its curve and the X axis
+func showIntegral(name string, a float64, b float64) error {
+	e, ok := findEquation(name)
+	if !ok {
+		return fmt.Errorf("there's no equation called '%s'", name)
+	}
+	if e.parametric {
+		return fmt.Errorf("parametric curves can't be integrated")
+	}
+	v, err := integrate(e.expr, a, b)
+	if err != nil {
+		return err
+	}
+
+	// The shaded area follows the curve from a to b, then comes back along the X axis
+	shape := Object{Name: "area", C: integralColour, Alpha: integralAlpha, Equation: fmt.Sprintf("∫ %s dx", e.expr),
+		Tags: []string{"annotations"}}
+	shape.P = append(shape.P, Point{X: a})
+	vars := map[string]float64{}
+	for i := 0; i <= integralPoints; i++ {
+		vars["x"] = a + (b-a)*float64(i)/integralPoints
+		shape.P = append(shape.P, Point{X: vars["x"], Y: e.expr.Eval(vars)})
+	}
+	shape.P = append(shape.P, Point{X: b})
+	var s Surface
+	for i := range shape.P {
+		s = append(s, i)
+	}
+	shape.S = []Surface{s}
+	replaceObject(shape)
+	area = &integral{eq: name, a: a, b: b, value: v}
+	return nil
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	interpBelow  = 30.0 // Frame rate animations start being interpolated below, in frames per second
+	interpAbove  = 45.0 // Frame rate they stop being interpolated above again.  The gap stops it flicking on and off
+	interpRate   = 10.0 // Times a second the world space is worked out properly while interpolating
+	interpSmooth = 0.1  // Weight of each new frame in the smoothed frame rate
+	interpGap    = 250  // Longest gap between frames counted towards the frame rate, in milliseconds
+)
+
+// Two states of the animation, worked out properly at either side of the frames being drawn
+type interpolation struct {
+	from, to   *scene.Registry
+	fromM, toM matrix
+	toV        geometry.View   // The world view of the later state, which operations carry on from
+	fromT, toT float64         // Frame timestamps of the two states, in milliseconds
+	shown      *scene.Registry // The world space drawn, with its points moved in between the two states
+	shownM     matrix          // The world matrix drawn, in between the two states
+}
+
+var (
+	frameRate float64 // Smoothed frame rate while animating, in frames per second
+	interp    *interpolation
+	interpOn  bool    // Set while the frame rate is low enough for animations to be interpolated
+	lastStep  float64 // Timestamp of the last animation step, in milliseconds
+)
+
+// Returns the lines for the Operation section about interpolation, when it's being used
+func interpLines() []panelLine {
+	if !interpOn {
+		return nil
+	}
+	return []panelLine{{text: fmt.Sprintf("Smoothing animation at %.0f fps", frameRate), colour: theme.Muted}}
+}
+
+// Moves the points of the shown world space to part way between two states, t being 0 for the first and 1 for the
+// second.  Objects are matched up by name, and any not in the first state, or with different points, are shown as
+// they are in the second
+func lerpObjects(shown *scene.Registry, a *scene.Registry, b *scene.Registry, t float64) {
+	for _, o := range shown.Objects() {
+		from, ok := a.Get(o.Name)
+		to, _ := b.Get(o.Name)
+		if !ok || len(from.P) != len(to.P) {
+			copy(o.P, to.P)
+			continue
+		}
+		for j := range o.P {
+			p, q := from.P[j], to.P[j]
+			s := &o.P[j] // The points are shared with the registry's copy
+			s.X, s.Y, s.Z = p.X+(q.X-p.X)*t, p.Y+(q.Y-p.Y)*t, p.Z+(q.Z-p.Z)*t
+		}
+	}
+}
+
+// Updates the smoothed frame rate while something's animating, and turns interpolation on or off to suit
+func measureFrameRate() {
+	if renderActive.Load() && lastStep > 0 && frameTime > lastStep && frameTime-lastStep < interpGap {
+		fps := 1000 / (frameTime - lastStep)
+		if frameRate == 0 {
+			frameRate = fps
+		} else {
+			frameRate += (fps - frameRate) * interpSmooth
+		}
+		switch {
+		case frameRate < interpBelow:
+			interpOn = true
+		case frameRate > interpAbove:
+			interpOn = false
+		}
+	}
+	lastStep = frameTime
+}
+
+// Returns true if the world space is still the one shown by the interpolation, rather than having been rebuilt by
+// something else since
+func showingInterp() bool {
+	return world == interp.shown
+}
+
+// Steps the animations for this frame.  Normally the operations are just stepped to the frame time.  When the frame
+// rate drops too low though, working out the world space for every frame holds things up even more, so instead it's
+// worked out properly a few times a second, ahead of time, and the frames in between are shown part way between the
+// last two states.  The world matrix is moved between them the same way, so everything projected with it lines up
+func stepAnimation() {
+	measureFrameRate()
+	if interp == nil && (!interpOn || !renderActive.Load()) {
+		stepOperations()
+		return
+	}
+	if interp == nil {
+		interp = &interpolation{to: world, toM: worldMatrix, toV: worldView, toT: frameTime, shownM: worldMatrix}
+	} else if !showingInterp() {
+		// Something else changed the scene, so work out both states again with the change included.  Panning or
+		// zooming while an operation's animating moves the world matrix, which is carried over to both
+		if len(worldMatrix) > 0 && &worldMatrix[0] != &interp.shownM[0] {
+			if inv, ok := geometry.Invert(interp.shownM); ok {
+				change := geometry.Multiply(worldMatrix, inv)
+				interp.fromM = geometry.Multiply(change, interp.fromM)
+				interp.toM = geometry.Multiply(change, interp.toM)
+				interp.toV = geometry.MatrixView(interp.toM)
+			}
+		}
+		interp.from = flattenWorld(interp.fromM)
+		interp.to = flattenWorld(interp.toM)
+		interp.shown = nil
+	}
+	if frameTime >= interp.toT {
+		// Reached the later state, so work out the next one.  Operations are stepped to its time then put the frame
+		// time back, so they play at the same speed as they would otherwise
+		worldView, worldMatrix, world = interp.toV, interp.toM, interp.to
+		if !interpOn || !renderActive.Load() {
+			interp = nil
+			stepOperations()
+			return
+		}
+		now := frameTime
+		next := &interpolation{from: interp.to, fromM: interp.toM, fromT: interp.toT, toT: now + 1000/interpRate}
+		frameTime = next.toT
+		stepOperations()
+		frameTime = now
+		next.to, next.toM, next.toV = world, worldMatrix, worldView
+		interp = next
+	}
+	if interp.shown == nil {
+		objs := make([]Object, interp.to.Len())
+		for i, o := range interp.to.Objects() {
+			objs[i] = o
+			objs[i].P = append([]Point(nil), o.P...)
+		}
+		interp.shown, _ = scene.NewRegistry(objs) // Already in draw order, with unique names
+	}
+	t := (frameTime - interp.fromT) / (interp.toT - interp.fromT)
+	lerpObjects(interp.shown, interp.from, interp.to, t)
+	m := make(matrix, len(interp.toM))
+	for i := range m {
+		m[i] = interp.fromM[i] + (interp.toM[i]-interp.fromM[i])*t
+	}
+	interp.shownM = m
+	worldMatrix, world = m, interp.shown
+}
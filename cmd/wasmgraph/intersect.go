@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/render"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	intersectColour = "darkslategray"
+)
+
+// A point where two equation curves cross
+type intersection struct {
+	a, b string // Names of the two equations
+	x, y float64
+}
+
+var (
+	showIntersections bool // Whether the intersections of the equation curves are marked
+	intersections     []intersection
+)
+
+// Draws the co-ordinate labels for the intersections, placed around their markers so they don't overlap each other
+func drawIntersectionLabels(left float64, top float64) {
+	if !showIntersections || len(intersections) == 0 {
+		return
+	}
+	ctx.Save()
+	ctx.BeginPath()
+	ctx.Rect(left, top, graphWidth-left, graphHeight-top)
+	ctx.Clip()
+	ctx.SetFont("12px sans-serif")
+	ctx.SetTextAlign("left")
+	ctx.SetTextBaseline("alphabetic")
+	ctx.SetFillStyle(theme.Text)
+	var anchors [][2]float64
+	var text []string
+	var widths []float64
+	for _, p := range intersections {
+		x, y := geometry.Project(worldMatrix, centerX, centerY, step, p.x, p.y, 0)
+		t := scene.FormatPoint(p.x, p.y)
+		anchors = append(anchors, [2]float64{x, y})
+		text = append(text, t)
+		widths = append(widths, ctx.MeasureText(t))
+	}
+	for i, p := range render.PlaceLabels(anchors, widths, 12) {
+		ctx.FillText(text[i], p[0], p[1])
+	}
+	ctx.Restore()
+}
+
+// Finds the points between minX and maxX where two equation curves cross, by finding the roots of their difference
+func findIntersections(e1 *equation, e2 *equation, minX float64, maxX float64) (l []intersection) {
+	// Curves which are the same everywhere don't have separate crossing points
+	if e1.expr.String() == e2.expr.String() {
+		return
+	}
+	vars := map[string]float64{}
+	for _, x := range findRoots(expr.Sub(e1.expr, e2.expr), expr.Sub(e1.deriv, e2.deriv), minX, maxX) {
+		vars["x"] = x
+		if y := e1.expr.Eval(vars); scene.Finite(y) {
+			l = append(l, intersection{a: e1.name, b: e2.name, x: x, y: y})
+		}
+	}
+	return
+}
+
+// Lines for the info panel, listing the intersections of each pair of equations
+func intersectionLines() (l []panelLine) {
+	if !showIntersections {
+		return
+	}
+	var pairs []string
+	found := map[string][]string{}
+	for _, p := range intersections {
+		pair := p.a + " & " + p.b
+		if _, ok := found[pair]; !ok {
+			pairs = append(pairs, pair)
+		}
+		found[pair] = append(found[pair], scene.FormatPoint(p.x, p.y))
+	}
+	if len(pairs) == 0 {
+		return []panelLine{{text: "Intersections: none", swatch: intersectColour}}
+	}
+	for _, pair := range pairs {
+		l = append(l, panelLine{text: fmt.Sprintf("%s cross at  %s", pair, strings.Join(found[pair], ", ")),
+			swatch: intersectColour})
+	}
+	return
+}
+
+// Marks the points where each pair of equation curves cross, replacing any earlier markers
+func plotIntersections() {
+	removeObjects("intersections")
+	intersections = nil
+	if !showIntersections {
+		return
+	}
+	for i, e1 := range equations {
+		if e1.parametric {
+			continue
+		}
+		for _, e2 := range equations[i+1:] {
+			if !e2.parametric {
+				intersections = append(intersections, findIntersections(e1, e2, graphMinX, graphMaxX)...)
+			}
+		}
+	}
+	o := Object{Name: "intersections", C: intersectColour, Scatter: true, Marker: "square",
+		Equation: "intersections of the equations", Tags: []string{"annotations"}}
+	for _, p := range intersections {
+		o.P = append(o.P, Point{X: p.x, Y: p.y})
+	}
+	if len(o.P) > 0 {
+		replaceObject(o)
+	}
+}
+
+// Turns the marking of intersections on or off
+func toggleIntersections() {
+	showIntersections = !showIntersections
+	plotIntersections()
+}
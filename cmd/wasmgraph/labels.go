@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/justinclift/wasmGraph4/pkg/render"
+)
+
+var (
+	hiddenLabels int // Number of point labels left out of the last frame, as there was nowhere clear to put them
+)
+
+// Lines for the Operation section, saying when the label layout is off or had to leave some labels out.  Clicking
+// them turns it on or off
+func labelLayoutLines() []panelLine {
+	switch {
+	case !render.LabelLayout:
+		return []panelLine{{text: "Label layout: off", colour: theme.Link, action: toggleLabelLayout}}
+	case hiddenLabels > 0:
+		return []panelLine{{text: fmt.Sprintf("Labels: %d hidden (overlapping)", hiddenLabels), colour: theme.Link,
+			action: toggleLabelLayout}}
+	}
+	return nil
+}
+
+// Turns the label layout on or off.  When it's off, every point label is drawn where its point says, even on top of
+// others
+func toggleLabelLayout() {
+	render.LabelLayout = !render.LabelLayout
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"math"
+)
+
+const (
+	narrowWidth   = 700 // Viewports narrower than this (in CSS pixels) use the stacked layout
+	stackedGraphH = 0.6 // Fraction of the viewport height given to the graph in the stacked layout
+	sourceLinkH   = 55  // Height of the source code link area, at the bottom of the info panel
+)
+
+type layoutMode int
+
+const (
+	SIDE    layoutMode = iota // Info panel to the right of the graph
+	STACKED                   // Info panel below the graph, for narrow or portrait screens
+)
+
+var (
+	layout                         layoutMode
+	panelX, panelY, panelW, panelH float64 // The info panel area, including the source code link
+)
+
+// Returns true if the given point is inside the info panel
+func inPanel(x float64, y float64) bool {
+	return x >= panelX && x <= panelX+panelW && y >= panelY && y <= panelY+panelH
+}
+
+// Returns true if the given point is over the source code link
+func inSourceLink(x float64, y float64) bool {
+	return inPanel(x, y) && y > panelY+panelH-40
+}
+
+// Works out where the graph and info panel go, based on the size and shape of the viewport
+func updateLayout() {
+	if width < narrowWidth || height > width {
+		layout = STACKED
+		graphWidth = width - 1
+		graphHeight = math.Floor(height * stackedGraphH)
+		panelX, panelY = 0, graphHeight+1
+		panelW, panelH = width, height-graphHeight-1
+	} else {
+		layout = SIDE
+		graphWidth = width * 0.75
+		graphHeight = height - 1
+		panelX, panelY = graphWidth+1, 0
+		panelW, panelH = width-graphWidth-1, height
+	}
+}
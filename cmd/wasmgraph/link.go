@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	linkChannel = "wasmGraph-link" // BroadcastChannel the linked plots talk over
+
+	linkOff     = "off"     // Not linked
+	linkZoomPan = "zoompan" // Follow the zoom and panning of the other plots, keeping our own rotation
+	linkAll     = "all"     // Follow everything, rotation included
+)
+
+var (
+	link      = js.Undefined() // The BroadcastChannel, or undefined when the browser doesn't have them
+	linkCall  js.Callback      // Receives the view from the other plots
+	linkMode  = linkOff
+	linkModes = []string{linkOff, linkZoomPan, linkAll} // In the order clicking the info panel line cycles through them
+	linkSent  matrix                                    // The world matrix last sent to, or taken from, the other plots
+)
+
+// Opens the channel for linking the view with other plots on the page, or in other tabs or frames
+func initLink() {
+	linkCall = js.NewCallback(linkReceive)
+	bc := js.Global().Get("BroadcastChannel")
+	if bc == js.Undefined() || safeMode {
+		return
+	}
+	link = bc.New(linkChannel)
+	link.Set("onmessage", linkCall)
+}
+
+// Returns the text describing a link mode, for the info panel
+func linkText(mode string) string {
+	switch mode {
+	case linkZoomPan:
+		return "zoom and pan"
+	case linkAll:
+		return "zoom, pan, and rotation"
+	}
+	return "off"
+}
+
+// Lines for the info panel, showing what's linked with the other plots.  Clicking it changes that
+func linkLines() []panelLine {
+	if link == js.Undefined() {
+		return nil
+	}
+	return []panelLine{{text: fmt.Sprintf("Linked plots: %s", linkText(linkMode)), colour: theme.Link, action: func() {
+		for i, m := range linkModes {
+			if m == linkMode {
+				setLink(linkModes[(i+1)%len(linkModes)])
+				return
+			}
+		}
+	}}}
+}
+
+// Moves the graph to the view another plot has sent.  Our own animations take priority, so the view is ignored while
+// one's in progress
+func linkReceive(args []js.Value) {
+	if linkMode == linkOff || renderActive.Load() {
+		return
+	}
+	data := args[0].Get("data").Get("m")
+	if data == js.Undefined() || data.Length() != 16 {
+		return
+	}
+	m := make(matrix, 16)
+	for i := range m {
+		m[i] = data.Index(i).Float()
+	}
+	target := m
+	if linkMode == linkZoomPan {
+		target = zoomPanMatrix(m)
+	}
+	if !scene.Finite(geometry.Zoom(target)) {
+		return
+	}
+	setWorldMatrix(target)
+	linkSent = target
+	markActivity()
+}
+
+// Closes the link channel, and releases its callback
+func releaseLink() {
+	if link != js.Undefined() {
+		link.Call("close")
+	}
+	linkCall.Release()
+}
+
+// Sends the view to the other plots when it's changed.  Called each frame, so they follow along with animations too
+func sendLink() {
+	if link == js.Undefined() || linkMode == linkOff || sameMatrix(worldMatrix, linkSent) {
+		return
+	}
+	linkSent = append(matrix(nil), worldMatrix...)
+	m := make([]interface{}, len(worldMatrix))
+	for i, v := range worldMatrix {
+		m[i] = v
+	}
+	link.Call("postMessage", map[string]interface{}{"m": m})
+}
+
+// Sets what's linked with the other plots: "off", "zoompan", or "all"
+func setLink(mode string) error {
+	for _, m := range linkModes {
+		if m == mode {
+			if link == js.Undefined() {
+				return fmt.Errorf("this browser can't link plots, as it doesn't have BroadcastChannel")
+			}
+			linkMode = mode
+			linkSent = nil // Send our view to the others straight away
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown link mode '%s' (the choices are off, zoompan, and all)", mode)
+}
+
+// Returns true if two matrices are exactly the same
+func sameMatrix(a matrix, b matrix) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns a world matrix with our own rotation, but the zoom and panning of the given one
+func zoomPanMatrix(m matrix) matrix {
+	z := geometry.Zoom(m)
+	r := geometry.Rotation(worldMatrix)
+	return matrix{
+		r[0] * z, r[1] * z, r[2] * z, m[3],
+		r[4] * z, r[5] * z, r[6] * z, m[7],
+		r[8] * z, r[9] * z, r[10] * z, m[11],
+		0, 0, 0, 1,
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+// Switches numbers to the next locale, for the info panel's locale line
+func cycleLocale() {
+	cur := scene.CurrentLocale()
+	for i, l := range scene.Locales {
+		if l.Code == cur.Code {
+			setLocale(scene.Locales[(i+1)%len(scene.Locales)].Code)
+			return
+		}
+	}
+}
+
+// Picks the locale for numbers from the browser's language, leaving them in English if it isn't one there's a
+// locale for
+func detectLocale() {
+	lang := js.Global().Get("navigator").Get("language")
+	if lang.Type() != js.TypeString {
+		return
+	}
+	if l, ok := scene.FindLocale(lang.String()); ok {
+		scene.SetLocale(l.Code)
+	}
+}
+
+// Lines for the Operation section, showing how numbers are written.  Clicking switches to the next locale
+func localeLines() []panelLine {
+	l := scene.CurrentLocale()
+	return []panelLine{{text: fmt.Sprintf("Numbers: %s (%s)", scene.FormatNumber(1.5), l.Name), colour: theme.Link,
+		action: cycleLocale}}
+}
+
+// Sets the locale numbers are shown in, from its language code.  Anything with numbers baked into its labels, like
+// the tick marks and the marked roots, is plotted again to match
+func setLocale(code string) error {
+	if err := scene.SetLocale(code); err != nil {
+		return err
+	}
+	tickZoom = 0
+	for i, e := range equations {
+		plotEquation(e, i+1)
+	}
+	if showIntersections {
+		plotIntersections()
+	}
+	for _, d := range dists {
+		plotDistribution(d)
+	}
+	for _, s := range sliders {
+		s.set(s.value)
+	}
+	explained = explanation{}
+	logActivity("scene", "Showing numbers in %s", scene.CurrentLocale().Name)
+	return nil
+}
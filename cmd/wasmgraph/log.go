@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"syscall/js"
+)
+
+const (
+	logParam = "debug" // Query parameter which sets how much is logged, eg "index.html?debug=trace"
+)
+
+// How much is written to the javascript console.  Each level includes everything from the ones before it
+type logLevel int
+
+const (
+	levelOff   logLevel = iota // Nothing at all
+	levelError                 // Things which went wrong
+	levelInfo                  // What the app is doing, like files saved and fallbacks taken
+	levelTrace                 // Every input event, for working out what's going on
+)
+
+var (
+	logLevelNow = levelError
+
+	// Names for the log levels, as used in the query parameter, in order
+	logLevelNames = []string{"off", "error", "info", "trace"}
+
+	// The console method each level is written with
+	logMethods = []string{"", "error", "info", "debug"}
+)
+
+// Switches to the next log level, going back to off after trace
+func cycleLogLevel() {
+	logLevelNow = (logLevelNow + 1) % logLevel(len(logLevelNames))
+	opText = fmt.Sprintf("Logging to the console: %s", logLevelNames[logLevelNow])
+	js.Global().Get("console").Call("info", "wasmGraph: "+opText)
+}
+
+// Writes a message to the javascript console at the given level, if that's being logged
+func logAt(level logLevel, format string, a ...interface{}) {
+	if level > logLevelNow || level == levelOff {
+		return
+	}
+	js.Global().Get("console").Call(logMethods[level], fmt.Sprintf(format, a...))
+}
+
+// Logs something which went wrong
+func logError(format string, a ...interface{}) {
+	logAt(levelError, format, a...)
+}
+
+// Logs what the app is doing
+func logInfo(format string, a ...interface{}) {
+	logAt(levelInfo, format, a...)
+}
+
+// Lines for the Operation section, showing the log level when it's been changed from the usual.  Clicking switches to
+// the next one
+func logLines() []panelLine {
+	if logLevelNow == levelError {
+		return nil
+	}
+	return []panelLine{{text: fmt.Sprintf("Console logging: %s", logLevelNames[logLevelNow]), colour: theme.Link,
+		action: cycleLogLevel}}
+}
+
+// Logs fine detail, such as every input event
+func logTrace(format string, a ...interface{}) {
+	logAt(levelTrace, format, a...)
+}
+
+// Returns the log level asked for by the query parameter, or the usual one if there isn't one.  Just "?debug" on its
+// own means trace
+func wantLogLevel() logLevel {
+	q, err := url.ParseQuery(strings.TrimPrefix(js.Global().Get("location").Get("search").String(), "?"))
+	if err != nil {
+		return levelError
+	}
+	v, ok := q[logParam]
+	if !ok {
+		return levelError
+	}
+	if len(v) == 0 || v[0] == "" {
+		return levelTrace
+	}
+	for i, n := range logLevelNames {
+		if strings.EqualFold(n, v[0]) {
+			return logLevel(i)
+		}
+	}
+	return levelError
+}
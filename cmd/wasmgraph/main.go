@@ -0,0 +1,730 @@
+// Wasming
+// compile: GOOS=js GOARCH=wasm go build -o main.wasm ./cmd/wasmgraph
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"syscall/js"
+	"time"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/render"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+	"go.uber.org/atomic"
+)
+
+type matrix = geometry.Matrix
+
+type Point = scene.Point
+type Edge = scene.Edge
+type Surface = scene.Surface
+type Object = scene.Object
+
+type OperationType int
+
+const (
+	ROTATE OperationType = iota
+	SCALE
+	TRANSLATE
+	ROTATEAXIS // Rotates around the axis given by X, Y, and Z, by the length of that vector in degrees
+)
+
+type Operation struct {
+	op OperationType
+	t  int32 // Number of milliseconds the operation should take
+	f  int32 // Number of equal parts the operation is broken into.  Frames show the parts reached by then
+	X  float64
+	Y  float64
+	Z  float64
+
+	target string // Name of the object or group to transform, or empty for the whole world space
+}
+
+const (
+	sourceURL = "https://github.com/justinclift/wasmGraph4"
+)
+
+var (
+	// The objects of the scene graph, flattened and transformed by the world matrix, then sorted into draw order
+	world = &scene.Registry{}
+
+	// The 4x4 identity matrix
+	identityMatrix = geometry.Identity()
+
+	// The accumulation of every transformation applied to the world space so far, with its rotation kept as a
+	// quaternion
+	worldView = geometry.IdentityView()
+
+	// The world view as a matrix, which is what everything's transformed and projected with
+	worldMatrix = identityMatrix
+
+	// True while an operation is being animated, or waiting to be
+	renderActive *atomic.Bool
+
+	width, height    float64
+	graphWidth       float64
+	graphHeight      float64
+	centerX, centerY float64
+	step             float64 // Number of pixels per world space unit
+	rCall            js.Callback
+	doc, canvasEl    js.Value
+	ctx              render.Renderer // What the frame is drawn with, normally the canvas
+	opText           string
+	highLightSource  bool
+	pointStep        = scene.DefaultStep
+)
+
+func main() {
+	// Leave out anything which could stop the page starting, if asked to
+	safeMode = wantSafeMode()
+
+	// Log as much to the javascript console as asked for.  When tracing, the first frame is checked against the SVG
+	// renderer too
+	logLevelNow = wantLogLevel()
+	checkBackends = logLevelNow == levelTrace
+
+	// Initialise canvas
+	doc = js.Global().Get("document")
+	canvasEl = doc.Call("getElementById", "mycanvas")
+	width = doc.Get("body").Get("clientWidth").Float()
+	height = doc.Get("body").Get("clientHeight").Float()
+	canvasEl.Set("tabIndex", 0) // Not sure if this is needed
+	initViewport()
+	defer releaseViewport()
+	canvasEl.Call("setAttribute", "width", width*pixelRatio)
+	canvasEl.Call("setAttribute", "height", height*pixelRatio)
+
+	// Draw in a render worker when the browser can hand the canvas over to one, so drawing a heavy scene doesn't hold
+	// up input on the page, or on the page itself when it can't
+	if !initOffscreen() {
+		ctx = &canvasRenderer{ctx: canvasEl.Call("getContext", "2d")}
+	}
+	defer releaseOffscreen()
+
+	// Set up the mouse and keyboard handlers.  Events are handled as soon as they arrive
+	renderActive = atomic.NewBool(false)
+	initInput()
+	defer releaseInput()
+	initPan()
+	defer releasePan()
+
+	// Set the frame renderer going
+	initIdle()
+	defer releaseIdle()
+	rCall = newFrameCallback()
+	js.Global().Call("requestAnimationFrame", rCall)
+	defer func() { rCall.Release() }()
+	go watchdog()
+
+	// Let lists of expressions be imported by dropping them on the page
+	initImport()
+	defer releaseImport()
+
+	// Add the X/Y axes object to the scene graph, with their tick marks, Z arm, and grid planes under them so they move
+	// together.  Those are generated by the frame renderer, with the grid planes hidden until they're wanted
+	axes := scene.NewNode(importObject(scene.Axes, 0.0, 0.0, 0.0))
+	axes.Add(scene.NewNode(Object{Name: "ticks"}))
+	axes.Add(scene.NewNode(Object{Name: "zaxis"}))
+	for _, p := range scene.GridPlanes {
+		axes.Add(scene.NewNode(Object{Name: "grid" + p, Tags: []string{"grid"}, Hidden: true}))
+	}
+	sceneRoot.Add(axes)
+
+	// Plot the starting equation, along with its derivative
+	addEquation("y = x^3")
+
+	// Flatten the scene graph into the world space, sorted by draw order
+	rebuildWorld()
+
+	// Start the compute worker, for calculations too slow to do between frames
+	initWorker()
+	defer releaseWorker()
+
+	// Let the view be linked with other plots
+	initLink()
+	defer releaseLink()
+
+	// Show numbers the way the browser's language writes them
+	detectLocale()
+
+	// Use the deployment's own settings, if it has a config file next to the app
+	if !safeMode {
+		initConfig()
+		defer releaseConfig()
+	}
+
+	// Let host pages drive things through javascript
+	registerAPI()
+	defer releaseAPI()
+
+	// Open the scene from a share link, if the page was loaded from one
+	if !safeMode {
+		loadEmbeddedScene()
+		loadSceneFromURL()
+	}
+
+	// Spin the graph as a demo, if the page was loaded with the auto-rotate query parameter
+	autoRotate = wantAutoRotate()
+
+	// Smoke test the build, if the page was loaded with the self-test query parameter
+	if wantSelfTest() && !safeMode {
+		go runSelfTest()
+	}
+
+	// Keep the application running
+	done := make(chan struct{}, 0)
+	<-done
+}
+
+// Simple mouse handler watching for people clicking on the source code link
+func clickHandler(args []js.Value) {
+	markActivity()
+	event := args[0]
+
+	// Leave clicks on other page elements, such as the sliders, to them
+	if event.Get("target") != canvasEl {
+		return
+	}
+	clientX := event.Get("clientX").Float()
+	clientY := event.Get("clientY").Float()
+
+	// The middle button pans the graph around
+	if event.Get("button").Int() == 1 {
+		startPan(clientX, clientY)
+		return
+	}
+	logTrace("Mouse down at %v, %v", clientX, clientY)
+
+	// Check for clicks on things like the info panel section titles
+	if hitHotspot(clientX, clientY) {
+		return
+	}
+
+	// If the user clicks the source code URL area, open the URL
+	if inSourceLink(clientX, clientY) {
+		w := js.Global().Call("open", sourceURL)
+		if w == js.Null() {
+			// Couldn't open a new window, so try loading directly in the existing one instead
+			doc.Set("location", sourceURL)
+		}
+		return
+	}
+
+	// Clicks in the graph area either pick up the draggable point, start a selection box, start turning the graph as an
+	// arcball, or select the nearest point
+	if !inPanel(clientX, clientY) {
+		if startDrag(clientX, clientY) || startBox(clientX, clientY) || startArcball(clientX, clientY) {
+			return
+		}
+		selectAt(clientX, clientY)
+	}
+}
+
+// Draws the grid, objects, and curves in the graph area
+func drawGraph(left float64, top float64) {
+	points, hidden := render.DrawGraph(ctx, graphFrame(left, top))
+	framePoints += points
+	hiddenLabels = hidden
+}
+
+// Returns what's needed to draw the graph area of the current frame
+func graphFrame(left float64, top float64) render.Frame {
+	return render.Frame{Objects: world.Objects(), Matrix: worldMatrix, Theme: theme, Left: left, Top: top,
+		W: graphWidth, H: graphHeight, CX: centerX, CY: centerY, Unit: step, GridInterval: tickInterval(),
+		Grid: showGrid, PathLabels: pathLabels}
+}
+
+// Returns an object whose points have been transformed into 3D world space XYZ co-ordinates.  Also assigns a number
+// to each point
+func importObject(ob Object, x float64, y float64, z float64) (translatedObject Object) {
+	// X and Y translation matrix.  Translates the objects into the world space at the given X and Y co-ordinates
+	translateMatrix := matrix{
+		1, 0, 0, x,
+		0, 1, 0, y,
+		0, 0, 1, z,
+		0, 0, 0, 1,
+	}
+
+	// Translate the points
+	var pt Point
+	for _, j := range ob.P {
+		pt = Point{
+			Label:      j.Label,
+			LabelAlign: j.LabelAlign,
+			X:          (translateMatrix[0] * j.X) + (translateMatrix[1] * j.Y) + (translateMatrix[2] * j.Z) + (translateMatrix[3] * 1),   // 1st col, top
+			Y:          (translateMatrix[4] * j.X) + (translateMatrix[5] * j.Y) + (translateMatrix[6] * j.Z) + (translateMatrix[7] * 1),   // 1st col, upper middle
+			Z:          (translateMatrix[8] * j.X) + (translateMatrix[9] * j.Y) + (translateMatrix[10] * j.Z) + (translateMatrix[11] * 1), // 1st col, lower middle
+		}
+		translatedObject.P = append(translatedObject.P, pt)
+	}
+
+	// Copy the remaining object info across
+	translatedObject.C = ob.C
+	translatedObject.Name = ob.Name
+	translatedObject.DrawOrder = ob.DrawOrder
+	translatedObject.LabelFont = ob.LabelFont
+	translatedObject.Equation = ob.Equation
+	translatedObject.LabelTemplate = ob.LabelTemplate
+	translatedObject.Scatter = ob.Scatter
+	translatedObject.Marker = ob.Marker
+	translatedObject.Tags = ob.Tags
+	translatedObject.Meta = ob.Meta
+	translatedObject.Hidden = ob.Hidden
+	translatedObject.Backface = ob.Backface
+	translatedObject.Shading = ob.Shading
+	translatedObject.Mode = ob.Mode
+	translatedObject.Alpha = ob.Alpha
+	translatedObject.LineWidth = ob.LineWidth
+	translatedObject.DashPattern = ob.DashPattern
+	translatedObject.PointRadius = ob.PointRadius
+	translatedObject.Values = ob.Values
+	translatedObject.ValuesLabel = ob.ValuesLabel
+	translatedObject.LabelBox = ob.LabelBox
+	for _, j := range ob.E {
+		translatedObject.E = append(translatedObject.E, j)
+	}
+	for _, j := range ob.S {
+		translatedObject.S = append(translatedObject.S, j)
+	}
+
+	return translatedObject
+}
+
+// Simple keyboard handler for catching the arrow, WASD, and numpad keys
+// Key value info can be found here: https://developer.mozilla.org/en-US/docs/Web/API/KeyboardEvent/key/Key_Values
+func keypressHandler(args []js.Value) {
+	markActivity()
+	event := args[0]
+	key := event.Get("key").String()
+	logTrace("Key is: %v", key)
+
+	// Keys pressed while a slider has the focus are for the slider
+	if event.Get("target").Get("tagName").String() == "INPUT" {
+		return
+	}
+	if len(key) == 1 || key == "Escape" || strings.HasPrefix(key, "Arrow") {
+		logActivity("key", "Pressed %s", key)
+	}
+
+	// Keys for tools the deployment has turned off do nothing
+	if !keyAllowed(key) {
+		return
+	}
+
+	// Changing the console log level (Ctrl+Shift+L), exporting (Ctrl+S for an HTML page), recording, changing the
+	// animation speed, showing the frame timings, and clearing the selection (or self-test results) don't change the
+	// world space, so they're allowed even while an operation is in progress
+	if (key == "l" || key == "L") && event.Get("ctrlKey").Bool() && event.Get("shiftKey").Bool() {
+		cycleLogLevel()
+		return
+	}
+	if (key == "s" || key == "S") && (event.Get("ctrlKey").Bool() || event.Get("metaKey").Bool()) {
+		if !toolOn("export") {
+			return
+		}
+		if err := saveHTML(); err != nil {
+			opText = fmt.Sprintf("Couldn't export the page: %v", err)
+		}
+		return
+	}
+	switch key {
+	case "v", "V":
+		saveSVG()
+		return
+	case "r", "R":
+		toggleRecording()
+		return
+	case "[":
+		if err := setAnimSpeed(animSpeed / 2); err != nil {
+			opText = "Can't change the speed: " + err.Error()
+		}
+		return
+	case "]":
+		if err := setAnimSpeed(animSpeed * 2); err != nil {
+			opText = "Can't change the speed: " + err.Error()
+		}
+		return
+	case "`":
+		togglePerf()
+		return
+	case "Escape":
+		selected = nil
+		clearBox()
+		if !selfTestRunning {
+			selfTestSteps = nil
+			cancelOperations()
+		}
+		return
+	}
+
+	// Operations are queued up behind any already in progress, except while a preset is feeding the queue.  Holding
+	// Alt turns just the selected object, rather than everything
+	stepSize := float64(25)
+	target := ""
+	if event.Get("altKey").Bool() && selected != nil && selected.point < 0 {
+		target = selected.object
+	}
+	if !presetActive.Load() {
+		if event.Get("shiftKey").Bool() && (panKey(key) || viewKey(event.Get("code").String())) {
+			return
+		}
+		switch key {
+		case "ArrowLeft", "a", "A", "4":
+			queueOperation(Operation{op: ROTATE, t: 50, f: 12, X: 0, Y: -stepSize, Z: 0, target: target})
+		case "ArrowRight", "d", "D", "6":
+			queueOperation(Operation{op: ROTATE, t: 50, f: 12, X: 0, Y: stepSize, Z: 0, target: target})
+		case "ArrowUp", "w", "W", "8":
+			queueOperation(Operation{op: ROTATE, t: 50, f: 12, X: -stepSize, Y: 0, Z: 0, target: target})
+		case "ArrowDown", "s", "S", "2":
+			queueOperation(Operation{op: ROTATE, t: 50, f: 12, X: stepSize, Y: 0, Z: 0, target: target})
+		case "7", "Home":
+			queueOperation(Operation{op: ROTATE, t: 50, f: 12, X: -stepSize, Y: -stepSize, Z: 0, target: target})
+		case "9", "PageUp":
+			queueOperation(Operation{op: ROTATE, t: 50, f: 12, X: -stepSize, Y: stepSize, Z: 0, target: target})
+		case "1", "End":
+			queueOperation(Operation{op: ROTATE, t: 50, f: 12, X: stepSize, Y: -stepSize, Z: 0, target: target})
+		case "3", "PageDown":
+			queueOperation(Operation{op: ROTATE, t: 50, f: 12, X: stepSize, Y: stepSize, Z: 0, target: target})
+		case "-":
+			queueOperation(Operation{op: ROTATE, t: 50, f: 12, X: 0, Y: 0, Z: -stepSize, target: target})
+		case "+":
+			queueOperation(Operation{op: ROTATE, t: 50, f: 12, X: 0, Y: 0, Z: stepSize, target: target})
+		case "0":
+			setZoom(1)
+		case "5":
+			fitToView()
+		case "x", "X":
+			toggleRoots()
+		case "z", "Z":
+			promptZoom()
+		case "m", "M":
+			toggleTheme()
+		case "e", "E":
+			promptEquation()
+		case "Delete":
+			promptRemoveEquation()
+		case "b", "B":
+			promptDistribution()
+		case "c", "C":
+			toggleComparison()
+		case "f", "F":
+			toggleIntersections()
+		case "g", "G":
+			placeDragMarker()
+		case "h", "H":
+			toggleCursor()
+		case "i", "I":
+			promptIntegral()
+		case "j", "J":
+			toggleProjectile()
+		case "k", "K":
+			toggleMonteCarlo()
+		case "l", "L":
+			pathLabels = !pathLabels
+		case "n", "N":
+			promptSpriteSheet()
+		case "p", "P":
+			promptParametric()
+		case "q", "Q":
+			toggleExtrema()
+		case "t", "T", "o", "O", "u", "U":
+			playPreset(key)
+		case "y", "Y":
+			toggleTimeline()
+		case "'":
+			toggleTagVisible("derivatives")
+		case "|":
+			toggleTagVisible("axes")
+		case "#":
+			showGrid = !showGrid
+		case "\\":
+			toggleTagVisible("grid")
+		case "=":
+			promptDerived()
+		case ".":
+			toggleAutoRotate()
+		case ";":
+			cycleRenderMode()
+		case "/":
+			toggleSlopeColours()
+		case ",":
+			toggleLabelLayout()
+		}
+	}
+}
+
+// Simple mouse handler watching for people moving the mouse over the source code link
+func moveHandler(args []js.Value) {
+	markActivity()
+	event := args[0]
+	clientX := event.Get("clientX").Float()
+	clientY := event.Get("clientY").Float()
+	logTrace("Mouse at %v, %v", clientX, clientY)
+
+	// Remember where the mouse is, for showing the tangent of the curve underneath it and its graph co-ordinates
+	mouseX, mouseY = clientX, clientY
+	dragTo(clientX, clientY)
+	panTo(clientX, clientY)
+	arcballTo(clientX, clientY)
+	boxTo(clientX, clientY)
+
+	// If the mouse is over the source code link, let the frame renderer know to draw the url in bold
+	if inSourceLink(clientX, clientY) {
+		highLightSource = true
+	} else {
+		highLightSource = false
+	}
+}
+
+// Returns the transform for the given number of parts of an operation, as whole parts and then a fraction of the next
+func operationMatrix(i Operation, parts float64) matrix {
+	whole := math.Floor(parts)
+	m := identityMatrix
+	p := partMatrix(i, 1)
+	for j := 0; j < int(whole); j++ {
+		m = geometry.Multiply(p, m)
+	}
+	if parts > whole {
+		m = geometry.Multiply(partMatrix(i, parts-whole), m)
+	}
+	return m
+}
+
+// Returns the rotation for the given number of parts of a rotating operation, as whole parts and then a fraction of
+// the next.  The parts are combined as quaternions, so it's a true rotation however many there are
+func operationTurn(i Operation, parts float64) geometry.Quaternion {
+	whole := math.Floor(parts)
+	q := geometry.IdentityQuaternion()
+	p := partTurn(i, 1)
+	for j := 0; j < int(whole); j++ {
+		q = q.Then(p)
+	}
+	if parts > whole {
+		q = q.Then(partTurn(i, parts-whole))
+	}
+	return q.Normalize()
+}
+
+// Returns the transform for the given fraction of one part of an operation.  Operations are applied as f equal parts
+func partMatrix(i Operation, frac float64) matrix {
+	parts := float64(i.f)
+	m := identityMatrix
+	switch i.op {
+	case ROTATE: // Rotate the objects in world space
+		m = partTurn(i, frac).Matrix()
+
+	case SCALE:
+		// Scale the objects in world space
+		m = geometry.Scale(m, (i.X-1)/parts*frac+1, (i.Y-1)/parts*frac+1, (i.Z-1)/parts*frac+1)
+
+	case TRANSLATE:
+		// Translate (move) the objects in world space
+		m = geometry.Translate(m, i.X/parts*frac, i.Y/parts*frac, i.Z/parts*frac)
+
+	case ROTATEAXIS:
+		// Rotate the objects in world space around a single axis, such as when moving to a camera view
+		m = partTurn(i, frac).Matrix()
+	}
+	return m
+}
+
+// Returns the rotation for the given fraction of one part of a ROTATE or ROTATEAXIS operation, as a quaternion.  A
+// ROTATE operation's angles around each axis are divided into the parts and combined, and a ROTATEAXIS operation's
+// single angle is divided around its axis
+func partTurn(i Operation, frac float64) geometry.Quaternion {
+	parts := float64(i.f)
+	switch i.op {
+	case ROTATE:
+		return geometry.AxisQuaternion(1, 0, 0, i.X/parts*frac).
+			Then(geometry.AxisQuaternion(0, 1, 0, i.Y/parts*frac)).
+			Then(geometry.AxisQuaternion(0, 0, 1, i.Z/parts*frac))
+	case ROTATEAXIS:
+		if degrees := math.Sqrt(i.X*i.X + i.Y*i.Y + i.Z*i.Z); degrees != 0 {
+			return geometry.AxisQuaternion(i.X, i.Y, i.Z, degrees/parts*frac)
+		}
+	}
+	return geometry.IdentityQuaternion()
+}
+
+// Renders one frame of the animation
+func renderFrame(args []js.Value) {
+	lastFrame = time.Now()
+	frameTime = frameTimestamp(args)
+	defer recoverFrame()
+
+	// Handle window resizing
+	curBodyW := doc.Get("body").Get("clientWidth").Float()
+	curBodyH := doc.Get("body").Get("clientHeight").Float()
+	if curBodyW != width || curBodyH != height || pixelsDirty {
+		width, height = curBodyW, curBodyH
+		pixelRatio = effectivePixelRatio()
+		pixelsDirty = false
+		resizeCanvas(width*pixelRatio, height*pixelRatio)
+		needsRedraw = true // Resizing the canvas clears it
+	}
+
+	// Nothing to do if nothing has changed since the last frame
+	if !frameNeeded() {
+		scheduleFrame()
+		return
+	}
+	needsRedraw = false
+	beginFrame()
+
+	// Draw using CSS pixel co-ordinates, whatever the resolution of the canvas
+	ctx.SetTransform(pixelRatio, 0, 0, pixelRatio, 0, 0)
+
+	// Clickable areas are registered again as the frame is drawn
+	hotspots = hotspots[:0]
+
+	// Setup useful variables
+	border := float64(2)
+	gap := float64(3)
+	left := border + gap
+	top := border + gap
+	updateLayout()
+	centerX = graphWidth / 2
+	centerY = graphHeight / 2
+
+	// Clear the background
+	ctx.SetFillStyle(theme.Background)
+	ctx.FillRect(0, 0, width, height)
+
+	step = math.Min(width, height) / 30
+
+	// The tick spacing depends on the zoom level and screen size, so regenerate the tick marks when those change.
+	// Imported data series are downsampled for the graph width, so they're redone when that changes too, as are
+	// adaptively sampled curves when the zoom level changes a lot
+	if !renderActive.Load() {
+		updateAxisTicks()
+		updateSeries()
+		updateSampling()
+	}
+
+	// Draw the graph area contents, then the tangent at the mouse and the info card for any selected point on top
+	stepAutoRotate()
+	applySceneChanges()
+	stepAnimation()
+	stepMonteCarlo()
+	stepProjectile()
+	stepTimeline()
+	sendLink()
+	updateProjection(left, top)
+	positionMarkers(left, top)
+	updateTeXElement()
+	if checkBackends {
+		checkBackends = false
+		compareBackends(left, top)
+	} else {
+		drawGraph(left, top)
+	}
+	drawIntersectionLabels(left, top)
+	drawArrows(left, top)
+	drawTangent(left, top)
+	drawCursor(left, top)
+	drawDragMarker(left, top)
+	drawSelection(left, top)
+	drawBox(left, top)
+	drawTooltip(left, top)
+	drawSelfTest(left, top)
+	drawCSVProgress(left, top)
+	drawColourBars(left, top)
+	drawHookOverlay(left, top)
+	drawCompass()
+
+	// Let the user know when a recording is in progress
+	if recording {
+		ctx.SetFillStyle(theme.Alert)
+		ctx.SetFont("bold 14px sans-serif")
+		ctx.SetTextAlign("right")
+		ctx.FillText("● REC", graphWidth-15, top+20)
+	}
+
+	// Clear the information area
+	ctx.SetFillStyle(theme.Background)
+	ctx.FillRect(panelX, panelY, panelW, panelH)
+
+	// Draw the info panel sections, leaving room for the source code link at the bottom
+	drawInfoPanel(panelX, panelY+top, panelW, panelH-top-sourceLinkH-5)
+
+	// Clear the source code link area
+	ctx.SetFillStyle(theme.Background)
+	ctx.FillRect(panelX, panelY+panelH-sourceLinkH, panelW, sourceLinkH)
+
+	// Add the URL to the source code
+	ctx.SetFillStyle(theme.Text)
+	ctx.SetFont("bold 14px serif")
+	ctx.SetTextAlign("left")
+	ctx.FillText("Source code:", panelX+20, panelY+panelH-36)
+	ctx.SetFillStyle(theme.Link)
+	if highLightSource == true {
+		ctx.SetFont("bold 12px sans-serif")
+	} else {
+		ctx.SetFont("12px sans-serif")
+	}
+	ctx.FillText(sourceURL, panelX+20, panelY+panelH-16)
+
+	// Draw a border around the graph area
+	ctx.SetLineDash()
+	ctx.SetLineWidth(2)
+	ctx.SetStrokeStyle(theme.Background)
+	ctx.BeginPath()
+	ctx.MoveTo(0, 0)
+	ctx.LineTo(width, 0)
+	ctx.LineTo(width, height)
+	ctx.LineTo(0, height)
+	ctx.ClosePath()
+	ctx.Stroke()
+	ctx.SetLineWidth(2)
+	ctx.SetStrokeStyle(theme.Foreground)
+	ctx.BeginPath()
+	ctx.MoveTo(border, border)
+	ctx.LineTo(graphWidth, border)
+	ctx.LineTo(graphWidth, graphHeight)
+	ctx.LineTo(border, graphHeight)
+	ctx.ClosePath()
+	ctx.Stroke()
+
+	// Let the user know when the frame rate has been reduced due to inactivity
+	if isIdle() {
+		ctx.SetFillStyle(theme.Muted)
+		ctx.SetFont("12px sans-serif")
+		ctx.SetTextAlign("left")
+		ctx.FillText("paused", border+10, graphHeight-10)
+	}
+
+	// Show how long the frame took to draw, if asked to
+	measureFrame()
+	drawPerf(top)
+
+	// Hand the frame over to the render worker, if it's drawing them
+	flushFrame()
+
+	// Schedule the next frame render call
+	scheduleFrame()
+}
+
+// Simple mouse handler watching for mouse wheel events
+// Reference info can be found here: https://developer.mozilla.org/en-US/docs/Web/Events/wheel
+func wheelHandler(args []js.Value) {
+	markActivity()
+	event := args[0]
+	wheelDelta := event.Get("deltaY").Float()
+
+	// Scroll the info panel when the mouse is over it, instead of zooming
+	if inPanel(event.Get("clientX").Float(), event.Get("clientY").Float()) {
+		if event.Get("deltaMode").Int() == 1 {
+			wheelDelta *= panelLineHeight // The delta is in lines rather than pixels
+		}
+		scrollPanel(wheelDelta)
+		return
+	}
+	scaleSize := 1 + (wheelDelta / 5)
+	logTrace("Wheel delta: %v, scaleSize: %v", wheelDelta, scaleSize)
+
+	// Zooms queued up while one is in progress are merged, so fast scrolling catches up
+	logActivity("mouse", "Zoomed with the mouse wheel")
+	queueOperation(Operation{op: SCALE, t: 50, f: 12, X: scaleSize, Y: scaleSize, Z: scaleSize})
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+)
+
+// An HTML element from the host page, kept over a point on the graph
+type marker struct {
+	name    string
+	x, y, z float64
+	el      js.Value
+	px, py  float64 // Where the element was last put on the screen
+	shown   bool
+}
+
+var (
+	markers []*marker
+)
+
+// Adds an HTML element over a point on the graph, given in graph co-ordinates.  content is either an element, which
+// is moved onto the page, or a string of HTML to fill a new one with.  A marker with the same name is replaced
+func addMarker(name string, x float64, y float64, z float64, content js.Value) error {
+	if name == "" {
+		return fmt.Errorf("the marker needs a name")
+	}
+	var el js.Value
+	switch content.Type() {
+	case js.TypeString:
+		el = doc.Call("createElement", "div")
+		el.Set("innerHTML", content.String())
+	case js.TypeObject:
+		if content.Get("nodeType") == js.Undefined() {
+			return fmt.Errorf("expected an HTML element or a string of HTML")
+		}
+		el = content
+	default:
+		return fmt.Errorf("expected an HTML element or a string of HTML")
+	}
+	removeMarker(name)
+	el.Get("classList").Call("add", "wasmGraph-marker")
+	style := el.Get("style")
+	style.Set("position", "fixed")
+	style.Set("display", "none")
+	doc.Get("body").Call("appendChild", el)
+	markers = append(markers, &marker{name: name, x: x, y: y, z: z, el: el, px: math.NaN(), py: math.NaN()})
+	return nil
+}
+
+// Removes all of the markers
+func clearMarkers() {
+	for _, m := range markers {
+		m.el.Call("remove")
+	}
+	markers = nil
+}
+
+// Moves each marker to where its point is now drawn, after the graph has been rotated, zoomed, or panned.  Markers
+// whose points are outside the graph area are hidden.  Elements are only touched when they need to move, so markers
+// over a still graph don't cost the browser any layout work
+func positionMarkers(left float64, top float64) {
+	for _, m := range markers {
+		px, py := geometry.Project(worldMatrix, centerX, centerY, step, m.x, m.y, m.z)
+		show := px >= left && px <= graphWidth && py >= top && py <= graphHeight
+		style := m.el.Get("style")
+		if show != m.shown {
+			m.shown = show
+			if show {
+				style.Set("display", "")
+			} else {
+				style.Set("display", "none")
+			}
+		}
+		if !show || (px == m.px && py == m.py) {
+			continue
+		}
+		m.px, m.py = px, py
+		style.Set("left", fmt.Sprintf("%.1fpx", px))
+		style.Set("top", fmt.Sprintf("%.1fpx", py))
+	}
+}
+
+// Removes the marker with the given name.  Returns false if there isn't one
+func removeMarker(name string) bool {
+	for i, m := range markers {
+		if m.name == name {
+			m.el.Call("remove")
+			markers = append(markers[:i], markers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
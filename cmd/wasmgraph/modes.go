@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+var (
+	renderMode string // Render mode used for everything but the axes, or empty for each object's own
+)
+
+// Switches to the next render mode, going through each of them and then back to each object's own
+func cycleRenderMode() {
+	next := scene.Modes[0]
+	for i, m := range scene.Modes {
+		if m == renderMode {
+			next = ""
+			if i < len(scene.Modes)-1 {
+				next = scene.Modes[i+1]
+			}
+		}
+	}
+	setRenderMode(next)
+}
+
+// Returns the line for the Operation section showing the render mode, when there is one.  Clicking it switches to
+// the next
+func renderModeLines() []panelLine {
+	if renderMode == "" {
+		return nil
+	}
+	return []panelLine{{text: "Render mode: " + renderMode, colour: theme.Link, action: cycleRenderMode}}
+}
+
+// Sets the render mode everything but the axes is drawn in.  Empty goes back to each object's own
+func setRenderMode(mode string) error {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if !scene.ValidMode(mode) {
+		return fmt.Errorf("unknown render mode '%s' (the choices are %s)", mode, strings.Join(scene.Modes, ", "))
+	}
+	renderMode = mode
+	rebuildWorld()
+	return nil
+}
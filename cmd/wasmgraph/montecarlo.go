@@ -0,0 +1,143 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	mcPoints      = 2000         // Number of random points scattered by the Monte Carlo demo
+	mcPerFrame    = 20           // Number of random points added each frame, so the estimate can be watched converging
+	mcBelowColour = "seagreen"   // Colour of the points landing under the curve
+	mcAboveColour = "lightcoral" // Colour of the points landing outside the area
+)
+
+// The state of a Monte Carlo integration demo
+type monteCarlo struct {
+	eq           *equation
+	a, b         float64 // Range of x
+	minY, maxY   float64 // Range of y, covering the curve and the X axis
+	exact        float64 // The integral, worked out with Simpson's rule
+	n            int     // Points scattered so far
+	sum          int     // Points under the curve, counted negative where the curve is below the X axis
+	below, above Object
+}
+
+var (
+	mc    *monteCarlo // The Monte Carlo demo in progress, if any
+	mcRnd = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// Returns the current Monte Carlo estimate of the area under the curve
+func (m *monteCarlo) estimate() float64 {
+	if m.n == 0 {
+		return 0
+	}
+	return (m.b - m.a) * (m.maxY - m.minY) * float64(m.sum) / float64(m.n)
+}
+
+// Stops the Monte Carlo demo, and removes its points
+func clearMonteCarlo() {
+	mc = nil
+	removeObjects("mc below", "mc above")
+}
+
+// Lines for the info panel, showing the running Monte Carlo estimate
+func monteCarloLines() (l []panelLine) {
+	if mc == nil {
+		return
+	}
+	l = append(l, panelLine{text: fmt.Sprintf("Monte Carlo ∫ %s, %d points   ✕", mc.eq.name, mc.n),
+		swatch: mcBelowColour, action: clearMonteCarlo})
+	l = append(l, panelLine{text: fmt.Sprintf("Estimate %s, exact %s", scene.FormatCoord(mc.estimate()),
+		scene.FormatCoord(mc.exact)), indent: 15})
+	return
+}
+
+// Starts a Monte Carlo integration demo for an equation between two x values.  Random points are scattered over a
+// box around the curve a few at a time, with the fraction landing under the curve giving an estimate of the area
+func startMonteCarlo(name string, a float64, b float64) error {
+	e, ok := findEquation(name)
+	if !ok {
+		return fmt.Errorf("there's no equation called '%s'", name)
+	}
+	if e.parametric {
+		return fmt.Errorf("parametric curves can't be integrated")
+	}
+	if a > b {
+		a, b = b, a
+	}
+	exact, err := integrate(e.expr, a, b)
+	if err != nil {
+		return err
+	}
+
+	// The box covers the curve and the X axis over the range
+	m := &monteCarlo{eq: e, a: a, b: b, exact: exact}
+	vars := map[string]float64{}
+	for i := 0; i <= integralPoints; i++ {
+		vars["x"] = a + (b-a)*float64(i)/integralPoints
+		y := e.expr.Eval(vars)
+		m.minY, m.maxY = math.Min(m.minY, y), math.Max(m.maxY, y)
+	}
+	if m.maxY-m.minY == 0 {
+		return fmt.Errorf("the curve has no area between those x values")
+	}
+	m.below = Object{Name: "mc below", C: mcBelowColour, Scatter: true, Equation: "under the curve",
+		Tags: []string{"annotations"}}
+	m.above = Object{Name: "mc above", C: mcAboveColour, Scatter: true, Equation: "outside the area",
+		Tags: []string{"annotations"}}
+	clearMonteCarlo()
+	mc = m
+	return nil
+}
+
+// Adds the next few random points to the Monte Carlo demo.  Called each frame until all the points are in
+func stepMonteCarlo() {
+	if mc == nil || mc.n >= mcPoints {
+		return
+	}
+	vars := map[string]float64{}
+	for i := 0; i < mcPerFrame && mc.n < mcPoints; i++ {
+		x := mc.a + mcRnd.Float64()*(mc.b-mc.a)
+		y := mc.minY + mcRnd.Float64()*(mc.maxY-mc.minY)
+		vars["x"] = x
+		f := mc.eq.expr.Eval(vars)
+		switch {
+		case f >= 0 && y >= 0 && y <= f:
+			mc.sum++
+			mc.below.P = append(mc.below.P, Point{X: x, Y: y})
+		case f < 0 && y < 0 && y >= f:
+			mc.sum--
+			mc.below.P = append(mc.below.P, Point{X: x, Y: y})
+		default:
+			mc.above.P = append(mc.above.P, Point{X: x, Y: y})
+		}
+		mc.n++
+	}
+	replaceObject(mc.below)
+	replaceObject(mc.above)
+}
+
+// Turns the Monte Carlo demo off, or on for the integral being shown.  Without an integral, the most recently added
+// equation is used over the range of x it's plotted for
+func toggleMonteCarlo() {
+	if mc != nil {
+		clearMonteCarlo()
+		return
+	}
+	if area != nil {
+		startMonteCarlo(area.eq, area.a, area.b)
+		return
+	}
+	for i := len(equations) - 1; i >= 0; i-- {
+		if !equations[i].parametric {
+			startMonteCarlo(equations[i].name, graphMinX, graphMaxX)
+			return
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+// A change to the scene, waiting to be made between frames
+type sceneChange struct {
+	kind string // "add", "remove", or "replace"
+	ob   Object // The object to add or replace
+	name string // Name of the object to remove
+}
+
+var (
+	changeMu     sync.Mutex
+	sceneChanges []sceneChange // Changes waiting for the next frame, oldest first
+)
+
+// Adds an object to the scene.  It can be called from any goroutine, as the change is queued and made at the start of
+// the next frame, so the world space and draw order never change part way through drawing them.  Objects are checked
+// straight away, so problems are returned to the caller.  Whether the name's already taken can only be told once the
+// change is made though, so that's reported on the javascript console
+func AddObject(ob Object) error {
+	if err := scene.Validate(ob); err != nil {
+		return err
+	}
+	queueSceneChange(sceneChange{kind: "add", ob: ob})
+	return nil
+}
+
+// Makes the scene changes queued up since the last frame, in the order they were made.  Called each frame, on the
+// frame renderer's goroutine
+func applySceneChanges() {
+	changeMu.Lock()
+	c := sceneChanges
+	sceneChanges = nil
+	changeMu.Unlock()
+	for _, j := range c {
+		switch j.kind {
+		case "add":
+			if err := addObject(j.ob); err != nil {
+				logError("wasmGraph: couldn't add the object: %v", err)
+			}
+		case "remove":
+			removeObjects(j.name)
+			removeUserObject(j.name)
+			logActivity("scene", "Removed object %s", j.name)
+		case "replace":
+			replaceObject(j.ob)
+			removeUserObject(j.ob.Name)
+			userObjects = append(userObjects, j.ob)
+			logActivity("scene", "Replaced object %s", j.ob.Name)
+		}
+	}
+}
+
+// Queues up a scene change for the next frame, making sure there's one to make it
+func queueSceneChange(c sceneChange) {
+	changeMu.Lock()
+	sceneChanges = append(sceneChanges, c)
+	changeMu.Unlock()
+	markActivity()
+}
+
+// Removes the object (or group) with the given name from the scene, along with anything grouped under it.  As with
+// AddObject, it's safe to call from any goroutine, and happens at the start of the next frame
+func RemoveObject(name string) error {
+	if name == "" {
+		return fmt.Errorf("expected the name of an object")
+	}
+	queueSceneChange(sceneChange{kind: "remove", name: name})
+	return nil
+}
+
+// Drops an object added through the API from those saved with the scene
+func removeUserObject(name string) {
+	for i, o := range userObjects {
+		if o.Name == name {
+			userObjects = append(userObjects[:i], userObjects[i+1:]...)
+			return
+		}
+	}
+}
+
+// Replaces the object with the same name in the scene, keeping its transform and anything grouped under it, or adds
+// it if there isn't one.  As with AddObject, it's safe to call from any goroutine, and happens at the start of the
+// next frame
+func ReplaceObject(ob Object) error {
+	if err := scene.Validate(ob); err != nil {
+		return err
+	}
+	queueSceneChange(sceneChange{kind: "replace", ob: ob})
+	return nil
+}
@@ -0,0 +1,522 @@
+package main
+
+import (
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/render"
+)
+
+// The drawing calls an offscreenRenderer records, as the numbers they're posted to the render worker as
+const (
+	opArc = iota
+	opBeginPath
+	opCalls // Replays the given number of calls made by the draw hook
+	opClip
+	opClosePath
+	opDots
+	opEllipse
+	opFill
+	opFillPath
+	opFillRect
+	opFillStyle
+	opFillText
+	opFont
+	opGlobalAlpha
+	opLineJoin
+	opLineTo
+	opLineWidth
+	opMoveTo
+	opPolyline
+	opRect
+	opRestore
+	opRotate
+	opSave
+	opSetLineDash
+	opSetTransform
+	opStroke
+	opStrokePath
+	opStrokeRect
+	opStrokeStyle
+	opStrokeText
+	opTextAlign
+	opTextBaseline
+	opTranslate
+)
+
+// Javascript for the render worker.  It's handed the canvas once, then replays each frame's drawing calls on it and
+// says when it's done.  Each call is its number, how many numbers it's given, then those numbers.  Calls taking a
+// string take the next from the frame's strings, ahead of any numbers
+const renderWorkerJS = `
+var canvas, ctx, names;
+var props = {fillStyle: 1, font: 1, globalAlpha: 1, lineJoin: 1, lineWidth: 1, strokeStyle: 1, textAlign: 1,
+	textBaseline: 1};
+var strArgs = {fillPath: 1, fillStyle: 1, fillText: 1, font: 1, lineJoin: 1, strokePath: 1, strokeStyle: 1,
+	strokeText: 1, textAlign: 1, textBaseline: 1};
+function each(xy, f) {
+	for (var i = 0; i + 1 < xy.length; i += 2) {
+		if (isFinite(xy[i]) && isFinite(xy[i+1])) {
+			f(xy[i], xy[i+1]);
+		}
+	}
+}
+onmessage = function(e) {
+	var m = e.data;
+	if (m.canvas) {
+		canvas = m.canvas;
+		ctx = canvas.getContext("2d");
+		names = m.names;
+		return;
+	}
+	if (canvas.width !== m.width || canvas.height !== m.height) {
+		canvas.width = m.width;
+		canvas.height = m.height;
+	}
+	var ops = m.ops, strs = m.strs.split("\u0000"), s = 0, c = 0;
+	for (var i = 0; i < ops.length;) {
+		var name = names[ops[i]], a = ops.subarray(i + 2, i + 2 + ops[i+1]);
+		i += 2 + ops[i+1];
+		switch (name) {
+		case "calls":
+			for (var j = 0; j < a[0]; j++, c++) {
+				var h = m.calls[c];
+				if (h[1]) {
+					ctx[h[0]] = h[2][0];
+				} else {
+					ctx[h[0]].apply(ctx, h[2]);
+				}
+			}
+			break;
+		case "dots":
+			var r = a[0];
+			ctx.beginPath();
+			each(a.subarray(2), function(x, y) {
+				ctx.moveTo(x + r, y);
+				ctx.ellipse(x, y, r, r, 0, 0, 2 * Math.PI);
+			});
+			ctx.fill();
+			if (a[1]) {
+				ctx.stroke();
+			}
+			break;
+		case "fillPath":
+			ctx.fill(new Path2D(strs[s++]));
+			break;
+		case "polyline":
+			var started = false;
+			ctx.beginPath();
+			each(a, function(x, y) {
+				if (started) {
+					ctx.lineTo(x, y);
+				} else {
+					ctx.moveTo(x, y);
+					started = true;
+				}
+			});
+			ctx.stroke();
+			break;
+		case "setLineDash":
+			ctx.setLineDash(Array.from(a));
+			break;
+		case "strokePath":
+			ctx.stroke(new Path2D(strs[s++]));
+			break;
+		default:
+			var args = Array.from(a);
+			if (strArgs[name]) {
+				args.unshift(strs[s++]);
+			}
+			if (props[name]) {
+				ctx[name] = args[0];
+			} else {
+				ctx[name].apply(ctx, args);
+			}
+		}
+	}
+	postMessage("drawn");
+};`
+
+// Javascript making the stand-in 2D context the draw hook is given while the render worker has the canvas.  The calls
+// and property settings made on it are kept in rec.calls, to be replayed by the worker in their place in the frame.
+// Text is measured with the page's own measuring context, kept in the current font
+const hookContextJS = `return function(measure, rec) {
+	return new Proxy({}, {
+		get: function(t, name) {
+			if (name in t) {
+				return t[name];
+			}
+			if (name === "measureText") {
+				return function(text) { return measure.measureText(text); };
+			}
+			return function() { rec.calls.push([name, false, Array.prototype.slice.call(arguments)]); };
+		},
+		set: function(t, name, v) {
+			t[name] = v;
+			if (name === "font") {
+				measure.font = v;
+			}
+			rec.calls.push([name, true, [v]]);
+			return true;
+		}
+	});
+};`
+
+var (
+	offscreen       *offscreenRenderer // Records the frames for the render worker, or nil when it isn't in use
+	offscreenReply  js.Callback
+	offscreenOpName = [...]string{opArc: "arc", opBeginPath: "beginPath", opCalls: "calls", opClip: "clip",
+		opClosePath: "closePath", opDots: "dots", opEllipse: "ellipse", opFill: "fill", opFillPath: "fillPath",
+		opFillRect: "fillRect", opFillStyle: "fillStyle", opFillText: "fillText", opFont: "font",
+		opGlobalAlpha: "globalAlpha", opLineJoin: "lineJoin", opLineTo: "lineTo", opLineWidth: "lineWidth",
+		opMoveTo: "moveTo", opPolyline: "polyline", opRect: "rect", opRestore: "restore", opRotate: "rotate",
+		opSave: "save", opSetLineDash: "setLineDash", opSetTransform: "setTransform", opStroke: "stroke",
+		opStrokePath: "strokePath", opStrokeRect: "strokeRect", opStrokeStyle: "strokeStyle",
+		opStrokeText: "strokeText", opTextAlign: "textAlign", opTextBaseline: "textBaseline",
+		opTranslate: "translate"}
+)
+
+// Records each frame's drawing calls, and posts them to the render worker to draw on the canvas it's been handed with
+// transferControlToOffscreen.  The browser's drawing of the frame then happens in the worker, leaving the page's own
+// thread free for input.  The numbers go in a Float64Array and the strings in one joined up string, so a whole frame
+// crosses over to javascript and on to the worker in a few calls
+type offscreenRenderer struct {
+	ops           []float64
+	strs          []string
+	worker        js.Value
+	measure       js.Value // The 2D context of a canvas of its own, kept in the same font, for measuring text
+	hook          js.Value // The stand-in 2D context given to the draw hook, made the first time it's needed
+	hookRec       js.Value // Holds the list of calls made on the stand-in context this frame, if it's been used
+	width, height float64  // Size of the canvas in pixels, which the worker matches it to before each frame
+	busy          bool     // Set from posting a frame until the worker says it's drawn it
+	next          js.Value // The newest frame waiting for the worker while it's busy, or undefined for none
+}
+
+// Adds a call to the frame, with its numbers
+func (o *offscreenRenderer) add(op int, nums ...float64) {
+	o.ops = append(o.ops, float64(op), float64(len(nums)))
+	o.ops = append(o.ops, nums...)
+}
+
+// Adds a call taking a string to the frame.  The strings are joined up with NULs to post them, so any in the string
+// are dropped
+func (o *offscreenRenderer) addString(op int, s string, nums ...float64) {
+	o.strs = append(o.strs, strings.Replace(s, "\x00", "", -1))
+	o.add(op, nums...)
+}
+
+// Records a call to arc()
+func (o *offscreenRenderer) Arc(x float64, y float64, r float64, start float64, end float64) {
+	o.add(opArc, x, y, r, start, end)
+}
+
+// Drops anything recorded for a frame which didn't finish, so the next starts afresh
+func beginFrame() {
+	if offscreen != nil {
+		offscreen.ops, offscreen.strs = offscreen.ops[:0], offscreen.strs[:0]
+		if offscreen.hookRec != js.Undefined() {
+			offscreen.hookRec.Set("calls", js.Undefined())
+		}
+	}
+}
+
+// Records a call to beginPath()
+func (o *offscreenRenderer) BeginPath() {
+	o.add(opBeginPath)
+}
+
+// Records a call to clip()
+func (o *offscreenRenderer) Clip() {
+	o.add(opClip)
+}
+
+// Records a call to closePath()
+func (o *offscreenRenderer) ClosePath() {
+	o.add(opClosePath)
+}
+
+// Records the points for the worker to fill a dot at each, outlining them too if asked
+func (o *offscreenRenderer) Dots(pts [][2]float64, r float64, outline bool) {
+	b := 0.0
+	if outline {
+		b = 1
+	}
+	o.ops = append(o.ops, opDots, float64(2+2*len(pts)), r, b)
+	for _, p := range pts {
+		o.ops = append(o.ops, p[0], p[1])
+	}
+}
+
+// Records a call to ellipse()
+func (o *offscreenRenderer) Ellipse(x float64, y float64, rx float64, ry float64, rotation float64, start float64,
+	end float64) {
+	o.add(opEllipse, x, y, rx, ry, rotation, start, end)
+}
+
+// Records a call to fill()
+func (o *offscreenRenderer) Fill() {
+	o.add(opFill)
+}
+
+// Records filling a path, with its path data
+func (o *offscreenRenderer) FillPath(p *render.Path) {
+	if !p.Empty() {
+		o.addString(opFillPath, p.String())
+	}
+}
+
+// Records a call to fillRect()
+func (o *offscreenRenderer) FillRect(x float64, y float64, w float64, h float64) {
+	o.add(opFillRect, x, y, w, h)
+}
+
+// Records a call to fillText()
+func (o *offscreenRenderer) FillText(text string, x float64, y float64) {
+	o.addString(opFillText, text, x, y)
+}
+
+// Posts the frame recorded to the render worker.  While it's still drawing the last one, the frame waits instead,
+// replacing any older one waiting, so a heavy scene drops frames rather than falling further and further behind
+func flushFrame() {
+	o := offscreen
+	if o == nil {
+		return
+	}
+	ta := js.TypedArrayOf(o.ops)
+	m := js.Global().Get("Object").New()
+	m.Set("ops", js.Global().Get("Float64Array").New(ta)) // A copy, as the view's of Go's memory
+	ta.Release()
+	m.Set("strs", strings.Join(o.strs, "\x00"))
+	if o.hookRec != js.Undefined() && o.hookRec.Get("calls") != js.Undefined() {
+		m.Set("calls", o.hookRec.Get("calls"))
+	}
+	m.Set("width", o.width)
+	m.Set("height", o.height)
+	beginFrame()
+	if o.busy {
+		o.next = m
+		return
+	}
+	o.post(m)
+}
+
+// Returns the stand-in 2D context for the draw hook, starting a new list of calls for it if there isn't one this
+// frame, and the number of calls already in the list
+func (o *offscreenRenderer) hookContext() (js.Value, int) {
+	if o.hook == js.Undefined() {
+		o.hookRec = js.Global().Get("Object").New()
+		o.hook = js.Global().Get("Function").New(hookContextJS).Invoke().Invoke(o.measure, o.hookRec)
+	}
+	if o.hookRec.Get("calls") == js.Undefined() {
+		o.hookRec.Set("calls", js.Global().Get("Array").New())
+	}
+	return o.hook, o.hookRec.Get("calls").Length()
+}
+
+// Records the calls the draw hook has made since hookContext, for the worker to replay in their place
+func (o *offscreenRenderer) hookDone(start int) {
+	if n := o.hookRec.Get("calls").Length() - start; n > 0 {
+		o.add(opCalls, float64(n))
+	}
+}
+
+// Hands the canvas over to a render worker, and records the frames for it from then on, if the browser can.  The
+// worker is made from a Blob, so there's no extra file to serve.  Returns false if it can't, leaving the canvas to be
+// drawn on by the page
+func initOffscreen() bool {
+	g := js.Global()
+	if safeMode || canvasEl.Get("transferControlToOffscreen") == js.Undefined() || g.Get("Worker") == js.Undefined() {
+		return false
+	}
+	blob := g.Get("Blob").New([]interface{}{renderWorkerJS}, map[string]interface{}{"type": "text/javascript"})
+	url := g.Get("URL").Call("createObjectURL", blob)
+	o := &offscreenRenderer{worker: g.Get("Worker").New(url), hook: js.Undefined(), hookRec: js.Undefined(),
+		next: js.Undefined(), width: canvasEl.Get("width").Float(), height: canvasEl.Get("height").Float()}
+	g.Get("URL").Call("revokeObjectURL", url)
+	o.measure = doc.Call("createElement", "canvas").Call("getContext", "2d")
+	offscreenReply = js.NewCallback(offscreenDrawn)
+	o.worker.Set("onmessage", offscreenReply)
+
+	names := make([]interface{}, len(offscreenOpName))
+	for i, n := range offscreenOpName {
+		names[i] = n
+	}
+	c := canvasEl.Call("transferControlToOffscreen")
+	o.worker.Call("postMessage", map[string]interface{}{"canvas": c, "names": names}, []interface{}{c})
+	offscreen = o
+	ctx = o
+	logInfo("Drawing in a render worker, through an OffscreenCanvas")
+	return true
+}
+
+// Records a call to lineTo()
+func (o *offscreenRenderer) LineTo(x float64, y float64) {
+	o.add(opLineTo, x, y)
+}
+
+// Returns the width the text takes up in the current font
+func (o *offscreenRenderer) MeasureText(text string) float64 {
+	return o.measure.Call("measureText", text).Get("width").Float()
+}
+
+// Records a call to moveTo()
+func (o *offscreenRenderer) MoveTo(x float64, y float64) {
+	o.add(opMoveTo, x, y)
+}
+
+// Once the render worker has drawn a frame, posts it the newest one waiting, if there is one
+func offscreenDrawn(args []js.Value) {
+	o := offscreen
+	if o == nil {
+		return
+	}
+	o.busy = false
+	if o.next != js.Undefined() {
+		m := o.next
+		o.next = js.Undefined()
+		o.post(m)
+	}
+}
+
+// Records the points for the worker to draw a line joining them up
+func (o *offscreenRenderer) Polyline(pts [][2]float64) {
+	o.ops = append(o.ops, opPolyline, float64(2*len(pts)))
+	for _, p := range pts {
+		o.ops = append(o.ops, p[0], p[1])
+	}
+}
+
+// Posts a frame to the render worker.  Its numbers' buffer is transferred rather than copied.  When the draw hook has
+// passed something which can't go to a worker, such as a Path2D, the frame can't be posted, so the hook is removed and
+// the frame drawn again without it
+func (o *offscreenRenderer) post(m js.Value) {
+	defer func() {
+		if r := recover(); r != nil {
+			o.busy = false
+			drawHook = js.Undefined()
+			needsRedraw = true
+			logError("wasmGraph draw hook removed, as what it drew couldn't be sent to the render worker: %v", r)
+		}
+	}()
+	o.busy = true
+	o.worker.Call("postMessage", m, []interface{}{m.Get("ops").Get("buffer")})
+}
+
+// Records a call to rect()
+func (o *offscreenRenderer) Rect(x float64, y float64, w float64, h float64) {
+	o.add(opRect, x, y, w, h)
+}
+
+// Stops the render worker, and releases its callback
+func releaseOffscreen() {
+	if offscreen != nil {
+		offscreen.worker.Call("terminate")
+		offscreenReply.Release()
+		offscreen = nil
+	}
+}
+
+// Sets the size of the canvas in pixels.  Once it's been handed over to the render worker, the worker sets it before
+// drawing the next frame
+func resizeCanvas(w float64, h float64) {
+	if offscreen != nil {
+		offscreen.width, offscreen.height = w, h
+		return
+	}
+	canvasEl.Set("width", w)
+	canvasEl.Set("height", h)
+}
+
+// Records a call to restore(), and puts back the measuring context's font to match
+func (o *offscreenRenderer) Restore() {
+	o.measure.Call("restore")
+	o.add(opRestore)
+}
+
+// Records a call to rotate()
+func (o *offscreenRenderer) Rotate(angle float64) {
+	o.add(opRotate, angle)
+}
+
+// Records a call to save(), and puts aside the measuring context's font to match
+func (o *offscreenRenderer) Save() {
+	o.measure.Call("save")
+	o.add(opSave)
+}
+
+// Records setting fillStyle
+func (o *offscreenRenderer) SetFillStyle(style string) {
+	o.addString(opFillStyle, style)
+}
+
+// Records setting font, and sets the measuring context's to match
+func (o *offscreenRenderer) SetFont(font string) {
+	o.measure.Set("font", font)
+	o.addString(opFont, font)
+}
+
+// Records setting globalAlpha
+func (o *offscreenRenderer) SetGlobalAlpha(alpha float64) {
+	o.add(opGlobalAlpha, alpha)
+}
+
+// Records a call to setLineDash()
+func (o *offscreenRenderer) SetLineDash(segments ...float64) {
+	o.add(opSetLineDash, segments...)
+}
+
+// Records setting lineJoin
+func (o *offscreenRenderer) SetLineJoin(join string) {
+	o.addString(opLineJoin, join)
+}
+
+// Records setting lineWidth
+func (o *offscreenRenderer) SetLineWidth(width float64) {
+	o.add(opLineWidth, width)
+}
+
+// Records setting strokeStyle
+func (o *offscreenRenderer) SetStrokeStyle(style string) {
+	o.addString(opStrokeStyle, style)
+}
+
+// Records setting textAlign
+func (o *offscreenRenderer) SetTextAlign(align string) {
+	o.addString(opTextAlign, align)
+}
+
+// Records setting textBaseline
+func (o *offscreenRenderer) SetTextBaseline(baseline string) {
+	o.addString(opTextBaseline, baseline)
+}
+
+// Records a call to setTransform()
+func (o *offscreenRenderer) SetTransform(a float64, b float64, c float64, d float64, e float64, f float64) {
+	o.add(opSetTransform, a, b, c, d, e, f)
+}
+
+// Records a call to stroke()
+func (o *offscreenRenderer) Stroke() {
+	o.add(opStroke)
+}
+
+// Records stroking a path, with its path data
+func (o *offscreenRenderer) StrokePath(p *render.Path) {
+	if !p.Empty() {
+		o.addString(opStrokePath, p.String())
+	}
+}
+
+// Records a call to strokeRect()
+func (o *offscreenRenderer) StrokeRect(x float64, y float64, w float64, h float64) {
+	o.add(opStrokeRect, x, y, w, h)
+}
+
+// Records a call to strokeText()
+func (o *offscreenRenderer) StrokeText(text string, x float64, y float64) {
+	o.addString(opStrokeText, text, x, y)
+}
+
+// Records a call to translate()
+func (o *offscreenRenderer) Translate(x float64, y float64) {
+	o.add(opTranslate, x, y)
+}
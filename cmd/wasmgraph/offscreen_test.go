@@ -0,0 +1,100 @@
+package main
+
+import (
+	"syscall/js"
+	"testing"
+
+	"github.com/justinclift/wasmGraph4/pkg/render"
+)
+
+// Sets up an offscreenRenderer posting to a stand-in for the render worker, which keeps the frames posted to it.
+// Calling done puts things back as they were
+func testOffscreen() (o *offscreenRenderer, posted chan js.Value, done func()) {
+	posted = make(chan js.Value, 4)
+	post := js.NewCallback(func(args []js.Value) { posted <- args[0] })
+	w := js.Global().Get("Object").New()
+	w.Set("postMessage", post)
+	o = &offscreenRenderer{worker: w, measure: js.Global().Get("Object").New(), hook: js.Undefined(),
+		hookRec: js.Undefined(), next: js.Undefined(), width: 300, height: 150}
+	savedCtx := ctx
+	offscreen, ctx = o, o
+	return o, posted, func() {
+		post.Release()
+		offscreen, ctx = nil, savedCtx
+	}
+}
+
+// Returns the numbers in a posted frame
+func frameOps(m js.Value) (l []float64) {
+	ops := m.Get("ops")
+	for i := 0; i < ops.Length(); i++ {
+		l = append(l, ops.Index(i).Float())
+	}
+	return
+}
+
+func TestOffscreenFrame(t *testing.T) {
+	o, posted, done := testOffscreen()
+	defer done()
+	o.SetFillStyle("#fff")
+	o.FillRect(0, 0, 10, 20)
+	o.FillText("hi", 3, 4)
+	o.Dots([][2]float64{{1, 2}, {3, 4}}, 2.5, true)
+	var p render.Path
+	p.Line(0, 0, 5, 5)
+	o.StrokePath(&p)
+	resizeCanvas(640, 480)
+	flushFrame()
+
+	m := <-posted
+	want := []float64{opFillStyle, 0, opFillRect, 4, 0, 0, 10, 20, opFillText, 2, 3, 4, opDots, 6, 2.5, 1, 1, 2, 3, 4,
+		opStrokePath, 0}
+	got := frameOps(m)
+	if len(got) != len(want) {
+		t.Fatalf("ops = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("ops = %v, want %v", got, want)
+		}
+	}
+	if s, w := m.Get("strs").String(), "#fff\x00hi\x00"+p.String(); s != w {
+		t.Errorf("strs = %q, want %q", s, w)
+	}
+	if m.Get("width").Float() != 640 || m.Get("height").Float() != 480 {
+		t.Errorf("size = %v x %v, want 640 x 480", m.Get("width"), m.Get("height"))
+	}
+	if len(o.ops) != 0 || len(o.strs) != 0 {
+		t.Errorf("the frame was kept after posting it, with %d numbers and %d strings", len(o.ops), len(o.strs))
+	}
+}
+
+// Frames drawn while the worker is still busy with the last one wait for it, with only the newest kept
+func TestOffscreenBusy(t *testing.T) {
+	o, posted, done := testOffscreen()
+	defer done()
+	o.FillRect(0, 0, 1, 1)
+	flushFrame()
+	<-posted
+	for _, w := range []float64{2, 3} {
+		o.FillRect(0, 0, w, w)
+		flushFrame()
+	}
+	select {
+	case <-posted:
+		t.Fatal("a frame was posted while the worker was busy")
+	default:
+	}
+
+	offscreenDrawn(nil)
+	m := <-posted
+	if got := frameOps(m); len(got) != 6 || got[4] != 3 {
+		t.Errorf("posted %v once the worker was done, want the newest frame", got)
+	}
+	offscreenDrawn(nil)
+	select {
+	case m := <-posted:
+		t.Errorf("posted %v with no frame waiting", frameOps(m))
+	default:
+	}
+}
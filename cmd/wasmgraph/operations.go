@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"syscall/js"
+	"time"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"go.uber.org/atomic"
+)
+
+const (
+	opQueueMax = 64                   // Operations waiting before any more are dropped
+	opWaitFor  = 5 * time.Millisecond // How often waitForQueue checks the queue
+)
+
+// An operation being animated.  Its progress is worked out from the frame timestamps, so it takes the same time
+// whatever the frame rate, and the graph only ever moves between frames
+type animation struct {
+	op      Operation
+	start   float64             // Frame timestamp the operation started at, in milliseconds
+	dur     float64             // How long it takes, in milliseconds, after the animation speed is applied
+	done    matrix              // The part of the operation's transform applied so far
+	turned  geometry.Quaternion // The part of a rotation applied to the whole world space so far
+	cancels int64               // opCancels when the operation started
+}
+
+var (
+	opMu      sync.Mutex
+	pending   []Operation          // Operations waiting to be animated, oldest first
+	opCancels = atomic.NewInt64(0) // Bumped to cancel the operation being animated
+	anim      *animation           // The operation being animated, if any
+	frameTime float64              // Timestamp of the frame being drawn, in milliseconds
+)
+
+// Cancels the operation being animated, leaving the graph where it's got to, and drops those waiting
+func cancelOperations() {
+	opMu.Lock()
+	pending = nil
+	opCancels.Inc()
+	if anim == nil {
+		renderActive.Store(false)
+	}
+	opMu.Unlock()
+	opText = "Cancelled."
+}
+
+// Returns the timestamp requestAnimationFrame() passed the frame renderer, or the current time if it didn't
+func frameTimestamp(args []js.Value) float64 {
+	if len(args) > 0 && args[0].Type() == js.TypeNumber {
+		return args[0].Float()
+	}
+	return js.Global().Get("performance").Call("now").Float()
+}
+
+// Merges an operation into the one before it, when applying them one after the other would give the same result.  So
+// holding down a key builds up a bigger rotation, rather than a backlog of small ones.  Rotations are merged when
+// they're around the same single axis, moves always, and zooms when they're split into the same number of parts
+func mergeOperations(a Operation, b Operation) (Operation, bool) {
+	if a.op != b.op || a.target != b.target {
+		return a, false
+	}
+	switch a.op {
+	case ROTATE:
+		ax, bx := rotationAxis(a), rotationAxis(b)
+		if ax < 0 || ax != bx {
+			return a, false
+		}
+		a.X, a.Y, a.Z = a.X+b.X, a.Y+b.Y, a.Z+b.Z
+	case TRANSLATE:
+		a.X, a.Y, a.Z = a.X+b.X, a.Y+b.Y, a.Z+b.Z
+	case SCALE:
+		if a.f != b.f {
+			return a, false
+		}
+		n := float64(a.f)
+		merge := func(x float64, y float64) float64 {
+			// Each part scales by ((X-1)/f)+1, so multiply the overall ratios and work back to X
+			r := math.Pow((x-1)/n+1, n) * math.Pow((y-1)/n+1, n)
+			return n*(math.Pow(r, 1/n)-1) + 1
+		}
+		a.X, a.Y, a.Z = merge(a.X, b.X), merge(a.Y, b.Y), merge(a.Z, b.Z)
+	default:
+		return a, false
+	}
+	a.t = max32(a.t, b.t)
+	return a, true
+}
+
+// Returns the larger of two durations
+func max32(a int32, b int32) int32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Returns the text describing an operation, for the info panel
+func operationText(i Operation) string {
+	if i.target != "" {
+		t := i
+		t.target = ""
+		return i.target + ": " + operationText(t)
+	}
+	switch i.op {
+	case ROTATE:
+		return fmt.Sprintf("Rotation. X: %0.2f Y: %0.2f Z: %0.2f", i.X, i.Y, i.Z)
+	case SCALE:
+		return fmt.Sprintf("Scale. X: %0.2f Y: %0.2f Z: %0.2f", i.X, i.Y, i.Z)
+	case TRANSLATE:
+		return fmt.Sprintf("Translate (move). X: %0.2f Y: %0.2f Z: %0.2f", i.X, i.Y, i.Z)
+	case ROTATEAXIS:
+		degrees := math.Sqrt(i.X*i.X + i.Y*i.Y + i.Z*i.Z)
+		return fmt.Sprintf("Rotation. Axis X: %0.2f Y: %0.2f Z: %0.2f, %0.2f°", i.X/degrees, i.Y/degrees,
+			i.Z/degrees, degrees)
+	}
+	return ""
+}
+
+// Marks the animation as finished when nothing else is waiting
+func operationDone() {
+	opMu.Lock()
+	if len(pending) == 0 {
+		renderActive.Store(false)
+	}
+	opMu.Unlock()
+}
+
+// Adds an operation to the queue, merging it into the last one waiting if they're compatible.  Operations aren't
+// dropped while one is being animated, so fast input catches up instead of being lost.  Returns false if the queue
+// is full
+func queueOperation(op Operation) bool {
+	markActivity()
+	opMu.Lock()
+	defer opMu.Unlock()
+	if n := len(pending); n > 0 {
+		if m, ok := mergeOperations(pending[n-1], op); ok {
+			pending[n-1] = m
+			return true
+		}
+	}
+	if len(pending) >= opQueueMax {
+		return false
+	}
+	pending = append(pending, op)
+	renderActive.Store(true)
+	return true
+}
+
+// Returns which single axis a rotation is around (0 for X, 1 for Y, 2 for Z), or -1 if it's around more than one
+func rotationAxis(op Operation) int {
+	axis := -1
+	for i, v := range []float64{op.X, op.Y, op.Z} {
+		if v == 0 {
+			continue
+		}
+		if axis >= 0 {
+			return -1
+		}
+		axis = i
+	}
+	return axis
+}
+
+// Advances the operation being animated to where it should be by this frame, starting the next one from the queue
+// when it finishes.  Any time left over from an operation finishing between frames is carried over into the next, so
+// a run of operations plays smoothly.  Rotations of the whole world space are combined into its quaternion, so its
+// orientation never drifts however long it's rotated for.  Called each frame
+func stepOperations() {
+	start := frameTime
+	for {
+		if anim == nil {
+			op, ok := takeOperation()
+			if !ok {
+				return
+			}
+			anim = &animation{op: op, start: start, dur: float64(op.t) / animSpeed, done: identityMatrix,
+				turned: geometry.IdentityQuaternion(), cancels: opCancels.Load()}
+			opText = operationText(op)
+		}
+		if opCancels.Load() != anim.cancels {
+			anim = nil
+			operationDone()
+			continue
+		}
+		progress := 1.0
+		if anim.dur > 0 {
+			progress = math.Min((frameTime-anim.start)/anim.dur, 1)
+		}
+		parts := progress * float64(anim.op.f)
+		if anim.op.target == "" && (anim.op.op == ROTATE || anim.op.op == ROTATEAXIS) {
+			q := operationTurn(anim.op, parts)
+			setWorldView(worldView.Rotate(anim.turned.Conjugate().Then(q)))
+			anim.turned = q
+		} else {
+			m := operationMatrix(anim.op, parts)
+			if inv, ok := geometry.Invert(anim.done); ok {
+				step := geometry.Multiply(m, inv)
+				if anim.op.target != "" {
+					transformObject(anim.op.target, step)
+				} else {
+					setWorldView(worldView.Transform(step))
+				}
+			}
+			anim.done = m
+		}
+		if progress < 1 {
+			return
+		}
+
+		// Start the next operation from when this one finished, rather than from this frame
+		start = anim.start + anim.dur
+		anim = nil
+		opText = "Complete."
+		operationDone()
+	}
+}
+
+// Takes the next operation waiting off the queue
+func takeOperation() (Operation, bool) {
+	opMu.Lock()
+	defer opMu.Unlock()
+	if len(pending) == 0 {
+		return Operation{}, false
+	}
+	op := pending[0]
+	pending = pending[1:]
+	return op, true
+}
+
+// Calls a function once no operations are being animated or waiting.  Used for operations worked out from where the
+// graph is now, which would be wrong if they were queued behind others
+func whenIdle(fn func()) {
+	go func() {
+		for renderActive.Load() {
+			time.Sleep(opWaitFor)
+		}
+		fn()
+	}()
+}
+
+// Waits until every operation in the queue has been started, so the next one added won't be merged into them.
+// Returns false if the operations were cancelled meanwhile
+func waitForQueue(cancels int64) bool {
+	for {
+		opMu.Lock()
+		n := len(pending)
+		opMu.Unlock()
+		if opCancels.Load() != cancels {
+			return false
+		}
+		if n == 0 {
+			return true
+		}
+		time.Sleep(opWaitFor)
+	}
+}
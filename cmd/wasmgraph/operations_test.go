@@ -0,0 +1,50 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"go.uber.org/atomic"
+)
+
+// A long session of rotating should leave the world matrix a true rotation at the same zoom, turned by the same
+// amount as all the rotations multiplied together, rather than gradually skewing or shrinking the graph
+func TestLongRotation(t *testing.T) {
+	if renderActive == nil {
+		renderActive = atomic.NewBool(false)
+	}
+	saved := worldMatrix
+	defer setWorldMatrix(saved)
+	setWorldMatrix(geometry.Scale(identityMatrix, 2, 2, 2))
+
+	want := worldMatrix
+	for i := 0; i < 5000; i++ {
+		op := Operation{op: ROTATE, t: 50, f: 12, X: 7, Y: -3, Z: 1.5}
+		if i%2 == 1 {
+			op = Operation{op: ROTATEAXIS, t: 50, f: 12, X: 1, Y: 2, Z: -0.5}
+		}
+		if !queueOperation(op) {
+			t.Fatal("the operation queue is full")
+		}
+		for frameTime = 0; anim != nil || len(pending) > 0; frameTime += 16 {
+			stepOperations()
+		}
+		want = geometry.Multiply(operationMatrix(op, float64(op.f)), want)
+	}
+
+	m := worldMatrix
+	for c := 0; c < 3; c++ {
+		if z := math.Sqrt(m[c]*m[c] + m[4+c]*m[4+c] + m[8+c]*m[8+c]); math.Abs(z-2) > 1e-12 {
+			t.Errorf("column %d has length %v, want 2", c, z)
+		}
+	}
+	if d := geometry.Determinant(m); math.Abs(d-8) > 1e-10 {
+		t.Errorf("Determinant = %v, want 8", d)
+	}
+	for i := range m {
+		if math.Abs(m[i]-want[i]) > 1e-6 {
+			t.Fatalf("world matrix = %v, want %v", m, want)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"syscall/js"
+)
+
+const (
+	panStep = 40 // Distance shift+arrow keys move the graph, in pixels
+)
+
+var (
+	panning      bool    // True while the middle mouse button is held down over the graph
+	panX, panY   float64 // Mouse position the pan was last updated from
+	panDX, panDY float64 // Pan movement not yet sent as an operation, in pixels
+	pdCall       js.Callback
+)
+
+// Finishes panning with the mouse, sending any movement still waiting
+func endPan() {
+	if !panning {
+		return
+	}
+	panning = false
+	sendPan()
+	logActivity("mouse", "Panned by dragging")
+}
+
+// Sets up the handler stopping the browser's own behaviour for mouse presses on the canvas, such as scrolling with the
+// middle button or selecting text while dragging.  This needs doing before the event returns, so can't wait for the
+// (asynchronous) click handler
+func initPan() {
+	pdCall = js.NewEventCallback(js.PreventDefault, func(event js.Value) {})
+	canvasEl.Call("addEventListener", "mousedown", pdCall)
+}
+
+// Moves the graph when shift+arrow keys are pressed.  Returns false for other keys
+func panKey(key string) bool {
+	var dx, dy float64
+	switch key {
+	case "ArrowLeft":
+		dx = -panStep
+	case "ArrowRight":
+		dx = panStep
+	case "ArrowUp":
+		dy = -panStep
+	case "ArrowDown":
+		dy = panStep
+	default:
+		return false
+	}
+	queueOperation(panOp(dx, dy, 50, 12))
+	return true
+}
+
+// Returns a translate operation moving the graph by the given number of pixels on screen
+func panOp(dx float64, dy float64, t int32, f int32) Operation {
+	return Operation{op: TRANSLATE, t: t, f: f, X: dx / step, Y: -dy / step}
+}
+
+// Follows the mouse while panning.  Movement builds up while an operation is in progress, and is sent once it's done
+func panTo(x float64, y float64) {
+	if !panning {
+		return
+	}
+	panDX += x - panX
+	panDY += y - panY
+	panX, panY = x, y
+	if !renderActive.Load() && !presetActive.Load() {
+		sendPan()
+	}
+}
+
+// Releases the mouse press handler
+func releasePan() {
+	canvasEl.Call("removeEventListener", "mousedown", pdCall)
+	pdCall.Release()
+}
+
+// Sends the pan movement built up so far as a single step translate operation
+func sendPan() {
+	if panDX == 0 && panDY == 0 {
+		return
+	}
+	queueOperation(panOp(panDX, panDY, 0, 1))
+	panDX, panDY = 0, 0
+}
+
+// Starts panning with the mouse
+func startPan(x float64, y float64) {
+	panning = true
+	panX, panY = x, y
+	panDX, panDY = 0, 0
+}
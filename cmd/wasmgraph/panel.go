@@ -0,0 +1,318 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	panelHeaderHeight = 22 // Height of a section header in the info panel
+	panelLineHeight   = 20 // Height of a line of section content
+	panelSectionGap   = 10 // Space left after each section
+)
+
+// A line of text in the info panel
+type panelLine struct {
+	text   string
+	font   string  // Defaults to "14px sans-serif"
+	colour string  // Defaults to the theme text colour
+	indent float64 // Extra indentation from the left of the panel
+	swatch string  // If set, a small square of this colour is drawn before the text
+	action func()  // If set, clicking the line calls this
+
+	swatchAction func() // If set, clicking the swatch calls this instead of action
+	swatchEmpty  bool   // Draws the swatch as just an outline
+}
+
+// A collapsible section of the info panel
+type panelSection struct {
+	title     string
+	collapsed bool
+	lines     func() []panelLine // Generates the current content of the section
+}
+
+var (
+	// The sections of the info panel, in display order
+	panelSections = []*panelSection{
+		{title: "Operation", lines: operationLines},
+		{title: "Selection", lines: selectedLines},
+		{title: "Equations", lines: equationLines},
+		{title: "Gallery", collapsed: true, lines: galleryLines},
+		{title: "Legend", lines: legendLines},
+		{title: "Tags", collapsed: true, lines: tagLines},
+		{title: "Analysis", lines: analysisLines},
+		{title: "Explanation", lines: explainLines},
+		{title: "Activity", collapsed: true, lines: activityLines},
+		{title: "Help", lines: helpLines},
+	}
+
+	panelScroll  float64 // How far the info panel content has been scrolled, in pixels
+	panelContent float64 // Total height of the info panel content, as of the last frame
+	panelHeight  float64 // Visible height of the info panel, as of the last frame
+)
+
+// Returns the lines for the Analysis section
+func analysisLines() (l []panelLine) {
+	var objects, points int
+	for _, o := range world.Objects() {
+		objects++
+		points += len(o.P)
+	}
+	l = append(l, panelLine{text: fmt.Sprintf("Objects: %d", objects)})
+	l = append(l, panelLine{text: fmt.Sprintf("Points: %d", points)})
+	l = append(l, rootLines()...)
+	l = append(l, extremaLines()...)
+	l = append(l, intersectionLines()...)
+	l = append(l, integralLines()...)
+	l = append(l, comparisonLines()...)
+	l = append(l, monteCarloLines()...)
+	l = append(l, projectileLines()...)
+	l = append(l, seriesLines()...)
+	l = append(l, dragLines()...)
+	return
+}
+
+// Draws the info panel sections into the given area, clipped and scrolled as needed
+func drawInfoPanel(x float64, y float64, w float64, h float64) {
+	panelHeight = h
+	clampPanelScroll()
+
+	ctx.Save()
+	ctx.BeginPath()
+	ctx.Rect(x, y, w, h)
+	ctx.Clip()
+	ctx.SetTextAlign("left")
+
+	textY := y - panelScroll
+	for _, s := range panelSections {
+		// Section header, with an indicator showing whether it's expanded
+		sec := s
+		indicator := "▾"
+		if sec.collapsed {
+			indicator = "▸"
+		}
+		ctx.SetFillStyle(theme.Text)
+		ctx.SetFont("bold 14px serif")
+		ctx.FillText(indicator+" "+sec.title, x+15, textY+16)
+		if textY+panelHeaderHeight > y && textY < y+h {
+			addHotspot(x, math.Max(textY, y), w, math.Min(textY+panelHeaderHeight, y+h)-math.Max(textY, y), func() {
+				sec.collapsed = !sec.collapsed
+			})
+		}
+		textY += panelHeaderHeight
+
+		// Section content
+		if !sec.collapsed {
+			for _, l := range sec.lines() {
+				font, colour := l.font, l.colour
+				if font == "" {
+					font = "14px sans-serif"
+				}
+				if colour == "" {
+					colour = theme.Text
+				}
+				textX := x + 25 + l.indent
+				if l.swatch != "" {
+					ctx.SetFillStyle(l.swatch)
+					if !l.swatchEmpty {
+						ctx.FillRect(textX, textY+5, 12, 12)
+					}
+					ctx.SetStrokeStyle(theme.Foreground)
+					ctx.SetLineWidth(1)
+					ctx.StrokeRect(textX, textY+5, 12, 12)
+					textX += 18
+				}
+				ctx.SetFont(font)
+				ctx.SetFillStyle(colour)
+				ctx.FillText(l.text, textX, textY+15)
+				if l.action != nil && textY+panelLineHeight > y && textY < y+h {
+					addHotspot(x, math.Max(textY, y), w, math.Min(textY+panelLineHeight, y+h)-math.Max(textY, y),
+						l.action)
+				}
+				if l.swatchAction != nil && textY+panelLineHeight > y && textY < y+h {
+					addHotspot(x+20+l.indent, math.Max(textY, y), 22,
+						math.Min(textY+panelLineHeight, y+h)-math.Max(textY, y), l.swatchAction)
+				}
+				textY += panelLineHeight
+			}
+		}
+		textY += panelSectionGap
+	}
+	panelContent = textY + panelScroll - y
+	ctx.Restore()
+
+	// Draw a scroll bar when the content doesn't fit
+	if panelContent > h {
+		barH := math.Max(20, h*(h/panelContent))
+		barY := y + (h-barH)*(panelScroll/(panelContent-h))
+		ctx.SetFillStyle(theme.Muted)
+		ctx.FillRect(x+w-8, barY, 5, barH)
+	}
+}
+
+// Returns the lines for the Equations section, listing each equation and its derivative.  Clicking an equation
+// removes it, and there's a line at the end for adding a new one
+func equationLines() (l []panelLine) {
+	for _, j := range equations {
+		e := j
+		l = append(l, panelLine{text: e.name + ":  " + e.formatted() + "   ✕", font: "bold 12px sans-serif",
+			swatch: e.colour, action: func() { removeEquation(e.name) }})
+		if e.parametric {
+			continue
+		}
+		l = append(l, paramLines(e)...)
+		l = append(l, panelLine{text: e.name + "':  " + scene.FormatEquation(e.deriv), font: "12px sans-serif",
+			swatch: e.dColour, indent: 15})
+	}
+	l = append(l, distributionLines()...)
+	l = append(l, derivedLines()...)
+	l = append(l, domainLines()...)
+	l = append(l, panelLine{text: "+ Add equation", colour: theme.Link, action: promptEquation})
+	l = append(l, panelLine{text: "+ Add parametric curve", colour: theme.Link, action: promptParametric})
+	l = append(l, panelLine{text: "+ Add distribution", colour: theme.Link, action: promptDistribution})
+	l = append(l, panelLine{text: "+ Add derived curve", colour: theme.Link, action: promptDerived})
+	return
+}
+
+// Returns the lines for the Help section
+func helpLines() []panelLine {
+	help := []string{
+		"Use wasd/numpad keys to rotate,",
+		"mouse wheel to zoom.  Drag with the",
+		"middle button or use shift+arrows to pan.",
+		"Shift+1 to 4 turn to the front, top,",
+		"side, and isometric views.  Or click an",
+		"axis on the compass to look down it.",
+		"Press z to enter a zoom level, 0 for 100%.",
+		"Press 5 to fit everything in the view.",
+		"Press v to export as SVG, n for a 360°",
+		"sprite sheet, Ctrl+S for an HTML page",
+		"with the graph in it that opens offline.",
+		"Press r to start/stop recording.",
+		"Press [ or ] to slow down or speed up",
+		"the animations.",
+		"Press . to start/stop auto-rotate.",
+		"Press ` to show/hide frame timings.",
+		"Press ; to switch between points,",
+		"wireframe, and solid surfaces.",
+		"Press / to colour the curves by their",
+		"slope, with a colour bar for the scale.",
+		"Press , to turn the label layout on or",
+		"off.  It moves or hides labels which",
+		"would overlap each other or the curves.",
+		"Press Ctrl+Shift+L to change how much",
+		"is logged to the javascript console.",
+		"Press m to switch light/dark mode.",
+		"Press l for labels along the curves.",
+		"Press e to add an equation, p for a",
+		"parametric curve, Delete to remove one.",
+		"Letters like the a in y = a·x² become",
+		"parameters, with sliders to adjust them.",
+		"Press = to work out a curve from others,",
+		"eg c1 = f1 - f2.",
+		"Open the Gallery section for ready made",
+		"graphs of interesting functions.",
+		"Numbers can be typed as 1.5 or 1,5.",
+		"Drop a text file of expressions, or a",
+		"CSV file of data, on the page to plot it.",
+		"Press b to plot a probability distribution,",
+		"x to mark the roots of the equations,",
+		"q for their extrema and inflections,",
+		"f for where they cross.",
+		"Press i to integrate an equation, c to",
+		"check its derivative numerically, k",
+		"for a Monte Carlo area demo.",
+		"Press j for projectile motion.",
+		"Press t (turntable), o (wobble), or",
+		"u (zoom pulse) for animations, y to",
+		"play/pause the demo timeline.",
+		"Hover over a curve to see its tangent,",
+		"press g for a point to drag along it.",
+		"Press h for a cursor line showing every",
+		"curve's value at the same x.",
+		"Click a point for its details, or a",
+		"curve, surface, or legend entry to",
+		"select it.  Escape deselects, and stops",
+		"the animation in progress.  Hold Alt to",
+		"rotate just the selected object.",
+		"Press ' to hide/show the derivatives,",
+		"| for the axes, # for the grid, \\ for",
+		"the XY, XZ, and YZ grid planes.  Click",
+		"a legend swatch to hide/show that curve.",
+		"Turn on Box select in the Operation",
+		"section to drag a box over points for",
+		"their statistics and best fit line.",
+		"Click section titles to expand/collapse.",
+	}
+	var l []panelLine
+	for _, j := range help {
+		l = append(l, panelLine{text: j, colour: theme.Help})
+	}
+	return l
+}
+
+// Returns the lines for the Legend section.  This lists each plotted object (everything except the axes and their tick
+// marks) with a swatch of its colour, and the equation it came from.  Clicking the swatch hides or shows the object,
+// with hidden ones having an empty swatch
+func legendLines() (l []panelLine) {
+	for _, o := range world.Objects() {
+		if isAxesPart(o) {
+			continue
+		}
+		text := o.Name
+		if o.Equation != "" {
+			text += ":  " + o.Equation
+		}
+		name, hidden := o.Name, o.Hidden
+		line := panelLine{text: text, swatch: o.C, swatchEmpty: hidden, action: func() {
+			selected = &selection{object: name, point: -1}
+		}, swatchAction: func() { setVisible(name, hidden) }}
+		if hidden {
+			line.colour = theme.Muted
+		}
+		l = append(l, line)
+	}
+	if len(l) == 0 {
+		l = append(l, panelLine{text: "Nothing plotted", colour: theme.Muted})
+	}
+	return
+}
+
+// Returns the lines for the Operation section
+func operationLines() []panelLine {
+	l := []panelLine{
+		{text: opText},
+		{text: fmt.Sprintf("Zoom: %s", zoomText(currentZoom()))},
+	}
+	l = append(l, speedLines()...)
+	l = append(l, autoRotateLines()...)
+	l = append(l, logLines()...)
+	if recording {
+		l = append(l, panelLine{text: "Recording in progress", colour: theme.Alert})
+	}
+	l = append(l, safeModeLines()...)
+	l = append(l, interpLines()...)
+	l = append(l, renderScaleLines()...)
+	l = append(l, renderModeLines()...)
+	l = append(l, slopeColourLines()...)
+	l = append(l, labelLayoutLines()...)
+	l = append(l, rotationLines()...)
+	l = append(l, boxModeLines()...)
+	l = append(l, localeLines()...)
+	l = append(l, timelineLines()...)
+	return append(l, linkLines()...)
+}
+
+// Scrolls the info panel content by the given number of pixels
+func scrollPanel(delta float64) {
+	panelScroll += delta
+	clampPanelScroll()
+}
+
+// Keeps the info panel scroll position within the content
+func clampPanelScroll() {
+	maxScroll := math.Max(0, panelContent-panelHeight)
+	panelScroll = math.Max(0, math.Min(panelScroll, maxScroll))
+}
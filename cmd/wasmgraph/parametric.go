@@ -0,0 +1,31 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+// Parses a parametric curve such as "x = cos(t); y = sin(t); z = t/5; t = 0..4pi"
+func newParametric(src string) (*equation, error) {
+	c, err := scene.ParseParametric(src)
+	if err != nil {
+		return nil, err
+	}
+	return &equation{src: c.Src, parametric: true, curve: c}, nil
+}
+
+// Asks the user for a parametric curve to plot
+func promptParametric() {
+	val := js.Global().Call("prompt", "Parametric curve, with x, y, and z as functions of t:",
+		"x = cos(t); y = sin(t); z = t/5; t = 0..4pi")
+	if val == js.Null() || val == js.Undefined() || strings.TrimSpace(val.String()) == "" {
+		return
+	}
+	_, err := addEquation(val.String())
+	if err != nil {
+		js.Global().Call("alert", fmt.Sprintf("Couldn't plot that curve: %v", err))
+	}
+}
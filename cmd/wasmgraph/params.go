@@ -0,0 +1,101 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	paramMin  = -5.0 // Range of the parameter sliders.  Values outside it can still be set through the API
+	paramMax  = 5.0
+	paramStep = 0.1
+)
+
+// Adds a slider for each of an equation's parameters, which plots the curve again as it's moved
+func addParamSliders(e *equation) {
+	for _, j := range paramNames(e) {
+		p := j
+		s := addSlider(fmt.Sprintf("%s in %s", p, e.name), paramMin, paramMax, paramStep, e.params[p],
+			func(v float64) {
+				setParam(e, p, v)
+			})
+		e.sliders = append(e.sliders, s)
+	}
+}
+
+// Fills the current parameter values into an equation, giving the expression its curve is sampled from
+func fillParams(e *equation) {
+	e.expr = expr.Substitute(e.family, e.params)
+	e.deriv = e.expr.Deriv("x")
+}
+
+// Lines for the Equations section, showing the values of an equation's parameters
+func paramLines(e *equation) []panelLine {
+	if len(e.params) == 0 {
+		return nil
+	}
+	var p []string
+	for _, n := range paramNames(e) {
+		p = append(p, fmt.Sprintf("%s = %s", n, scene.FormatCoord(e.params[n])))
+	}
+	return []panelLine{{text: strings.Join(p, ", "), font: "12px sans-serif", colour: theme.Muted, indent: 15}}
+}
+
+// Returns the names of an equation's parameters, sorted
+func paramNames(e *equation) []string {
+	var l []string
+	for n := range e.params {
+		l = append(l, n)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// Removes the sliders for an equation's parameters
+func removeParamSliders(e *equation) {
+	removeSliders(e.sliders...)
+	e.sliders = nil
+}
+
+// Sets one of an equation's parameters, and plots the equation again with it.  Anything else worked out from the
+// curve, like the intersections or a shaded integral, is worked out again too
+func setParam(e *equation, name string, v float64) error {
+	if _, ok := e.params[name]; !ok {
+		return fmt.Errorf("%s doesn't have a parameter called '%s'", e.name, name)
+	}
+	if !scene.Finite(v) {
+		return fmt.Errorf("the value of %s isn't a number", name)
+	}
+	e.params[name] = v
+	fillParams(e)
+	for i, j := range equations {
+		if j == e {
+			plotEquation(e, i+1)
+		}
+	}
+	for i, p := range paramNames(e) {
+		if p == name && i < len(e.sliders) {
+			e.sliders[i].set(v)
+		}
+	}
+	if showIntersections {
+		plotIntersections()
+	}
+	if area != nil && area.eq == e.name {
+		showIntegral(e.name, area.a, area.b)
+	}
+	if compared != nil && compared.eq == e.name {
+		compareDerivative(e.name)
+	}
+	if mc != nil && mc.eq == e {
+		clearMonteCarlo()
+	}
+	replotDerived()
+	explained = explanation{}
+	markActivity()
+	return nil
+}
@@ -0,0 +1,5 @@
+package main
+
+var (
+	pathLabels bool // Whether curves are labelled with their names along their paths, instead of at their first point
+)
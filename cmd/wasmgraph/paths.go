@@ -0,0 +1,119 @@
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/render"
+)
+
+const (
+	bulkPoints = 5000 // Objects with at least this many points are handed to javascript as a typed array to draw
+)
+
+// Javascript drawing large numbers of points from a Float64Array of screen x, y pairs.  Points which aren't finite are
+// skipped, as with the Path2D data
+const bulkJS = `
+function each(xy, f) {
+	for (var i = 0; i + 1 < xy.length; i += 2) {
+		if (isFinite(xy[i]) && isFinite(xy[i+1])) {
+			f(xy[i], xy[i+1]);
+		}
+	}
+}
+return {
+	dots: function(ctx, xy, r, outline) {
+		ctx.beginPath();
+		each(xy, function(x, y) {
+			ctx.moveTo(x + r, y);
+			ctx.ellipse(x, y, r, r, 0, 0, 2 * Math.PI);
+		});
+		ctx.fill();
+		if (outline) {
+			ctx.stroke();
+		}
+	},
+	line: function(ctx, xy) {
+		var started = false;
+		ctx.beginPath();
+		each(xy, function(x, y) {
+			if (started) {
+				ctx.lineTo(x, y);
+			} else {
+				ctx.moveTo(x, y);
+				started = true;
+			}
+		});
+		ctx.stroke();
+	}
+};`
+
+var (
+	bulkBuf  []float64        // Screen co-ordinates of the points being handed over, reused between objects
+	bulkDraw = js.Undefined() // The javascript shim, made the first time it's needed
+)
+
+// Draws points as dots of the given radius, outlining them too if asked.  Small sets go through a Path2D, large ones
+// through the typed array shim
+func (c *canvasRenderer) Dots(pts [][2]float64, r float64, outline bool) {
+	if len(pts) >= bulkPoints && !safeMode {
+		c.drawBulk("dots", pts, r, outline)
+		return
+	}
+	var dots render.Path
+	for _, p := range pts {
+		dots.Dot(p[0], p[1], r)
+	}
+	c.FillPath(&dots)
+	if outline {
+		c.StrokePath(&dots)
+	}
+}
+
+// Hands the screen co-ordinates of a large set of points to the javascript shim in one go, as a Float64Array viewing
+// Go's own memory.  Building path data for hundreds of thousands of points takes longer than drawing them, and the
+// array isn't copied at all.  The view is released straight after, before anything can grow the memory under it
+func (c *canvasRenderer) drawBulk(mode string, pts [][2]float64, args ...interface{}) {
+	bulkBuf = bulkBuf[:0]
+	for _, p := range pts {
+		bulkBuf = append(bulkBuf, p[0], p[1])
+	}
+	if bulkDraw == js.Undefined() {
+		bulkDraw = js.Global().Get("Function").New(bulkJS).Invoke()
+	}
+	ta := js.TypedArrayOf(bulkBuf)
+	defer ta.Release()
+	bulkDraw.Call(mode, append([]interface{}{c.ctx, ta}, args...)...)
+}
+
+// Fills the shapes gathered in a path, with a single call to the canvas
+func (c *canvasRenderer) FillPath(p *render.Path) {
+	if !p.Empty() {
+		c.ctx.Call("fill", path2D(p))
+	}
+}
+
+// Returns a javascript Path2D holding the shapes gathered in a path.  Building the path in Go then handing it over as
+// a string takes one call across to javascript, rather than one for every point
+func path2D(p *render.Path) js.Value {
+	return js.Global().Get("Path2D").New(p.String())
+}
+
+// Draws a line joining up points.  Small sets go through a Path2D, large ones through the typed array shim
+func (c *canvasRenderer) Polyline(pts [][2]float64) {
+	if len(pts) >= bulkPoints && !safeMode {
+		c.drawBulk("line", pts)
+		return
+	}
+	var line render.Path
+	for _, p := range pts {
+		line.LineTo(p[0], p[1])
+	}
+	c.StrokePath(&line)
+}
+
+// Strokes the lines gathered in a path, with a single call to the canvas
+func (c *canvasRenderer) StrokePath(p *render.Path) {
+	if !p.Empty() {
+		c.ctx.Call("stroke", path2D(p))
+	}
+}
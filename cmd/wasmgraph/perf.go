@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	perfWindow = 1000.0 // Milliseconds of frames the frame rate is worked out over
+)
+
+var (
+	showPerf    bool            // True while the performance overlay is shown
+	perfFrames  []float64       // Timestamps of the frames drawn within the last perfWindow, oldest first
+	perfTimes   []time.Duration // How long each of those frames took to render
+	perfTook    time.Duration   // How long the last frame took to render
+	perfSlowest time.Duration   // The longest any frame within the last perfWindow took
+	perfPoints  int             // Points drawn in the last frame
+	framePoints int             // Points drawn so far in this frame
+)
+
+// Draws the performance overlay in the top right corner of the graph area: the frame rate, how long the last frame
+// took to render (and the slowest recently), and how many points it drew.  Frames aren't drawn while nothing changes,
+// so the frame rate drops to nothing on a still graph
+func drawPerf(top float64) {
+	if !showPerf {
+		return
+	}
+	fps := 0.0
+	if n := len(perfFrames); n > 1 {
+		fps = float64(n-1) * 1000 / (perfFrames[n-1] - perfFrames[0])
+	}
+	lines := []string{
+		fmt.Sprintf("%.0f fps", fps),
+		fmt.Sprintf("Frame: %.1f ms (max %.1f)", perfTook.Seconds()*1000, perfSlowest.Seconds()*1000),
+		fmt.Sprintf("Points: %d", perfPoints),
+	}
+	ctx.Save()
+	ctx.SetFont("12px monospace")
+	ctx.SetTextAlign("left")
+	ctx.SetTextBaseline("top")
+	w := 0.0
+	for _, l := range lines {
+		w = math.Max(w, ctx.MeasureText(l))
+	}
+	w, h := w+tooltipPadding*2, float64(len(lines))*16+tooltipPadding*2
+	x, y := graphWidth-w-10, top+30
+	ctx.SetGlobalAlpha(0.8)
+	ctx.SetFillStyle(theme.Background)
+	ctx.FillRect(x, y, w, h)
+	ctx.SetGlobalAlpha(1)
+	ctx.SetStrokeStyle(theme.Muted)
+	ctx.SetLineWidth(1)
+	ctx.SetLineDash()
+	ctx.StrokeRect(x, y, w, h)
+	ctx.SetFillStyle(theme.Text)
+	for i, l := range lines {
+		ctx.FillText(l, x+tooltipPadding, y+tooltipPadding+float64(i)*16)
+	}
+	ctx.Restore()
+}
+
+// Records the timings for the frame just drawn while the overlay is shown, dropping those from before the frame rate
+// window.  Called at the end of each frame drawn
+func measureFrame() {
+	perfPoints, framePoints = framePoints, 0
+	if !showPerf {
+		return
+	}
+	perfTook = time.Since(lastFrame)
+	perfFrames = append(perfFrames, frameTime)
+	perfTimes = append(perfTimes, perfTook)
+	for len(perfFrames) > 1 && frameTime-perfFrames[0] > perfWindow {
+		perfFrames, perfTimes = perfFrames[1:], perfTimes[1:]
+	}
+	perfSlowest = 0
+	for _, t := range perfTimes {
+		if t > perfSlowest {
+			perfSlowest = t
+		}
+	}
+}
+
+// Shows or hides the performance overlay
+func togglePerf() {
+	showPerf = !showPerf
+	perfFrames, perfTimes = nil, nil
+}
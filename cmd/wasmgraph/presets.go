@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"go.uber.org/atomic"
+)
+
+// A named animation, built from a sequence of operations
+type animationPreset struct {
+	name string
+	key  string // Keyboard shortcut
+	ops  func() []Operation
+}
+
+var (
+	animationPresets = []animationPreset{
+		{name: "turntable", key: "t", ops: func() []Operation { return turntable(12, 360, 3000) }},
+		{name: "wobble", key: "o", ops: func() []Operation { return wobble(15, 2, 400) }},
+		{name: "zoom pulse", key: "u", ops: func() []Operation { return zoomPulse(1.3, 2, 300) }},
+	}
+
+	presetActive = atomic.NewBool(false) // True while a preset is feeding operations into the queue
+)
+
+// Returns the preset with the given name or keyboard shortcut
+func findPreset(s string) (animationPreset, bool) {
+	for _, p := range animationPresets {
+		if strings.EqualFold(p.name, s) || p.key == strings.ToLower(s) {
+			return p, true
+		}
+	}
+	return animationPreset{}, false
+}
+
+// Starts playing the named animation preset
+func playPreset(name string) error {
+	p, ok := findPreset(name)
+	if !ok {
+		return fmt.Errorf("unknown animation preset '%s'", name)
+	}
+	if presetActive.Load() {
+		return fmt.Errorf("an animation preset is already playing")
+	}
+	if safeMode {
+		return fmt.Errorf("animation presets are off in safe mode")
+	}
+	presetActive.Store(true)
+	ops := p.ops()
+	cancels := opCancels.Load()
+	go func() {
+		// Each operation goes in once the one before has started, so they follow on smoothly without being merged
+		for _, op := range ops {
+			if !waitForQueue(cancels) {
+				break
+			}
+			queueOperation(op)
+		}
+		presetActive.Store(false)
+	}()
+	return nil
+}
+
+// Spins the world space around the Y axis by the given total number of degrees, split into the given number of
+// operations, taking the given number of milliseconds overall
+func turntable(steps int, degrees float64, ms int32) (ops []Operation) {
+	t := ms / int32(steps)
+	for i := 0; i < steps; i++ {
+		ops = append(ops, Operation{op: ROTATE, t: t, f: 15, Y: degrees / float64(steps)})
+	}
+	return
+}
+
+// Rocks the world space from side to side around the Y axis by the given number of degrees, ending where it started
+func wobble(degrees float64, cycles int, ms int32) (ops []Operation) {
+	ops = append(ops, Operation{op: ROTATE, t: ms / 2, f: 10, Y: degrees})
+	for i := 0; i < cycles; i++ {
+		ops = append(ops, Operation{op: ROTATE, t: ms, f: 20, Y: -2 * degrees})
+		if i < cycles-1 {
+			ops = append(ops, Operation{op: ROTATE, t: ms, f: 20, Y: 2 * degrees})
+		}
+	}
+	ops = append(ops, Operation{op: ROTATE, t: ms / 2, f: 10, Y: degrees})
+	return
+}
+
+// Zooms in by the given factor and back out again, the given number of times
+func zoomPulse(factor float64, cycles int, ms int32) (ops []Operation) {
+	for i := 0; i < cycles; i++ {
+		ops = append(ops, scaleOp(factor, ms, 15), scaleOp(1/factor, ms, 15))
+	}
+	return
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	projVelScale = 0.25 // Seconds of travel shown by the velocity arrow
+	projAccScale = 0.1  // Scale of the acceleration arrow
+	projPause    = 0.75 // Seconds to wait at the end of the flight before starting again
+)
+
+// The projectile motion scene
+type projectileScene struct {
+	speed, angle, gravity float64 // Launch speed, launch angle in degrees, and gravity
+	flight                float64 // Time of flight, in seconds
+	t                     float64 // Time since launch
+	last                  time.Time
+	sliders               []*slider
+}
+
+var (
+	projectile *projectileScene // The projectile scene in progress, if any
+)
+
+// Lines for the info panel, describing the projectiles' flight
+func projectileLines() (l []panelLine) {
+	if projectile == nil {
+		return
+	}
+	p := projectile
+	rad := p.angle * math.Pi / 180
+	vy := p.speed * math.Sin(rad)
+	l = append(l, panelLine{text: fmt.Sprintf("Projectile: range %s, height %s   ✕",
+		scene.FormatCoord(p.speed*math.Cos(rad)*p.flight), scene.FormatCoord(vy*vy/(2*p.gravity))),
+		swatch: "darkorange", action: stopProjectile})
+	l = append(l, panelLine{text: fmt.Sprintf("Flight time %ss, t = %ss", scene.FormatCoord(p.flight),
+		scene.FormatCoord(math.Min(p.t, p.flight))), indent: 15})
+	return
+}
+
+// Regenerates the trajectory after the launch settings change, and starts the flight again
+func resetProjectile() {
+	p := projectile
+	rad := p.angle * math.Pi / 180
+	p.flight = 2 * p.speed * math.Sin(rad) / p.gravity
+	p.t = 0
+	p.last = time.Now()
+
+	// The trajectory is a parametric curve, with the launch settings filled in
+	removeObjects("trajectory")
+	if p.flight <= 0 {
+		return
+	}
+	num := func(v float64) string {
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	e, err := newParametric(fmt.Sprintf("x = %s*t; y = %s*t - %s*t^2; t = 0..%s", num(p.speed*math.Cos(rad)),
+		num(p.speed*math.Sin(rad)), num(p.gravity/2), num(p.flight)))
+	if err != nil {
+		return
+	}
+	o := e.curve.Sample(scene.ParametricSamples / 4)
+	o.Name = "trajectory"
+	o.C = "darkorange"
+	o.Equation = fmt.Sprintf("speed %s, angle %s°, gravity %s", scene.FormatCoord(p.speed), scene.FormatCoord(p.angle),
+		scene.FormatCoord(p.gravity))
+	replaceObject(o)
+}
+
+// Starts the projectile motion scene, with sliders for the launch speed and angle, and gravity
+func startProjectile() {
+	stopProjectile()
+	p := &projectileScene{speed: 8, angle: 45, gravity: 9.81}
+	projectile = p
+	p.sliders = append(p.sliders, addSlider("Speed", 1, 15, 0.1, p.speed, func(v float64) {
+		p.speed = v
+		resetProjectile()
+	}))
+	p.sliders = append(p.sliders, addSlider("Angle", 0, 90, 1, p.angle, func(v float64) {
+		p.angle = v
+		resetProjectile()
+	}))
+	p.sliders = append(p.sliders, addSlider("Gravity", 1, 20, 0.01, p.gravity, func(v float64) {
+		p.gravity = v
+		resetProjectile()
+	}))
+	resetProjectile()
+}
+
+// Moves the projectile along its trajectory, showing its velocity and acceleration vectors.  Called each frame while
+// the scene is running
+func stepProjectile() {
+	if projectile == nil {
+		return
+	}
+	p := projectile
+	now := time.Now()
+	p.t += now.Sub(p.last).Seconds()
+	p.last = now
+	if p.t > p.flight+projPause {
+		p.t = 0
+	}
+	t := math.Min(p.t, p.flight)
+	rad := p.angle * math.Pi / 180
+	vx, vy := p.speed*math.Cos(rad), p.speed*math.Sin(rad)-p.gravity*t
+	x, y := p.speed*math.Cos(rad)*t, p.speed*math.Sin(rad)*t-p.gravity*t*t/2
+	replaceObject(Object{Name: "projectile", C: "darkorange", Scatter: true, Marker: "diamond",
+		P: []Point{{X: x, Y: y}}})
+	arrows = []arrow{
+		{x: x, y: y, dx: vx * projVelScale, dy: vy * projVelScale, colour: "royalblue", label: "v"},
+		{x: x, y: y, dy: -p.gravity * projAccScale, colour: "crimson", label: "a"},
+	}
+}
+
+// Stops the projectile motion scene, removing its objects and sliders
+func stopProjectile() {
+	if projectile == nil {
+		return
+	}
+	removeSliders(projectile.sliders...)
+	projectile = nil
+	arrows = nil
+	removeObjects("trajectory", "projectile")
+}
+
+// Starts or stops the projectile motion scene
+func toggleProjectile() {
+	if projectile != nil {
+		stopProjectile()
+	} else {
+		startProjectile()
+	}
+}
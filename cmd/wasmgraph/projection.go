@@ -0,0 +1,73 @@
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+)
+
+// Javascript for wasmGraph.worldToScreen(x, y, z), run against the projection object.  These are plain javascript
+// functions rather than Go callbacks, as Go callbacks can't return anything to the page
+const worldToScreenJS = `
+return function(x, y, z) {
+	var m = view.matrix;
+	z = z || 0;
+	var tx = m[0] * x + m[1] * y + m[2] * z + m[3];
+	var ty = m[4] * x + m[5] * y + m[6] * z + m[7];
+	return {x: view.centerX + tx * view.step, y: view.centerY - ty * view.step};
+};`
+
+// Javascript for wasmGraph.screenToWorld(px, py), the same as geometry.Unproject()
+const screenToWorldJS = `
+return function(px, py) {
+	var m = view.matrix;
+	var wx = (px - view.centerX) / view.step - m[3];
+	var wy = (view.centerY - py) / view.step - m[7];
+	var det = m[0] * m[5] - m[1] * m[4];
+	if (Math.abs(det) < 1e-9) {
+		return null;
+	}
+	return {x: (wx * m[5] - m[1] * wy) / det, y: (m[0] * wy - wx * m[4]) / det, z: 0};
+};`
+
+var (
+	projection = js.Undefined() // The current projection, as a javascript object kept up to date each frame
+	projMatrix matrix           // The world matrix last copied into the projection object
+	projScreen [7]float64       // The screen layout last copied into it: centre, step, and graph area edges
+)
+
+// Creates the projection object, and the javascript API functions for converting between graph and screen
+// co-ordinates using it
+func initProjection(api js.Value) {
+	projection = js.Global().Get("Object").New()
+	fn := js.Global().Get("Function")
+	api.Set("screenToWorld", fn.New("view", screenToWorldJS).Invoke(projection))
+	api.Set("worldToScreen", fn.New("view", worldToScreenJS).Invoke(projection))
+}
+
+// Copies the current projection into the projection object, when it's changed since the last frame
+func updateProjection(left float64, top float64) {
+	if projection == js.Undefined() {
+		return
+	}
+	projection.Set("time", frameTime)
+	screen := [7]float64{centerX, centerY, step, left, top, graphWidth, graphHeight}
+	if screen == projScreen && sameMatrix(worldMatrix, projMatrix) {
+		return
+	}
+	projScreen = screen
+	projMatrix = append(matrix(nil), worldMatrix...)
+	m := make([]interface{}, len(worldMatrix))
+	for i, v := range worldMatrix {
+		m[i] = v
+	}
+	projection.Set("matrix", m)
+	projection.Set("centerX", centerX)
+	projection.Set("centerY", centerY)
+	projection.Set("step", step)
+	projection.Set("zoom", geometry.Zoom(worldMatrix))
+	projection.Set("left", left)
+	projection.Set("top", top)
+	projection.Set("width", graphWidth-left)
+	projection.Set("height", graphHeight-top)
+}
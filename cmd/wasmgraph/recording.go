@@ -0,0 +1,84 @@
+package main
+
+import (
+	"syscall/js"
+)
+
+const (
+	recordFPS = 30 // Frame rate captured from the canvas while recording
+)
+
+var (
+	recording    bool
+	recorder     js.Value
+	recordStream js.Value
+	recordChunks js.Value
+	recordMime   string
+	recDataCall  js.Callback
+	recStopCall  js.Callback
+)
+
+// Starts recording the canvas to a WebM video, using the browsers' captureStream and MediaRecorder APIs
+func startRecording() {
+	mediaRec := js.Global().Get("MediaRecorder")
+	if canvasEl.Get("captureStream") == js.Undefined() || mediaRec == js.Undefined() {
+		js.Global().Call("alert", "Sorry, this browser doesn't support recording the canvas to video.")
+		return
+	}
+
+	// Use VP9 when it's available, as it gives noticeably smaller files
+	recordMime = "video/webm"
+	if mediaRec.Call("isTypeSupported", "video/webm;codecs=vp9").Bool() {
+		recordMime = "video/webm;codecs=vp9"
+	}
+
+	recordStream = canvasEl.Call("captureStream", recordFPS)
+	recordChunks = js.Global().Get("Array").New()
+	recorder = mediaRec.New(recordStream, map[string]interface{}{"mimeType": recordMime})
+	recDataCall = js.NewCallback(recordData)
+	recStopCall = js.NewCallback(recordStopped)
+	recorder.Set("ondataavailable", recDataCall)
+	recorder.Set("onstop", recStopCall)
+	recorder.Call("start")
+	recording = true
+	logInfo("Recording started, using %v", recordMime)
+}
+
+// Collects the chunks of video data handed over by the MediaRecorder
+func recordData(args []js.Value) {
+	data := args[0].Get("data")
+	if data.Get("size").Int() > 0 {
+		recordChunks.Call("push", data)
+	}
+}
+
+// Once the MediaRecorder has flushed its remaining data, joins the chunks together and offers the video for download
+func recordStopped(args []js.Value) {
+	blob := js.Global().Get("Blob").New(recordChunks, map[string]interface{}{"type": recordMime})
+	downloadBlob("wasmGraph.webm", blob)
+	logInfo("Recording saved, %v bytes", blob.Get("size").Int())
+
+	// Clean up
+	tracks := recordStream.Call("getTracks")
+	for i := 0; i < tracks.Length(); i++ {
+		tracks.Index(i).Call("stop")
+	}
+	recDataCall.Release()
+	recStopCall.Release()
+	recordChunks = js.Undefined()
+}
+
+// Stops the recording in progress.  The video is saved when the recorder signals it has finished
+func stopRecording() {
+	recording = false
+	recorder.Call("stop")
+}
+
+// Starts or stops recording, depending on whether a recording is already in progress
+func toggleRecording() {
+	if recording {
+		stopRecording()
+	} else {
+		startRecording()
+	}
+}
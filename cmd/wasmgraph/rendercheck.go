@@ -0,0 +1,287 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/render"
+)
+
+const (
+	checkReportLimit = 20 // Maximum number of differences reported each way
+)
+
+var (
+	checkBackends bool // When set, the next frame is drawn through both the canvas and SVG backends, and compared
+)
+
+// Reduces the drawing commands recorded for the canvas to the shapes they draw.  Paths which are filled then stroked
+// (like the marker shapes) count once, as filled shapes
+func canvasCommands(log []render.Command) map[string]int {
+	cmds := map[string]int{}
+	var subs [][][2]float64
+	var dots [][3]float64
+	filled, lastFilled := false, ""
+	fill := func(subs [][][2]float64, dots [][3]float64) {
+		for _, s := range subs {
+			if len(s) >= 3 {
+				cmds[polyKey(s)]++
+			}
+		}
+		for _, d := range dots {
+			cmds[dotKey(d[0], d[1], d[2])]++
+		}
+	}
+	stroke := func(subs [][][2]float64) {
+		for _, s := range subs {
+			for j := 1; j < len(s); j++ {
+				cmds[lineKey(s[j-1], s[j])]++
+			}
+		}
+	}
+	for _, e := range log {
+		num := func(j int) float64 {
+			f, _ := e.Args[j-1].(float64)
+			return f
+		}
+
+		// Fills and strokes given path data draw that, rather than the path built up with the context
+		var d string
+		if len(e.Args) > 0 && (e.Name == "fill" || e.Name == "stroke") {
+			d, _ = e.Args[0].(string)
+		}
+		switch e.Name {
+		case "beginPath", "clip":
+			subs, dots, filled = nil, nil, false
+		case "moveTo":
+			subs = append(subs, [][2]float64{{num(1), num(2)}})
+		case "lineTo":
+			if len(subs) == 0 {
+				subs = append(subs, nil)
+			}
+			subs[len(subs)-1] = append(subs[len(subs)-1], [2]float64{num(1), num(2)})
+		case "ellipse":
+			dots = append(dots, [3]float64{num(1), num(2), num(3)})
+		case "fill":
+			if d != "" {
+				fill(pathShapes(d))
+				lastFilled = d
+				continue
+			}
+			fill(subs, dots)
+			filled = true
+		case "stroke":
+			if d != "" {
+				if d != lastFilled {
+					ps, _ := pathShapes(d)
+					stroke(ps)
+				}
+				continue
+			}
+			if !filled {
+				stroke(subs)
+			}
+		case "fillText":
+			cmds[textKey(e.Args[0].(string))]++
+		}
+	}
+	return cmds
+}
+
+// Draws the graph area as usual, and again into a recorder to see the commands sent to the canvas, then renders the
+// same scene through the SVG backend and reports any differences in the shapes drawn on the javascript console.  Text
+// is compared by content only, as the canvas positions some of it with transforms
+func compareBackends(left float64, top float64) {
+	drawGraph(left, top)
+	rec := render.NewRecorder()
+	render.DrawGraph(rec, graphFrame(left, top))
+	canvas := canvasCommands(rec.Commands)
+
+	console := js.Global().Get("console")
+	svg, err := svgCommands(render.SVG(world.Objects(), worldMatrix, theme, graphWidth, graphHeight, centerX, centerY,
+		step, pathLabels, showGrid))
+	if err != nil {
+		console.Call("error", fmt.Sprintf("Backend check: couldn't read the SVG output: %v", err))
+		return
+	}
+	onlyCanvas, onlySVG := diffCommands(canvas, svg)
+	total := 0
+	for _, n := range canvas {
+		total += n
+	}
+	if len(onlyCanvas) == 0 && len(onlySVG) == 0 {
+		console.Call("log", fmt.Sprintf("Backend check: canvas and SVG match (%d shapes)", total))
+		return
+	}
+	report := func(l []string) string {
+		if len(l) > checkReportLimit {
+			l = append(l[:checkReportLimit:checkReportLimit], fmt.Sprintf("... and %d more", len(l)-checkReportLimit))
+		}
+		return strings.Join(l, "\n  ")
+	}
+	console.Call("warn", fmt.Sprintf("Backend check: canvas and SVG differ (%d shapes on the canvas)\n"+
+		"Only on the canvas (%d):\n  %s\nOnly in the SVG (%d):\n  %s", total, len(onlyCanvas), report(onlyCanvas),
+		len(onlySVG), report(onlySVG)))
+}
+
+// Returns the shapes drawn more times by one backend than the other, with the number of extra times if it's more
+// than once
+func diffCommands(a map[string]int, b map[string]int) (onlyA []string, onlyB []string) {
+	extra := func(x map[string]int, y map[string]int) (l []string) {
+		for k, n := range x {
+			switch d := n - y[k]; {
+			case d == 1:
+				l = append(l, k)
+			case d > 1:
+				l = append(l, fmt.Sprintf("%s (x%d)", k, d))
+			}
+		}
+		sort.Strings(l)
+		return
+	}
+	return extra(a, b), extra(b, a)
+}
+
+// Key identifying a dot, to 2 decimal places as used by the SVG output
+func dotKey(x float64, y float64, r float64) string {
+	return fmt.Sprintf("dot %.2f,%.2f r%.2f", x, y, r)
+}
+
+// Key identifying a line, which is the same whichever way round it's drawn
+func lineKey(a [2]float64, b [2]float64) string {
+	p1, p2 := fmt.Sprintf("%.2f,%.2f", a[0], a[1]), fmt.Sprintf("%.2f,%.2f", b[0], b[1])
+	if p2 < p1 {
+		p1, p2 = p2, p1
+	}
+	return "line " + p1 + " " + p2
+}
+
+// Reads the SVG path data written by render.Path back into its lines and polygons, and its dots
+func pathShapes(d string) (subs [][][2]float64, dots [][3]float64) {
+	f := strings.Fields(d)
+	num := func(i int) float64 {
+		v, _ := strconv.ParseFloat(f[i], 64)
+		return v
+	}
+	for i := 0; i < len(f); {
+		switch f[i] {
+		case "M":
+			subs = append(subs, [][2]float64{{num(i + 1), num(i + 2)}})
+			i += 3
+		case "L":
+			if len(subs) > 0 {
+				subs[len(subs)-1] = append(subs[len(subs)-1], [2]float64{num(i + 1), num(i + 2)})
+			}
+			i += 3
+		case "A":
+			// A dot's first arc, starting from its right hand edge.  Its second arc is skipped
+			if len(subs) > 0 {
+				start, r := subs[len(subs)-1][0], num(i+1)
+				subs = subs[:len(subs)-1]
+				dots = append(dots, [3]float64{start[0] - r, start[1], r})
+			}
+			i += 16
+		default:
+			i++
+		}
+	}
+	return
+}
+
+// Key identifying a filled polygon, which is the same whichever point it starts from and whichever way round it goes
+func polyKey(p [][2]float64) string {
+	s := make([]string, len(p))
+	first := 0
+	for i, c := range p {
+		s[i] = fmt.Sprintf("%.2f,%.2f", c[0], c[1])
+		if s[i] < s[first] {
+			first = i
+		}
+	}
+	n := len(s)
+	step := 1
+	if n > 2 && s[(first+n-1)%n] < s[(first+1)%n] {
+		step = n - 1
+	}
+	l := make([]string, n)
+	for i := range l {
+		l[i] = s[(first+i*step)%n]
+	}
+	return "poly " + strings.Join(l, " ")
+}
+
+// Reduces an SVG document written by render.SVG to the shapes it draws, matching canvasCommands.  The background and
+// border rectangles aren't part of the graph area drawing, so are left out
+func svgCommands(svg string) (map[string]int, error) {
+	cmds := map[string]int{}
+	d := xml.NewDecoder(strings.NewReader(svg))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return cmds, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		el, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		attrs := map[string]string{}
+		for _, a := range el.Attr {
+			attrs[a.Name.Local] = a.Value
+		}
+		num := func(name string) float64 {
+			f, _ := strconv.ParseFloat(attrs[name], 64)
+			return f
+		}
+		switch el.Name.Local {
+		case "clipPath":
+			if err = d.Skip(); err != nil {
+				return nil, err
+			}
+		case "line":
+			cmds[lineKey([2]float64{num("x1"), num("y1")}, [2]float64{num("x2"), num("y2")})]++
+		case "circle":
+			cmds[dotKey(num("cx"), num("cy"), num("r"))]++
+		case "polygon":
+			cmds[polyKey(svgPoints(strings.Replace(attrs["points"], ",", " ", -1)))]++
+		case "path":
+			p := svgPoints(strings.NewReplacer("M", "", "L", "", "Z", "").Replace(attrs["d"]))
+			if attrs["fill"] != "none" {
+				cmds[polyKey(p)]++
+				continue
+			}
+			for j := 1; j < len(p); j++ {
+				cmds[lineKey(p[j-1], p[j])]++
+			}
+		case "text":
+			var t string
+			if err = d.DecodeElement(&t, &el); err != nil {
+				return nil, err
+			}
+			cmds[textKey(t)]++
+		}
+	}
+}
+
+// Parses a space separated list of numbers as X/Y co-ordinate pairs
+func svgPoints(s string) (p [][2]float64) {
+	f := strings.Fields(s)
+	for i := 0; i+1 < len(f); i += 2 {
+		x, _ := strconv.ParseFloat(f[i], 64)
+		y, _ := strconv.ParseFloat(f[i+1], 64)
+		p = append(p, [2]float64{x, y})
+	}
+	return
+}
+
+// Key identifying a piece of text
+func textKey(t string) string {
+	return fmt.Sprintf("text %q", t)
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+)
+
+const (
+	renderScaleMin = 50  // Lowest render resolution, as a percentage of the screen's own
+	renderScaleMax = 200 // Highest render resolution, as a percentage of the screen's own
+)
+
+var (
+	renderScale  = 100.0 // Render resolution, as a percentage of the screen's own
+	renderSlider *slider // Slider for the render resolution, while it's shown
+)
+
+// Returns the line for the Operation section showing the render resolution.  Clicking it shows or hides a slider for
+// changing it
+func renderScaleLines() []panelLine {
+	return []panelLine{{text: fmt.Sprintf("Resolution: %.0f%%", renderScale), colour: theme.Link,
+		action: toggleRenderSlider}}
+}
+
+// Changes the render resolution, as a percentage of the screen's own.  The canvas stays the same size on the page,
+// with only the number of pixels drawn changing, so a lower resolution is blurrier but quicker to draw
+func setRenderScale(pct float64) error {
+	if !(pct >= renderScaleMin && pct <= renderScaleMax) {
+		return fmt.Errorf("the resolution must be from %d%% to %d%%", renderScaleMin, renderScaleMax)
+	}
+	renderScale = pct
+	pixelsDirty = true
+	markActivity()
+	if renderSlider != nil {
+		renderSlider.set(pct)
+	}
+	return nil
+}
+
+// Shows or hides the render resolution slider
+func toggleRenderSlider() {
+	if renderSlider != nil {
+		removeSliders(renderSlider)
+		renderSlider = nil
+		return
+	}
+	renderSlider = addSlider("Resolution %", renderScaleMin, renderScaleMax, 10, renderScale, func(v float64) {
+		setRenderScale(v)
+	})
+}
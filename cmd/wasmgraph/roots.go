@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	rootScanSteps = 2000 // Number of intervals the plotted range is scanned in, looking for roots
+	rootTolerance = 1e-9 // How close to zero a function needs to be for a point to count as a root
+)
+
+var (
+	showRoots bool // Whether the roots of the equations are marked
+)
+
+// Finds the roots of an expression of x between minX and maxX.  The range is scanned for sign changes, which are
+// narrowed down by bisection.  Roots where the curve just touches zero (like x^2) don't change sign, so Newton's method
+// is also tried from any turning points close to zero, using the derivative
+func findRoots(n expr.Node, d expr.Node, minX float64, maxX float64) (roots []float64) {
+	vars := map[string]float64{}
+	f := func(x float64) float64 {
+		vars["x"] = x
+		return n.Eval(vars)
+	}
+	df := func(x float64) float64 {
+		vars["x"] = x
+		return d.Eval(vars)
+	}
+	add := func(x float64) {
+		for _, r := range roots {
+			if math.Abs(r-x) < 1e-7 {
+				return
+			}
+		}
+		roots = append(roots, x)
+	}
+
+	h := (maxX - minX) / rootScanSteps
+	for i := 0; i < rootScanSteps; i++ {
+		a, b := minX+float64(i)*h, minX+float64(i+1)*h
+		fa, fb := f(a), f(b)
+		if !scene.Finite(fa) || !scene.Finite(fb) {
+			continue
+		}
+		if fa == 0 {
+			add(a)
+			continue
+		}
+		if fa*fb < 0 {
+			// Bisect down to the root.  Poles (like tan at π/2) change sign too, but don't end up near zero
+			for j := 0; j < 100 && b-a > 1e-15; j++ {
+				m := (a + b) / 2
+				fm := f(m)
+				if fa*fm <= 0 {
+					b = m
+				} else {
+					a, fa = m, fm
+				}
+			}
+			r := (a + b) / 2
+			if math.Abs(f(r)) < 1e-6 {
+				add(r)
+			}
+			continue
+		}
+
+		// Turning point close to zero, so try Newton's method from there
+		da, db := df(a), df(b)
+		if scene.Finite(da) && scene.Finite(db) && da*db <= 0 && math.Min(math.Abs(fa), math.Abs(fb)) < 1e-3 {
+			x := (a + b) / 2
+			for j := 0; j < 50; j++ {
+				dx := df(x)
+				if dx == 0 || !scene.Finite(dx) {
+					break
+				}
+				x -= f(x) / dx
+			}
+			if math.Abs(f(x)) < rootTolerance && x >= minX && x <= maxX {
+				add(x)
+			}
+		}
+	}
+	if fb := f(maxX); fb == 0 {
+		add(maxX)
+	}
+	sort.Float64s(roots)
+	return
+}
+
+// Marks the roots of an equation with labelled dots, replacing any earlier ones
+func plotRoots(e *equation) {
+	name := e.name + " roots"
+	removeObjects(name)
+	if e.parametric {
+		return
+	}
+	minX, maxX, _ := e.sampling()
+	e.roots = findRoots(e.expr, e.deriv, minX, maxX)
+	o := Object{Name: name, C: e.colour, Scatter: true, LabelTemplate: " x = %x", LabelFont: "12px sans-serif",
+		Equation: "roots of " + e.name, Tags: []string{"annotations"}}
+	for _, r := range e.roots {
+		o.P = append(o.P, Point{X: r, LabelAlign: "left"})
+	}
+	if len(o.P) > 0 {
+		replaceObject(o)
+	}
+}
+
+// Lines for the info panel, listing the roots of each equation
+func rootLines() (l []panelLine) {
+	if !showRoots {
+		return
+	}
+	for _, e := range equations {
+		if e.parametric {
+			continue
+		}
+		var r []string
+		for _, x := range e.roots {
+			r = append(r, scene.FormatCoord(x))
+		}
+		if len(r) == 0 {
+			r = append(r, "none")
+		}
+		l = append(l, panelLine{text: fmt.Sprintf("Roots of %s:  %s", e.name, strings.Join(r, ", ")), swatch: e.colour})
+	}
+	return
+}
+
+// Turns the marking of roots on or off
+func toggleRoots() {
+	showRoots = !showRoots
+	for _, e := range equations {
+		if showRoots {
+			plotRoots(e)
+		} else {
+			removeObjects(e.name + " roots")
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+	"syscall/js"
+)
+
+const (
+	safeParam = "safe" // Query parameter which starts in safe mode, eg "index.html?safe=1"
+)
+
+var (
+	// Set when started in safe mode.  The scene in any share link isn't loaded, and the compute and render workers,
+	// linked plots, animation presets, self-test, draw hook, typed array drawing, and deployment settings are all left
+	// off, so a page which breaks on startup can still be opened to put things right
+	safeMode bool
+)
+
+// Reloads the page without the safe mode query parameter.  The share link, if there is one, is kept
+func leaveSafeMode() {
+	loc := js.Global().Get("location")
+	q, _ := url.ParseQuery(strings.TrimPrefix(loc.Get("search").String(), "?"))
+	q.Del(safeParam)
+	search := ""
+	if len(q) > 0 {
+		search = "?" + q.Encode()
+	}
+	loc.Set("search", search)
+}
+
+// Lines for the Operation section of the info panel, saying when safe mode is on
+func safeModeLines() []panelLine {
+	if !safeMode {
+		return nil
+	}
+	return []panelLine{
+		{text: "Safe mode: only the basics are loaded", colour: theme.Alert},
+		{text: "Reload normally", colour: theme.Link, indent: 15, action: leaveSafeMode},
+	}
+}
+
+// Reports whether the page was loaded with the safe mode query parameter, set to anything other than 0
+func wantSafeMode() bool {
+	q, err := url.ParseQuery(strings.TrimPrefix(js.Global().Get("location").Get("search").String(), "?"))
+	if err != nil {
+		return false
+	}
+	v, ok := q[safeParam]
+	return ok && (len(v) == 0 || v[0] != "0")
+}
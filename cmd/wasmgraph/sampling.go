@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	curveMaxPoints    = 200000 // Most points an equation's curve can be sampled at
+	sampleTolerance   = 0.5    // Furthest an adaptively sampled curve can stray from the line drawn, in pixels
+	sampleResample    = 2.0    // Factor the zoom can change by before adaptively sampled curves are sampled again
+	sampleDefaultUnit = 20.0   // Pixels per unit assumed before the first frame has worked it out
+)
+
+// Asks the user for the range of x to plot an equation over
+func promptSampleRange(e *equation) {
+	minX, maxX, _ := e.sampling()
+	def := scene.FormatCoord(minX) + ".." + scene.FormatCoord(maxX)
+	val := js.Global().Call("prompt",
+		fmt.Sprintf("Range of x to plot %s over (e.g. -1..2), or empty for the graph's own:", e.name), def)
+	if val == js.Null() || val == js.Undefined() {
+		return
+	}
+	var err error
+	a, b := 0.0, 0.0
+	if strings.TrimSpace(val.String()) != "" {
+		a, b, err = scene.ParseRange(val.String())
+	}
+	if err == nil {
+		err = setSampling(e, a, b, e.step, e.samples)
+	}
+	if err != nil {
+		js.Global().Call("alert", fmt.Sprintf("Couldn't change the sampling: %v", err))
+	}
+}
+
+// Asks the user for the number of points to sample a parametric curve at
+func promptSamples(e *equation) {
+	val := js.Global().Call("prompt", fmt.Sprintf("Number of points to sample %s at:", e.name), strconv.Itoa(e.samples))
+	if val == js.Null() || val == js.Undefined() || strings.TrimSpace(val.String()) == "" {
+		return
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(val.String()))
+	if err == nil {
+		err = setSampling(e, e.minX, e.maxX, e.step, n)
+	}
+	if err != nil {
+		js.Global().Call("alert", fmt.Sprintf("Couldn't change the sampling: %v", err))
+	}
+}
+
+// Asks the user for the distance along x between the points an equation is sampled at
+func promptSampleStep(e *equation) {
+	def := ""
+	if e.step > 0 {
+		def = scene.FormatNumber(e.step)
+	}
+	val := js.Global().Call("prompt", fmt.Sprintf("Step along x between the points of %s, or empty for the graph's "+
+		"own (e.g. %s):", e.name, scene.FormatNumber(pointStep)), def)
+	if val == js.Null() || val == js.Undefined() {
+		return
+	}
+	var err error
+	st := 0.0
+	if strings.TrimSpace(val.String()) != "" {
+		st, err = scene.ParseNumber(val.String())
+	}
+	if err == nil {
+		err = setSampling(e, e.minX, e.maxX, st, e.samples)
+	}
+	if err != nil {
+		js.Global().Call("alert", fmt.Sprintf("Couldn't change the sampling: %v", err))
+	}
+}
+
+// Samples an equation's curve (or its derivative, n) over a range of x.  A step of 0 samples it adaptively, with the
+// points placed closely enough to be within sampleTolerance pixels of the curve at the current zoom level
+func sampleEquation(e *equation, n expr.Node, minX float64, maxX float64, st float64) Object {
+	if st > 0 {
+		return scene.SampleCurve(n, minX, maxX, st)
+	}
+	e.scale = sampleScale()
+	return scene.SampleAdaptive(n, minX, maxX, sampleTolerance/e.scale)
+}
+
+// Returns the number of pixels per unit along x, for adaptive sampling
+func sampleScale() float64 {
+	if s := step * currentZoom(); s > 0 && scene.Finite(s) {
+		return s
+	}
+	return sampleDefaultUnit
+}
+
+// Returns the range of x and the step an equation is sampled with.  Its own settings are used where it has them, and
+// the graph's otherwise.  The step is 0 when it's sampled adaptively
+func (e *equation) sampling() (minX float64, maxX float64, st float64) {
+	minX, maxX, st = graphMinX, graphMaxX, graphStep
+	if e.minX < e.maxX {
+		minX, maxX = e.minX, e.maxX
+	}
+	if e.step > 0 {
+		st = e.step
+	}
+	return
+}
+
+// Lines for the Selection section of the info panel, showing how the selected equation's curve is sampled.  Each can
+// be clicked to change it, which plots the curve again
+func samplingLines(e *equation) (l []panelLine) {
+	if e.parametric {
+		return []panelLine{{text: fmt.Sprintf("Samples: %d", e.samples), colour: theme.Link, indent: 15,
+			action: func() { promptSamples(e) }}}
+	}
+	minX, maxX, st := e.sampling()
+	text := fmt.Sprintf("Step: %s (%d points)", scene.FormatNumber(st), int(math.Floor((maxX-minX)/st+0.5))+1)
+	if st == 0 {
+		o, _ := findObject(e.name)
+		text = fmt.Sprintf("Step: adaptive (%d points)", len(o.P))
+	}
+	l = append(l, panelLine{text: text, colour: theme.Link, indent: 15, action: func() { promptSampleStep(e) }})
+	l = append(l, panelLine{text: fmt.Sprintf("Plotted for x: %s to %s", scene.FormatCoord(minX),
+		scene.FormatCoord(maxX)), colour: theme.Link, indent: 15, action: func() { promptSampleRange(e) }})
+	if e.step > 0 || e.minX < e.maxX {
+		l = append(l, panelLine{text: "Use the graph's sampling", colour: theme.Link, indent: 15,
+			action: func() { setSampling(e, 0, 0, 0, scene.ParametricSamples) }})
+	}
+	return
+}
+
+// Changes how an equation's curve is sampled, then plots it again.  A range with minX not less than maxX means the
+// graph's own is used, and a step of 0 samples it adaptively.  samples is only used by parametric curves
+func setSampling(e *equation, minX float64, maxX float64, st float64, samples int) error {
+	if st < 0 || !scene.Finite(st) {
+		return fmt.Errorf("the step must be a positive number")
+	}
+	if samples < 2 || samples > curveMaxPoints {
+		return fmt.Errorf("the number of samples must be from 2 to %d", curveMaxPoints)
+	}
+	old := *e
+	e.minX, e.maxX, e.step, e.samples = minX, maxX, st, samples
+	a, b, s := e.sampling()
+	if s > 0 && (b-a)/s+1 > curveMaxPoints {
+		e.minX, e.maxX, e.step, e.samples = old.minX, old.maxX, old.step, old.samples
+		return fmt.Errorf("that would be more than %d points, so please use a larger step", curveMaxPoints)
+	}
+	for i, eq := range equations {
+		if eq == e {
+			plotEquation(e, i+1)
+		}
+	}
+	if showIntersections {
+		plotIntersections()
+	}
+	logActivity("scene", "Resampled %s", e.name)
+	return nil
+}
+
+// Samples the adaptively sampled curves again once the zoom level has changed enough since they were, so zooming in
+// doesn't show their corners, and zooming out doesn't leave them with more points than needed.  Called each frame
+func updateSampling() {
+	s := sampleScale()
+	for i, e := range equations {
+		if _, _, st := e.sampling(); e.parametric || st > 0 || e.scale == 0 {
+			continue
+		}
+		if r := s / e.scale; r > sampleResample || r < 1/sampleResample {
+			plotEquation(e, i+1)
+		}
+	}
+}
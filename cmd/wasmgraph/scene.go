@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+var (
+	// The scene graph.  Its objects are kept in graph co-ordinates, and flattened into the world space whenever it or
+	// the world matrix changes
+	sceneRoot = scene.NewNode(Object{})
+)
+
+// Adds an object to the top of the scene graph.  It's drawn through the accumulated world transform, so it lines up
+// with everything already rotated, scaled, or moved.  Names are unique, so one already in use is refused
+func addObject(ob Object) error {
+	if sceneRoot.Find(ob.Name) != nil {
+		return fmt.Errorf("there's already something called '%s'", ob.Name)
+	}
+	sceneRoot.Add(scene.NewNode(importObject(ob, 0.0, 0.0, 0.0)))
+	userObjects = append(userObjects, ob)
+	rebuildWorld()
+	logActivity("scene", "Added object %s", ob.Name)
+	return nil
+}
+
+// Returns the object with the given name, as it's drawn
+func findObject(name string) (Object, bool) {
+	return world.Get(name)
+}
+
+// Removes all objects except the axes and their tick marks from the world space
+func clearObjects() {
+	for _, e := range equations {
+		removeParamSliders(e)
+	}
+	equations = nil
+	derived = nil
+	area = nil
+	compared = nil
+	mc = nil
+	dists = nil
+	intersections = nil
+	series = nil
+	userObjects = nil
+	removeDragMarker()
+	clearMarkers()
+	stopProjectile()
+	axes := sceneRoot.Find("axes")
+	sceneRoot.Children = nil
+	if axes != nil {
+		parts := axes.Children
+		axes.Children = nil
+		sceneRoot.Add(axes)
+		for _, n := range parts {
+			if isAxesPart(n.Object) {
+				axes.Add(n)
+			}
+		}
+	}
+	rebuildWorld()
+	logActivity("scene", "Cleared everything except the axes")
+}
+
+// Gathers objects into a new group in the scene graph, so they can be transformed together.  Anything under the
+// objects comes along with them
+func groupObjects(name string, members []string) error {
+	if name == "" {
+		return fmt.Errorf("the group needs a name")
+	}
+	if sceneRoot.Find(name) != nil {
+		return fmt.Errorf("there's already something called '%s'", name)
+	}
+	var nodes []*scene.Node
+	for _, m := range members {
+		n := sceneRoot.Find(m)
+		if n == nil || m == "" {
+			return fmt.Errorf("there's no object called '%s'", m)
+		}
+		nodes = append(nodes, n)
+	}
+	g := scene.NewNode(Object{Name: name})
+	sceneRoot.Add(g)
+	for _, n := range nodes {
+		// Keep it where it was, by giving it the transforms of the parents it's leaving
+		n.Local = geometry.Multiply(n.Parent().World(), n.Local)
+		g.Add(n)
+	}
+	rebuildWorld()
+	return nil
+}
+
+// Flattens the scene graph through a world matrix, any tag styles, and the render modes, into a registry sorted into
+// draw order.  Names are kept unique as objects are added, so a clash here is a bug, which is logged rather than
+// stopping the frame
+func flattenWorld(m matrix) *scene.Registry {
+	w, err := scene.NewRegistry(scene.ApplyModes(applyTagStyles(sceneRoot.Flatten(m)), renderMode))
+	if err != nil {
+		logError("wasmGraph: %v", err)
+	}
+	return w
+}
+
+// Flattens the scene graph into the world space.  Needs calling whenever objects are added, removed, or changed
+func rebuildWorld() {
+	world = flattenWorld(worldMatrix)
+	extentDirty = true
+	needsRedraw = true
+}
+
+// Removes the objects with the given names from the world space, along with anything grouped under them
+func removeObjects(names ...string) {
+	for _, name := range names {
+		if n := sceneRoot.Find(name); n != nil && n != sceneRoot {
+			n.Parent().Remove(n)
+		}
+	}
+	rebuildWorld()
+}
+
+// Replaces the object with the same name in the scene graph, adding it if there isn't one already.  Its transform,
+// whether it's hidden, and anything grouped under it are kept
+func replaceObject(ob Object) {
+	o := importObject(ob, 0.0, 0.0, 0.0)
+	if n := sceneRoot.Find(ob.Name); n != nil && n != sceneRoot {
+		o.Hidden = o.Hidden || n.Object.Hidden
+		n.Object = o
+	} else {
+		sceneRoot.Add(scene.NewNode(o))
+	}
+	rebuildWorld()
+}
+
+// Sets the transform of an object or group within its parent, replacing any earlier one
+func setObjectTransform(name string, m matrix) error {
+	n := sceneRoot.Find(name)
+	if n == nil || name == "" {
+		return fmt.Errorf("there's no object or group called '%s'", name)
+	}
+	if _, ok := geometry.Invert(m); !ok {
+		return fmt.Errorf("the transform can't be undone, so would flatten the object")
+	}
+	n.Local = m
+	rebuildWorld()
+	return nil
+}
+
+// Changes the world matrix, moving everything in the world space to match.  The world view is worked out from it, so
+// later rotations are combined with its orientation
+func setWorldMatrix(m matrix) {
+	worldView = geometry.MatrixView(m)
+	worldMatrix = m
+	world = flattenWorld(worldMatrix)
+	needsRedraw = true
+}
+
+// Changes the world view, moving everything in the world space to match
+func setWorldView(v geometry.View) {
+	worldView = v
+	worldMatrix = v.Matrix()
+	world = flattenWorld(worldMatrix)
+	needsRedraw = true
+}
+
+// Applies a transform to just one object or group, as seen on the screen, so rotating it turns it the same way an
+// operation on the whole world space would.  Objects which have gone are left alone
+func transformObject(name string, step matrix) {
+	n := sceneRoot.Find(name)
+	if n == nil || name == "" {
+		return
+	}
+	p := geometry.Multiply(worldMatrix, n.Parent().World())
+	inv, ok := geometry.Invert(p)
+	if !ok {
+		return
+	}
+	n.Local = geometry.Multiply(inv, geometry.Multiply(step, geometry.Multiply(p, n.Local)))
+	rebuildWorld()
+}
+
+// Moves the objects in a group back up to the group's parent, keeping them where they are, then removes the group
+func ungroupObjects(name string) error {
+	g := sceneRoot.Find(name)
+	if g == nil || name == "" || len(g.Object.P) > 0 {
+		return fmt.Errorf("there's no group called '%s'", name)
+	}
+	parent := g.Parent()
+	for _, n := range append([]*scene.Node(nil), g.Children...) {
+		n.Local = geometry.Multiply(g.Local, n.Local)
+		parent.Add(n)
+	}
+	parent.Remove(g)
+	rebuildWorld()
+	return nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/render"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	sceneHash = "#scene=" // Prefix of share link URL fragments holding a scene
+)
+
+var (
+	userObjects []Object // Objects added through the API, in graph co-ordinates, for saving with the scene
+)
+
+// Replaces everything plotted with the contents of a scene
+func loadScene(s *scene.File) error {
+	if renderActive.Load() || presetActive.Load() {
+		return fmt.Errorf("an operation is still in progress")
+	}
+	for _, o := range s.Objects {
+		if err := scene.Validate(o); err != nil {
+			return err
+		}
+	}
+	for _, p := range s.GridPlanes {
+		if !scene.ValidGridPlane(p) {
+			return fmt.Errorf("unknown grid plane '%s'", p)
+		}
+	}
+	minX, maxX := scene.DefaultMinX, scene.DefaultMaxX
+	if len(s.Domain) == 2 {
+		minX, maxX = s.Domain[0], s.Domain[1]
+	}
+	if err := checkDomain(minX, maxX, s.Step); err != nil {
+		return err
+	}
+	light := render.DefaultLight
+	if len(s.Light) == 3 {
+		copy(light[:], s.Light)
+	}
+	if err := render.SetLight(light[0], light[1], light[2]); err != nil {
+		return err
+	}
+	if err := setRenderMode(s.Mode); err != nil {
+		return err
+	}
+	logActivity("scene", "Loaded a scene")
+	clearObjects()
+	for _, p := range scene.GridPlanes {
+		shown := false
+		for _, q := range s.GridPlanes {
+			shown = shown || q == p
+		}
+		if n := sceneRoot.Find("grid" + p); n != nil {
+			n.Object.Hidden = !shown
+		}
+	}
+	if len(s.View) == 16 {
+		// Switch to the saved view, then plot everything into it
+		setWorldMatrix(append(matrix(nil), s.View...))
+		tickZoom = 0 // Regenerate the tick marks for the new zoom level
+	}
+	setDomain(minX, maxX, s.Step)
+	var problems []string
+	for i, src := range s.Equations {
+		e, err := addEquation(src)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("equation '%s': %v", src, err))
+			continue
+		}
+		for p, v := range s.ParamValues(i, paramNames(e)) {
+			setParam(e, p, v)
+		}
+	}
+	for _, src := range s.Distributions {
+		if _, err := addDistribution(src); err != nil {
+			problems = append(problems, fmt.Sprintf("distribution '%s': %v", src, err))
+		}
+	}
+	for _, o := range s.Objects {
+		if err := addObject(o); err != nil {
+			problems = append(problems, fmt.Sprintf("object: %v", err))
+		}
+	}
+	for _, src := range s.Derived {
+		if _, err := addDerived(src); err != nil {
+			problems = append(problems, fmt.Sprintf("derived curve '%s': %v", src, err))
+		}
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("some of the scene couldn't be plotted: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Loads the scene in the page URL, if it's a share link
+func loadSceneFromURL() {
+	hash := js.Global().Get("location").Get("hash").String()
+	if !strings.HasPrefix(hash, sceneHash) {
+		return
+	}
+	data, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(hash[len(sceneHash):], "="))
+	if err == nil {
+		var s *scene.File
+		if s, err = scene.Parse(data); err == nil {
+			err = loadScene(s)
+		}
+	}
+	if err != nil {
+		logError("Couldn't load the scene in the link: %v", err)
+	}
+}
+
+// Returns the current scene, ready for saving
+func saveScene() *scene.File {
+	s := &scene.File{Version: scene.Version, View: append(matrix(nil), worldMatrix...), Objects: userObjects,
+		Step: graphStep, Mode: renderMode}
+	if graphMinX != scene.DefaultMinX || graphMaxX != scene.DefaultMaxX {
+		s.Domain = []float64{graphMinX, graphMaxX}
+	}
+	if render.Light != render.DefaultLight {
+		s.Light = append([]float64(nil), render.Light[:]...)
+	}
+	for i, e := range equations {
+		s.Equations = append(s.Equations, e.src)
+		if len(e.params) == 0 {
+			continue
+		}
+		for len(s.Params) < i {
+			s.Params = append(s.Params, nil)
+		}
+		p := map[string]float64{}
+		for n, v := range e.params {
+			p[n] = v
+		}
+		s.Params = append(s.Params, p)
+	}
+	for _, d := range dists {
+		s.Distributions = append(s.Distributions, d.Source())
+	}
+	for _, p := range scene.GridPlanes {
+		if n := sceneRoot.Find("grid" + p); n != nil && !n.Object.Hidden {
+			s.GridPlanes = append(s.GridPlanes, p)
+		}
+	}
+	for _, d := range derived {
+		s.Derived = append(s.Derived, d.src)
+	}
+	return s
+}
+
+// Returns a share link for the current scene.  This is the page URL, with the scene encoded in the fragment
+func sceneLink() (string, error) {
+	data, err := json.Marshal(saveScene())
+	if err != nil {
+		return "", err
+	}
+	loc := js.Global().Get("location")
+	base := loc.Get("href").String()
+	if i := strings.Index(base, "#"); i >= 0 {
+		base = base[:i]
+	}
+	return base + sceneHash + base64.RawURLEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,356 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	selectRadius   = 10 // How close a click needs to be to a point or object to select it, in pixels
+	curvePointSize = 3  // How close a click needs to be to a point on a curve to select it, rather than the curve
+	cardPadding    = 8  // Space around the text of the selection info card
+	cardLineHeight = 16 // Line spacing of the selection info card
+)
+
+// A selected point of an object, or a whole object
+type selection struct {
+	object string // Name of the object
+	point  int    // Index of the point in the object, or -1 when the whole object is selected
+}
+
+var (
+	selected *selection // The selected point or object, if any
+)
+
+// Returns the equation an object was plotted from, and whether the object is the equation itself (0) or its
+// derivative (1)
+func equationFor(name string) (*equation, int, bool) {
+	for _, e := range equations {
+		switch name {
+		case e.name:
+			return e, 0, true
+		case e.name + "'":
+			return e, 1, true
+		}
+	}
+	return nil, 0, false
+}
+
+// Draws a selected object highlighted, by outlining its surfaces, edges, and curve in the alert colour.  Curves are
+// redrawn in their own colour on top, so they're still recognisable
+func drawObjectHighlight(o Object, left float64, top float64) {
+	screen := func(p Point) (float64, float64) {
+		return centerX + (p.X * step), centerY + ((p.Y * step) * -1)
+	}
+	ctx.Save()
+	ctx.BeginPath()
+	ctx.Rect(left, top, graphWidth-left, graphHeight-top)
+	ctx.Clip()
+	ctx.SetLineDash()
+	ctx.SetStrokeStyle(theme.Alert)
+	ctx.SetLineJoin("round")
+	ctx.SetLineWidth(3)
+	for _, l := range o.S {
+		ctx.BeginPath()
+		for m, n := range l {
+			px, py := screen(o.P[n])
+			if m == 0 {
+				ctx.MoveTo(px, py)
+			} else {
+				ctx.LineTo(px, py)
+			}
+		}
+		ctx.ClosePath()
+		ctx.Stroke()
+	}
+	for _, l := range o.E {
+		x1, y1 := screen(o.P[l[0]])
+		x2, y2 := screen(o.P[l[1]])
+		ctx.BeginPath()
+		ctx.MoveTo(x1, y1)
+		ctx.LineTo(x2, y2)
+		ctx.Stroke()
+	}
+	if o.Scatter {
+		for _, p := range o.P {
+			px, py := screen(p)
+			ctx.BeginPath()
+			ctx.Ellipse(px, py, scene.MarkerSize+2, scene.MarkerSize+2, 0, 0, 2*math.Pi)
+			ctx.Stroke()
+		}
+	}
+	if scene.IsCurve(o) {
+		ctx.SetLineWidth(6)
+		for _, c := range []string{theme.Alert, o.C} {
+			ctx.SetStrokeStyle(c)
+			ctx.BeginPath()
+			for k, p := range o.P {
+				px, py := screen(p)
+				if k == 0 {
+					ctx.MoveTo(px, py)
+				} else {
+					ctx.LineTo(px, py)
+				}
+			}
+			ctx.Stroke()
+			ctx.SetLineWidth(2)
+		}
+	}
+	ctx.Restore()
+}
+
+// Draws a highlight around the selected point, and the info card beside it.  Selected objects are highlighted, with
+// their details shown in the info panel instead
+func drawSelection(left float64, top float64) {
+	if selected == nil {
+		return
+	}
+	o, ok := findObject(selected.object)
+	if !ok || selected.point >= len(o.P) {
+		selected = nil // The object has gone, or has been regenerated with fewer points
+		return
+	}
+	if selected.point < 0 {
+		drawObjectHighlight(o, left, top)
+		return
+	}
+	p := o.P[selected.point]
+	px := centerX + (p.X * step)
+	py := centerY + ((p.Y * step) * -1)
+	ctx.SetLineWidth(2)
+	ctx.SetLineDash()
+	ctx.SetStrokeStyle(theme.Alert)
+	ctx.BeginPath()
+	ctx.Ellipse(px, py, 5, 5, 0, 0, 2*math.Pi)
+	ctx.Stroke()
+
+	// Size the card to fit its text, and keep it inside the graph area
+	inv, _ := geometry.Invert(worldMatrix)
+	g := scene.Transform(inv, p)
+	lines := selectionLines(o, g.X, g.Y, g.Z)
+	ctx.SetFont("12px sans-serif")
+	w := 0.0
+	for _, l := range lines {
+		w = math.Max(w, ctx.MeasureText(l))
+	}
+	w += cardPadding*2 + 14 // Room for the close button
+	h := float64(len(lines))*cardLineHeight + cardPadding*2
+	x := math.Max(left, math.Min(px+12, graphWidth-w-4))
+	y := math.Max(top, math.Min(py+12, graphHeight-h-4))
+
+	ctx.SetFillStyle(theme.Background)
+	ctx.FillRect(x, y, w, h)
+	ctx.SetLineWidth(1)
+	ctx.SetStrokeStyle(theme.Foreground)
+	ctx.StrokeRect(x, y, w, h)
+	ctx.SetTextAlign("left")
+	for i, l := range lines {
+		ctx.SetFillStyle(theme.Text)
+		if i == 0 {
+			ctx.SetFont("bold 12px sans-serif")
+		} else {
+			ctx.SetFont("12px sans-serif")
+		}
+		ctx.FillText(l, x+cardPadding, y+cardPadding+float64(i+1)*cardLineHeight-4)
+	}
+
+	// Clicks on the card itself shouldn't select whatever is underneath, except for the close button on top
+	addHotspot(x, y, w, h, func() {})
+	ctx.SetFillStyle(theme.Muted)
+	ctx.SetTextAlign("right")
+	ctx.FillText("✕", x+w-cardPadding+2, y+cardPadding+cardLineHeight-4)
+	addHotspot(x+w-cardPadding-14, y, cardPadding+14, cardPadding+cardLineHeight, func() { selected = nil })
+}
+
+// Returns the name of the object at the given screen co-ordinates.  Curves and edges close enough to them are
+// picked first, then any surface they're inside, with the surfaces drawn last (on top) winning
+func objectAt(x float64, y float64) (string, bool) {
+	screen := func(p Point) (float64, float64) {
+		return centerX + (p.X * step), centerY + ((p.Y * step) * -1)
+	}
+	name, best := "", float64(selectRadius)
+	for _, o := range world.Objects() {
+		if isAxesPart(o) || o.Hidden {
+			continue
+		}
+		var segs [][2]int
+		for _, l := range o.E {
+			segs = append(segs, [2]int{l[0], l[1]})
+		}
+		if scene.IsCurve(o) {
+			for i := 1; i < len(o.P); i++ {
+				segs = append(segs, [2]int{i - 1, i})
+			}
+		}
+		for _, g := range segs {
+			ax, ay := screen(o.P[g[0]])
+			bx, by := screen(o.P[g[1]])
+			if d := segmentDistance(x, y, ax, ay, bx, by); d <= best {
+				name, best = o.Name, d
+			}
+		}
+	}
+	if name != "" {
+		return name, true
+	}
+	objs := world.Objects()
+	for i := len(objs) - 1; i >= 0; i-- {
+		o := objs[i]
+		if o.Hidden {
+			continue
+		}
+		for _, f := range scene.Faces(o) {
+			// Ray casting point in polygon test.  Culled surfaces can't be seen, so can't be clicked on either
+			l, in := f.S, false
+			for j, k := 0, len(l)-1; j < len(l); k, j = j, j+1 {
+				x1, y1 := screen(o.P[l[j]])
+				x2, y2 := screen(o.P[l[k]])
+				if (y1 > y) != (y2 > y) && x < (x2-x1)*(y-y1)/(y2-y1)+x1 {
+					in = !in
+				}
+			}
+			if in {
+				return o.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+// Returns the distance from a point to the line segment between two others
+func segmentDistance(x float64, y float64, ax float64, ay float64, bx float64, by float64) float64 {
+	vx, vy := bx-ax, by-ay
+	t := 0.0
+	if l := vx*vx + vy*vy; l > 0 {
+		t = math.Max(0, math.Min(1, ((x-ax)*vx+(y-ay)*vy)/l))
+	}
+	return math.Hypot(ax+t*vx-x, ay+t*vy-y)
+}
+
+// Selects the point nearest the given screen co-ordinates, if there's one close enough.  Otherwise selects the object
+// there, if any, or clears the selection when the click was on empty space.  The points of curves are close together,
+// so clicks need to be right on them to select one instead of the curve
+func selectAt(x float64, y float64) {
+	selected = nil
+	best := float64(selectRadius)
+	for _, o := range world.Objects() {
+		if isAxesPart(o) || o.Hidden {
+			continue
+		}
+		for i, p := range o.P {
+			d := math.Hypot(centerX+(p.X*step)-x, centerY+((p.Y*step)*-1)-y)
+			if scene.IsCurve(o) && d > curvePointSize {
+				continue
+			}
+			if d <= best {
+				best = d
+				selected = &selection{object: o.Name, point: i}
+			}
+		}
+	}
+	if selected != nil {
+		return
+	}
+	if name, ok := objectAt(x, y); ok {
+		selected = &selection{object: name, point: -1}
+	}
+}
+
+// Returns the lines for the Selection section, describing the selected object, after the statistics of any points
+// picked by the selection box
+func selectedLines() (l []panelLine) {
+	l = boxLines()
+	if selected == nil || selected.point >= 0 {
+		if len(l) > 0 {
+			return
+		}
+		return []panelLine{{text: "Click a curve or surface to select it", colour: theme.Muted}}
+	}
+	o, ok := findObject(selected.object)
+	if !ok {
+		return
+	}
+	l = append(l, panelLine{text: o.Name + "   ✕", swatch: o.C, action: func() { selected = nil }})
+	if o.Equation != "" {
+		l = append(l, panelLine{text: o.Equation, indent: 15})
+	}
+	l = append(l, panelLine{text: fmt.Sprintf("%d points, %d edges, %d surfaces", len(o.P), len(o.E), len(o.S)),
+		indent: 15})
+	if len(o.Tags) > 0 {
+		l = append(l, panelLine{text: "Tags: " + strings.Join(o.Tags, ", "), indent: 15})
+	}
+	var keys []string
+	for k := range o.Meta {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		l = append(l, panelLine{text: k + ": " + o.Meta[k], indent: 15})
+	}
+	if e, ok := findEquation(strings.TrimSuffix(o.Name, "'")); ok {
+		l = append(l, samplingLines(e)...)
+	}
+
+	// Extent and length in graph co-ordinates, so they don't change as the graph is rotated or zoomed
+	inv, ok := geometry.Invert(worldMatrix)
+	if !ok || len(o.P) == 0 {
+		return
+	}
+	minX, minY, maxX, maxY := math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)
+	var length float64
+	var last Point
+	for i, p := range o.P {
+		g := scene.Transform(inv, p)
+		minX, maxX = math.Min(minX, g.X), math.Max(maxX, g.X)
+		minY, maxY = math.Min(minY, g.Y), math.Max(maxY, g.Y)
+		if i > 0 {
+			length += math.Sqrt((g.X-last.X)*(g.X-last.X) + (g.Y-last.Y)*(g.Y-last.Y) + (g.Z-last.Z)*(g.Z-last.Z))
+		}
+		last = g
+	}
+	l = append(l, panelLine{text: fmt.Sprintf("x: %s to %s,  y: %s to %s", scene.FormatCoord(minX),
+		scene.FormatCoord(maxX), scene.FormatCoord(minY), scene.FormatCoord(maxY)), indent: 15})
+	if scene.IsCurve(o) {
+		l = append(l, panelLine{text: fmt.Sprintf("Length: %s", scene.FormatCoord(length)), indent: 15})
+	}
+	return
+}
+
+// Returns the lines of text for the selection info card, given the selected object and the graph co-ordinates of the
+// selected point.  Points on plotted equations get their derivatives, worked out from the equation rather than the
+// sampled points
+func selectionLines(o Object, x float64, y float64, z float64) (l []string) {
+	title := o.Name
+	if o.Equation != "" {
+		title += ":  " + o.Equation
+	}
+	l = append(l, title)
+
+	e, d, ok := equationFor(o.Name)
+	if !ok || e.parametric {
+		l = append(l, fmt.Sprintf("x = %s,  y = %s,  z = %s", scene.FormatCoord(x), scene.FormatCoord(y),
+			scene.FormatCoord(z)))
+	} else {
+		// Differentiate as far as needed, starting from the curve that was selected
+		n := e.expr
+		if d == 1 {
+			n = e.deriv
+		}
+		d1 := n.Deriv("x")
+		d2 := d1.Deriv("x")
+		vars := map[string]float64{"x": x}
+		y = n.Eval(vars)
+		dy := d1.Eval(vars)
+		l = append(l, fmt.Sprintf("x = %s,  y = %s", scene.FormatCoord(x), scene.FormatCoord(y)))
+		l = append(l, fmt.Sprintf("y' = %s,  y'' = %s", scene.FormatCoord(dy), scene.FormatCoord(d2.Eval(vars))))
+		l = append(l, fmt.Sprintf("Slope angle: %s°", scene.FormatCoord(math.Atan(dy)*180/math.Pi)))
+		z = 0
+	}
+	l = append(l, fmt.Sprintf("Distance from origin: %s", scene.FormatCoord(math.Sqrt(x*x+y*y+z*z))))
+	return
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"syscall/js"
+	"time"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/render"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	selfTestParam     = "selftest" // Query parameter which runs the self-test on startup, eg "index.html?selftest"
+	selfTestTolerance = 1e-6       // How far numbers can drift from where they should be before it counts as a failure
+)
+
+// One step of the self-test, and how it went
+type selfTestStep struct {
+	name string
+	run  func() error
+	err  error
+	took time.Duration
+}
+
+var (
+	// The scene the self-test runs over, with one of each kind of thing which can be plotted
+	selfTestScene = scene.File{
+		Version:       scene.Version,
+		View:          geometry.Identity(),
+		Equations:     []string{"y = x^2 - 1", "x = cos(t); y = sin(t); z = t/5; t = 0..4pi"},
+		Distributions: []string{"normal(0, 1); -1..1"},
+		Objects: []Object{{Name: "tri", C: "red", P: []Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}},
+			S: []Surface{{0, 1, 2}}}},
+	}
+
+	selfTestSteps   []*selfTestStep // The steps of the running (or finished) self-test, for the overlay
+	selfTestRunning bool
+	selfTestTook    time.Duration // How long the whole self-test took
+)
+
+// Reports whether two matrices are the same, give or take rounding
+func closeMatrix(a matrix, b matrix) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > selfTestTolerance {
+			return false
+		}
+	}
+	return true
+}
+
+// Draws the self-test results over the top left of the graph area.  They stay until escape is pressed
+func drawSelfTest(left float64, top float64) {
+	if selfTestSteps == nil {
+		return
+	}
+	lines := []string{"Self-test: running..."}
+	colours := []string{theme.Text}
+	if !selfTestRunning {
+		lines[0] = fmt.Sprintf("Self-test: %s in %v", selfTestVerdict(), selfTestTook.Round(time.Millisecond))
+		if selfTestFailed() {
+			colours[0] = theme.Alert
+		}
+	}
+	for _, s := range selfTestSteps {
+		switch {
+		case s.took == 0:
+			lines = append(lines, "  … "+s.name)
+			colours = append(colours, theme.Muted)
+		case s.err != nil:
+			lines = append(lines, fmt.Sprintf("  ✗ %s: %v", s.name, s.err))
+			colours = append(colours, theme.Alert)
+		default:
+			lines = append(lines, fmt.Sprintf("  ✓ %s (%v)", s.name, s.took.Round(time.Millisecond)))
+			colours = append(colours, theme.Text)
+		}
+	}
+	ctx.Save()
+	ctx.SetFont("12px sans-serif")
+	ctx.SetTextAlign("left")
+	ctx.SetTextBaseline("top")
+	w := 0.0
+	for _, l := range lines {
+		w = math.Max(w, ctx.MeasureText(l))
+	}
+	x, y := left+10, top+10
+	w, h := w+tooltipPadding*2, float64(len(lines))*16+tooltipPadding*2
+	ctx.SetFillStyle(theme.Background)
+	ctx.SetStrokeStyle(theme.Foreground)
+	ctx.SetLineWidth(1)
+	ctx.SetLineDash()
+	ctx.FillRect(x, y, w, h)
+	ctx.StrokeRect(x, y, w, h)
+	for i, l := range lines {
+		ctx.SetFillStyle(colours[i])
+		ctx.FillText(l, x+tooltipPadding, y+tooltipPadding+float64(i)*16)
+	}
+	ctx.Restore()
+}
+
+// Runs a fixed script of operations over a known scene, checking the results along the way, then puts back whatever
+// was plotted before.  How each step went, and how long it took, is reported on the javascript console and in an
+// overlay on the graph
+func runSelfTest() {
+	console := js.Global().Get("console")
+	selfTestSteps = []*selfTestStep{
+		{name: "load scene", run: selfTestLoad},
+		{name: "rotate, scale, and move, then back", run: selfTestRoundTrip},
+		{name: "camera views", run: selfTestViews},
+		{name: "save and reload", run: selfTestSave},
+		{name: "render SVG", run: selfTestSVG},
+		{name: "frames drawn", run: selfTestFrames},
+	}
+	selfTestRunning = true
+	before := saveScene()
+	start := time.Now()
+	for _, s := range selfTestSteps {
+		t := time.Now()
+		s.err = s.run()
+		s.took = time.Since(t)
+		if s.err != nil {
+			console.Call("error", fmt.Sprintf("Self-test: FAIL %s (%v): %v", s.name, s.took, s.err))
+			continue
+		}
+		console.Call("log", fmt.Sprintf("Self-test: pass %s (%v)", s.name, s.took))
+	}
+	selfTestTook = time.Since(start)
+	if err := loadScene(before); err != nil {
+		console.Call("error", fmt.Sprintf("Self-test: couldn't put the scene back: %v", err))
+	}
+	selfTestRunning = false
+	summary := fmt.Sprintf("Self-test: %s in %v", selfTestVerdict(), selfTestTook)
+	if selfTestFailed() {
+		console.Call("error", summary)
+		return
+	}
+	console.Call("log", summary)
+}
+
+// Reports whether any of the self-test steps failed
+func selfTestFailed() bool {
+	for _, s := range selfTestSteps {
+		if s.err != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// Checks frames are still being drawn
+func selfTestFrames() error {
+	start := time.Now()
+	for time.Since(start) < time.Second {
+		time.Sleep(50 * time.Millisecond)
+		if lastFrame.After(start) {
+			return nil
+		}
+	}
+	return fmt.Errorf("no frame drawn for %v", time.Since(start).Round(time.Millisecond))
+}
+
+// Loads the self-test scene, checking everything in it was plotted
+func selfTestLoad() error {
+	s := selfTestScene
+	if err := loadScene(&s); err != nil {
+		return err
+	}
+	if len(equations) != len(s.Equations) || len(dists) != len(s.Distributions) {
+		return fmt.Errorf("expected %d equations and %d distributions, but got %d and %d", len(s.Equations),
+			len(s.Distributions), len(equations), len(dists))
+	}
+	for _, o := range s.Objects {
+		if _, ok := findObject(o.Name); !ok {
+			return fmt.Errorf("object '%s' is missing", o.Name)
+		}
+	}
+	return nil
+}
+
+// Feeds operations into the queue, and waits for them to finish.  Keyboard and API operations are held off meanwhile
+func selfTestOps(ops ...Operation) {
+	presetActive.Store(true)
+	for _, op := range ops {
+		queueOperation(op)
+	}
+	for renderActive.Load() {
+		time.Sleep(opWaitFor)
+	}
+	presetActive.Store(false)
+}
+
+// Rotates, zooms, and moves the graph, then undoes it all in reverse, checking everything ends up where it started.
+// The tick marks are left out, as they're regenerated as the zoom level changes
+func selfTestRoundTrip() error {
+	m := append(matrix(nil), worldMatrix...)
+	var before []Object
+	for _, o := range world.Objects() {
+		if o.Name != "ticks" {
+			before = append(before, o)
+		}
+	}
+	selfTestOps(
+		Operation{op: ROTATE, t: 100, f: 10, X: 30},
+		Operation{op: ROTATE, t: 100, f: 10, Y: 45},
+		scaleOp(2, 100, 10),
+		Operation{op: TRANSLATE, t: 100, f: 10, X: 1, Y: -2},
+		Operation{op: TRANSLATE, t: 100, f: 10, X: -1, Y: 2},
+		scaleOp(0.5, 100, 10),
+		Operation{op: ROTATE, t: 100, f: 10, Y: -45},
+		Operation{op: ROTATE, t: 100, f: 10, X: -30},
+	)
+	if !closeMatrix(worldMatrix, m) {
+		return fmt.Errorf("the world matrix didn't return to where it started")
+	}
+	var after []Object
+	for _, o := range world.Objects() {
+		if o.Name != "ticks" {
+			after = append(after, o)
+		}
+	}
+	if len(after) != len(before) {
+		return fmt.Errorf("expected %d objects, but got %d", len(before), len(after))
+	}
+	for i, o := range after {
+		if o.Name != before[i].Name || len(o.P) != len(before[i].P) {
+			return fmt.Errorf("object %d changed from '%s' to '%s'", i, before[i].Name, o.Name)
+		}
+		for j, p := range o.P {
+			b := before[i].P[j]
+			if math.Abs(p.X-b.X) > selfTestTolerance || math.Abs(p.Y-b.Y) > selfTestTolerance ||
+				math.Abs(p.Z-b.Z) > selfTestTolerance {
+				return fmt.Errorf("point %d of '%s' moved from (%g, %g, %g) to (%g, %g, %g)", j, o.Name, b.X, b.Y,
+					b.Z, p.X, p.Y, p.Z)
+			}
+		}
+	}
+	return nil
+}
+
+// Saves the scene and parses it again, checking nothing was lost
+func selfTestSave() error {
+	data, err := json.Marshal(saveScene())
+	if err != nil {
+		return err
+	}
+	s, err := scene.Parse(data)
+	if err != nil {
+		return err
+	}
+	if strings.Join(s.Equations, "\n") != strings.Join(selfTestScene.Equations, "\n") {
+		return fmt.Errorf("the equations came back as %q", s.Equations)
+	}
+	if strings.Join(s.Distributions, "\n") != strings.Join(selfTestScene.Distributions, "\n") {
+		return fmt.Errorf("the distributions came back as %q", s.Distributions)
+	}
+	if len(s.Objects) != len(selfTestScene.Objects) {
+		return fmt.Errorf("expected %d objects, but got %d", len(selfTestScene.Objects), len(s.Objects))
+	}
+	if !closeMatrix(s.View, worldMatrix) {
+		return fmt.Errorf("the view didn't match the world matrix")
+	}
+	return nil
+}
+
+// Renders the scene as SVG, checking it can be read back and has something in it
+func selfTestSVG() error {
+	cmds, err := svgCommands(render.SVG(world.Objects(), worldMatrix, theme, graphWidth, graphHeight, centerX, centerY,
+		step, pathLabels, showGrid))
+	if err != nil {
+		return err
+	}
+	if len(cmds) == 0 {
+		return fmt.Errorf("nothing was drawn")
+	}
+	return nil
+}
+
+// Returns "passed" or "failed", for the self-test summaries
+func selfTestVerdict() string {
+	if selfTestFailed() {
+		return "failed"
+	}
+	return "passed"
+}
+
+// Turns the graph to each of the camera views, checking it ends up facing the right way, then back to the front
+func selfTestViews() error {
+	for _, v := range append(append([]cameraView(nil), cameraViews[1:]...), cameraViews[0]) {
+		if op, ok := viewOp(v); ok {
+			selfTestOps(op)
+		}
+		if !closeMatrix(geometry.Rotation(worldMatrix), v.rotation()) {
+			return fmt.Errorf("the %s view is facing the wrong way", v.name)
+		}
+	}
+	return nil
+}
+
+// Reports whether the page was loaded with the self-test query parameter
+func wantSelfTest() bool {
+	q, err := url.ParseQuery(strings.TrimPrefix(js.Global().Get("location").Get("search").String(), "?"))
+	if err != nil {
+		return false
+	}
+	_, ok := q[selfTestParam]
+	return ok
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/justinclift/wasmGraph4/pkg/render"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	seriesDefaultPoints = 2000 // Points a series is reduced to before the graph has been laid out
+	envelopeAlpha       = 0.25 // Opacity of the shaded band around a series' mean line
+)
+
+// A series of imported data.  Every point is kept, so the series can be downsampled again differently, or for a new
+// graph size
+type dataSeries struct {
+	obj   Object  // The series' object, without its points
+	raw   []Point // Every point, in graph co-ordinates
+	mode  string  // How the series is downsampled for drawing
+	shown int     // Number of points drawn, after downsampling
+}
+
+var (
+	series      []*dataSeries
+	seriesWidth float64 // Graph width the series were last downsampled for
+)
+
+// Adds a new, empty, data series.  Once it's too big to draw every point, it's drawn as its mean with a shaded band
+// from the lowest to highest points around it, unless another way is picked
+func addSeries(o Object) {
+	s := &dataSeries{obj: o, mode: scene.DownsampleEnvelope}
+	s.obj.P = nil
+	series = append(series, s)
+	plotSeries(s)
+}
+
+// Adds points, in graph co-ordinates, to the end of a data series and redraws it
+func appendSeries(name string, pts []Point) {
+	s, ok := findSeries(name)
+	if !ok || len(pts) == 0 {
+		return
+	}
+	s.raw = append(s.raw, pts...)
+	plotSeries(s)
+	replotDerived()
+}
+
+// Switches a data series to the next way of downsampling it
+func cycleDownsample(s *dataSeries) {
+	for i, m := range scene.DownsampleModes {
+		if m == s.mode {
+			s.mode = scene.DownsampleModes[(i+1)%len(scene.DownsampleModes)]
+			break
+		}
+	}
+	plotSeries(s)
+}
+
+// Returns the maximum number of points drawn for each series.  The min/max buckets are a pixel wide
+func downsampleTarget() int {
+	if graphWidth <= 0 {
+		return seriesDefaultPoints
+	}
+	return 2 * int(graphWidth)
+}
+
+// Returns the name of the object shading the range of a data series
+func envelopeName(name string) string {
+	return name + " range"
+}
+
+// Returns the data series with the given name
+func findSeries(name string) (*dataSeries, bool) {
+	for _, s := range series {
+		if s.obj.Name == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// Replaces the object for a data series with its points downsampled.  When the series is bucketed as an envelope, the
+// shaded band between each bucket's lowest and highest points goes in its own object, drawn underneath the series
+func plotSeries(s *dataSeries) {
+	o := s.obj
+	n := downsampleTarget()
+	if s.mode != scene.DownsampleEnvelope || len(s.raw) <= n {
+		o.P = scene.Downsample(s.raw, s.mode, n)
+		s.shown = len(o.P)
+		removeObjects(envelopeName(o.Name))
+		replaceObject(o)
+		return
+	}
+	lo, hi, mean := scene.Envelope(s.raw, n)
+	o.P = mean
+	s.shown = len(o.P)
+	band := Object{Name: envelopeName(o.Name), C: render.Translucent(o.C, envelopeAlpha), DrawOrder: o.DrawOrder - 50,
+		Equation: "lowest to highest of " + o.Name, Tags: o.Tags}
+	band.P = append(band.P, hi...)
+	for i := len(lo) - 1; i >= 0; i-- {
+		band.P = append(band.P, lo[i])
+	}
+	var surf Surface
+	for i := range band.P {
+		surf = append(surf, i)
+	}
+	band.S = []Surface{surf}
+	replaceObject(band)
+	replaceObject(o)
+}
+
+// Removes data series, along with their objects
+func removeSeries(names ...string) {
+	for _, n := range names {
+		removeObjects(n, envelopeName(n))
+	}
+	var kept []*dataSeries
+	for _, s := range series {
+		remove := false
+		for _, n := range names {
+			if s.obj.Name == n {
+				remove = true
+			}
+		}
+		if !remove {
+			kept = append(kept, s)
+		}
+	}
+	series = kept
+	replotDerived()
+}
+
+// Lines for the info panel, listing the data series with how many of their points are drawn.  Clicking one switches
+// it to the next way of downsampling
+func seriesLines() (l []panelLine) {
+	for _, j := range series {
+		s := j
+		l = append(l, panelLine{text: fmt.Sprintf("%s: %d points, %d drawn (%s)", s.obj.Name, len(s.raw), s.shown,
+			s.mode), swatch: s.obj.C, action: func() { cycleDownsample(s) }})
+	}
+	return
+}
+
+// Sets how a data series is downsampled: "envelope", "minmax", "lttb", "nth", or "none"
+func setDownsample(name string, mode string) error {
+	s, ok := findSeries(name)
+	if !ok {
+		return fmt.Errorf("there's no data series called '%s'", name)
+	}
+	m, err := scene.ParseDownsample(mode)
+	if err != nil {
+		return err
+	}
+	s.mode = m
+	plotSeries(s)
+	return nil
+}
+
+// Downsamples the data series again when the graph changes size.  Called each frame
+func updateSeries() {
+	if graphWidth == seriesWidth {
+		return
+	}
+	seriesWidth = graphWidth
+	for _, s := range series {
+		plotSeries(s)
+	}
+}
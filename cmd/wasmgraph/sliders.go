@@ -0,0 +1,109 @@
+package main
+
+import (
+	"strconv"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+// A labelled range slider, floating over the top left of the graph area
+type slider struct {
+	label    string
+	value    float64
+	onChange func(v float64)
+	row      js.Value
+	input    js.Value
+	output   js.Value
+	call     js.Callback
+}
+
+var (
+	sliderBox    js.Value // Element holding the sliders, created when the first one is added
+	sliderBoxSet bool
+	sliders      []*slider
+)
+
+// Adds a slider for adjusting a value between min and max.  The onChange function is called as the slider moves
+func addSlider(label string, min float64, max float64, step float64, value float64, onChange func(v float64)) *slider {
+	if !sliderBoxSet {
+		sliderBox = doc.Call("createElement", "div")
+		sliderBoxSet = true
+		sliderBox.Get("style").Set("cssText", "position: fixed; left: 12px; top: 12px; padding: 6px 10px; "+
+			"border-radius: 4px; font: 12px sans-serif; display: none")
+		doc.Get("body").Call("appendChild", sliderBox)
+	}
+	s := &slider{label: label, value: value, onChange: onChange}
+	s.row = doc.Call("createElement", "label")
+	s.row.Get("style").Set("cssText", "display: block; margin: 2px 0")
+	text := doc.Call("createElement", "span")
+	text.Set("textContent", label+" ")
+	text.Get("style").Set("cssText", "display: inline-block; width: 70px")
+	s.input = doc.Call("createElement", "input")
+	s.input.Set("type", "range")
+	s.input.Set("min", min)
+	s.input.Set("max", max)
+	s.input.Set("step", step)
+	s.input.Set("value", value)
+	s.input.Get("style").Set("cssText", "vertical-align: middle")
+	s.output = doc.Call("createElement", "span")
+	s.output.Set("textContent", " "+scene.FormatCoord(value))
+	s.call = js.NewCallback(func(args []js.Value) {
+		markActivity()
+		v, err := strconv.ParseFloat(s.input.Get("value").String(), 64)
+		if err != nil {
+			return
+		}
+		s.value = v
+		s.output.Set("textContent", " "+scene.FormatCoord(v))
+		s.onChange(v)
+	})
+	s.input.Call("addEventListener", "input", s.call)
+	s.row.Call("appendChild", text)
+	s.row.Call("appendChild", s.input)
+	s.row.Call("appendChild", s.output)
+	sliderBox.Call("appendChild", s.row)
+	sliders = append(sliders, s)
+	styleSliders()
+	return s
+}
+
+// Removes the given sliders, leaving any others
+func removeSliders(l ...*slider) {
+	for _, s := range l {
+		for i, j := range sliders {
+			if j != s {
+				continue
+			}
+			s.input.Call("removeEventListener", "input", s.call)
+			s.call.Release()
+			sliderBox.Call("removeChild", s.row)
+			sliders = append(sliders[:i], sliders[i+1:]...)
+			break
+		}
+	}
+	styleSliders()
+}
+
+// Moves the slider to a new value, without calling its onChange function
+func (s *slider) set(v float64) {
+	s.value = v
+	s.input.Set("value", v)
+	s.output.Set("textContent", " "+scene.FormatCoord(v))
+}
+
+// Updates the slider box to match the current theme, hiding it when there are no sliders
+func styleSliders() {
+	if !sliderBoxSet {
+		return
+	}
+	st := sliderBox.Get("style")
+	st.Set("background", theme.Background)
+	st.Set("color", theme.Text)
+	st.Set("border", "1px solid "+theme.Foreground)
+	if len(sliders) == 0 {
+		st.Set("display", "none")
+	} else {
+		st.Set("display", "block")
+	}
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/justinclift/wasmGraph4/pkg/render"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	colourBarWidth  = 160.0 // Size of the colour bar for a curve coloured by its values, in pixels
+	colourBarHeight = 8.0
+	colourBarSteps  = 32 // Number of blocks of colour the bar is drawn with
+)
+
+var (
+	slopeColours bool // Whether the equations' curves are coloured by their slope
+)
+
+// Draws a colour bar for each curve coloured by its values, up from the bottom left of the graph area, showing which
+// colours stand for which values
+func drawColourBars(left float64, top float64) {
+	y := graphHeight - 16
+	ctx.Save()
+	ctx.SetFont("12px sans-serif")
+	ctx.SetTextAlign("left")
+	ctx.SetLineWidth(1)
+	ctx.SetLineDash()
+	for _, o := range world.Objects() {
+		if o.Hidden || !scene.IsCurve(o) || !scene.HasGradient(o) {
+			continue
+		}
+		lo, hi, ok := render.GradientRange(o.Values)
+		if !ok || y-colourBarHeight-20 < top {
+			continue
+		}
+		label := o.ValuesLabel
+		if label == "" {
+			label = o.Name
+		}
+		x, w := left+10, colourBarWidth/colourBarSteps
+		for i := 0; i < colourBarSteps; i++ {
+			ctx.SetFillStyle(render.Gradient(float64(i) / (colourBarSteps - 1)))
+			ctx.FillRect(x+float64(i)*w, y-colourBarHeight, w+0.5, colourBarHeight) // Overlapped, so there are no gaps
+		}
+		ctx.SetStrokeStyle(theme.Muted)
+		ctx.StrokeRect(x, y-colourBarHeight, colourBarWidth, colourBarHeight)
+		ctx.SetFillStyle(theme.Text)
+		ctx.FillText(fmt.Sprintf("%s: %s to %s", label, scene.FormatCoord(lo), scene.FormatCoord(hi)), x,
+			y-colourBarHeight-5)
+		y -= colourBarHeight + 26
+	}
+	ctx.Restore()
+}
+
+// Fills in the values of an equation's curve with its slope at each point, so it's coloured by them
+func slopeValues(e *equation, o Object) Object {
+	o.Values = make([]float64, len(o.P))
+	vars := map[string]float64{}
+	for i, p := range o.P {
+		vars["x"] = p.X
+		o.Values[i] = e.deriv.Eval(vars)
+	}
+	o.ValuesLabel = e.name + " slope"
+	return o
+}
+
+// Lines for the Operation section, saying when the curves are coloured by their slope
+func slopeColourLines() []panelLine {
+	if !slopeColours {
+		return nil
+	}
+	return []panelLine{{text: "Curves coloured by slope", colour: theme.Link, action: toggleSlopeColours}}
+}
+
+// Colours the equations' curves by their slope, from dark for the lowest to light for the highest, or puts them back
+// to their own colours
+func toggleSlopeColours() {
+	slopeColours = !slopeColours
+	for i, e := range equations {
+		plotEquation(e, i+1)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	animSpeedMin = 0.125 // Slowest the animations can be slowed down to, as a multiple of their normal speed
+	animSpeedMax = 8.0   // Fastest they can be sped up to
+)
+
+var (
+	animSpeed = 1.0 // Multiplier for the speed of every animated operation, so 2 takes half the time
+)
+
+// Sets how fast the animated operations play, as a multiple of their normal speed.  It applies from the next
+// operation, so one already under way finishes at the speed it started at
+func setAnimSpeed(s float64) error {
+	if !(s >= animSpeedMin && s <= animSpeedMax) {
+		return fmt.Errorf("the speed must be from %sx to %sx", scene.FormatNumber(animSpeedMin),
+			scene.FormatNumber(animSpeedMax))
+	}
+	animSpeed = s
+	return nil
+}
+
+// Lines for the Operation section, showing the animation speed.  Clicking puts it back to normal
+func speedLines() []panelLine {
+	text := fmt.Sprintf("Speed: %sx   [ slower, ] faster", scene.FormatNumber(animSpeed))
+	if animSpeed == 1 {
+		return []panelLine{{text: text}}
+	}
+	return []panelLine{{text: text, colour: theme.Link, action: func() { setAnimSpeed(1) }}}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+)
+
+const (
+	spriteFrames    = 24  // Default number of frames in a sprite sheet
+	spriteMaxFrames = 360 // Most frames allowed in a sprite sheet
+	spriteTileWidth = 256 // Width of each frame in the sprite sheet, in pixels
+)
+
+var (
+	spriteCall js.Callback
+)
+
+// Asks the user how many frames to put in a sprite sheet, then exports it
+func promptSpriteSheet() {
+	val := js.Global().Call("prompt", "Number of frames for the 360° sprite sheet:", strconv.Itoa(spriteFrames))
+	if val == js.Null() || val == js.Undefined() {
+		return
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(val.String()))
+	if err == nil {
+		err = saveSpriteSheet(n)
+	}
+	if err != nil {
+		js.Global().Call("alert", fmt.Sprintf("Couldn't create the sprite sheet: %v", err))
+	}
+}
+
+// Renders the graph area at evenly spaced rotations around the Y axis, and offers the frames for download tiled into a
+// single PNG sprite sheet.  The frames go left to right, then top to bottom
+func saveSpriteSheet(frames int) error {
+	if frames < 2 || frames > spriteMaxFrames {
+		return fmt.Errorf("the number of frames must be between 2 and %d", spriteMaxFrames)
+	}
+	if renderActive.Load() || presetActive.Load() {
+		return fmt.Errorf("an operation is still in progress")
+	}
+	if graphWidth <= 0 || graphHeight <= 0 {
+		return fmt.Errorf("the graph area has no size")
+	}
+
+	// Work out the sheet layout, keeping it roughly square
+	tileW := float64(spriteTileWidth)
+	tileH := math.Round(tileW * graphHeight / graphWidth)
+	cols := int(math.Ceil(math.Sqrt(float64(frames))))
+	rows := (frames + cols - 1) / cols
+	sheet := doc.Call("createElement", "canvas")
+	sheet.Set("width", float64(cols)*tileW)
+	sheet.Set("height", float64(rows)*tileH)
+
+	// Draw each rotation straight onto its place on the sheet, scaled down to the tile size and clipped to it.  The
+	// graph is drawn through the sheet's own context for the while, so it works the same whether or not the render
+	// worker has the page's canvas, and the next frame render puts things back as they were
+	savedView, savedCtx := worldView, ctx
+	defer func() {
+		setWorldView(savedView)
+		ctx = savedCtx
+	}()
+	ctx = &canvasRenderer{ctx: sheet.Call("getContext", "2d")}
+	left, top := 5.0, 5.0 // The border plus gap around the graph area, as used by the frame renderer
+	scale := tileW / graphWidth
+	for i := 0; i < frames; i++ {
+		setWorldView(savedView.Rotate(geometry.AxisQuaternion(0, 1, 0, 360*float64(i)/float64(frames))))
+		ctx.Save()
+		ctx.SetTransform(scale, 0, 0, scale, float64(i%cols)*tileW, float64(i/cols)*tileH)
+		ctx.BeginPath()
+		ctx.Rect(0, 0, graphWidth, graphHeight)
+		ctx.Clip()
+		ctx.SetFillStyle(theme.Background)
+		ctx.FillRect(0, 0, graphWidth, graphHeight)
+		drawGraph(left, top)
+		ctx.Restore()
+	}
+
+	// The PNG is encoded asynchronously by the browser
+	spriteCall.Release()
+	spriteCall = js.NewCallback(func(args []js.Value) {
+		if args[0] == js.Null() {
+			logError("The browser couldn't encode the sprite sheet")
+			return
+		}
+		downloadBlob("wasmGraph-sprites.png", args[0])
+		logInfo("Sprite sheet saved, %v bytes", args[0].Get("size").Int())
+	})
+	sheet.Call("toBlob", spriteCall, "image/png")
+	logInfo("Sprite sheet of %d frames, %d x %d tiles of %vx%v", frames, cols, rows, tileW, tileH)
+	return nil
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+// How the objects with a tag are shown
+type tagStyle struct {
+	hidden bool
+	colour string    // Colour to draw them in instead of their own, if set
+	width  float64   // Width to draw their lines, if set
+	dash   []float64 // Dash pattern for their lines, if set
+}
+
+var (
+	tagStyles = map[string]tagStyle{} // Styles set for tags, by tag
+)
+
+// Leaves out the objects with hidden tags, and recolours and restyles the lines of those with styled ones.  Where an
+// object has several styled tags, the first of its tags with each setting is used
+func applyTagStyles(objs []Object) []Object {
+	if len(tagStyles) == 0 {
+		return objs
+	}
+	kept := objs[:0]
+	for _, o := range objs {
+		var style tagStyle
+		for _, t := range o.Tags {
+			s := tagStyles[t]
+			style.hidden = style.hidden || s.hidden
+			if style.colour == "" {
+				style.colour = s.colour
+			}
+			if style.width == 0 {
+				style.width = s.width
+			}
+			if style.dash == nil {
+				style.dash = s.dash
+			}
+		}
+		if style.hidden {
+			continue
+		}
+		if style.colour != "" {
+			o.C = style.colour
+		}
+		if style.width != 0 {
+			o.LineWidth = style.width
+		}
+		if style.dash != nil {
+			o.DashPattern = style.dash
+		}
+		kept = append(kept, o)
+	}
+	return kept
+}
+
+// Returns the tags used in the scene, including those of hidden objects, with the number of objects having each
+func sceneTags() (tags []string, counts map[string]int) {
+	counts = map[string]int{}
+	sceneRoot.Walk(func(n *scene.Node) {
+		for _, t := range n.Object.Tags {
+			if counts[t] == 0 {
+				tags = append(tags, t)
+			}
+			counts[t]++
+		}
+	})
+	sort.Strings(tags)
+	return
+}
+
+// Sets how the objects with a tag are shown, then redraws the world space.  The empty style puts them back to normal
+func setTagStyle(tag string, s tagStyle) {
+	if !s.hidden && s.colour == "" && s.width == 0 && s.dash == nil {
+		delete(tagStyles, tag)
+	} else {
+		tagStyles[tag] = s
+	}
+	rebuildWorld()
+	logActivity("scene", "Tag %s: hidden %v, colour '%s', width %g, dash %v", tag, s.hidden, s.colour, s.width, s.dash)
+}
+
+// Lines for the Tags section of the info panel, listing the tags in the scene.  Clicking one hides or shows its
+// objects
+func tagLines() (l []panelLine) {
+	tags, counts := sceneTags()
+	for _, t := range tags {
+		t, s := t, tagStyles[t]
+		line := panelLine{text: fmt.Sprintf("%s (%d)", t, counts[t]), swatch: s.colour, colour: theme.Link,
+			action: func() {
+				s.hidden = !s.hidden
+				setTagStyle(t, s)
+			}}
+		if s.hidden {
+			line.text += "  hidden"
+			line.colour = theme.Muted
+		}
+		l = append(l, line)
+	}
+	if len(l) == 0 {
+		l = append(l, panelLine{text: "No tagged objects", colour: theme.Muted})
+	}
+	return
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	hoverRadius   = 15  // How close the mouse needs to be to a curve for its tangent to be shown, in pixels
+	tangentLength = 1.5 // Length of the tangent line either side of the point, in graph units
+)
+
+var (
+	mouseX, mouseY float64 // Last known mouse position, in CSS pixels
+)
+
+// Draws the tangent line of the equation curve nearest the mouse, along with a readout of the point and slope
+func drawTangent(left float64, top float64) {
+	if inPanel(mouseX, mouseY) || dragMarker != nil {
+		return
+	}
+	e, d, x, ok := hoverCurve(mouseX, mouseY)
+	if !ok {
+		return
+	}
+	n := e.expr
+	if d == 1 {
+		n = e.deriv
+	}
+	vars := map[string]float64{"x": x}
+	y := n.Eval(vars)
+	slope := n.Deriv("x").Eval(vars)
+	if !scene.Finite(y) || !scene.Finite(slope) {
+		return
+	}
+	ctx.Save()
+	ctx.BeginPath()
+	ctx.Rect(left, top, graphWidth-left, graphHeight-top)
+	ctx.Clip()
+	px, py := drawTangentLines(x, y, slope, false, theme.Foreground)
+	ctx.SetFillStyle(theme.Foreground)
+	ctx.BeginPath()
+	ctx.Ellipse(px, py, 3, 3, 0, 0, 2*math.Pi)
+	ctx.Fill()
+
+	// Readout beside the point
+	ctx.SetFont("12px sans-serif")
+	ctx.SetTextAlign("left")
+	ctx.SetFillStyle(theme.Text)
+	ctx.FillText(fmt.Sprintf("%s  slope %s", scene.FormatPoint(x, y), scene.FormatCoord(slope)), px+8, py-8)
+	ctx.Restore()
+}
+
+// Draws the tangent line through a point on the graphs' XY plane with the given slope, and optionally the normal line
+// too.  The lines are worked out in graph units, then projected like everything else.  Returns the screen
+// co-ordinates of the point
+func drawTangentLines(x float64, y float64, slope float64, normal bool, colour string) (float64, float64) {
+	dx := tangentLength / math.Sqrt(1+slope*slope)
+	dy := slope * dx
+	px, py := geometry.Project(worldMatrix, centerX, centerY, step, x, y, 0)
+	ctx.SetLineWidth(1)
+	ctx.SetLineDash(6, 4)
+	ctx.SetStrokeStyle(colour)
+	lines := [][4]float64{{x - dx, y - dy, x + dx, y + dy}}
+	if normal {
+		lines = append(lines, [4]float64{x + dy, y - dx, x - dy, y + dx})
+	}
+	for _, l := range lines {
+		x1, y1 := geometry.Project(worldMatrix, centerX, centerY, step, l[0], l[1], 0)
+		x2, y2 := geometry.Project(worldMatrix, centerX, centerY, step, l[2], l[3], 0)
+		ctx.BeginPath()
+		ctx.MoveTo(x1, y1)
+		ctx.LineTo(x2, y2)
+		ctx.Stroke()
+	}
+	ctx.SetLineDash()
+	return px, py
+}
+
+// Finds the equation curve (or derivative curve) nearest the given screen co-ordinates, if one is close enough.
+// Returns the equation, whether it was the derivative curve (1) or not (0), and the graph X value at the nearest point
+func hoverCurve(sx float64, sy float64) (e *equation, d int, x float64, ok bool) {
+	inv, invOk := geometry.Invert(worldMatrix)
+	if !invOk {
+		return
+	}
+	best := float64(hoverRadius)
+	for _, o := range world.Objects() {
+		oe, od, found := equationFor(o.Name)
+		if !found || oe.parametric || o.Hidden {
+			continue
+		}
+		if cx, dist, near := nearestOnCurve(o, sx, sy, inv); near && dist < best {
+			best = dist
+			e, d, x, ok = oe, od, cx, true
+		}
+	}
+	return
+}
+
+// Finds the point on a curve nearest the given screen co-ordinates, by projecting onto each line segment of the
+// curve.  Returns the graph X value of that point, and its distance from the screen co-ordinates in pixels
+func nearestOnCurve(o Object, sx float64, sy float64, inv matrix) (x float64, dist float64, ok bool) {
+	dist = math.Inf(1)
+	for i := 1; i < len(o.P); i++ {
+		p1, p2 := o.P[i-1], o.P[i]
+		ax, ay := centerX+(p1.X*step), centerY+((p1.Y*step)*-1)
+		bx, by := centerX+(p2.X*step), centerY+((p2.Y*step)*-1)
+
+		// Nearest point on the segment between the two points
+		vx, vy := bx-ax, by-ay
+		t := 0.0
+		if l := vx*vx + vy*vy; l > 0 {
+			t = math.Max(0, math.Min(1, ((sx-ax)*vx+(sy-ay)*vy)/l))
+		}
+		if d := math.Hypot(ax+t*vx-sx, ay+t*vy-sy); d < dist {
+			g1, g2 := scene.Transform(inv, p1), scene.Transform(inv, p2)
+			x, dist, ok = g1.X+t*(g2.X-g1.X), d, true
+		}
+	}
+	return
+}
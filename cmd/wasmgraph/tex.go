@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+var (
+	texElement js.Value = js.Undefined() // Element on the page the equations are typeset in, if any
+	texShown   string                    // The TeX last put in the element, so it's only redone when it changes
+)
+
+// Returns the equations and their derivatives as TeX, along with the same typeset as plain text for pages without
+// KaTeX.  Parametric curves are given by their co-ordinates
+func equationTeX() (tex []string, text []string) {
+	for _, e := range equations {
+		if e.parametric {
+			c := e.curve
+			tex = append(tex, fmt.Sprintf(`%s:\; x = %s,\; y = %s,\; z = %s`, e.name, expr.TeX(c.X), expr.TeX(c.Y),
+				expr.TeX(c.Z)))
+			text = append(text, e.name+": "+e.src)
+			continue
+		}
+		n := e.expr
+		if e.family != nil {
+			n = e.family
+		}
+		tex = append(tex, fmt.Sprintf("%s(x) = %s", e.name, expr.TeX(n)), fmt.Sprintf("%s'(x) = %s", e.name,
+			expr.TeX(e.deriv)))
+		text = append(text, e.name+": "+e.formatted(), e.name+"': "+scene.FormatEquation(e.deriv))
+	}
+	return
+}
+
+// Sets the element the equations are typeset in, replacing its contents.  Undefined stops typesetting them
+func setTeXElement(el js.Value) error {
+	if el != js.Undefined() && (el.Type() != js.TypeObject || el.Get("nodeType") == js.Undefined()) {
+		return fmt.Errorf("expected an HTML element")
+	}
+	texElement, texShown = el, ""
+	updateTeXElement()
+	return nil
+}
+
+// Typesets the equations in the page's element, if there is one and they've changed.  Each goes on its own line,
+// typeset by KaTeX when the page has loaded it, and as plain text otherwise
+func updateTeXElement() {
+	if texElement == js.Undefined() {
+		return
+	}
+	tex, text := equationTeX()
+	all := strings.Join(tex, "\n")
+	if all == texShown {
+		return
+	}
+	texShown = all
+	texElement.Set("innerHTML", "")
+	katex := js.Global().Get("katex")
+	opts := js.ValueOf(map[string]interface{}{"throwOnError": false})
+	for i, t := range tex {
+		line := doc.Call("createElement", "div")
+		line.Get("classList").Call("add", "wasmGraph-equation")
+		if katex != js.Undefined() {
+			katex.Call("render", t, line, opts)
+		} else {
+			line.Set("textContent", text[i])
+		}
+		texElement.Call("appendChild", line)
+	}
+}
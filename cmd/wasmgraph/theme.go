@@ -0,0 +1,22 @@
+package main
+
+import (
+	"github.com/justinclift/wasmGraph4/pkg/render"
+)
+
+var (
+	// The theme currently in use
+	theme = render.LightTheme
+)
+
+// Switches to the next theme
+func toggleTheme() {
+	defer styleSliders()
+	for i, t := range render.Themes {
+		if t.Name == theme.Name {
+			theme = render.Themes[(i+1)%len(render.Themes)]
+			return
+		}
+	}
+	theme = render.Themes[0]
+}
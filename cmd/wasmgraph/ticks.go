@@ -0,0 +1,77 @@
+package main
+
+import (
+	"math"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	zAxisSteps = 8 // Steps the Z arm fades through as it turns toward the viewer, so it's not redone every frame
+)
+
+var (
+	tickZoom, tickStep float64 // The zoom level and pixel step the current tick marks were generated for
+
+	// Length of the arms of the X, Y, and Z axes, which grow to reach anything plotted further out than usual
+	axisX, axisY, axisZ = float64(scene.AxisLength), float64(scene.AxisLength), float64(scene.AxisLength)
+	extentDirty         bool // Set when the scene has changed, so the axes might need to be a different length
+
+	zAxisOpacity = -1.0 // How opaque the Z arm was generated, in steps of 1/zAxisSteps.  -1 until it's generated
+)
+
+// Returns true for the objects making up the axes: the X/Y bars, their tick marks, the Z arm, and the grid planes
+func isAxesPart(o Object) bool {
+	switch o.Name {
+	case "axes", "ticks", "zaxis", "gridxy", "gridxz", "gridyz":
+		return true
+	}
+	return false
+}
+
+// Returns the tick interval suiting the current zoom level
+func tickInterval() float64 {
+	return scene.TickInterval(step * currentZoom())
+}
+
+// Regenerates the grid planes to match the axis lengths and tick marks.  They're kept up to date even when hidden, so
+// they're ready to show
+func updateGridPlanes() {
+	for _, p := range scene.GridPlanes {
+		a, b := scene.GridPlaneLengths(p, axisX, axisY, axisZ)
+		if g, err := scene.GridPlane(p, a, b, tickInterval()); err == nil {
+			replaceObject(g)
+		}
+	}
+}
+
+// Regenerates the axes if the scene now reaches further than them, or no longer needs them as long, and the tick marks
+// if that or the zoom level or screen size have changed since they were last generated.  The Z arm is also redone when
+// the graph turns far enough to fade it in or out
+func updateAxisTicks() {
+	if extentDirty {
+		extentDirty = false
+		x, y, z := float64(scene.AxisLength), float64(scene.AxisLength), float64(scene.AxisLength)
+		if b, ok := sceneRoot.Extent(); ok {
+			x, y, z = scene.AxisLengths(b)
+		}
+		if x != axisX || y != axisY || z != axisZ {
+			axisX, axisY, axisZ = x, y, z
+			tickZoom = 0
+			replaceObject(scene.NewAxes(axisX, axisY))
+		}
+	}
+	opacity := math.Round(scene.ZAxisOpacity(worldMatrix)*zAxisSteps) / zAxisSteps
+	z := currentZoom()
+	if z == tickZoom && step == tickStep && opacity == zAxisOpacity {
+		return
+	}
+	if z != tickZoom || step != tickStep {
+		tickZoom, tickStep = z, step
+		replaceObject(scene.AxisTicks(tickInterval(), step*currentZoom(), axisX, axisY))
+		updateGridPlanes()
+	}
+	zAxisOpacity = opacity
+	replaceObject(scene.NewZAxis(axisZ, tickInterval(), step*currentZoom(), zAxisOpacity))
+	extentDirty = false // Replacing the parts of the axes doesn't change how long they need to be
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	keyframeMs     = 500 // Default length of a keyframe operation loaded from JSON, in milliseconds
+	keyframePartMs = 20  // Length of each part of a keyframe operation loaded from JSON
+)
+
+// An operation in a timeline, starting a number of milliseconds after the timeline does
+type keyframe struct {
+	at int32
+	op Operation
+}
+
+// A keyframe animation, which can be played, paused, looped, and scrubbed to any point.  The graph's position at any
+// time is worked out afresh from where it was when the timeline was loaded, so scrubbing backwards works too
+type timeline struct {
+	keys    []keyframe // In order of starting time
+	length  float64    // Milliseconds until the last operation finishes
+	start   matrix     // The world matrix when the timeline was loaded
+	pos     float64    // Current position, in milliseconds
+	playing bool
+	loop    bool
+	last    time.Time // When the position was last advanced
+	slider  *slider   // Scrubs through the timeline
+}
+
+// A timeline, as loaded from JSON.  eg:
+//
+//	{"Loop": true, "Keyframes": [
+//		{"At": 0, "Op": "rotate", "Ms": 1000, "Y": 90},
+//		{"At": 1000, "Op": "scale", "X": 2, "Y": 2, "Z": 2}
+//	]}
+type timelineFile struct {
+	Loop      bool
+	Keyframes []struct {
+		At      int32  // Milliseconds from the start of the timeline
+		Op      string // "rotate", "scale", "translate", or "rotateaxis"
+		Ms      int32  // How long the operation takes, in milliseconds
+		X, Y, Z float64
+	}
+}
+
+var (
+	tl *timeline // The loaded timeline, if any
+
+	// Names of the operations in timeline JSON
+	keyframeOps = map[string]OperationType{
+		"rotate":     ROTATE,
+		"scale":      SCALE,
+		"translate":  TRANSLATE,
+		"rotateaxis": ROTATEAXIS,
+	}
+)
+
+// The built in demo timeline.  It tilts the graph over, zooms in, spins it around, then puts it back where it started,
+// so it loops smoothly
+func demoTimeline() []keyframe {
+	return []keyframe{
+		{at: 0, op: Operation{op: ROTATE, t: 1000, f: 30, X: -30}},
+		{at: 0, op: Operation{op: ROTATE, t: 1000, f: 30, Y: 45}},
+		{at: 1200, op: scaleOp(1.5, 800, 24)},
+		{at: 2200, op: Operation{op: ROTATE, t: 4000, f: 120, Y: 360}},
+		{at: 6400, op: scaleOp(1/1.5, 800, 24)},
+		{at: 7400, op: Operation{op: ROTATE, t: 1000, f: 30, Y: -45}},
+		{at: 7400, op: Operation{op: ROTATE, t: 1000, f: 30, X: 30}},
+	}
+}
+
+// Loads a timeline, replacing any earlier one.  It starts paused, from the graph's current position
+func loadTimeline(keys []keyframe, loop bool) {
+	stopTimeline()
+	t := &timeline{keys: append([]keyframe(nil), keys...), start: append(matrix(nil), worldMatrix...), loop: loop}
+	sort.SliceStable(t.keys, func(i, j int) bool { return t.keys[i].at < t.keys[j].at })
+	for _, k := range t.keys {
+		t.length = math.Max(t.length, float64(k.at+k.op.t))
+	}
+	t.slider = addSlider("Time", 0, t.length, 10, 0, func(v float64) {
+		pauseTimeline()
+		seekTimeline(v)
+	})
+	tl = t
+}
+
+// Returns the world matrix at the given position in the timeline.  Each operation started by then is applied the way
+// stepOperations would
+func (t *timeline) matrixAt(pos float64) matrix {
+	m := t.start
+	for _, k := range t.keys {
+		if pos <= float64(k.at) {
+			break
+		}
+		parts := float64(k.op.f) * math.Min((pos-float64(k.at))/float64(k.op.t), 1)
+		m = geometry.Multiply(operationMatrix(k.op, parts), m)
+	}
+	return m
+}
+
+// Parses a timeline saved as JSON
+func parseTimeline(data []byte) ([]keyframe, bool, error) {
+	var f timelineFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, false, err
+	}
+	if len(f.Keyframes) == 0 {
+		return nil, false, fmt.Errorf("the timeline has no keyframes")
+	}
+	var keys []keyframe
+	for i, k := range f.Keyframes {
+		op, ok := keyframeOps[strings.ToLower(k.Op)]
+		if !ok {
+			return nil, false, fmt.Errorf("keyframe %d: unknown operation '%s'", i+1, k.Op)
+		}
+		if k.At < 0 || k.Ms < 0 {
+			return nil, false, fmt.Errorf("keyframe %d: times can't be negative", i+1)
+		}
+		if !scene.Finite(k.X) || !scene.Finite(k.Y) || !scene.Finite(k.Z) {
+			return nil, false, fmt.Errorf("keyframe %d: X, Y, and Z must be numbers", i+1)
+		}
+		if k.Ms == 0 {
+			k.Ms = keyframeMs
+		}
+		o := Operation{op: op, t: k.Ms, f: int32(math.Max(1, float64(k.Ms/keyframePartMs))), X: k.X, Y: k.Y, Z: k.Z}
+		if op == SCALE {
+			// Scales in timelines are given as the overall zoom, so work out the parts which compound to it
+			if k.X <= 0 || k.Y <= 0 || k.Z <= 0 {
+				return nil, false, fmt.Errorf("keyframe %d: scale factors must be more than zero", i+1)
+			}
+			n := float64(o.f)
+			o.X, o.Y, o.Z = n*(math.Pow(k.X, 1/n)-1)+1, n*(math.Pow(k.Y, 1/n)-1)+1, n*(math.Pow(k.Z, 1/n)-1)+1
+		}
+		keys = append(keys, keyframe{at: k.At, op: o})
+	}
+	return keys, f.Loop, nil
+}
+
+// Pauses the timeline where it is
+func pauseTimeline() {
+	if tl != nil {
+		tl.playing = false
+	}
+}
+
+// Plays the timeline from its current position, or from the start if it's at the end
+func playTimeline() {
+	if tl == nil {
+		return
+	}
+	if tl.pos >= tl.length {
+		seekTimeline(0)
+	}
+	tl.playing = true
+	tl.last = time.Now()
+}
+
+// Moves the graph to the given position in the timeline
+func seekTimeline(ms float64) {
+	if tl == nil {
+		return
+	}
+	tl.pos = math.Max(0, math.Min(ms, tl.length))
+	tl.slider.set(tl.pos)
+	target := tl.matrixAt(tl.pos)
+	setWorldMatrix(target)
+}
+
+// Advances the timeline while it's playing, looping back to the start at the end if it's set to.  Called each frame.
+// Operations from the queue take priority, so it waits for them to finish
+func stepTimeline() {
+	if tl == nil || !tl.playing || renderActive.Load() {
+		return
+	}
+	now := time.Now()
+	pos := tl.pos + float64(now.Sub(tl.last))/float64(time.Millisecond)
+	tl.last = now
+	if pos >= tl.length {
+		if tl.loop && tl.length > 0 {
+			pos = math.Mod(pos, tl.length)
+		} else {
+			pos = tl.length
+			tl.playing = false
+		}
+	}
+	seekTimeline(pos)
+}
+
+// Unloads the timeline, leaving the graph where it is
+func stopTimeline() {
+	if tl == nil {
+		return
+	}
+	removeSliders(tl.slider)
+	tl = nil
+}
+
+// Lines for the info panel, showing the timeline's position.  Clicking the first line plays or pauses it
+func timelineLines() (l []panelLine) {
+	if tl == nil {
+		return
+	}
+	state := "paused"
+	if tl.playing {
+		state = "playing"
+	}
+	if tl.loop {
+		state += ", looping"
+	}
+	l = append(l, panelLine{text: fmt.Sprintf("Timeline: %.1fs of %.1fs (%s)", tl.pos/1000, tl.length/1000, state),
+		action: toggleTimeline})
+	l = append(l, panelLine{text: "Remove timeline   ✕", colour: theme.Link, indent: 15, action: stopTimeline})
+	return
+}
+
+// Plays or pauses the timeline, loading the demo one if there isn't one yet
+func toggleTimeline() {
+	switch {
+	case tl == nil:
+		loadTimeline(demoTimeline(), true)
+		playTimeline()
+	case tl.playing:
+		pauseTimeline()
+	default:
+		playTimeline()
+	}
+}
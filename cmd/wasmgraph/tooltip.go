@@ -0,0 +1,48 @@
+package main
+
+import (
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	tooltipOffset  = 14 // Distance of the tooltip from the mouse pointer, in pixels
+	tooltipPadding = 4
+)
+
+// Draws a small tooltip beside the mouse pointer, showing the graph co-ordinates underneath it
+func drawTooltip(left float64, top float64) {
+	if dragging || inPanel(mouseX, mouseY) || mouseX < left || mouseY < top || mouseX > graphWidth ||
+		mouseY > graphHeight {
+		return
+	}
+	x, y, ok := geometry.Unproject(worldMatrix, centerX, centerY, step, mouseX, mouseY)
+	if !ok {
+		return
+	}
+	text := scene.FormatPoint(x, y)
+	ctx.Save()
+	ctx.SetFont("11px sans-serif")
+	ctx.SetTextAlign("left")
+	ctx.SetTextBaseline("top")
+	w := ctx.MeasureText(text) + tooltipPadding*2
+	h := 11.0 + tooltipPadding*2
+
+	// Keep the tooltip inside the graph area, flipping it to the other side of the pointer near the edges
+	tx, ty := mouseX+tooltipOffset, mouseY+tooltipOffset
+	if tx+w > graphWidth {
+		tx = mouseX - tooltipOffset - w
+	}
+	if ty+h > graphHeight {
+		ty = mouseY - tooltipOffset - h
+	}
+	ctx.SetFillStyle(theme.Background)
+	ctx.SetStrokeStyle(theme.Foreground)
+	ctx.SetLineWidth(1)
+	ctx.SetLineDash()
+	ctx.FillRect(tx, ty, w, h)
+	ctx.StrokeRect(tx, ty, w, h)
+	ctx.SetFillStyle(theme.Text)
+	ctx.FillText(text, tx+tooltipPadding, ty+tooltipPadding)
+	ctx.Restore()
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"math"
+	"syscall/js"
+)
+
+var (
+	pixelRatio  = 1.0 // Canvas backing store pixels per CSS pixel
+	pinchDist   float64
+	pinchScale  = 1.0 // Pinch zoom gathered while an operation was still in progress
+	tsCall      js.Callback
+	tmCall      js.Callback
+	teCall      js.Callback
+	vvCall      js.Callback
+	pixelsDirty bool // Set when the effective resolution has changed, and the canvas needs resizing
+)
+
+// Works out how many canvas pixels are needed per CSS pixel.  On mobile the browsers' own pinch-zoom magnifies the
+// page, so the canvas is rendered at that effective resolution rather than being blurrily upscaled.  The render
+// resolution setting then scales that up or down
+func effectivePixelRatio() float64 {
+	r := 1.0
+	if dpr := js.Global().Get("devicePixelRatio"); dpr.Type() == js.TypeNumber {
+		r = dpr.Float()
+	}
+	if vv := js.Global().Get("visualViewport"); vv != js.Undefined() && vv != js.Null() {
+		r *= vv.Get("scale").Float()
+	}
+
+	// Don't let the backing store become unreasonably large
+	return math.Max(1, math.Min(r, 4)) * renderScale / 100
+}
+
+// Sets up the touch and visual viewport handlers
+func initViewport() {
+	pixelRatio = effectivePixelRatio()
+
+	// Touch handlers for the app's own pinch zoom.  The canvas has "touch-action: none" set in its CSS, so touches on
+	// it aren't also treated by the browser as page zooming or scrolling
+	tsCall = inputCallback(touchStartHandler)
+	tmCall = inputCallback(touchMoveHandler)
+	teCall = inputCallback(touchEndHandler)
+	canvasEl.Call("addEventListener", "touchstart", tsCall)
+	canvasEl.Call("addEventListener", "touchmove", tmCall)
+	canvasEl.Call("addEventListener", "touchend", teCall)
+	canvasEl.Call("addEventListener", "touchcancel", teCall)
+
+	// The browser can still be pinch-zoomed outside the canvas, so watch for that changing the effective resolution
+	vvCall = js.NewCallback(func(args []js.Value) {
+		pixelsDirty = true
+	})
+	if vv := js.Global().Get("visualViewport"); vv != js.Undefined() && vv != js.Null() {
+		vv.Call("addEventListener", "resize", vvCall)
+	}
+}
+
+// Releases the touch and visual viewport handlers
+func releaseViewport() {
+	tsCall.Release()
+	tmCall.Release()
+	teCall.Release()
+	vvCall.Release()
+}
+
+// Returns the distance between the first two touch points of a touch event
+func touchDistance(event js.Value) float64 {
+	touches := event.Get("touches")
+	t1, t2 := touches.Index(0), touches.Index(1)
+	dx := t1.Get("clientX").Float() - t2.Get("clientX").Float()
+	dy := t1.Get("clientY").Float() - t2.Get("clientY").Float()
+	return math.Hypot(dx, dy)
+}
+
+// Ends a pinch gesture
+func touchEndHandler(args []js.Value) {
+	if args[0].Get("touches").Length() < 2 {
+		pinchDist = 0
+	}
+}
+
+// Converts two finger pinches on the canvas into scale operations
+func touchMoveHandler(args []js.Value) {
+	markActivity()
+	event := args[0]
+	if event.Get("touches").Length() < 2 || pinchDist == 0 {
+		return
+	}
+	d := touchDistance(event)
+	if d == 0 {
+		return
+	}
+	pinchScale *= d / pinchDist
+	pinchDist = d
+	logTrace("Pinch scale: %v", pinchScale)
+
+	// Apply the pinch in a single step, so the zoom tracks the fingers closely
+	if !renderActive.Load() {
+		s := pinchScale
+		pinchScale = 1
+		queueOperation(Operation{op: SCALE, t: 16, f: 1, X: s, Y: s, Z: s})
+	}
+}
+
+// Starts tracking a pinch gesture when a second finger touches the canvas
+func touchStartHandler(args []js.Value) {
+	markActivity()
+	event := args[0]
+	if event.Get("touches").Length() == 2 {
+		pinchDist = touchDistance(event)
+		pinchScale = 1
+	}
+}
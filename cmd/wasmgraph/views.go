@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+)
+
+// A standard camera view, given as the rotations taking the graph there from the front view
+type cameraView struct {
+	name string
+	code string  // Keyboard shortcut, as the key code pressed along with shift
+	x, y float64 // Degrees around the X and Y axes, with the Y rotation done first
+}
+
+var (
+	cameraViews = []cameraView{
+		{name: "front", code: "Digit1"},
+		{name: "top", code: "Digit2", x: 90},
+		{name: "side", code: "Digit3", y: -90},
+		{name: "isometric", code: "Digit4", x: 35.264, y: -45}, // Tilted so the X, Y, and Z axes look equally long
+	}
+)
+
+// Returns the operation turning the graph from its current orientation to the given view, keeping its zoom level.
+// Returns false when it's already there
+func viewOp(v cameraView) (Operation, bool) {
+	turn := worldView.Turn.Conjugate().Then(v.quaternion())
+	x, y, z := turn.AxisAngle()
+	if x*x+y*y+z*z < 1e-6 {
+		return Operation{}, false
+	}
+	return Operation{op: ROTATEAXIS, t: 400, f: 24, X: x, Y: y, Z: z}, true
+}
+
+// Returns the rotation of the view, as a quaternion
+func (v cameraView) quaternion() geometry.Quaternion {
+	return geometry.AxisQuaternion(0, 1, 0, v.y).Then(geometry.AxisQuaternion(1, 0, 0, v.x))
+}
+
+// Returns the rotation matrix of the view
+func (v cameraView) rotation() matrix {
+	return v.quaternion().Matrix()
+}
+
+// Turns the graph to a standard view, once any operations in progress have finished
+func turnToView(v cameraView) {
+	whenIdle(func() {
+		if op, ok := viewOp(v); ok {
+			queueOperation(op)
+		}
+	})
+}
+
+// Turns the graph to one of the standard views when shift+1 to shift+4 are pressed.  The key codes are used rather
+// than the keys, as the symbols on the number keys vary between keyboard layouts.  Returns false for other keys
+func viewKey(code string) bool {
+	for _, v := range cameraViews {
+		if v.code == code {
+			turnToView(v)
+			return true
+		}
+	}
+	return false
+}
+
+// Turns the graph to the named standard view
+func showView(name string) error {
+	for _, v := range cameraViews {
+		if strings.EqualFold(v.name, name) {
+			if renderActive.Load() || presetActive.Load() {
+				return fmt.Errorf("an operation is still in progress")
+			}
+			if op, ok := viewOp(v); ok {
+				queueOperation(op)
+			}
+			return nil
+		}
+	}
+	var names []string
+	for _, v := range cameraViews {
+		names = append(names, v.name)
+	}
+	return fmt.Errorf("unknown view '%s' (the views are %s)", name, strings.Join(names, ", "))
+}
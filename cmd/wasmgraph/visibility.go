@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+var (
+	showGrid = true // Whether the background grid is drawn
+)
+
+// Shows or hides an object, or the grid when the name is "grid".  The grid planes are objects, so are shown and hidden
+// like the rest.  Hidden objects stay in the scene, and in the legend, so they can be shown again
+func setVisible(name string, visible bool) error {
+	if name == "grid" {
+		showGrid = visible
+		return nil
+	}
+	n := sceneRoot.Find(name)
+	if n == nil || name == "" {
+		return fmt.Errorf("there's no object called '%s'", name)
+	}
+	n.Object.Hidden = !visible
+	rebuildWorld()
+	logActivity("scene", "%s %s", visibilityText(visible), name)
+	return nil
+}
+
+// Hides all of the objects with a tag, or shows them again if they're all hidden already
+func toggleTagVisible(tag string) {
+	var nodes []*scene.Node
+	hidden := true
+	sceneRoot.Walk(func(n *scene.Node) {
+		if scene.HasTag(n.Object, tag) {
+			nodes = append(nodes, n)
+			hidden = hidden && n.Object.Hidden
+		}
+	})
+	for _, n := range nodes {
+		n.Object.Hidden = !hidden
+	}
+	rebuildWorld()
+	logActivity("scene", "%s the %s", visibilityText(hidden), tag)
+}
+
+// Returns "Showed" or "Hid", for the activity log
+func visibilityText(visible bool) string {
+	if visible {
+		return "Showed"
+	}
+	return "Hid"
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	rtdebug "runtime/debug"
+	"syscall/js"
+	"time"
+)
+
+const (
+	watchdogInterval = time.Second     // How often the watchdog checks the frame renderer
+	watchdogTimeout  = 3 * time.Second // How long without a frame before the renderer is considered dead
+)
+
+var (
+	lastFrame        = time.Now() // When the frame renderer last ran
+	frameGen         int          // Generation of the current frame callback.  Older ones stop rescheduling themselves
+	watchdogRestarts int          // Number of times the watchdog has restarted the frame renderer
+)
+
+// Returns a new frame render callback, superseding any earlier one
+func newFrameCallback() js.Callback {
+	frameGen++
+	gen := frameGen
+	return js.NewCallback(func(args []js.Value) {
+		if gen != frameGen {
+			return // Replaced by the watchdog, so let this one die off
+		}
+		renderFrame(args)
+	})
+}
+
+// Recovers from a panic while rendering a frame, logging it and keeping the frame renderer going
+func recoverFrame() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	logError("Frame renderer panic: %v\n%s", r, rtdebug.Stack())
+	ctx.Restore() // In case the panic happened between save() and restore()
+	scheduleFrame()
+}
+
+// Watches for the frame renderer no longer being called, such as when its callback has been released, and re-registers
+// it when that happens
+func watchdog() {
+	for range time.Tick(watchdogInterval) {
+		// Browsers don't run animation frames for hidden pages, so there's nothing to check then
+		if doc.Get("hidden").Bool() || time.Since(lastFrame) < watchdogTimeout {
+			continue
+		}
+		watchdogRestarts++
+		logError("No frame rendered for %v, restarting the frame renderer "+
+			"(restart %d)", time.Since(lastFrame).Round(time.Millisecond), watchdogRestarts)
+		old := rCall
+		rCall = newFrameCallback()
+		old.Release()
+		lastFrame = time.Now()
+		js.Global().Call("requestAnimationFrame", rCall)
+	}
+}
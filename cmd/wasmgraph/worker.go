@@ -0,0 +1,109 @@
+package main
+
+import (
+	"sort"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/compute"
+)
+
+const (
+	workerScript = "worker.js" // Starts the compute worker, built from cmd/worker
+)
+
+// A task handed over to the compute worker, waiting for its results
+type workerJob struct {
+	task string
+	req  js.Value
+	done func(reply js.Value)
+}
+
+// Parameters given as a javascript object, for running a task on the page or generating an object
+type jsParams = compute.JSParams
+
+var (
+	worker     = js.Undefined() // The compute worker, or undefined when it isn't available
+	workerCall js.Callback
+	workerJobs = map[int]workerJob{} // Tasks waiting for results from the worker, by ID
+	workerSeq  int                   // ID of the most recent task
+)
+
+// Starts the compute worker, if the browser can run one
+func initWorker() {
+	workerCall = js.NewCallback(workerReply)
+	w := js.Global().Get("Worker")
+	if w == js.Undefined() || safeMode {
+		return
+	}
+	worker = w.New(workerScript)
+	worker.Set("onmessage", workerCall)
+}
+
+// Runs one of the tasks in pkg/compute in the compute worker, calling done with the reply once it's finished.  The
+// reply holds the task's results as Float64Arrays, or an "error" message.  When there's no worker the task is run
+// straight away on the page instead, holding things up while it does.  The request is a javascript object of the
+// task's parameters, which is changed to say which task it's for
+func offload(task string, req js.Value, done func(reply js.Value)) {
+	if worker == js.Undefined() {
+		runTask(workerJob{task: task, req: req, done: done})
+		return
+	}
+	workerSeq++
+	req.Set("id", workerSeq)
+	req.Set("task", task)
+	workerJobs[workerSeq] = workerJob{task: task, req: req, done: done}
+	worker.Call("postMessage", req)
+}
+
+// Stops the compute worker, and releases its callback
+func releaseWorker() {
+	if worker != js.Undefined() {
+		worker.Call("terminate")
+	}
+	workerCall.Release()
+}
+
+// Runs a task on the page
+func runTask(j workerJob) {
+	reply := map[string]interface{}{}
+	res, err := compute.Run(j.task, jsParams{V: j.req})
+	if err != nil {
+		reply["error"] = err.Error()
+	}
+	for k, v := range res {
+		reply[k] = compute.Float64Array(v)
+	}
+	j.done(js.ValueOf(reply))
+}
+
+// Passes the results from the compute worker on to the task waiting for them.  If the worker couldn't start, the
+// tasks sent to it are run on the page instead, as are any later ones.  The results arrive in between frames, so the
+// next frame is drawn to show whatever they changed
+func workerReply(args []js.Value) {
+	defer markActivity()
+	data := args[0].Get("data")
+	if f := data.Get("failed"); f != js.Undefined() {
+		logInfo("The compute worker couldn't start, so calculations "+
+			"will be done on the page: %s", f.String())
+		worker.Call("terminate")
+		worker = js.Undefined()
+		var ids []int
+		for id := range workerJobs {
+			ids = append(ids, id)
+		}
+		sort.Ints(ids)
+		for _, id := range ids {
+			j := workerJobs[id]
+			delete(workerJobs, id)
+			runTask(j)
+		}
+		return
+	}
+	id := data.Get("id").Int()
+	j, ok := workerJobs[id]
+	if !ok {
+		return
+	}
+	delete(workerJobs, id)
+	j.done(data)
+}
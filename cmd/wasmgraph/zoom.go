@@ -0,0 +1,82 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+// Zoom levels offered when asking the user for an exact value
+var zoomPresets = []float64{0.5, 1, 2}
+
+// Returns the current zoom factor, derived from the accumulated world transform.  Rotation and translation don't
+// change the length of a transformed unit vector, so only scaling shows up here
+func currentZoom() float64 {
+	return math.Sqrt(worldMatrix[0]*worldMatrix[0] + worldMatrix[4]*worldMatrix[4] + worldMatrix[8]*worldMatrix[8])
+}
+
+// Parses a zoom level entered by the user.  Accepts either a percentage ("150%") or a plain factor ("1.5" or "1,5")
+func parseZoom(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	pct := strings.HasSuffix(s, "%")
+	z, err := scene.ParseNumber(strings.TrimSuffix(s, "%"))
+	if err != nil {
+		return 0, fmt.Errorf("'%s' isn't a valid zoom level", s)
+	}
+	if pct {
+		z /= 100
+	}
+	if z <= 0 || math.IsInf(z, 0) || math.IsNaN(z) {
+		return 0, errors.New("the zoom level needs to be greater than zero")
+	}
+	return z, nil
+}
+
+// Asks the user for an exact zoom level, then animates to it
+func promptZoom() {
+	var presets []string
+	for _, p := range zoomPresets {
+		presets = append(presets, zoomText(p))
+	}
+	msg := fmt.Sprintf("Zoom level (e.g. %s):", strings.Join(presets, ", "))
+	val := js.Global().Call("prompt", msg, zoomText(currentZoom()))
+	if val == js.Null() || val == js.Undefined() {
+		return // The user cancelled
+	}
+	z, err := parseZoom(val.String())
+	if err != nil {
+		js.Global().Call("alert", err.Error())
+		return
+	}
+	setZoom(z)
+}
+
+// Animates the world space to the given absolute zoom factor, once any operations in progress have finished
+func setZoom(z float64) {
+	whenIdle(func() {
+		ratio := z / currentZoom()
+		if math.Abs(ratio-1) < 1e-9 {
+			return
+		}
+		queueOperation(scaleOp(ratio, 50, 12))
+	})
+}
+
+// Returns a scale operation which changes the zoom by exactly the given ratio.  stepOperations applies a scale as f
+// equal steps of ((X-1)/f)+1, so this picks the X that compounds to the ratio
+func scaleOp(ratio float64, t int32, f int32) Operation {
+	op := Operation{op: SCALE, t: t, f: f}
+	parts := float64(f)
+	op.X = parts*(math.Pow(ratio, 1/parts)-1) + 1
+	op.Y, op.Z = op.X, op.X
+	return op
+}
+
+// Formats a zoom factor as a percentage, to one decimal place at most
+func zoomText(z float64) string {
+	return scene.FormatNumber(math.Round(z*1000)/10) + "%"
+}
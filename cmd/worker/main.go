@@ -0,0 +1,49 @@
+// Runs the calculations in pkg/compute for the page in a Web Worker, so drawing isn't held up while they're worked
+// out.  The page posts it tasks, and gets the results back as typed arrays
+//
+// compile: GOOS=js GOARCH=wasm go build -o worker.wasm ./cmd/worker
+package main
+
+import (
+	"syscall/js"
+
+	"github.com/justinclift/wasmGraph4/pkg/compute"
+)
+
+func main() {
+	c := js.NewCallback(func(args []js.Value) {
+		handle(args[0].Get("data"))
+	})
+	defer c.Release()
+	js.Global().Set("onmessage", c)
+
+	// Take over any tasks which arrived while the wasm was loading, queued up by worker.js
+	pending := js.Global().Get("pending")
+	for i := 0; i < pending.Length(); i++ {
+		handle(pending.Index(i))
+	}
+	js.Global().Set("pending", js.Undefined())
+
+	// Keep the worker running
+	done := make(chan struct{}, 0)
+	<-done
+}
+
+// Runs a task posted by the page, and posts back the results.  The arrays' buffers are transferred rather than
+// copied, so even large results are handed over quickly
+func handle(req js.Value) {
+	reply := map[string]interface{}{"id": req.Get("id")}
+	res, err := compute.Run(req.Get("task").String(), compute.JSParams{V: req})
+	if err != nil {
+		reply["error"] = err.Error()
+		js.Global().Call("postMessage", reply)
+		return
+	}
+	var transfer []interface{}
+	for k, v := range res {
+		a := compute.Float64Array(v)
+		reply[k] = a
+		transfer = append(transfer, a.Get("buffer"))
+	}
+	js.Global().Call("postMessage", reply, transfer)
+}
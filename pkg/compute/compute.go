@@ -0,0 +1,71 @@
+// Package compute holds the expensive calculations which can be handed over to the compute worker, so drawing isn't
+// held up while they're worked out.  They're kept free of javascript, so the page can also run them itself when the
+// worker isn't available.  The one exception is js.go, which is only built for the browser, and reads parameters and
+// makes results for the page and the worker alike
+package compute
+
+import (
+	"fmt"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+// A task's parameters, as posted to the worker.  Missing arrays are nil
+type Params interface {
+	scene.Params
+	Floats(name string) []float64
+}
+
+// A calculation, returning its results as named arrays of numbers
+type Task func(p Params) (map[string][]float64, error)
+
+var (
+	// The tasks which can be run, by name
+	Tasks = map[string]Task{
+		"fft":        FFT,
+		"fit":        Fit,
+		"isosurface": Isosurface,
+		"sample":     Sample,
+	}
+)
+
+// Works out the surface where the expression "src" of x, y, and z equals "level", the same way the isosurface
+// generator does, as "x", "y", and "z" arrays holding the corners of its triangles three at a time
+func Isosurface(p Params) (map[string][]float64, error) {
+	f, level, min, max, n, err := scene.IsosurfaceParams(p)
+	if err != nil {
+		return nil, err
+	}
+	x, y, z, err := scene.Isosurface(f, level, min, max, n)
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]float64{"x": x, "y": y, "z": z}, nil
+}
+
+// Runs the named task
+func Run(name string, p Params) (map[string][]float64, error) {
+	t, ok := Tasks[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown task '%s'", name)
+	}
+	return t(p)
+}
+
+// Samples the equation or parametric curve "src" at "n" evenly spaced points from "min" to "max", as x, y, and (for
+// parametric curves) z arrays
+func Sample(p Params) (map[string][]float64, error) {
+	n := p.Float("n")
+	if !scene.Finite(n) {
+		return nil, fmt.Errorf("the number of samples isn't a number")
+	}
+	x, y, z, err := scene.SampleArrays(p.String("src"), p.Float("min"), p.Float("max"), int(n))
+	if err != nil {
+		return nil, err
+	}
+	res := map[string][]float64{"x": x, "y": y}
+	if z != nil {
+		res["z"] = z
+	}
+	return res, nil
+}
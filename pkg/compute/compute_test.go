@@ -0,0 +1,152 @@
+package compute
+
+import (
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+// Parameters for the tests, as numbers, arrays of numbers, and strings
+type testParams map[string]interface{}
+
+func (p testParams) Float(name string) float64 {
+	if f, ok := p[name].(float64); ok {
+		return f
+	}
+	return math.NaN()
+}
+
+func (p testParams) Floats(name string) []float64 {
+	f, _ := p[name].([]float64)
+	return f
+}
+
+func (p testParams) String(name string) string {
+	s, _ := p[name].(string)
+	return s
+}
+
+func TestFFT(t *testing.T) {
+	// Five values, so they're padded to eight
+	in := []float64{1, 2, -0.5, 3, 0.25}
+	res, err := Run("fft", testParams{"re": in})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res["re"]) != 8 || len(res["im"]) != 8 || len(res["magnitude"]) != 8 {
+		t.Fatalf("got %d, %d, and %d values, want 8 of each", len(res["re"]), len(res["im"]), len(res["magnitude"]))
+	}
+	for k := 0; k < 8; k++ {
+		var want complex128
+		for j, v := range in {
+			want += complex(v, 0) * cmplx.Exp(complex(0, -2*math.Pi*float64(j*k)/8))
+		}
+		got := complex(res["re"][k], res["im"][k])
+		if cmplx.Abs(got-want) > 1e-12 || math.Abs(res["magnitude"][k]-cmplx.Abs(want)) > 1e-12 {
+			t.Errorf("frequency %d = %v (magnitude %v), want %v", k, got, res["magnitude"][k], want)
+		}
+	}
+}
+
+func TestFFTErrors(t *testing.T) {
+	tests := []testParams{
+		{},
+		{"re": []float64{1, 2, 3}, "im": []float64{1}},
+	}
+	for _, p := range tests {
+		if _, err := FFT(p); err == nil {
+			t.Errorf("FFT(%v) gave no error", p)
+		}
+	}
+}
+
+func TestPolyFit(t *testing.T) {
+	tests := []struct {
+		coeffs []float64
+		from   float64
+	}{
+		{[]float64{3, -2}, 0},
+		{[]float64{1, 0, 0.5}, -5},
+		{[]float64{-4, 1, -3, 2}, 1000},
+		{[]float64{0.1, 0.2, 0.3, 0.4, 0.5, 0.6}, -1},
+	}
+	for _, tc := range tests {
+		var x, y []float64
+		for i := 0; i < 20; i++ {
+			v, f := tc.from+float64(i)*0.5, 0.0
+			for j := len(tc.coeffs) - 1; j >= 0; j-- {
+				f = f*v + tc.coeffs[j]
+			}
+			x, y = append(x, v), append(y, f)
+		}
+		res, err := Run("fit", testParams{"x": x, "y": y, "degree": float64(len(tc.coeffs) - 1)})
+		if err != nil {
+			t.Errorf("fitting %v: %v", tc.coeffs, err)
+			continue
+		}
+		for i := range y {
+			if math.Abs(res["y"][i]-y[i]) > 1e-6*math.Max(1, math.Abs(y[i])) {
+				t.Errorf("fitting %v: y(%v) = %v, want %v", tc.coeffs, x[i], res["y"][i], y[i])
+				break
+			}
+		}
+		if tc.from == 1000 {
+			continue // Far from zero the coefficients are too poorly conditioned to compare
+		}
+		for j, c := range tc.coeffs {
+			if math.Abs(res["coeffs"][j]-c) > 1e-8 {
+				t.Errorf("fitting %v: coefficient %d = %v", tc.coeffs, j, res["coeffs"][j])
+			}
+		}
+	}
+}
+
+func TestPolyFitErrors(t *testing.T) {
+	tests := []struct {
+		x, y   []float64
+		degree int
+	}{
+		{[]float64{1, 2}, []float64{1}, 1},
+		{[]float64{1, 2}, []float64{1, 2}, 2},
+		{[]float64{1, 1, 1}, []float64{1, 2, 3}, 1},
+		{[]float64{1, 2, 3}, []float64{1, 2, 3}, maxFitDegree + 1},
+	}
+	for _, tc := range tests {
+		if _, err := PolyFit(tc.x, tc.y, tc.degree); err == nil {
+			t.Errorf("PolyFit(%v, %v, %d) gave no error", tc.x, tc.y, tc.degree)
+		}
+	}
+}
+
+func TestIsosurface(t *testing.T) {
+	res, err := Run("isosurface", testParams{"src": "x^2 + y^2 + z^2 = 4", "min": -3.0, "max": 3.0, "n": 24.0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, y, z := res["x"], res["y"], res["z"]
+	if len(x) == 0 || len(x)%3 != 0 || len(y) != len(x) || len(z) != len(x) {
+		t.Fatalf("got %d, %d, and %d corners", len(x), len(y), len(z))
+	}
+	for i := range x {
+		// The corners are interpolated along the cells' edges, so are only as close as the grid is fine
+		if r := math.Sqrt(x[i]*x[i] + y[i]*y[i] + z[i]*z[i]); math.Abs(r-2) > 0.05 {
+			t.Fatalf("corner (%v, %v, %v) is %v from the centre, want 2", x[i], y[i], z[i], r)
+		}
+	}
+
+	// The triangles face outward, where x^2 + y^2 + z^2 is larger
+	for i := 0; i < len(x); i += 3 {
+		ux, uy, uz := x[i+1]-x[i], y[i+1]-y[i], z[i+1]-z[i]
+		wx, wy, wz := x[i+2]-x[i], y[i+2]-y[i], z[i+2]-z[i]
+		nx, ny, nz := uy*wz-uz*wy, uz*wx-ux*wz, ux*wy-uy*wx
+		if nx*x[i]+ny*y[i]+nz*z[i] < 0 {
+			t.Fatalf("triangle %d faces inward", i/3)
+		}
+	}
+}
+
+func TestRunUnknown(t *testing.T) {
+	if _, err := Run("nosuchtask", testParams{}); err == nil {
+		t.Error("running an unknown task gave no error")
+	}
+}
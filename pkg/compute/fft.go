@@ -0,0 +1,73 @@
+package compute
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+const (
+	maxFFT = 1 << 22 // Most values the Fourier transform can be worked out for
+)
+
+// Works out the discrete Fourier transform of the values "re", and "im" if they're complex, as "re" and "im" arrays
+// along with the "magnitude" of each frequency.  The values are padded with zeros to a power of two
+func FFT(p Params) (map[string][]float64, error) {
+	in := p.Floats("re")
+	if len(in) == 0 {
+		return nil, fmt.Errorf("there are no values to transform")
+	}
+	if len(in) > maxFFT {
+		return nil, fmt.Errorf("there can be at most %d values", maxFFT)
+	}
+	n := 1
+	for n < len(in) {
+		n *= 2
+	}
+	re, im := make([]float64, n), make([]float64, n)
+	copy(re, in)
+	if v := p.Floats("im"); v != nil {
+		if len(v) != len(in) {
+			return nil, fmt.Errorf("there need to be as many imaginary parts as real ones")
+		}
+		copy(im, v)
+	}
+	Transform(re, im)
+	mag := make([]float64, n)
+	for i := range re {
+		mag[i] = math.Hypot(re[i], im[i])
+	}
+	return map[string][]float64{"re": re, "im": im, "magnitude": mag}, nil
+}
+
+// Replaces the real and imaginary parts of some values with their discrete Fourier transform, using the iterative
+// radix-2 fast Fourier transform.  There has to be a power of two of them
+func Transform(re []float64, im []float64) {
+	n := len(re)
+	if n < 2 {
+		return
+	}
+
+	// Put the values in bit reversed order, so the butterflies can work in place
+	shift := uint(bits.UintSize - bits.TrailingZeros(uint(n)))
+	for i := 0; i < n; i++ {
+		j := int(bits.Reverse(uint(i)) >> shift)
+		if j > i {
+			re[i], re[j] = re[j], re[i]
+			im[i], im[j] = im[j], im[i]
+		}
+	}
+	for size := 2; size <= n; size *= 2 {
+		step := -2 * math.Pi / float64(size)
+		for start := 0; start < n; start += size {
+			for k := 0; k < size/2; k++ {
+				wr, wi := math.Cos(step*float64(k)), math.Sin(step*float64(k))
+				a, b := start+k, start+k+size/2
+				tr := wr*re[b] - wi*im[b]
+				ti := wr*im[b] + wi*re[b]
+				re[b], im[b] = re[a]-tr, im[a]-ti
+				re[a], im[a] = re[a]+tr, im[a]+ti
+			}
+		}
+	}
+}
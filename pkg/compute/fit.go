@@ -0,0 +1,119 @@
+package compute
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	maxFitDegree = 10 // Highest degree of polynomial a curve can be fitted with
+)
+
+// Fits a polynomial of the given "degree" (1, a straight line, by default) to the points with co-ordinates "x" and
+// "y", by least squares.  Returns its "coeffs", starting with the constant, and the fitted "y" at each x
+func Fit(p Params) (map[string][]float64, error) {
+	x, y := p.Floats("x"), p.Floats("y")
+	degree := 1
+	if d := p.Float("degree"); !math.IsNaN(d) {
+		degree = int(d)
+	}
+	c, err := PolyFit(x, y, degree)
+	if err != nil {
+		return nil, err
+	}
+	fitted := make([]float64, len(x))
+	for i, v := range x {
+		for j := len(c) - 1; j >= 0; j-- {
+			fitted[i] = fitted[i]*v + c[j]
+		}
+	}
+	return map[string][]float64{"coeffs": c, "y": fitted}, nil
+}
+
+// Returns the coefficients of the least squares polynomial of the given degree through the points, starting with the
+// constant.  x is centred and scaled first, and the normal equations solved by Gaussian elimination with partial
+// pivoting, which keeps them well enough conditioned for the low degrees allowed
+func PolyFit(x []float64, y []float64, degree int) ([]float64, error) {
+	if degree < 0 || degree > maxFitDegree {
+		return nil, fmt.Errorf("the degree needs to be from 0 to %d", maxFitDegree)
+	}
+	if len(x) != len(y) {
+		return nil, fmt.Errorf("there need to be as many values of y as of x")
+	}
+	if len(x) <= degree {
+		return nil, fmt.Errorf("fitting a polynomial of degree %d needs at least %d points", degree, degree+1)
+	}
+
+	// Centre and scale x, so its powers stay near 1
+	var mean, scale float64
+	for i, v := range x {
+		if math.IsNaN(v) || math.IsInf(v, 0) || math.IsNaN(y[i]) || math.IsInf(y[i], 0) {
+			return nil, fmt.Errorf("the points need to be numbers")
+		}
+		mean += v / float64(len(x))
+	}
+	for _, v := range x {
+		scale = math.Max(scale, math.Abs(v-mean))
+	}
+	if scale == 0 {
+		scale = 1
+	}
+
+	// The normal equations, as an augmented matrix
+	m := degree + 1
+	a := make([][]float64, m)
+	for r := range a {
+		a[r] = make([]float64, m+1)
+	}
+	pow := make([]float64, 2*m)
+	for i, v := range x {
+		u := (v - mean) / scale
+		pow[0] = 1
+		for k := 1; k < len(pow); k++ {
+			pow[k] = pow[k-1] * u
+		}
+		for r := 0; r < m; r++ {
+			for c := 0; c < m; c++ {
+				a[r][c] += pow[r+c]
+			}
+			a[r][m] += pow[r] * y[i]
+		}
+	}
+	for col := 0; col < m; col++ {
+		best := col
+		for r := col + 1; r < m; r++ {
+			if math.Abs(a[r][col]) > math.Abs(a[best][col]) {
+				best = r
+			}
+		}
+		if math.Abs(a[best][col]) < 1e-12 {
+			return nil, fmt.Errorf("there aren't enough different values of x to fit a polynomial of degree %d", degree)
+		}
+		a[col], a[best] = a[best], a[col]
+		for r := 0; r < m; r++ {
+			if r == col {
+				continue
+			}
+			f := a[r][col] / a[col][col]
+			for c := col; c <= m; c++ {
+				a[r][c] -= f * a[col][c]
+			}
+		}
+	}
+	u := make([]float64, m) // Coefficients for the centred and scaled x
+	for r := 0; r < m; r++ {
+		u[r] = a[r][m] / a[r][r]
+	}
+
+	// Expand the powers of (x - mean) / scale back out into powers of x
+	c := make([]float64, m)
+	for k := 0; k < m; k++ {
+		sk := u[k] / math.Pow(scale, float64(k))
+		binom := 1.0
+		for j := 0; j <= k; j++ {
+			c[j] += sk * binom * math.Pow(-mean, float64(k-j))
+			binom = binom * float64(k-j) / float64(j+1)
+		}
+	}
+	return c, nil
+}
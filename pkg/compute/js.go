@@ -0,0 +1,64 @@
+//go:build js
+// +build js
+
+package compute
+
+import (
+	"math"
+	"syscall/js"
+)
+
+// Parameters given as a javascript object, for running a task or generating an object
+type JSParams struct {
+	V js.Value
+}
+
+// Returns a javascript Float64Array holding a copy of the numbers.  Typed arrays from js.TypedArrayOf are views onto
+// the wasm memory, so can't be kept or handed over to another thread
+func Float64Array(v []float64) js.Value {
+	ta := js.TypedArrayOf(v)
+	a := js.Global().Get("Float64Array").New(ta)
+	ta.Release()
+	return a
+}
+
+// Returns a number parameter, or NaN when it's missing or isn't a number
+func (p JSParams) Float(name string) float64 {
+	v := p.V.Get(name)
+	if v.Type() != js.TypeNumber {
+		return math.NaN()
+	}
+	return v.Float()
+}
+
+// Returns an array of numbers parameter, from a javascript array or typed array, or nil when it's missing.  Anything
+// in it which isn't a number is NaN.  Float64Arrays, such as the worker's results, are copied across all at once
+func (p JSParams) Floats(name string) []float64 {
+	v := p.V.Get(name)
+	if v.Type() != js.TypeObject || v.Get("length").Type() != js.TypeNumber {
+		return nil
+	}
+	f := make([]float64, v.Length())
+	if v.InstanceOf(js.Global().Get("Float64Array")) {
+		ta := js.TypedArrayOf(f)
+		ta.Call("set", v)
+		ta.Release()
+		return f
+	}
+	for i := range f {
+		f[i] = math.NaN()
+		if e := v.Index(i); e.Type() == js.TypeNumber {
+			f[i] = e.Float()
+		}
+	}
+	return f
+}
+
+// Returns a string parameter, or an empty string when it's missing or isn't a string
+func (p JSParams) String(name string) string {
+	v := p.V.Get(name)
+	if v.Type() != js.TypeString {
+		return ""
+	}
+	return v.String()
+}
@@ -0,0 +1,782 @@
+// Package expr parses mathematical expressions, and evaluates and differentiates them symbolically
+package expr
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// A node in a parsed expression tree
+type Node interface {
+	Eval(vars map[string]float64) float64
+	Deriv(v string) Node // Symbolic derivative with respect to the given variable
+	String() string
+}
+
+// A number
+type Num float64
+
+type varNode string
+
+// Negation
+type negNode struct {
+	x Node
+}
+
+// Binary operators: + - * / ^
+type binNode struct {
+	op   byte
+	l, r Node
+}
+
+// Function calls, such as sin(x)
+type callNode struct {
+	fn   string
+	args []Node
+}
+
+// The functions understood by the parser, along with their number of arguments
+var exprFuncs = map[string]int{
+	"sin": 1, "cos": 1, "tan": 1, "asin": 1, "acos": 1, "atan": 1,
+	"sinh": 1, "cosh": 1, "tanh": 1,
+	"exp": 1, "ln": 1, "log": 1, "sqrt": 1, "abs": 1,
+}
+
+// Named constants
+var exprConsts = map[string]float64{
+	"pi": math.Pi,
+	"e":  math.E,
+}
+
+func (n Num) Eval(vars map[string]float64) float64 { return float64(n) }
+func (n Num) Deriv(v string) Node                  { return Num(0) }
+func (n Num) String() string                       { return strconv.FormatFloat(float64(n), 'g', -1, 64) }
+
+func (n varNode) Eval(vars map[string]float64) float64 {
+	if v, ok := vars[string(n)]; ok {
+		return v
+	}
+	if c, ok := exprConsts[string(n)]; ok {
+		return c
+	}
+	return math.NaN()
+}
+
+func (n varNode) Deriv(v string) Node {
+	if string(n) == v {
+		return Num(1)
+	}
+	return Num(0)
+}
+
+func (n varNode) String() string { return string(n) }
+
+func (n negNode) Eval(vars map[string]float64) float64 { return -n.x.Eval(vars) }
+func (n negNode) Deriv(v string) Node                  { return neg(n.x.Deriv(v)) }
+func (n negNode) String() string                       { return "-" + wrap(n.x, precOf(n.x) < 3) }
+
+func (n binNode) Eval(vars map[string]float64) float64 {
+	l, r := n.l.Eval(vars), n.r.Eval(vars)
+	switch n.op {
+	case '+':
+		return l + r
+	case '-':
+		return l - r
+	case '*':
+		return l * r
+	case '/':
+		return l / r
+	case '^':
+		return math.Pow(l, r)
+	}
+	return math.NaN()
+}
+
+func (n binNode) Deriv(v string) Node {
+	u, w := n.l, n.r
+	du, dw := u.Deriv(v), w.Deriv(v)
+	switch n.op {
+	case '+':
+		return add(du, dw)
+	case '-':
+		return Sub(du, dw)
+	case '*':
+		return add(mul(du, w), mul(u, dw))
+	case '/':
+		return div(Sub(mul(du, w), mul(u, dw)), pow(w, Num(2)))
+	case '^':
+		if isConst(w, v) {
+			// Power rule
+			return mul(mul(w, pow(u, Sub(w, Num(1)))), du)
+		}
+		if isConst(u, v) {
+			// Exponential rule
+			if u == varNode("e") {
+				return mul(n, dw)
+			}
+			return mul(mul(n, call("ln", u)), dw)
+		}
+		// General case: d(u^w) = u^w * (w' ln(u) + w u'/u)
+		return mul(n, add(mul(dw, call("ln", u)), div(mul(w, du), u)))
+	}
+	return Num(math.NaN())
+}
+
+func (n binNode) String() string {
+	p := precOf(n)
+	lp, rp := precOf(n.l), precOf(n.r)
+	left := wrap(n.l, lp < p || (n.op == '^' && lp <= p))
+	right := wrap(n.r, rp < p || (rp == p && (n.op == '-' || n.op == '/')))
+	switch n.op {
+	case '+', '-':
+		return left + " " + string(n.op) + " " + right
+	}
+	return left + string(n.op) + right
+}
+
+func (n callNode) Eval(vars map[string]float64) float64 {
+	a := n.args[0].Eval(vars)
+	switch n.fn {
+	case "sin":
+		return math.Sin(a)
+	case "cos":
+		return math.Cos(a)
+	case "tan":
+		return math.Tan(a)
+	case "asin":
+		return math.Asin(a)
+	case "acos":
+		return math.Acos(a)
+	case "atan":
+		return math.Atan(a)
+	case "sinh":
+		return math.Sinh(a)
+	case "cosh":
+		return math.Cosh(a)
+	case "tanh":
+		return math.Tanh(a)
+	case "exp":
+		return math.Exp(a)
+	case "ln":
+		return math.Log(a)
+	case "log":
+		return math.Log10(a)
+	case "sqrt":
+		return math.Sqrt(a)
+	case "abs":
+		return math.Abs(a)
+	}
+	return math.NaN()
+}
+
+func (n callNode) Deriv(v string) Node {
+	u := n.args[0]
+	du := u.Deriv(v)
+	var d Node
+	switch n.fn {
+	case "sin":
+		d = call("cos", u)
+	case "cos":
+		d = neg(call("sin", u))
+	case "tan":
+		d = div(Num(1), pow(call("cos", u), Num(2)))
+	case "asin":
+		d = div(Num(1), call("sqrt", Sub(Num(1), pow(u, Num(2)))))
+	case "acos":
+		d = neg(div(Num(1), call("sqrt", Sub(Num(1), pow(u, Num(2))))))
+	case "atan":
+		d = div(Num(1), add(Num(1), pow(u, Num(2))))
+	case "sinh":
+		d = call("cosh", u)
+	case "cosh":
+		d = call("sinh", u)
+	case "tanh":
+		d = div(Num(1), pow(call("cosh", u), Num(2)))
+	case "exp":
+		d = n
+	case "ln":
+		d = div(Num(1), u)
+	case "log":
+		d = div(Num(1), mul(u, call("ln", Num(10))))
+	case "sqrt":
+		d = div(Num(1), mul(Num(2), n))
+	case "abs":
+		d = div(u, n)
+	default:
+		return Num(math.NaN())
+	}
+	return mul(d, du)
+}
+
+func (n callNode) String() string {
+	var a []string
+	for _, j := range n.args {
+		a = append(a, j.String())
+	}
+	return n.fn + "(" + strings.Join(a, ", ") + ")"
+}
+
+// Builds an addition, simplifying where possible
+func add(a Node, b Node) Node {
+	if isNum(a, 0) {
+		return b
+	}
+	if isNum(b, 0) {
+		return a
+	}
+	if x, ok := a.(Num); ok {
+		if y, ok := b.(Num); ok {
+			return x + y
+		}
+	}
+	if n, ok := b.(negNode); ok {
+		return Sub(a, n.x)
+	}
+	return binNode{'+', a, b}
+}
+
+// Builds a function call
+func call(fn string, args ...Node) Node {
+	return callNode{fn: fn, args: args}
+}
+
+// Returns the degree of an expression as a polynomial in the given variable, eg 3 for x^3 - 2x.  Returns false if it
+// isn't a polynomial, such as sin(x) or 1/x.  Terms cancelling each other out aren't noticed, so x^2 - x^2 counts as
+// degree 2
+func Degree(n Node, v string) (int, bool) {
+	if isConst(n, v) {
+		return 0, true
+	}
+	switch j := n.(type) {
+	case varNode:
+		return 1, true
+	case negNode:
+		return Degree(j.x, v)
+	case binNode:
+		l, lok := Degree(j.l, v)
+		switch j.op {
+		case '+', '-':
+			r, rok := Degree(j.r, v)
+			if l < r {
+				l = r
+			}
+			return l, lok && rok
+		case '*':
+			r, rok := Degree(j.r, v)
+			return l + r, lok && rok
+		case '/':
+			return l, lok && isConst(j.r, v)
+		case '^':
+			k, ok := j.r.(Num)
+			if !ok || k < 0 || float64(k) != math.Trunc(float64(k)) {
+				return 0, false
+			}
+			return l * int(k), lok
+		}
+	}
+	return 0, false
+}
+
+// Builds a division, simplifying where possible
+func div(a Node, b Node) Node {
+	if isNum(a, 0) {
+		return Num(0)
+	}
+	if isNum(b, 1) {
+		return a
+	}
+	if x, ok := a.(Num); ok {
+		if y, ok := b.(Num); ok && y != 0 && math.Mod(float64(x), float64(y)) == 0 {
+			return x / y
+		}
+	}
+	return binNode{'/', a, b}
+}
+
+// Returns true if the expression doesn't depend on the given variable
+func isConst(n Node, v string) bool {
+	for _, j := range Vars(n) {
+		if j == v {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns true if the expression is the given number
+func isNum(n Node, f float64) bool {
+	x, ok := n.(Num)
+	return ok && float64(x) == f
+}
+
+// Builds a multiplication, simplifying where possible
+func mul(a Node, b Node) Node {
+	if isNum(a, 0) || isNum(b, 0) {
+		return Num(0)
+	}
+	if isNum(a, 1) {
+		return b
+	}
+	if isNum(b, 1) {
+		return a
+	}
+	if isNum(a, -1) {
+		return neg(b)
+	}
+	if x, ok := a.(Num); ok {
+		if y, ok := b.(Num); ok {
+			return x * y
+		}
+		// Fold constant factors together, eg 3*(2*x) -> 6*x
+		if m, ok := b.(binNode); ok && m.op == '*' {
+			if y, ok := m.l.(Num); ok {
+				return mul(x*y, m.r)
+			}
+		}
+	}
+	// Keep constant factors at the front
+	if _, ok := b.(Num); ok {
+		return mul(b, a)
+	}
+
+	// Turn multiplying by a reciprocal into a division, eg 2*(1/x) -> 2/x
+	if d, ok := b.(binNode); ok && d.op == '/' && isNum(d.l, 1) {
+		return div(a, d.r)
+	}
+	if d, ok := a.(binNode); ok && d.op == '/' && isNum(d.l, 1) {
+		return div(b, d.r)
+	}
+	if n, ok := a.(negNode); ok {
+		return neg(mul(n.x, b))
+	}
+	if n, ok := b.(negNode); ok {
+		return neg(mul(a, n.x))
+	}
+	return binNode{'*', a, b}
+}
+
+// Builds a negation, simplifying where possible
+func neg(a Node) Node {
+	switch n := a.(type) {
+	case Num:
+		return -n
+	case negNode:
+		return n.x
+	}
+	return negNode{a}
+}
+
+// Builds a power, simplifying where possible
+func pow(a Node, b Node) Node {
+	if isNum(b, 0) {
+		return Num(1)
+	}
+	if isNum(b, 1) {
+		return a
+	}
+	if x, ok := a.(Num); ok {
+		if y, ok := b.(Num); ok {
+			return Num(math.Pow(float64(x), float64(y)))
+		}
+	}
+	return binNode{'^', a, b}
+}
+
+// Returns the precedence of an expression node, for deciding where brackets are needed when printing
+func precOf(n Node) int {
+	switch j := n.(type) {
+	case binNode:
+		switch j.op {
+		case '+', '-':
+			return 1
+		case '*', '/':
+			return 2
+		}
+		return 3
+	case negNode:
+		return 2
+	case Num:
+		if j < 0 {
+			return 2
+		}
+	}
+	return 4
+}
+
+// Builds a subtraction, simplifying where possible
+func Sub(a Node, b Node) Node {
+	if isNum(b, 0) {
+		return a
+	}
+	if isNum(a, 0) {
+		return neg(b)
+	}
+	if x, ok := a.(Num); ok {
+		if y, ok := b.(Num); ok {
+			return x - y
+		}
+	}
+	if n, ok := b.(negNode); ok {
+		return add(a, n.x)
+	}
+	return binNode{'-', a, b}
+}
+
+// Returns a copy of an expression with the given variables replaced by their values, simplifying where possible.
+// Variables without a value are left as they are
+func Substitute(n Node, vals map[string]float64) Node {
+	switch j := n.(type) {
+	case varNode:
+		if v, ok := vals[string(j)]; ok {
+			return Num(v)
+		}
+	case negNode:
+		return neg(Substitute(j.x, vals))
+	case binNode:
+		l, r := Substitute(j.l, vals), Substitute(j.r, vals)
+		switch j.op {
+		case '+':
+			return add(l, r)
+		case '-':
+			return Sub(l, r)
+		case '*':
+			return mul(l, r)
+		case '/':
+			return div(l, r)
+		}
+		return pow(l, r)
+	case callNode:
+		var args []Node
+		for _, a := range j.args {
+			args = append(args, Substitute(a, vals))
+		}
+		return call(j.fn, args...)
+	}
+	return n
+}
+
+// Returns the string for an expression, wrapped in brackets if asked
+func wrap(n Node, brackets bool) string {
+	if brackets {
+		return "(" + n.String() + ")"
+	}
+	return n.String()
+}
+
+// Returns the (sorted) names of the variables used in an expression, not counting constants like pi
+func Vars(n Node) []string {
+	found := map[string]bool{}
+	var walk func(n Node)
+	walk = func(n Node) {
+		switch j := n.(type) {
+		case varNode:
+			if _, ok := exprConsts[string(j)]; !ok {
+				found[string(j)] = true
+			}
+		case negNode:
+			walk(j.x)
+		case binNode:
+			walk(j.l)
+			walk(j.r)
+		case callNode:
+			for _, a := range j.args {
+				walk(a)
+			}
+		}
+	}
+	walk(n)
+	var v []string
+	for k := range found {
+		v = append(v, k)
+	}
+	sort.Strings(v)
+	return v
+}
+
+// The kinds of token produced when scanning an expression
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNum
+	tokIdent
+	tokOp
+	tokLParen
+	tokRParen
+	tokComma
+	tokBar
+)
+
+type token struct {
+	kind tokenKind
+	num  float64
+	text string
+	pos  int
+}
+
+// Splits an expression string into tokens.  A few common unicode symbols (superscripts, π, ×, ·, −) are accepted too
+func tokenise(s string) ([]token, error) {
+	r := strings.NewReplacer("²", "^2", "³", "^3", "π", "pi", "×", "*", "·", "*", "−", "-", "÷", "/", "**", "^")
+	s = r.Replace(s)
+	var toks []token
+	rs := []rune(s)
+	for i := 0; i < len(rs); {
+		c := rs[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			j := i
+			for j < len(rs) && (unicode.IsDigit(rs[j]) || rs[j] == '.') {
+				j++
+			}
+			// Scientific notation, eg 1.5e-3
+			if j < len(rs) && (rs[j] == 'e' || rs[j] == 'E') {
+				k := j + 1
+				if k < len(rs) && (rs[k] == '+' || rs[k] == '-') {
+					k++
+				}
+				if k < len(rs) && unicode.IsDigit(rs[k]) {
+					for k < len(rs) && unicode.IsDigit(rs[k]) {
+						k++
+					}
+					j = k
+				}
+			}
+			f, err := strconv.ParseFloat(string(rs[i:j]), 64)
+			if err != nil {
+				return nil, fmt.Errorf("'%s' isn't a valid number", string(rs[i:j]))
+			}
+			toks = append(toks, token{kind: tokNum, num: f, pos: i})
+			i = j
+		case unicode.IsLetter(c) || c == '_':
+			j := i
+			for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j]) || rs[j] == '_') {
+				j++
+			}
+			toks = append(toks, token{kind: tokIdent, text: string(rs[i:j]), pos: i})
+			i = j
+		case strings.ContainsRune("+-*/^", c):
+			toks = append(toks, token{kind: tokOp, text: string(c), pos: i})
+			i++
+		case c == '(' || c == '[' || c == '{':
+			toks = append(toks, token{kind: tokLParen, pos: i})
+			i++
+		case c == ')' || c == ']' || c == '}':
+			toks = append(toks, token{kind: tokRParen, pos: i})
+			i++
+		case c == ',':
+			toks = append(toks, token{kind: tokComma, pos: i})
+			i++
+		case c == '|':
+			toks = append(toks, token{kind: tokBar, pos: i})
+			i++
+		default:
+			return nil, fmt.Errorf("unexpected '%c' at position %d", c, i+1)
+		}
+	}
+	toks = append(toks, token{kind: tokEOF, pos: len(rs)})
+	return toks, nil
+}
+
+// A recursive descent parser for expressions
+type exprParser struct {
+	toks []token
+	pos  int
+	bars int // Depth of |abs| bars currently open
+}
+
+// Parses an expression string, such as "3x^2 + sin(x)".  Multiplication can be implied, as in "2x" or "3(x+1)"
+func Parse(s string) (Node, error) {
+	toks, err := tokenise(s)
+	if err != nil {
+		return nil, err
+	}
+	p := &exprParser{toks: toks}
+	n, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected input at position %d", t.pos+1)
+	}
+	return n, nil
+}
+
+// Parses an expression, checking it only uses the allowed variables
+func ParseVars(s string, allowed ...string) (Node, error) {
+	n, err := Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range Vars(n) {
+		ok := false
+		for _, a := range allowed {
+			if v == a {
+				ok = true
+			}
+		}
+		if !ok {
+			return nil, fmt.Errorf("unknown name '%s'", v)
+		}
+	}
+	return n, nil
+}
+
+func (p *exprParser) next() token {
+	t := p.toks[p.pos]
+	if p.pos < len(p.toks)-1 {
+		p.pos++
+	}
+	return t
+}
+
+func (p *exprParser) peek() token {
+	return p.toks[p.pos]
+}
+
+// expr := term (('+' | '-') term)*
+func (p *exprParser) expr() (Node, error) {
+	n, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		if t.kind != tokOp || (t.text != "+" && t.text != "-") {
+			return n, nil
+		}
+		p.next()
+		r, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		n = binNode{t.text[0], n, r}
+	}
+}
+
+// term := unary (('*' | '/')? unary)*
+func (p *exprParser) term() (Node, error) {
+	n, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		op := byte('*')
+		switch {
+		case t.kind == tokOp && (t.text == "*" || t.text == "/"):
+			op = t.text[0]
+			p.next()
+		case t.kind == tokNum || t.kind == tokIdent || t.kind == tokLParen || (t.kind == tokBar && p.bars == 0):
+			// Implied multiplication
+		default:
+			return n, nil
+		}
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		n = binNode{op, n, r}
+	}
+}
+
+// unary := ('-' | '+') unary | power
+func (p *exprParser) unary() (Node, error) {
+	t := p.peek()
+	if t.kind == tokOp && (t.text == "-" || t.text == "+") {
+		p.next()
+		n, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		if t.text == "-" {
+			if f, ok := n.(Num); ok {
+				return -f, nil
+			}
+			return negNode{n}, nil
+		}
+		return n, nil
+	}
+	return p.power()
+}
+
+// power := primary ('^' unary)?
+func (p *exprParser) power() (Node, error) {
+	n, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind == tokOp && t.text == "^" {
+		p.next()
+		r, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		n = binNode{'^', n, r}
+	}
+	return n, nil
+}
+
+// primary := number | name | name '(' args ')' | '(' expr ')' | '|' expr '|'
+func (p *exprParser) primary() (Node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNum:
+		return Num(t.num), nil
+	case tokIdent:
+		if nargs, ok := exprFuncs[t.text]; ok {
+			if p.peek().kind != tokLParen {
+				return nil, fmt.Errorf("expected '(' after %s", t.text)
+			}
+			p.next()
+			var args []Node
+			for {
+				a, err := p.expr()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, a)
+				if p.peek().kind != tokComma {
+					break
+				}
+				p.next()
+			}
+			if p.next().kind != tokRParen {
+				return nil, fmt.Errorf("missing ')' after the arguments to %s", t.text)
+			}
+			if len(args) != nargs {
+				return nil, fmt.Errorf("%s takes %d argument(s), but was given %d", t.text, nargs, len(args))
+			}
+			return callNode{fn: t.text, args: args}, nil
+		}
+		return varNode(t.text), nil
+	case tokLParen:
+		n, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokRParen {
+			return nil, fmt.Errorf("missing ')'")
+		}
+		return n, nil
+	case tokBar:
+		p.bars++
+		n, err := p.expr()
+		p.bars--
+		if err != nil {
+			return nil, err
+		}
+		if p.next().kind != tokBar {
+			return nil, fmt.Errorf("missing closing '|'")
+		}
+		return callNode{fn: "abs", args: []Node{n}}, nil
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected input at position %d", t.pos+1)
+}
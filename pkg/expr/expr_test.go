@@ -0,0 +1,113 @@
+package expr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		src, want string
+		val       float64 // Value at x = 2
+	}{
+		{"1 + 2*3", "1 + 2*3", 7},
+		{"2^3^2", "2^3^2", 512},
+		{"-x^2", "-x^2", -4},
+		{"3x", "3*x", 6},
+		{"2sin(x)", "2*sin(x)", 2 * math.Sin(2)},
+		{"2(x+1)", "2*(x + 1)", 6},
+		{"x(x+1)", "x*(x + 1)", 6},
+		{"2 pi", "2*pi", 2 * math.Pi},
+		{"sin(pi/2)", "sin(pi/2)", 1},
+		{"e^x", "e^x", math.Exp(2)},
+		{"log(100) + ln(e)", "log(100) + ln(e)", 3},
+		{"sqrt(16)*abs(-3)", "sqrt(16)*abs(-3)", 12},
+		{"x - -1", "x - -1", 3},
+		{"x-(1-x)", "x - (1 - x)", 3},
+		{"1e3 + .5x", "1000 + 0.5*x", 1001},
+		{"2x^2 - 3x + 1", "2*x^2 - 3*x + 1", 3},
+		{"1/0", "1/0", math.Inf(1)},
+	}
+	for _, tc := range tests {
+		n, err := Parse(tc.src)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.src, err)
+			continue
+		}
+		if got := n.String(); got != tc.want {
+			t.Errorf("Parse(%q) = %q, want %q", tc.src, got, tc.want)
+		}
+		if got := n.Eval(map[string]float64{"x": 2}); math.Abs(got-tc.val) > 1e-12 && got != tc.val {
+			t.Errorf("%q at x = 2 is %v, want %v", tc.src, got, tc.val)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"x +",
+		"(1+2)*(3",
+		"sin x",
+		"sin(x, y)",
+		"2 * * 3",
+	}
+	for _, src := range tests {
+		if n, err := Parse(src); err == nil {
+			t.Errorf("Parse(%q) = %q, want an error", src, n)
+		}
+	}
+}
+
+func TestParseVars(t *testing.T) {
+	if _, err := ParseVars("x^2 + y*z - pi", "x", "y", "z"); err != nil {
+		t.Errorf("ParseVars with allowed variables: %v", err)
+	}
+	if _, err := ParseVars("x + y + t", "x", "y"); err == nil {
+		t.Error("ParseVars allowed t, which isn't in the list")
+	}
+}
+
+func TestDeriv(t *testing.T) {
+	tests := []struct {
+		src, want string
+	}{
+		{"3", "0"},
+		{"y*x", "y"},
+		{"x^3", "3*x^2"},
+		{"2x^2 - 3x + 1", "4*x - 3"},
+		{"-x^2", "-(2*x)"},
+		{"sin(x)^2", "2*sin(x)*cos(x)"},
+		{"x*sin(x)", "sin(x) + x*cos(x)"},
+		{"cos(3x)", "-(3*sin(3*x))"},
+		{"tan(x)", "1/cos(x)^2"},
+		{"atan(x)", "1/(1 + x^2)"},
+		{"exp(2x)", "2*exp(2*x)"},
+		{"2^x", "2^x*ln(2)"},
+		{"ln(x)", "1/x"},
+		{"log(x)", "1/(x*ln(10))"},
+		{"1/x", "-1/x^2"},
+		{"sqrt(x)", "1/(2*sqrt(x))"},
+		{"abs(x)", "x/abs(x)"},
+	}
+	for _, tc := range tests {
+		n, err := Parse(tc.src)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.src, err)
+			continue
+		}
+		d := n.Deriv("x")
+		if got := d.String(); got != tc.want {
+			t.Errorf("d/dx %q = %q, want %q", tc.src, got, tc.want)
+		}
+
+		// Check it against a central difference too
+		const x, h = 1.3, 1e-6
+		vars := map[string]float64{"x": x, "y": 0.7}
+		want := (n.Eval(map[string]float64{"x": x + h, "y": 0.7}) - n.Eval(map[string]float64{"x": x - h, "y": 0.7})) /
+			(2 * h)
+		if got := d.Eval(vars); math.Abs(got-want) > 1e-6*math.Max(1, math.Abs(want)) {
+			t.Errorf("d/dx %q at x = %v is %v, but the central difference is %v", tc.src, x, got, want)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package expr
+
+import (
+	"strconv"
+	"strings"
+)
+
+var (
+	// Superscript versions of the characters which have them, for writing powers
+	superscripts = map[rune]rune{
+		'0': '⁰', '1': '¹', '2': '²', '3': '³', '4': '⁴', '5': '⁵', '6': '⁶', '7': '⁷', '8': '⁸', '9': '⁹',
+		'+': '⁺', '-': '⁻', '−': '⁻', '(': '⁽', ')': '⁾', 'a': 'ᵃ', 'b': 'ᵇ', 'c': 'ᶜ', 'd': 'ᵈ', 'e': 'ᵉ',
+		'i': 'ⁱ', 'k': 'ᵏ', 'm': 'ᵐ', 'n': 'ⁿ', 't': 'ᵗ', 'x': 'ˣ', 'y': 'ʸ', 'z': 'ᶻ',
+	}
+
+	// TeX commands for the functions which have their own
+	texFuncs = map[string]string{
+		"sin": `\sin`, "cos": `\cos`, "tan": `\tan`, "asin": `\arcsin`, "acos": `\arccos`, "atan": `\arctan`,
+		"sinh": `\sinh`, "cosh": `\cosh`, "tanh": `\tanh`, "ln": `\ln`, "log": `\log`,
+	}
+)
+
+// Returns true if a product should be written without a multiplication sign, as in 3x, 2sin(x), or 2(x + 1).  That's
+// when a number multiplies something starting with a letter or a bracket
+func implicitMul(n binNode) bool {
+	l, ok := n.l.(Num)
+	if !ok || l < 0 {
+		return false
+	}
+	switch r := n.r.(type) {
+	case varNode, callNode:
+		return true
+	case binNode:
+		if r.op == '^' {
+			return implicitMul(binNode{'*', l, r.l})
+		}
+		return precOf(r) < 2 // Bracketed
+	}
+	return false
+}
+
+// Returns true if an expression is a division, which is written as a fraction in TeX
+func isDiv(n Node) bool {
+	b, ok := n.(binNode)
+	return ok && b.op == '/'
+}
+
+// Returns true if an expression starts with a minus sign, so it needs brackets after another operator, as in
+// x − (−1)
+func isNeg(n Node) bool {
+	switch j := n.(type) {
+	case Num:
+		return j < 0
+	case negNode:
+		return true
+	}
+	return false
+}
+
+// Returns an expression typeset as plain text, with proper minus and multiplication signs, powers as superscripts
+// where they can be, and symbols for pi and square roots, eg "3x² − 2·sin(x)"
+func Pretty(n Node) string {
+	wrapped := func(n Node, brackets bool) string {
+		if brackets {
+			return "(" + Pretty(n) + ")"
+		}
+		return Pretty(n)
+	}
+	switch j := n.(type) {
+	case Num:
+		if j < 0 {
+			return "−" + Pretty(-j)
+		}
+		return j.String()
+	case varNode:
+		if j == "pi" {
+			return "π"
+		}
+		return string(j)
+	case negNode:
+		return "−" + wrapped(j.x, precOf(j.x) < 3)
+	case binNode:
+		p := precOf(j)
+		lp, rp := precOf(j.l), precOf(j.r)
+		left := wrapped(j.l, lp < p || (j.op == '^' && lp <= p))
+		if j.op == '^' {
+			if s, ok := superscript(Pretty(j.r)); ok {
+				return left + s
+			}
+		}
+		right := wrapped(j.r, rp < p || (rp == p && (j.op == '-' || j.op == '/')) || (p <= 2 && isNeg(j.r)))
+		switch j.op {
+		case '+':
+			return left + " + " + right
+		case '-':
+			return left + " − " + right
+		case '*':
+			if implicitMul(j) {
+				return left + right
+			}
+			return left + "·" + right
+		}
+		return left + string(j.op) + right
+	case callNode:
+		var a []string
+		for _, k := range j.args {
+			a = append(a, Pretty(k))
+		}
+		arg := strings.Join(a, ", ")
+		switch j.fn {
+		case "sqrt":
+			if precOf(j.args[0]) == 4 {
+				return "√" + arg
+			}
+			return "√(" + arg + ")"
+		case "abs":
+			return "|" + arg + "|"
+		case "exp":
+			if s, ok := superscript(arg); ok {
+				return "e" + s
+			}
+		}
+		return j.fn + "(" + arg + ")"
+	}
+	return n.String()
+}
+
+// Returns text written in superscript characters, leaving out the spaces.  Returns false if any of it has no
+// superscript version
+func superscript(s string) (string, bool) {
+	var b strings.Builder
+	for _, c := range s {
+		if c == ' ' {
+			continue
+		}
+		u, ok := superscripts[c]
+		if !ok {
+			return "", false
+		}
+		b.WriteRune(u)
+	}
+	return b.String(), true
+}
+
+// Returns an expression as TeX, for typesetting with KaTeX or MathJax.  Divisions are written as fractions, and
+// powers, roots, and the usual functions use their TeX forms
+func TeX(n Node) string {
+	wrapped := func(n Node, brackets bool) string {
+		if brackets {
+			return `\left(` + TeX(n) + `\right)`
+		}
+		return TeX(n)
+	}
+	switch j := n.(type) {
+	case Num:
+		return strconv.FormatFloat(float64(j), 'g', -1, 64)
+	case varNode:
+		if j == "pi" {
+			return `\pi`
+		}
+		return string(j)
+	case negNode:
+		return "-" + wrapped(j.x, precOf(j.x) < 3)
+	case binNode:
+		p := precOf(j)
+		lp, rp := precOf(j.l), precOf(j.r)
+		switch j.op {
+		case '/':
+			return `\frac{` + TeX(j.l) + "}{" + TeX(j.r) + "}"
+		case '^':
+			return "{" + wrapped(j.l, lp <= p || isDiv(j.l)) + "}^{" + TeX(j.r) + "}"
+		}
+		left := wrapped(j.l, lp < p)
+		right := wrapped(j.r, rp < p || (rp == p && j.op == '-') || (p <= 2 && isNeg(j.r)))
+		switch j.op {
+		case '*':
+			if implicitMul(j) {
+				return left + " " + right
+			}
+			return left + ` \cdot ` + right
+		}
+		return left + " " + string(j.op) + " " + right
+	case callNode:
+		var a []string
+		for _, k := range j.args {
+			a = append(a, TeX(k))
+		}
+		arg := strings.Join(a, ", ")
+		switch j.fn {
+		case "sqrt":
+			return `\sqrt{` + arg + "}"
+		case "abs":
+			return `\left|` + arg + `\right|`
+		case "exp":
+			return "e^{" + arg + "}"
+		}
+		if f, ok := texFuncs[j.fn]; ok {
+			return f + `\left(` + arg + `\right)`
+		}
+		return `\operatorname{` + j.fn + `}\left(` + arg + `\right)`
+	}
+	return n.String()
+}
@@ -0,0 +1,36 @@
+package expr
+
+import "testing"
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		src, pretty, tex string
+	}{
+		{"3x^2 - 2sin(x)", "3x² − 2sin(x)", `3 {x}^{2} - 2 \sin\left(x\right)`},
+		{"x - -1", "x − (−1)", `x - \left(-1\right)`},
+		{"x + -x", "x + (−x)", `x + \left(-x\right)`},
+		{"x * -2", "x·(−2)", `x \cdot \left(-2\right)`},
+		{"-x - 1", "−x − 1", "-x - 1"},
+		{"x - (1 - x)", "x − (1 − x)", `x - \left(1 - x\right)`},
+		{"(x + 1)/(x - 1)", "(x + 1)/(x − 1)", `\frac{x + 1}{x - 1}`},
+		{"2^(x+1)", "2ˣ⁺¹", "{2}^{x + 1}"},
+		{"(1/x)^2", "(1/x)²", `{\left(\frac{1}{x}\right)}^{2}`},
+		{"sqrt(x) + sqrt(x+1)", "√x + √(x + 1)", `\sqrt{x} + \sqrt{x + 1}`},
+		{"abs(x)*pi", "|x|·π", `\left|x\right| \cdot \pi`},
+		{"exp(2x)", "e²ˣ", "e^{2 x}"},
+		{"ln(x)/log(x)", "ln(x)/log(x)", `\frac{\ln\left(x\right)}{\log\left(x\right)}`},
+	}
+	for _, tc := range tests {
+		n, err := Parse(tc.src)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.src, err)
+			continue
+		}
+		if got := Pretty(n); got != tc.pretty {
+			t.Errorf("Pretty(%q) = %q, want %q", tc.src, got, tc.pretty)
+		}
+		if got := TeX(n); got != tc.tex {
+			t.Errorf("TeX(%q) = %q, want %q", tc.src, got, tc.tex)
+		}
+	}
+}
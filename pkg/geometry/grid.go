@@ -0,0 +1,108 @@
+package geometry
+
+import (
+	"math"
+)
+
+const (
+	GridSubdivisions = 5   // Number of minor grid cells per major one
+	maxGridLines     = 400 // Most grid lines to draw in each direction
+)
+
+// A grid line, in screen co-ordinates
+type GridLine struct {
+	X1, Y1, X2, Y2 float64
+}
+
+// Works out the grid lines for the graphs' XY plane, spaced in graph units so they line up with the axis tick marks.
+// The lines cover the given screen area, and are returned in screen co-ordinates
+func Grid(m Matrix, cX float64, cY float64, unit float64, w float64, h float64, interval float64) (major []GridLine,
+	minor []GridLine) {
+	if unit <= 0 || interval <= 0 {
+		return
+	}
+
+	// Find the area of the XY plane covered by the screen area
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, c := range [][2]float64{{0, 0}, {w, 0}, {0, h}, {w, h}} {
+		x, y, ok := Unproject(m, cX, cY, unit, c[0], c[1])
+		if !ok {
+			return
+		}
+		minX, maxX = math.Min(minX, x), math.Max(maxX, x)
+		minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+	}
+
+	// Merge grid cells together when there would be too many lines to draw
+	minorStep := interval / GridSubdivisions
+	for (maxX-minX)/minorStep > maxGridLines || (maxY-minY)/minorStep > maxGridLines {
+		minorStep *= GridSubdivisions
+		interval *= GridSubdivisions
+	}
+
+	// Lines of constant X, then lines of constant Y
+	line := func(x1, y1, x2, y2 float64) GridLine {
+		sx1, sy1 := Project(m, cX, cY, unit, x1, y1, 0)
+		sx2, sy2 := Project(m, cX, cY, unit, x2, y2, 0)
+		return GridLine{sx1, sy1, sx2, sy2}
+	}
+	isMajor := func(v float64) bool {
+		r := math.Abs(math.Remainder(v, interval))
+		return r < minorStep/2
+	}
+	for x := math.Ceil(minX/minorStep) * minorStep; x <= maxX; x += minorStep {
+		if isMajor(x) {
+			major = append(major, line(x, minY, x, maxY))
+		} else {
+			minor = append(minor, line(x, minY, x, maxY))
+		}
+	}
+	for y := math.Ceil(minY/minorStep) * minorStep; y <= maxY; y += minorStep {
+		if isMajor(y) {
+			major = append(major, line(minX, y, maxX, y))
+		} else {
+			minor = append(minor, line(minX, y, maxX, y))
+		}
+	}
+	return
+}
+
+// Rounds a number up to the next "nice" interval for tick marks: 1, 2, or 5 times a power of ten
+func NiceInterval(raw float64) float64 {
+	if raw <= 0 || math.IsInf(raw, 0) || math.IsNaN(raw) {
+		return 1
+	}
+	pow := math.Pow(10, math.Floor(math.Log10(raw)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if raw <= m*pow {
+			return m * pow
+		}
+	}
+	return 10 * pow
+}
+
+// Projects a point in graph co-ordinates (before any world transforms) to screen co-ordinates, using the given world
+// transform and screen mapping
+func Project(m Matrix, cX float64, cY float64, unit float64, x float64, y float64, z float64) (float64, float64) {
+	tx, ty, _ := Apply(m, x, y, z)
+	return cX + (tx * unit), cY + ((ty * unit) * -1)
+}
+
+// Finds the point on the graphs' XY plane (Z = 0, before any world transforms) which is displayed at the given screen
+// co-ordinates.  Returns false if the plane is edge on to the viewer, so there isn't a single point
+func Unproject(m Matrix, cX float64, cY float64, unit float64, px float64, py float64) (x float64, y float64, ok bool) {
+	// Screen co-ordinates back to world space
+	wx := (px - cX) / unit
+	wy := (cY - py) / unit
+
+	// Solve wx = m0.x + m1.y + m3, wy = m4.x + m5.y + m7 for x and y
+	det := m[0]*m[5] - m[1]*m[4]
+	if math.Abs(det) < 1e-9 {
+		return 0, 0, false
+	}
+	bx, by := wx-m[3], wy-m[7]
+	x = (bx*m[5] - m[1]*by) / det
+	y = (m[0]*by - bx*m[4]) / det
+	return x, y, true
+}
@@ -0,0 +1,289 @@
+// Package geometry holds the 4x4 matrices used for transforming the graph, and the projection between graph and screen
+// co-ordinates
+package geometry
+
+import (
+	"math"
+)
+
+// A 4x4 transformation matrix, in row major order
+type Matrix []float64
+
+// Transforms the XYZ co-ordinates using the values from the transformation matrix.  The fourth row can be ignored for
+// 3D matrices
+func Apply(m Matrix, x float64, y float64, z float64) (float64, float64, float64) {
+	return (m[0] * x) + (m[1] * y) + (m[2] * z) + m[3],
+		(m[4] * x) + (m[5] * y) + (m[6] * z) + m[7],
+		(m[8] * x) + (m[9] * y) + (m[10] * z) + m[11]
+}
+
+// Returns the axis and angle (in degrees) of a rotation matrix, as a vector along the axis with the angle as its
+// length.  Rotating around that axis by that angle gives the same result as the matrix.  Returns a zero vector for
+// the identity matrix
+func AxisAngle(r Matrix) (float64, float64, float64) {
+	c := math.Max(-1, math.Min(1, (r[0]+r[5]+r[10]-1)/2))
+	angle := math.Acos(c)
+	if angle < 1e-9 {
+		return 0, 0, 0
+	}
+	deg := angle * 180 / math.Pi
+	if s := math.Sin(angle); s > 1e-6 {
+		x, y, z := (r[9]-r[6])/(2*s), (r[2]-r[8])/(2*s), (r[4]-r[1])/(2*s)
+		return x * deg, y * deg, z * deg
+	}
+
+	// Half turns don't give the axis that way, but the diagonal holds it instead (up to its sign)
+	x := math.Sqrt(math.Max(0, (r[0]+1)/2))
+	y := math.Sqrt(math.Max(0, (r[5]+1)/2))
+	z := math.Sqrt(math.Max(0, (r[10]+1)/2))
+	switch {
+	case x >= y && x >= z:
+		y, z = math.Copysign(y, r[1]+r[4]), math.Copysign(z, r[2]+r[8])
+	case y >= z:
+		x, z = math.Copysign(x, r[1]+r[4]), math.Copysign(z, r[6]+r[9])
+	default:
+		x, y = math.Copysign(x, r[2]+r[8]), math.Copysign(y, r[6]+r[9])
+	}
+	return x * deg, y * deg, z * deg
+}
+
+// Returns the determinant of a 4x4 matrix, expanded along its bottom row.  For transformation matrices this is the
+// factor volumes are scaled by, and is zero when the matrix squashes everything flat
+func Determinant(m Matrix) float64 {
+	det := 0.0
+	sign := -1.0 // The bottom row's cofactor signs start negative
+	for col := 0; col < 4; col++ {
+		if m[12+col] != 0 {
+			det += sign * m[12+col] * minor(m, 3, col)
+		}
+		sign = -sign
+	}
+	return det
+}
+
+// Returns a new 4x4 identity matrix
+func Identity() Matrix {
+	return Matrix{
+		1, 0, 0, 0,
+		0, 1, 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	}
+}
+
+// Returns the inverse of a Matrix, for mapping world space co-ordinates back to graph ones.  It's worked out from the
+// cofactors, so any matrix with a non-zero determinant can be inverted, not just the rotations, scales, and
+// translations the transform operations produce.  Returns false if the Matrix can't be inverted, such as after being
+// scaled to zero
+func Invert(m Matrix) (inv Matrix, ok bool) {
+	det := Determinant(m)
+	if math.Abs(det) < 1e-12 {
+		return Identity(), false
+	}
+
+	// The inverse is the transpose of the cofactor matrix, divided by the determinant
+	inv = make(Matrix, 16)
+	for row := 0; row < 4; row++ {
+		for col := 0; col < 4; col++ {
+			c := minor(m, row, col) / det
+			if (row+col)%2 == 1 {
+				c = -c
+			}
+			inv[col*4+row] = c
+		}
+	}
+	return inv, true
+}
+
+// Returns the determinant of the 3x3 matrix left after removing a row and a column from a 4x4 one
+func minor(m Matrix, row int, col int) float64 {
+	var r, c [3]int
+	for i, nr, nc := 0, 0, 0; i < 4; i++ {
+		if i != row {
+			r[nr] = i * 4
+			nr++
+		}
+		if i != col {
+			c[nc] = i
+			nc++
+		}
+	}
+	at := func(i, j int) float64 { return m[r[i]+c[j]] }
+	return at(0, 0)*(at(1, 1)*at(2, 2)-at(1, 2)*at(2, 1)) - at(0, 1)*(at(1, 0)*at(2, 2)-at(1, 2)*at(2, 0)) +
+		at(0, 2)*(at(1, 0)*at(2, 1)-at(1, 1)*at(2, 0))
+}
+
+// Multiplies one Matrix by another
+func Multiply(opMatrix Matrix, m Matrix) (resultMatrix Matrix) {
+	top0 := m[0]
+	top1 := m[1]
+	top2 := m[2]
+	top3 := m[3]
+	upperMid0 := m[4]
+	upperMid1 := m[5]
+	upperMid2 := m[6]
+	upperMid3 := m[7]
+	lowerMid0 := m[8]
+	lowerMid1 := m[9]
+	lowerMid2 := m[10]
+	lowerMid3 := m[11]
+	bot0 := m[12]
+	bot1 := m[13]
+	bot2 := m[14]
+	bot3 := m[15]
+
+	resultMatrix = Matrix{
+		(opMatrix[0] * top0) + (opMatrix[1] * upperMid0) + (opMatrix[2] * lowerMid0) + (opMatrix[3] * bot0), // 1st col, top
+		(opMatrix[0] * top1) + (opMatrix[1] * upperMid1) + (opMatrix[2] * lowerMid1) + (opMatrix[3] * bot1), // 2nd col, top
+		(opMatrix[0] * top2) + (opMatrix[1] * upperMid2) + (opMatrix[2] * lowerMid2) + (opMatrix[3] * bot2), // 3rd col, top
+		(opMatrix[0] * top3) + (opMatrix[1] * upperMid3) + (opMatrix[2] * lowerMid3) + (opMatrix[3] * bot3), // 4th col, top
+
+		(opMatrix[4] * top0) + (opMatrix[5] * upperMid0) + (opMatrix[6] * lowerMid0) + (opMatrix[7] * bot0), // 1st col, upper middle
+		(opMatrix[4] * top1) + (opMatrix[5] * upperMid1) + (opMatrix[6] * lowerMid1) + (opMatrix[7] * bot1), // 2nd col, upper middle
+		(opMatrix[4] * top2) + (opMatrix[5] * upperMid2) + (opMatrix[6] * lowerMid2) + (opMatrix[7] * bot2), // 3rd col, upper middle
+		(opMatrix[4] * top3) + (opMatrix[5] * upperMid3) + (opMatrix[6] * lowerMid3) + (opMatrix[7] * bot3), // 4th col, upper middle
+
+		(opMatrix[8] * top0) + (opMatrix[9] * upperMid0) + (opMatrix[10] * lowerMid0) + (opMatrix[11] * bot0), // 1st col, lower middle
+		(opMatrix[8] * top1) + (opMatrix[9] * upperMid1) + (opMatrix[10] * lowerMid1) + (opMatrix[11] * bot1), // 2nd col, lower middle
+		(opMatrix[8] * top2) + (opMatrix[9] * upperMid2) + (opMatrix[10] * lowerMid2) + (opMatrix[11] * bot2), // 3rd col, lower middle
+		(opMatrix[8] * top3) + (opMatrix[9] * upperMid3) + (opMatrix[10] * lowerMid3) + (opMatrix[11] * bot3), // 4th col, lower middle
+
+		(opMatrix[12] * top0) + (opMatrix[13] * upperMid0) + (opMatrix[14] * lowerMid0) + (opMatrix[15] * bot0), // 1st col, bottom
+		(opMatrix[12] * top1) + (opMatrix[13] * upperMid1) + (opMatrix[14] * lowerMid1) + (opMatrix[15] * bot1), // 2nd col, bottom
+		(opMatrix[12] * top2) + (opMatrix[13] * upperMid2) + (opMatrix[14] * lowerMid2) + (opMatrix[15] * bot2), // 3rd col, bottom
+		(opMatrix[12] * top3) + (opMatrix[13] * upperMid3) + (opMatrix[14] * lowerMid3) + (opMatrix[15] * bot3), // 4th col, bottom
+	}
+	return resultMatrix
+}
+
+// Multiplies a matrix by a column vector of homogeneous co-ordinates.  Apply does the same for points, where the
+// fourth co-ordinate is always one
+func MultiplyVector(m Matrix, v [4]float64) (r [4]float64) {
+	for row := 0; row < 4; row++ {
+		r[row] = m[row*4]*v[0] + m[row*4+1]*v[1] + m[row*4+2]*v[2] + m[row*4+3]*v[3]
+	}
+	return
+}
+
+// Rotates a transformation matrix around an axis through the origin, by the given degrees.  The axis doesn't need to
+// be a unit vector
+func RotateAroundAxis(m Matrix, x float64, y float64, z float64, degrees float64) Matrix {
+	l := math.Sqrt(x*x + y*y + z*z)
+	if l == 0 {
+		return m
+	}
+	x, y, z = x/l, y/l, z/l
+	rad := (math.Pi / 180) * degrees
+	s, c := math.Sincos(rad)
+	t := 1 - c
+	rotateMatrix := Matrix{
+		t*x*x + c, t*x*y - s*z, t*x*z + s*y, 0,
+		t*x*y + s*z, t*y*y + c, t*y*z - s*x, 0,
+		t*x*z - s*y, t*y*z + s*x, t*z*z + c, 0,
+		0, 0, 0, 1,
+	}
+	return Multiply(rotateMatrix, m)
+}
+
+// Rotates a transformation Matrix around the X axis by the given degrees
+func RotateAroundX(m Matrix, degrees float64) Matrix {
+	rad := (math.Pi / 180) * degrees // The Go math functions use radians, so we convert degrees to radians
+	s, c := math.Sincos(rad)
+	rotateXMatrix := Matrix{
+		1, 0, 0, 0,
+		0, c, -s, 0,
+		0, s, c, 0,
+		0, 0, 0, 1,
+	}
+	return Multiply(rotateXMatrix, m)
+}
+
+// Rotates a transformation Matrix around the Y axis by the given degrees
+func RotateAroundY(m Matrix, degrees float64) Matrix {
+	rad := (math.Pi / 180) * degrees // The Go math functions use radians, so we convert degrees to radians
+	s, c := math.Sincos(rad)
+	rotateYMatrix := Matrix{
+		c, 0, s, 0,
+		0, 1, 0, 0,
+		-s, 0, c, 0,
+		0, 0, 0, 1,
+	}
+	return Multiply(rotateYMatrix, m)
+}
+
+// Rotates a transformation Matrix around the Z axis by the given degrees
+func RotateAroundZ(m Matrix, degrees float64) Matrix {
+	rad := (math.Pi / 180) * degrees // The Go math functions use radians, so we convert degrees to radians
+	s, c := math.Sincos(rad)
+	rotateZMatrix := Matrix{
+		c, -s, 0, 0,
+		s, c, 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	}
+	return Multiply(rotateZMatrix, m)
+}
+
+// Returns just the rotation part of a transformation matrix, without any scaling or translation
+func Rotation(m Matrix) Matrix {
+	// The columns of the upper left 3x3 part are the transformed axes, so make them unit length and at right angles
+	// to each other again
+	col := func(i int) [3]float64 { return [3]float64{m[i], m[4+i], m[8+i]} }
+	dot := func(a, b [3]float64) float64 { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+	norm := func(a [3]float64) [3]float64 {
+		l := math.Sqrt(dot(a, a))
+		if l == 0 {
+			return a
+		}
+		return [3]float64{a[0] / l, a[1] / l, a[2] / l}
+	}
+	x := norm(col(0))
+	y := col(1)
+	d := dot(x, y)
+	y = norm([3]float64{y[0] - d*x[0], y[1] - d*x[1], y[2] - d*x[2]})
+	z := [3]float64{x[1]*y[2] - x[2]*y[1], x[2]*y[0] - x[0]*y[2], x[0]*y[1] - x[1]*y[0]}
+	return Matrix{
+		x[0], y[0], z[0], 0,
+		x[1], y[1], z[1], 0,
+		x[2], y[2], z[2], 0,
+		0, 0, 0, 1,
+	}
+}
+
+// Scales a transformation Matrix by the given X, Y, and Z values
+func Scale(m Matrix, x float64, y float64, z float64) Matrix {
+	scaleMatrix := Matrix{
+		x, 0, 0, 0,
+		0, y, 0, 0,
+		0, 0, z, 0,
+		0, 0, 0, 1,
+	}
+	return Multiply(scaleMatrix, m)
+}
+
+// Translates (moves) a transformation Matrix by the given X, Y and Z values
+func Translate(m Matrix, translateX float64, translateY float64, translateZ float64) Matrix {
+	translateMatrix := Matrix{
+		1, 0, 0, translateX,
+		0, 1, 0, translateY,
+		0, 0, 1, translateZ,
+		0, 0, 0, 1,
+	}
+	return Multiply(translateMatrix, m)
+}
+
+// Returns the transpose of a matrix.  For rotation matrices, this is their inverse
+func Transpose(m Matrix) Matrix {
+	t := make(Matrix, 16)
+	for r := 0; r < 4; r++ {
+		for c := 0; c < 4; c++ {
+			t[c*4+r] = m[r*4+c]
+		}
+	}
+	return t
+}
+
+// Returns the zoom level of a transformation matrix, from the length of its transformed X axis
+func Zoom(m Matrix) float64 {
+	return math.Sqrt(m[0]*m[0] + m[4]*m[4] + m[8]*m[8])
+}
@@ -0,0 +1,162 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+)
+
+// Returns true when two matrices match to within tol
+func matrixNear(a Matrix, b Matrix, tol float64) bool {
+	if len(a) != 16 || len(b) != 16 {
+		return false
+	}
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+// A transformation matrix made of all the operations: rotating, scaling, and translating
+func testTransform() Matrix {
+	m := RotateAroundAxis(Identity(), 1, 2, -0.5, 37)
+	m = Scale(m, 2, 0.5, 3)
+	m = RotateAroundX(m, -70)
+	return Translate(m, 4, -1, 2.5)
+}
+
+func TestDeterminant(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Matrix
+		want float64
+	}{
+		{"identity", Identity(), 1},
+		{"rotation", RotateAroundY(RotateAroundX(Identity(), 30), 45), 1},
+		{"scale", Scale(Identity(), 2, 3, 4), 24},
+		{"mirror", Scale(Identity(), -1, 1, 1), -1},
+		{"flattened", Scale(Identity(), 1, 0, 1), 0},
+		{"translation", Translate(Identity(), 5, -2, 7), 1},
+		{"transform", testTransform(), 3},
+		{"general", Matrix{2, -1, 0, 3, 1, 4, 2, -2, 0, 5, -3, 1, 6, 1, 2, 7}, -62},
+		{"dependent rows", Matrix{1, 2, 3, 4, 2, 4, 6, 8, 0, 1, 0, 1, 1, 0, 1, 0}, 0},
+	}
+	for _, tc := range tests {
+		if got := Determinant(tc.m); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%s: Determinant = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestInvert(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Matrix
+	}{
+		{"identity", Identity()},
+		{"rotation", RotateAroundZ(RotateAroundY(Identity(), 120), -15)},
+		{"scale", Scale(Identity(), 0.25, 8, -2)},
+		{"translation", Translate(Identity(), 5, -2, 7)},
+		{"transform", testTransform()},
+		{"projective", Matrix{2, -1, 0, 3, 1, 4, 2, -2, 0, 5, -3, 1, 6, 1, 2, 7}},
+	}
+	for _, tc := range tests {
+		inv, ok := Invert(tc.m)
+		if !ok {
+			t.Errorf("%s: couldn't be inverted", tc.name)
+			continue
+		}
+		if p := Multiply(inv, tc.m); !matrixNear(p, Identity(), 1e-12) {
+			t.Errorf("%s: inverse times matrix = %v, want the identity", tc.name, p)
+		}
+		if p := Multiply(tc.m, inv); !matrixNear(p, Identity(), 1e-12) {
+			t.Errorf("%s: matrix times inverse = %v, want the identity", tc.name, p)
+		}
+	}
+}
+
+func TestInvertSingular(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Matrix
+	}{
+		{"zero scale", Scale(Identity(), 0, 0, 0)},
+		{"flattened", Scale(testTransform(), 1, 0, 1)},
+		{"dependent rows", Matrix{1, 2, 3, 0, 2, 4, 6, 0, 0, 1, 5, 0, 0, 0, 0, 1}},
+		{"dependent bottom row", Matrix{1, 2, 3, 4, 0, 1, 0, 1, 1, 0, 1, 0, 2, 4, 6, 8}},
+	}
+	for _, tc := range tests {
+		inv, ok := Invert(tc.m)
+		if ok {
+			t.Errorf("%s: inverted to %v, want it not to be invertible", tc.name, inv)
+		}
+		if !matrixNear(inv, Identity(), 0) {
+			t.Errorf("%s: gave %v, want the identity", tc.name, inv)
+		}
+	}
+}
+
+func TestMultiplyVector(t *testing.T) {
+	m := testTransform()
+	x, y, z := 1.5, -2.0, 0.75
+	r := MultiplyVector(m, [4]float64{x, y, z, 1})
+	ax, ay, az := Apply(m, x, y, z)
+	if math.Abs(r[0]-ax) > 1e-12 || math.Abs(r[1]-ay) > 1e-12 || math.Abs(r[2]-az) > 1e-12 || r[3] != 1 {
+		t.Errorf("MultiplyVector = %v, want (%v, %v, %v, 1) like Apply", r, ax, ay, az)
+	}
+
+	// Directions, with a fourth co-ordinate of zero, aren't moved by translations
+	d := MultiplyVector(Translate(Identity(), 5, 6, 7), [4]float64{1, 2, 3, 0})
+	if d != [4]float64{1, 2, 3, 0} {
+		t.Errorf("translated direction = %v, want it unchanged", d)
+	}
+
+	g := MultiplyVector(Matrix{2, -1, 0, 3, 1, 4, 2, -2, 0, 5, -3, 1, 6, 1, 2, 7}, [4]float64{1, 2, 3, 4})
+	if g != [4]float64{12, 7, 5, 42} {
+		t.Errorf("MultiplyVector = %v, want [12 7 5 42]", g)
+	}
+}
+
+func TestTranspose(t *testing.T) {
+	m := Matrix{2, -1, 0, 3, 1, 4, 2, -2, 0, 5, -3, 1, 6, 1, 2, 7}
+	want := Matrix{2, 1, 0, 6, -1, 4, 5, 1, 0, 2, -3, 2, 3, -2, 1, 7}
+	if got := Transpose(m); !matrixNear(got, want, 0) {
+		t.Errorf("Transpose = %v, want %v", got, want)
+	}
+	if got := Transpose(Transpose(m)); !matrixNear(got, m, 0) {
+		t.Errorf("transposing twice gave %v, want %v", got, m)
+	}
+
+	// A rotation's transpose is its inverse
+	r := RotateAroundAxis(Identity(), 1, -1, 2, 75)
+	if p := Multiply(Transpose(r), r); !matrixNear(p, Identity(), 1e-12) {
+		t.Errorf("rotation's transpose times itself = %v, want the identity", p)
+	}
+}
+
+var benchSink Matrix // Keeps the compiler from optimising the benchmarks away
+
+func BenchmarkMultiply(b *testing.B) {
+	m := RotateAroundY(RotateAroundX(Identity(), 30), 45)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchSink = Multiply(m, m)
+	}
+}
+
+func BenchmarkRotateAroundAxis(b *testing.B) {
+	m := RotateAroundY(RotateAroundX(Identity(), 30), 45)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchSink = RotateAroundAxis(m, 1, 1, 0, 5)
+	}
+}
+
+func BenchmarkRotateAroundY(b *testing.B) {
+	m := RotateAroundY(RotateAroundX(Identity(), 30), 45)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		benchSink = RotateAroundY(m, 5)
+	}
+}
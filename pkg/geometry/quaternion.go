@@ -0,0 +1,101 @@
+package geometry
+
+import (
+	"math"
+)
+
+// A rotation, as a unit quaternion.  Rotations are combined as quaternions and only turned into a matrix when it's
+// time to transform something, so long runs of small rotations don't build up the skew and shrinking that repeatedly
+// multiplying rotation matrices does
+type Quaternion struct {
+	W, X, Y, Z float64
+}
+
+// Returns the axis and angle (in degrees) of the rotation, as a vector along the axis with the angle as its length.
+// The shorter way round is always used, so the angle is at most 180°.  Returns a zero vector for no rotation
+func (q Quaternion) AxisAngle() (float64, float64, float64) {
+	q = q.Normalize()
+	if q.W < 0 {
+		q = Quaternion{W: -q.W, X: -q.X, Y: -q.Y, Z: -q.Z} // The same rotation, the other way round
+	}
+	s := math.Sqrt(q.X*q.X + q.Y*q.Y + q.Z*q.Z)
+	if s < 1e-12 {
+		return 0, 0, 0
+	}
+	deg := 2 * math.Atan2(s, q.W) * 180 / math.Pi
+	return q.X / s * deg, q.Y / s * deg, q.Z / s * deg
+}
+
+// Returns the quaternion rotating around an axis through the origin by the given degrees.  The axis doesn't need to
+// be a unit vector.  Returns the identity rotation for a zero length axis
+func AxisQuaternion(x float64, y float64, z float64, degrees float64) Quaternion {
+	l := math.Sqrt(x*x + y*y + z*z)
+	if l == 0 {
+		return IdentityQuaternion()
+	}
+	half := (math.Pi / 180) * degrees / 2
+	s := math.Sin(half) / l
+	return Quaternion{W: math.Cos(half), X: x * s, Y: y * s, Z: z * s}
+}
+
+// Returns the opposite rotation
+func (q Quaternion) Conjugate() Quaternion {
+	return Quaternion{W: q.W, X: -q.X, Y: -q.Y, Z: -q.Z}
+}
+
+// Returns the quaternion for no rotation
+func IdentityQuaternion() Quaternion {
+	return Quaternion{W: 1}
+}
+
+// Returns the rotation as a 4x4 transformation matrix
+func (q Quaternion) Matrix() Matrix {
+	q = q.Normalize()
+	w, x, y, z := q.W, q.X, q.Y, q.Z
+	return Matrix{
+		1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y), 0,
+		2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x), 0,
+		2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y), 0,
+		0, 0, 0, 1,
+	}
+}
+
+// Returns the rotation part of a transformation matrix as a quaternion.  Any scaling is removed first
+func MatrixQuaternion(m Matrix) Quaternion {
+	r := Rotation(m)
+	var q Quaternion
+	switch tr := r[0] + r[5] + r[10]; {
+	case tr > 0:
+		s := 2 * math.Sqrt(tr+1)
+		q = Quaternion{W: s / 4, X: (r[9] - r[6]) / s, Y: (r[2] - r[8]) / s, Z: (r[4] - r[1]) / s}
+	case r[0] > r[5] && r[0] > r[10]:
+		s := 2 * math.Sqrt(1+r[0]-r[5]-r[10])
+		q = Quaternion{W: (r[9] - r[6]) / s, X: s / 4, Y: (r[1] + r[4]) / s, Z: (r[2] + r[8]) / s}
+	case r[5] > r[10]:
+		s := 2 * math.Sqrt(1+r[5]-r[0]-r[10])
+		q = Quaternion{W: (r[2] - r[8]) / s, X: (r[1] + r[4]) / s, Y: s / 4, Z: (r[6] + r[9]) / s}
+	default:
+		s := 2 * math.Sqrt(1+r[10]-r[0]-r[5])
+		q = Quaternion{W: (r[4] - r[1]) / s, X: (r[2] + r[8]) / s, Y: (r[6] + r[9]) / s, Z: s / 4}
+	}
+	return q.Normalize()
+}
+
+// Returns the quaternion scaled back to unit length, undoing any rounding errors built up by combining rotations
+func (q Quaternion) Normalize() Quaternion {
+	l := math.Sqrt(q.W*q.W + q.X*q.X + q.Y*q.Y + q.Z*q.Z)
+	if l == 0 {
+		return IdentityQuaternion()
+	}
+	return Quaternion{W: q.W / l, X: q.X / l, Y: q.Y / l, Z: q.Z / l}
+}
+
+// Returns the rotation q followed by the rotation r, the same as multiplying their matrices r × q
+func (q Quaternion) Then(r Quaternion) Quaternion {
+	return Quaternion{
+		W: r.W*q.W - r.X*q.X - r.Y*q.Y - r.Z*q.Z,
+		X: r.W*q.X + r.X*q.W + r.Y*q.Z - r.Z*q.Y,
+		Y: r.W*q.Y - r.X*q.Z + r.Y*q.W + r.Z*q.X,
+		Z: r.W*q.Z + r.X*q.Y - r.Y*q.X + r.Z*q.W,
+	}
+}
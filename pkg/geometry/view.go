@@ -0,0 +1,84 @@
+package geometry
+
+import (
+	"math"
+)
+
+// The world transform kept as its parts: an orientation, then a zoom along each of the screen's axes, then a shift.
+// Rotations are combined into the orientation as quaternions, so its matrix is always a true rotation however many
+// small turns it's built up from, and never drifts into skewing or shrinking the graph
+type View struct {
+	Turn  Quaternion
+	Zoom  [3]float64 // Scale along the X, Y, and Z axes, after turning
+	Shift [3]float64 // Movement along the X, Y, and Z axes, after zooming
+}
+
+// Returns the view which doesn't change anything
+func IdentityView() View {
+	return View{Turn: IdentityQuaternion(), Zoom: [3]float64{1, 1, 1}}
+}
+
+// Returns the view as a transformation matrix
+func (v View) Matrix() Matrix {
+	m := v.Turn.Matrix()
+	for r := 0; r < 3; r++ {
+		for c := 0; c < 3; c++ {
+			m[r*4+c] *= v.Zoom[r]
+		}
+		m[r*4+3] = v.Shift[r]
+	}
+	return m
+}
+
+// Returns the view giving a transformation matrix, such as one saved with a scene.  The rows of its upper left 3x3
+// part are taken as the turned axes scaled by the zoom.  A mirrored matrix has its Z zoom made negative, as a
+// quaternion can only hold a rotation
+func MatrixView(m Matrix) View {
+	var v View
+	r := Identity()
+	for row := 0; row < 3; row++ {
+		l := math.Sqrt(m[row*4]*m[row*4] + m[row*4+1]*m[row*4+1] + m[row*4+2]*m[row*4+2])
+		v.Zoom[row] = l
+		v.Shift[row] = m[row*4+3]
+		if l > 0 {
+			for c := 0; c < 3; c++ {
+				r[row*4+c] = m[row*4+c] / l
+			}
+		}
+	}
+	if Determinant(r) < 0 {
+		v.Zoom[2] = -v.Zoom[2]
+		r[8], r[9], r[10] = -r[8], -r[9], -r[10]
+	}
+	v.Turn = MatrixQuaternion(r)
+	return v
+}
+
+// Returns the view turned by a rotation, the same as multiplying its matrix by the rotation's.  The shift turns along
+// with everything else, while the zoom stays along the screen's axes.  That's exactly the same while the zoom is the
+// same along each axis, which it is unless the API has scaled them differently
+func (v View) Rotate(q Quaternion) View {
+	m := q.Matrix()
+	x, y, z := Apply(m, v.Shift[0], v.Shift[1], v.Shift[2])
+	return View{Turn: v.Turn.Then(q).Normalize(), Zoom: v.Zoom, Shift: [3]float64{x, y, z}}
+}
+
+// Returns the view with a scale and translation applied after it, such as a step of a zoom or pan operation.  Any
+// other transform is worked out through the matrices instead
+func (v View) Transform(step Matrix) View {
+	for r := 0; r < 4; r++ {
+		for c := 0; c < 3; c++ {
+			if r != c && step[r*4+c] != 0 {
+				return MatrixView(Multiply(step, v.Matrix()))
+			}
+		}
+	}
+	if step[15] != 1 {
+		return MatrixView(Multiply(step, v.Matrix()))
+	}
+	for i := 0; i < 3; i++ {
+		v.Zoom[i] *= step[i*5]
+		v.Shift[i] = step[i*5]*v.Shift[i] + step[i*4+3]
+	}
+	return v
+}
@@ -0,0 +1,71 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMatrixView(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Matrix
+	}{
+		{"identity", Identity()},
+		{"rotation", RotateAroundZ(RotateAroundY(Identity(), 120), -15)},
+		{"zoomed", Translate(Scale(RotateAroundX(Identity(), 40), 3, 3, 3), 1, 2, -3)},
+		{"stretched", Scale(RotateAroundAxis(Identity(), 1, 1, 0, 25), 2, 0.5, 4)},
+		{"mirror", Scale(RotateAroundY(Identity(), 60), 1, 1, -2)},
+	}
+	for _, tc := range tests {
+		if got := MatrixView(tc.m).Matrix(); !matrixNear(got, tc.m, 1e-9) {
+			t.Errorf("%s: MatrixView(m).Matrix() = %v, want %v", tc.name, got, tc.m)
+		}
+	}
+}
+
+// Lots of small turns, zooms, and moves, like a long session of holding the keys down, should leave the view's
+// matrix matching the same transformations multiplied together, while its rotation stays a true rotation
+func TestViewSteps(t *testing.T) {
+	v, want := IdentityView(), Identity()
+	for i := 0; i < 20000; i++ {
+		q := AxisQuaternion(1, 0, 0, 0.7).Then(AxisQuaternion(0, 1, 0, -0.3)).Then(AxisQuaternion(0.2, 0.4, 1, 0.11))
+		v, want = v.Rotate(q), Multiply(q.Matrix(), want)
+		if i%1000 == 0 {
+			z := 1.01
+			if i%2000 == 0 {
+				z = 1 / 1.01
+			}
+			step := Translate(Scale(Identity(), z, z, z), 0.1, -0.05, 0.02)
+			v, want = v.Transform(step), Multiply(step, want)
+		}
+	}
+	if got := v.Matrix(); !matrixNear(got, want, 1e-6) {
+		t.Errorf("Matrix() = %v, want %v", got, want)
+	}
+	r := v.Turn.Matrix()
+	if d := Determinant(r); math.Abs(d-1) > 1e-12 {
+		t.Errorf("Determinant of the turn = %v, want 1", d)
+	}
+	if got := Multiply(Transpose(r), r); !matrixNear(got, Identity(), 1e-12) {
+		t.Errorf("turn times its transpose = %v, want the identity", got)
+	}
+}
+
+func TestViewTransform(t *testing.T) {
+	v := MatrixView(Translate(RotateAroundY(Identity(), 30), 1, -2, 0.5))
+	tests := []struct {
+		name string
+		step Matrix
+	}{
+		{"zoom", Scale(Identity(), 1.5, 1.5, 1.5)},
+		{"stretch", Scale(Identity(), 2, 1, 0.5)},
+		{"pan", Translate(Identity(), 0.3, 0, -4)},
+		{"rotation", RotateAroundX(Identity(), 15)},
+	}
+	for _, tc := range tests {
+		want := Multiply(tc.step, v.Matrix())
+		if got := v.Transform(tc.step).Matrix(); !matrixNear(got, want, 1e-9) {
+			t.Errorf("%s: Transform = %v, want %v", tc.name, got, want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package render
+
+import (
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	backfaceShade = 0.55 // Brightness of the backs of surfaces, as a fraction of their colour
+)
+
+var (
+	// CSS colour names understood when rasterising.  This covers the ones used by the built in palettes and themes,
+	// along with the other common ones
+	colourNames = map[string]uint32{
+		"black": 0x000000, "white": 0xffffff, "grey": 0x808080, "gray": 0x808080, "silver": 0xc0c0c0,
+		"red": 0xff0000, "green": 0x008000, "blue": 0x0000ff, "yellow": 0xffff00, "orange": 0xffa500,
+		"purple": 0x800080, "pink": 0xffc0cb, "brown": 0xa52a2a, "cyan": 0x00ffff, "magenta": 0xff00ff,
+		"lime": 0x00ff00, "maroon": 0x800000, "navy": 0x000080, "olive": 0x808000, "teal": 0x008080,
+		"aqua": 0x00ffff, "fuchsia": 0xff00ff, "gold": 0xffd700, "indigo": 0x4b0082, "violet": 0xee82ee,
+		"orchid": 0xda70d6, "turquoise": 0x40e0d0, "saddlebrown": 0x8b4513, "peru": 0xcd853f,
+		"crimson": 0xdc143c, "hotpink": 0xff69b4, "darkolivegreen": 0x556b2f, "yellowgreen": 0x9acd32,
+		"steelblue": 0x4682b4, "darkviolet": 0x9400d3, "darkcyan": 0x008b8b, "darkgoldenrod": 0xb8860b,
+		"firebrick": 0xb22222, "darkorange": 0xff8c00, "darkslategray": 0x2f4f4f, "darkslategrey": 0x2f4f4f,
+		"mediumvioletred": 0xc71585, "dodgerblue": 0x1e90ff, "darkred": 0x8b0000, "darkgreen": 0x006400,
+		"darkblue": 0x00008b, "lightgrey": 0xd3d3d3, "lightgray": 0xd3d3d3, "darkgrey": 0xa9a9a9,
+		"darkgray": 0xa9a9a9, "tomato": 0xff6347, "coral": 0xff7f50, "salmon": 0xfa8072, "tan": 0xd2b48c,
+		"chocolate": 0xd2691e, "seagreen": 0x2e8b57, "forestgreen": 0x228b22, "royalblue": 0x4169e1,
+		"slateblue": 0x6a5acd, "skyblue": 0x87ceeb,
+	}
+)
+
+// Returns the colour to fill a face of an object with.  Flat shaded objects are darker where the light falls less
+// directly, faces turned away from the viewer are darker again, and translucent objects' faces are translucent
+func FaceColour(o scene.Object, f scene.Face) string {
+	shade := 1.0
+	if o.Shading == "flat" {
+		shade = lightLevel(o, f)
+	}
+	if f.Back {
+		shade *= backfaceShade
+	}
+	alpha := scene.Opacity(o)
+	c, ok := parseColour(o.C)
+	if (shade == 1 && alpha == 1) || !ok {
+		return o.C
+	}
+	return fmt.Sprintf("rgba(%d, %d, %d, %g)", int(float64(c.R)*shade), int(float64(c.G)*shade),
+		int(float64(c.B)*shade), alpha*float64(c.A)/255)
+}
+
+// Converts a CSS colour into an RGBA one.  Named colours, "#rgb", "#rrggbb", "rgb(...)", and "rgba(...)" are
+// understood.  Returns false for anything else
+func parseColour(s string) (color.RGBA, bool) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if v, ok := colourNames[s]; ok {
+		return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 255}, true
+	}
+	if strings.HasPrefix(s, "#") {
+		h := s[1:]
+		if len(h) == 3 {
+			h = string([]byte{h[0], h[0], h[1], h[1], h[2], h[2]})
+		}
+		v, err := strconv.ParseUint(h, 16, 32)
+		if err != nil || len(h) != 6 {
+			return color.RGBA{}, false
+		}
+		return color.RGBA{uint8(v >> 16), uint8(v >> 8), uint8(v), 255}, true
+	}
+	open, end := strings.Index(s, "("), strings.LastIndex(s, ")")
+	if open < 0 || end < open || (s[:open] != "rgb" && s[:open] != "rgba") {
+		return color.RGBA{}, false
+	}
+	parts := strings.Split(s[open+1:end], ",")
+	if len(parts) < 3 || len(parts) > 4 {
+		return color.RGBA{}, false
+	}
+	var v [4]float64
+	v[3] = 1
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return color.RGBA{}, false
+		}
+		v[i] = f
+	}
+	clamp := func(f float64, max float64) uint8 {
+		if f < 0 {
+			f = 0
+		} else if f > max {
+			f = max
+		}
+		return uint8(f*255/max + 0.5)
+	}
+	return color.RGBA{clamp(v[0], 255), clamp(v[1], 255), clamp(v[2], 255), clamp(v[3], 1)}, true
+}
+
+// Returns a translucent version of a CSS colour, for shading.  Colours which can't be understood are returned as they
+// are
+func Translucent(s string, alpha float64) string {
+	c, ok := parseColour(s)
+	if !ok {
+		return s
+	}
+	return fmt.Sprintf("rgba(%d, %d, %d, %g)", c.R, c.G, c.B, alpha*float64(c.A)/255)
+}
@@ -0,0 +1,209 @@
+package render
+
+import (
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+// Everything needed to draw the graph area of a frame.  Sizes and positions are in CSS pixels
+type Frame struct {
+	Objects      []scene.Object  // The world space objects, in draw order
+	Matrix       geometry.Matrix // The world transform, for the grid and for undoing in the label templates
+	Theme        Theme
+	Left, Top    float64 // Top left corner of the graph area
+	W, H         float64 // Bottom right corner of the graph area
+	CX, CY       float64 // Where the origin is on screen
+	Unit         float64 // Pixels per graph unit
+	GridInterval float64 // Graph units between the minor grid lines
+	Grid         bool    // Draw the grid
+	PathLabels   bool    // Label the curves along their paths, rather than with point labels
+}
+
+// Draws the grid, objects, and curves in the graph area.  Returns the number of points drawn, and the number of point
+// labels left out as there was nowhere clear to put them
+func DrawGraph(r Renderer, f Frame) (points int, hiddenLabels int) {
+	// Draw grid lines.  These are in graph units on the XY plane, so they rotate and zoom along with everything else,
+	// with the minor lines merging away or appearing as the zoom level changes
+	var major, minor []geometry.GridLine
+	if f.Grid {
+		major, minor = geometry.Grid(f.Matrix, f.CX, f.CY, f.Unit, f.W, f.H, f.GridInterval)
+	}
+	r.Save()
+	r.BeginPath()
+	r.Rect(f.Left, f.Top, f.W-f.Left, f.H-f.Top)
+	r.Clip()
+	r.SetLineDash(1, 3)
+	var minorPath, majorPath Path
+	for _, l := range minor {
+		minorPath.Line(l.X1, l.Y1, l.X2, l.Y2)
+	}
+	for _, l := range major {
+		majorPath.Line(l.X1, l.Y1, l.X2, l.Y2)
+	}
+	r.SetStrokeStyle(f.Theme.GridMinor)
+	r.StrokePath(&minorPath)
+	r.SetStrokeStyle(f.Theme.GridMajor)
+	r.StrokePath(&majorPath)
+	r.Restore()
+
+	// Draw the axes.  Label templates show graph co-ordinates, so need the world transform undone.  Translucent objects
+	// are drawn after the opaque ones, so they're blended over everything behind them
+	inv, _ := geometry.Invert(f.Matrix)
+	r.SetStrokeStyle(f.Theme.Foreground)
+	r.SetLineWidth(1)
+	r.SetLineDash()
+	objs := f.Objects
+	for _, i := range scene.SurfaceOrder(objs) {
+		o := objs[i]
+		if o.Hidden {
+			continue
+		}
+		pts := ScreenPoints(o, f.CX, f.CY, f.Unit)
+		points += len(pts)
+
+		// Draw the surfaces, then the edges, each object's all at once.  Surfaces are only split up where they're
+		// shaded in different colours
+		var surfaces, edges Path
+		faces := scene.Faces(o)
+		fills := make([]string, len(faces))
+		for i, fc := range faces {
+			fills[i] = FaceColour(o, fc)
+		}
+		for i, fc := range faces {
+			poly := make([][2]float64, len(fc.S))
+			for m, n := range fc.S {
+				poly[m] = pts[n]
+			}
+			surfaces.Polygon(poly)
+			if i == len(faces)-1 || fills[i+1] != fills[i] {
+				r.SetFillStyle(fills[i])
+				r.FillPath(&surfaces)
+				surfaces = Path{}
+			}
+		}
+		for _, l := range o.E {
+			edges.Line(pts[l[0]][0], pts[l[0]][1], pts[l[1]][0], pts[l[1]][1])
+		}
+		r.SetGlobalAlpha(scene.Opacity(o))
+		r.SetLineWidth(scene.LineWidth(o, 1))
+		r.SetLineDash(o.DashPattern...)
+		r.StrokePath(&edges)
+		r.SetLineDash()
+		r.SetGlobalAlpha(1)
+	}
+
+	// Draw the graph and derivatives
+	r.SetLineWidth(2)
+	r.SetLineDash()
+	for _, o := range objs {
+		if o.Hidden {
+			continue
+		}
+		r.SetGlobalAlpha(scene.Opacity(o))
+		if scene.IsCurve(o) {
+			// Draw lines between the points, then dots for the points.  The dots are outlined at the usual width, so
+			// their size only depends on the point radius
+			pts := ScreenPoints(o, f.CX, f.CY, f.Unit)
+			r.SetStrokeStyle(o.C)
+			r.SetLineWidth(scene.LineWidth(o, 2))
+			r.SetLineDash(o.DashPattern...)
+			if scene.HasGradient(o) {
+				colours, paths := GradientPaths(pts, o.Values)
+				for i, p := range paths {
+					r.SetStrokeStyle(colours[i])
+					r.StrokePath(p)
+				}
+			} else {
+				r.Polyline(pts)
+			}
+			r.SetLineDash()
+			r.SetLineWidth(2)
+			r.SetFillStyle(f.Theme.Foreground)
+			r.Dots(pts, scene.PointRadius(o, 1), true)
+		} else if o.Scatter {
+			// Scattered points are drawn as larger dots or marker shapes in the objects' colour, without joining lines.
+			// Only the marker shapes are outlined
+			var dots [][2]float64
+			var shapes Path
+			for _, p := range ScreenPoints(o, f.CX, f.CY, f.Unit) {
+				shape := scene.MarkerPoints(o.Marker, p[0], p[1])
+				if shape == nil {
+					dots = append(dots, p)
+					continue
+				}
+				shapes.Polygon(shape)
+			}
+			r.SetFillStyle(o.C)
+			r.SetStrokeStyle(f.Theme.Background)
+			r.SetLineWidth(1)
+			r.Dots(dots, scene.PointRadius(o, 2), false)
+			r.FillPath(&shapes)
+			r.StrokePath(&shapes)
+			r.SetLineWidth(2)
+		}
+	}
+	r.SetGlobalAlpha(1)
+
+	// Draw the point labels on top of everything else, laid out so they don't cover each other.  Curves labelled along
+	// their paths don't need them
+	hiddenLabels = drawPointLabels(r, f, PointLabels(objs, inv, f.CX, f.CY, f.Unit, f.PathLabels),
+		CurveLines(objs, f.CX, f.CY, f.Unit))
+
+	// Label the curves with their names along their paths, outlined in the background colour so they stand out from
+	// whatever is underneath
+	if f.PathLabels {
+		r.SetFont("bold 12px sans-serif")
+		r.SetTextAlign("center")
+		r.SetLineWidth(3)
+		r.SetStrokeStyle(f.Theme.Background)
+		for _, o := range objs {
+			if !scene.IsCurve(o) || o.Name == "" || o.Hidden {
+				continue
+			}
+			r.SetFillStyle(o.C)
+			pts := ScreenPoints(o, f.CX, f.CY, f.Unit)
+			for _, l := range PathLabelPositions(pts, f.Left, f.Top, f.W, f.H) {
+				r.Save()
+				r.Translate(l.X, l.Y)
+				r.Rotate(l.Angle)
+				r.StrokeText(o.Name, 0, -PathLabelOffset)
+				r.FillText(o.Name, 0, -PathLabelOffset)
+				r.Restore()
+			}
+		}
+	}
+	return
+}
+
+// Lays out and draws the point labels, with leader lines back to the points of any which had to be moved well away
+// from them.  Returns the number left out
+func drawPointLabels(r Renderer, f Frame, labels []Label, curves [][][2]float64) (hidden int) {
+	var placed []PlacedLabel
+	placed, hidden = LayoutLabels(labels, curves, f.W, f.H)
+	var leaders Path
+	for _, l := range placed {
+		if l.Leader {
+			leaders.Line(l.X, l.Y, l.LX, l.LY)
+		}
+	}
+	r.SetStrokeStyle(f.Theme.Muted)
+	r.SetLineWidth(1)
+	r.StrokePath(&leaders)
+	font := ""
+	for _, l := range placed {
+		if l.Box {
+			r.SetFillStyle(f.Theme.Background)
+			r.FillRect(l.X1, l.Y1, l.X2-l.X1, l.Y2-l.Y1)
+			r.StrokeRect(l.X1, l.Y1, l.X2-l.X1, l.Y2-l.Y1)
+		}
+		if l.Font != font {
+			font = l.Font
+			r.SetFont(font)
+		}
+		r.SetFillStyle(f.Theme.Foreground)
+		r.SetTextAlign(l.Align)
+		r.FillText(l.Text, l.TX, l.TY)
+	}
+	r.SetLineWidth(2)
+	return
+}
@@ -0,0 +1,185 @@
+package render
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+// A small scene with one of each kind of thing drawn, in a 400x300 graph area with the origin in the middle
+func testFrame() Frame {
+	return Frame{
+		Objects: []scene.Object{
+			{Name: "tri", C: "green", P: []scene.Point{{X: -5}, {X: -3}, {X: -5, Y: 2}}, E: []scene.Edge{{0, 1}},
+				S: []scene.Surface{{0, 1, 2}}},
+			{Name: "f1", C: "red", P: []scene.Point{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 2, Y: 0}}},
+			{Name: "hid", C: "blue", Hidden: true, P: []scene.Point{{X: 3, Y: 3}, {X: 4, Y: 4}}},
+			{Name: "pt", C: "purple", Scatter: true, P: []scene.Point{{X: 5, Y: -5, Label: "A"}}},
+		},
+		Matrix: geometry.Identity(),
+		Theme:  LightTheme,
+		Left:   5, Top: 5, W: 400, H: 300, CX: 200, CY: 150, Unit: 10, GridInterval: 1,
+	}
+}
+
+// Returns a frame with just a curve of n points, a sine wave across the graph area
+func curveFrame(n int) Frame {
+	f := testFrame()
+	o := scene.Object{Name: "f1", C: "red", P: make([]scene.Point, n)}
+	for i := range o.P {
+		x := -10 + 20*float64(i)/float64(n-1)
+		o.P[i] = scene.Point{X: x, Y: 5 * math.Sin(x)}
+	}
+	f.Objects = []scene.Object{o}
+	return f
+}
+
+// Draws a curve the way the graph was drawn before its paths were batched up, with calls of its own for every point,
+// to compare against
+func drawCurvePerPoint(r Renderer, o scene.Object, f Frame) {
+	pts := ScreenPoints(o, f.CX, f.CY, f.Unit)
+	r.SetStrokeStyle(o.C)
+	r.BeginPath()
+	for k, p := range pts {
+		if k == 0 {
+			r.MoveTo(p[0], p[1])
+		} else {
+			r.LineTo(p[0], p[1])
+		}
+	}
+	r.Stroke()
+	r.SetFillStyle(f.Theme.Foreground)
+	for _, p := range pts {
+		r.BeginPath()
+		r.Ellipse(p[0], p[1], 1, 1, 0, 0, 2*math.Pi)
+		r.Fill()
+		r.Stroke()
+	}
+}
+
+// Returns the arguments of every call with the given name
+func commandArgs(r *Recorder, name string) (l [][]interface{}) {
+	for _, c := range r.Commands {
+		if c.Name == name {
+			l = append(l, c.Args)
+		}
+	}
+	return
+}
+
+// Returns true if the recorder has a call with the given name and arguments
+func hasCommand(r *Recorder, name string, args ...interface{}) bool {
+	for _, a := range commandArgs(r, name) {
+		if reflect.DeepEqual(a, args) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDrawGraph(t *testing.T) {
+	r := NewRecorder()
+	points, hidden := DrawGraph(r, testFrame())
+	if points != 7 || hidden != 0 {
+		t.Errorf("DrawGraph = %d points, %d hidden labels, want 7 and 0", points, hidden)
+	}
+
+	// The graph area is clipped, and the surface filled and its edge stroked in one go each
+	if !hasCommand(r, "rect", 5.0, 5.0, 395.0, 295.0) || r.Count("clip") != 1 {
+		t.Error("the graph area isn't clipped")
+	}
+	if !hasCommand(r, "fill", "M 150.00 130.00 L 170.00 150.00 L 150.00 150.00 Z") {
+		t.Errorf("the triangle isn't filled, fills were %v", commandArgs(r, "fill"))
+	}
+	if !hasCommand(r, "stroke", "M 150.00 150.00 L 170.00 150.00") {
+		t.Errorf("the triangle's edge isn't stroked, strokes were %v", commandArgs(r, "stroke"))
+	}
+
+	// The curve is a single line in its own colour, with outlined dots on its points
+	line := "M 200.00 150.00 L 210.00 140.00 L 220.00 150.00"
+	if !hasCommand(r, "strokeStyle", "red") || !hasCommand(r, "stroke", line) {
+		t.Errorf("the curve isn't stroked in red, strokes were %v", commandArgs(r, "stroke"))
+	}
+	dots := "M 201.00 150.00 A 1.00 1.00 0 1 0 199.00 150.00 A 1.00 1.00 0 1 0 201.00 150.00 Z " +
+		"M 211.00 140.00 A 1.00 1.00 0 1 0 209.00 140.00 A 1.00 1.00 0 1 0 211.00 140.00 Z " +
+		"M 221.00 150.00 A 1.00 1.00 0 1 0 219.00 150.00 A 1.00 1.00 0 1 0 221.00 150.00 Z"
+	if !hasCommand(r, "fill", dots) || !hasCommand(r, "stroke", dots) {
+		t.Errorf("the curve's points aren't dotted, fills were %v", commandArgs(r, "fill"))
+	}
+
+	// The scattered point is a dot in its own colour, with its label.  Nothing of the hidden curve is drawn
+	if !hasCommand(r, "fillStyle", "purple") ||
+		!hasCommand(r, "fill", "M 252.00 200.00 A 2.00 2.00 0 1 0 248.00 200.00 A 2.00 2.00 0 1 0 252.00 200.00 Z") {
+		t.Errorf("the scattered point isn't drawn, fills were %v", commandArgs(r, "fill"))
+	}
+	if got := r.Text(); !reflect.DeepEqual(got, []string{"A"}) {
+		t.Errorf("drew text %q, want just the label", got)
+	}
+	if hasCommand(r, "strokeStyle", "blue") || hasCommand(r, "fillStyle", "blue") {
+		t.Error("the hidden curve was drawn")
+	}
+
+	// Saves and restores are balanced, so nothing leaks into what's drawn after
+	if r.Count("save") != r.Count("restore") {
+		t.Errorf("%d saves but %d restores", r.Count("save"), r.Count("restore"))
+	}
+}
+
+func TestDrawGraphGrid(t *testing.T) {
+	f := testFrame()
+	off := NewRecorder()
+	DrawGraph(off, f)
+	f.Grid = true
+	on := NewRecorder()
+	DrawGraph(on, f)
+
+	// The grid is drawn as a stroke each for the minor and major lines, which are empty when it's off
+	if got := on.Count("stroke") - off.Count("stroke"); got != 2 {
+		t.Errorf("the grid added %d strokes, want 2", got)
+	}
+	if !hasCommand(on, "strokeStyle", LightTheme.GridMajor) {
+		t.Error("the major grid lines aren't drawn in the theme's colour")
+	}
+}
+
+func TestDrawGraphPathLabels(t *testing.T) {
+	f := testFrame()
+	f.Objects[1].P = []scene.Point{{X: -10, Y: 0}, {X: 10, Y: 0}}
+	f.PathLabels = true
+	r := NewRecorder()
+	DrawGraph(r, f)
+
+	// The curve is labelled along its path, outlined then filled, while the scattered point keeps its point label
+	text := r.Text()
+	if len(text) < 3 || text[0] != "A" || text[1] != "f1" || text[2] != "f1" {
+		t.Errorf("drew text %q, want the point label then the curve's name", text)
+	}
+}
+
+func TestDrawGraphCalls(t *testing.T) {
+	// Each object is drawn with a handful of calls however many points it has, where it used to take several calls for
+	// every point
+	for _, n := range []int{20, 2000, 20000} {
+		f := curveFrame(n)
+		batched, perPoint := NewRecorder(), NewRecorder()
+		DrawGraph(batched, f)
+		drawCurvePerPoint(perPoint, f.Objects[0], f)
+		t.Logf("%d point curve: %d calls, %d drawing it point by point", n, len(batched.Commands),
+			len(perPoint.Commands))
+		if len(batched.Commands) > 40 {
+			t.Errorf("%d point curve took %d calls to draw", n, len(batched.Commands))
+		}
+	}
+}
+
+func BenchmarkDrawGraph(b *testing.B) {
+	f := curveFrame(2000)
+	r := Discard()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		DrawGraph(r, f)
+	}
+}
@@ -0,0 +1,87 @@
+package render
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	gradientSteps = 32 // Number of separate colours a curve coloured by its values is drawn in
+)
+
+var (
+	// Colours along the gradient, evenly spaced from the lowest values to the highest.  They go from dark to light as
+	// well as changing hue, so the order still reads in greyscale
+	gradientStops = [][3]float64{
+		{68, 1, 84},
+		{59, 82, 139},
+		{33, 145, 140},
+		{94, 201, 98},
+		{253, 231, 37},
+	}
+)
+
+// Returns the colour at a point along the gradient, from 0 for the lowest values to 1 for the highest
+func Gradient(t float64) string {
+	t = math.Max(0, math.Min(1, t))
+	if !scene.Finite(t) {
+		t = 0
+	}
+	f := t * float64(len(gradientStops)-1)
+	i := int(math.Min(f, float64(len(gradientStops)-2)))
+	f -= float64(i)
+	a, b := gradientStops[i], gradientStops[i+1]
+	return fmt.Sprintf("rgb(%.0f, %.0f, %.0f)", a[0]+(b[0]-a[0])*f, a[1]+(b[1]-a[1])*f, a[2]+(b[2]-a[2])*f)
+}
+
+// Returns the colour of each line of a curve coloured by its values, from the average of the values at its two ends.
+// Lines with a value which isn't finite at either end get an empty colour
+func GradientColours(values []float64) []string {
+	lo, hi, ok := GradientRange(values)
+	c := make([]string, 0, len(values))
+	for k := 1; k < len(values); k++ {
+		v := (values[k-1] + values[k]) / 2
+		if !ok || !scene.Finite(v) {
+			c = append(c, "")
+			continue
+		}
+		t := 0.5
+		if hi > lo {
+			t = (v - lo) / (hi - lo)
+		}
+		c = append(c, Gradient(math.Floor(t*(gradientSteps-1)+0.5)/(gradientSteps-1)))
+	}
+	return c
+}
+
+// Gathers the lines of a curve coloured by its values into a path for each colour, so it can be drawn with a call per
+// colour rather than one per line.  The points are in screen co-ordinates
+func GradientPaths(pts [][2]float64, values []float64) (colours []string, paths []*Path) {
+	index := map[string]int{}
+	for k, c := range GradientColours(values) {
+		if c == "" {
+			continue
+		}
+		i, ok := index[c]
+		if !ok {
+			i = len(paths)
+			index[c] = i
+			colours, paths = append(colours, c), append(paths, &Path{})
+		}
+		paths[i].Line(pts[k][0], pts[k][1], pts[k+1][0], pts[k+1][1])
+	}
+	return
+}
+
+// Returns the lowest and highest of the finite values, or false if there aren't any
+func GradientRange(values []float64) (lo float64, hi float64, ok bool) {
+	lo, hi = math.Inf(1), math.Inf(-1)
+	for _, v := range values {
+		if scene.Finite(v) {
+			lo, hi, ok = math.Min(lo, v), math.Max(hi, v), true
+		}
+	}
+	return
+}
@@ -0,0 +1,285 @@
+package render
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	labelCharWidth = 0.6 // Estimated width of a character, as a fraction of the font size
+	labelDescent   = 0.2 // Estimated depth of the text below its baseline, as a fraction of the font size
+	labelFarGap    = 24  // Distance from a point to the outer ring of spots for its label, which get a leader line
+	labelGap       = 3   // Distance from a point to the inner ring of spots for its label
+	labelPadding   = 2   // Space around the text inside a label's box
+)
+
+var (
+	// Whether point labels are moved or hidden so they don't overlap each other, and moved off the curves where they
+	// can be.  When it's off they're all drawn where their points say
+	LabelLayout = true
+)
+
+// A point label, in screen co-ordinates.  Labels always stay upright, however the scene is turned
+type Label struct {
+	Text  string
+	Font  string
+	Align string  // Canvas textAlign value for drawing it at its point
+	X, Y  float64 // The point being labelled, where the text's baseline starts, is centred, or ends, going by Align
+	Box   bool    // Whether it's drawn on a box in the background colour
+}
+
+// Where a label ended up after the layout
+type PlacedLabel struct {
+	Label
+	TX, TY         float64 // Where to draw the text from, using Align, on its baseline
+	X1, Y1, X2, Y2 float64 // The box around the text
+	Leader         bool    // Whether it's far enough from its point to need a line back to it
+	LX, LY         float64 // The end of the leader line, on the edge of the box nearest the point
+}
+
+// A screen area taken up by a label
+type labelRect struct{ x1, y1, x2, y2 float64 }
+
+// Returns the screen lines of the visible curves, for keeping labels off them
+func CurveLines(objects []scene.Object, cX float64, cY float64, unit float64) [][][2]float64 {
+	var l [][][2]float64
+	for _, o := range objects {
+		if !o.Hidden && scene.IsCurve(o) && len(o.P) > 1 {
+			l = append(l, ScreenPoints(o, cX, cY, unit))
+		}
+	}
+	return l
+}
+
+// Returns the size of a CSS font, in pixels.  Fonts without a pixel size are taken to be 14 pixels, the default for
+// point labels
+func fontSize(font string) float64 {
+	for _, f := range strings.Fields(font) {
+		if strings.HasSuffix(f, "px") {
+			if v, err := strconv.ParseFloat(strings.TrimSuffix(f, "px"), 64); err == nil && v > 0 {
+				return v
+			}
+		}
+	}
+	return 14
+}
+
+// Returns the spots around a point a label can go in, nearest first: above right, below right, above left, below
+// left, above, below, right, and left.  The gap is the space kept clear between the point and the label
+func labelSpots(x float64, y float64, w float64, h float64, g float64) []labelRect {
+	return []labelRect{
+		{x + g, y - g - h, x + g + w, y - g},
+		{x + g, y + g, x + g + w, y + g + h},
+		{x - g - w, y - g - h, x - g, y - g},
+		{x - g - w, y + g, x - g, y + g + h},
+		{x - w/2, y - g - h, x + w/2, y - g},
+		{x - w/2, y + g, x + w/2, y + g + h},
+		{x + g, y - h/2, x + g + w, y + h/2},
+		{x - g - w, y - h/2, x - g, y + h/2},
+	}
+}
+
+// Lays out the point labels, in the order given so earlier ones keep their spots.  Each label goes where its point
+// says if nothing's in the way, otherwise in the nearest spot around its point which is clear of the labels placed
+// already, the curves (as lines in screen co-ordinates), and the edges of the w by h graph area.  Labels which can
+// only go on top of other labels are left out, and the number of them returned.  Labels of points well outside the
+// graph area can't be seen anyway, so are left out without counting.  The labels' sizes are estimated from their fonts
+// rather than measured, so every renderer lays them out the same way
+func LayoutLabels(labels []Label, curves [][][2]float64, w float64, h float64) (placed []PlacedLabel, hidden int) {
+	var taken []labelRect
+	for _, l := range labels {
+		if l.X < -w || l.Y < -h || l.X > w*2 || l.Y > h*2 {
+			continue
+		}
+		size := fontSize(l.Font)
+		tw := TextWidth(l.Text, l.Font)
+		bw, bh := tw+labelPadding*2, size+labelPadding*2
+
+		// Where the point says
+		x1 := l.X - labelPadding
+		switch l.Align {
+		case "center":
+			x1 -= tw / 2
+		case "right", "end":
+			x1 -= tw
+		}
+		y2 := l.Y + size*labelDescent + labelPadding
+		natural := labelRect{x1, y2 - bh, x1 + bw, y2}
+		if !LabelLayout {
+			placed = append(placed, placeLabel(l, natural, true, false))
+			continue
+		}
+
+		// Spots which overlap other labels are ruled out.  Of the rest the first which is clear of the curves and the
+		// edges is used, or failing that the one crossing the fewest of them
+		spots := []labelRect{natural}
+		spots = append(spots, labelSpots(l.X, l.Y, bw, bh, labelGap)...)
+		spots = append(spots, labelSpots(l.X, l.Y, bw, bh, labelFarGap)...)
+		best, bestScore := -1, math.Inf(1)
+		for i, s := range spots {
+			clear := true
+			for _, t := range taken {
+				if s.x1 < t.x2 && t.x1 < s.x2 && s.y1 < t.y2 && t.y1 < s.y2 {
+					clear = false
+					break
+				}
+			}
+			if !clear {
+				continue
+			}
+			score := 0.0
+			if s.x1 < 0 || s.y1 < 0 || s.x2 > w || s.y2 > h {
+				score++
+			}
+			score += float64(rectCrossings(s, curves, l.X, l.Y))
+			if score < bestScore {
+				best, bestScore = i, score
+			}
+			if score == 0 {
+				break
+			}
+		}
+		if best < 0 {
+			hidden++
+			continue
+		}
+		taken = append(taken, spots[best])
+		placed = append(placed, placeLabel(l, spots[best], best == 0, best > 8))
+	}
+	return
+}
+
+// Returns a label placed in the given box.  Labels where their points say are drawn as usual, and the rest left
+// aligned inside their boxes, with a leader line back to the point when asked for
+func placeLabel(l Label, r labelRect, natural bool, leader bool) PlacedLabel {
+	p := PlacedLabel{Label: l, TX: l.X, TY: l.Y, X1: r.x1, Y1: r.y1, X2: r.x2, Y2: r.y2}
+	if natural {
+		return p
+	}
+	p.Align = "left"
+	p.TX, p.TY = r.x1+labelPadding, r.y2-labelPadding-fontSize(l.Font)*labelDescent
+	if leader {
+		p.Leader = true
+		p.LX, p.LY = math.Max(r.x1, math.Min(l.X, r.x2)), math.Max(r.y1, math.Min(l.Y, r.y2))
+	}
+	return p
+}
+
+// Places labels around their anchor points (in screen co-ordinates), trying spots above, below, and either side of
+// each one until it finds one not overlapping the labels already placed, or the anchor markers.  If every spot
+// overlaps something, the one with the least overlap is used.  Returns the position to draw each label's text from,
+// left aligned on the baseline
+func PlaceLabels(anchors [][2]float64, widths []float64, height float64) [][2]float64 {
+	overlap := func(a labelRect, b labelRect) float64 {
+		w := math.Min(a.x2, b.x2) - math.Max(a.x1, b.x1)
+		h := math.Min(a.y2, b.y2) - math.Max(a.y1, b.y1)
+		if w <= 0 || h <= 0 {
+			return 0
+		}
+		return w * h
+	}
+
+	// The markers themselves are kept clear of labels too
+	var taken []labelRect
+	s := float64(scene.MarkerSize)
+	for _, a := range anchors {
+		taken = append(taken, labelRect{a[0] - s, a[1] - s, a[0] + s, a[1] + s})
+	}
+	pos := make([][2]float64, len(anchors))
+	for i, a := range anchors {
+		candidates := labelSpots(a[0], a[1], widths[i], height, s+2)
+		best, bestOverlap := candidates[0], math.Inf(1)
+		for _, c := range candidates {
+			total := 0.0
+			for _, t := range taken {
+				total += overlap(c, t)
+			}
+			if total < bestOverlap {
+				best, bestOverlap = c, total
+			}
+			if total == 0 {
+				break
+			}
+		}
+		taken = append(taken, best)
+		pos[i] = [2]float64{best.x1, best.y2 - 2}
+	}
+	return pos
+}
+
+// Returns the point labels of the objects in screen co-ordinates, in the order they're laid out.  The inverse world
+// transform is used for filling in the label templates.  Curves labelled along their paths don't get point labels
+func PointLabels(objects []scene.Object, inv geometry.Matrix, cX float64, cY float64, unit float64,
+	pathLabels bool) []Label {
+	var l []Label
+	for _, o := range objects {
+		if o.Hidden || (pathLabels && scene.IsCurve(o)) {
+			continue
+		}
+		font := scene.LabelFont(o)
+		for _, p := range o.P {
+			if t := scene.PointLabel(o, p, inv); t != "" {
+				l = append(l, Label{Text: t, Font: font, Align: p.LabelAlign, X: cX + (p.X * unit),
+					Y: cY + ((p.Y * unit) * -1), Box: o.LabelBox})
+			}
+		}
+	}
+	return l
+}
+
+// Returns the number of curve lines crossing a label's spot.  Lines from the label's own point don't count, so labels
+// can sit at the ends of curves
+func rectCrossings(r labelRect, curves [][][2]float64, x float64, y float64) int {
+	at := func(p [2]float64) bool {
+		return math.Abs(p[0]-x) < 0.5 && math.Abs(p[1]-y) < 0.5
+	}
+	n := 0
+	for _, c := range curves {
+		for i := 1; i < len(c); i++ {
+			a, b := c[i-1], c[i]
+			if math.Max(a[0], b[0]) < r.x1 || math.Min(a[0], b[0]) > r.x2 || math.Max(a[1], b[1]) < r.y1 ||
+				math.Min(a[1], b[1]) > r.y2 || at(a) || at(b) {
+				continue
+			}
+			if segmentInRect(a, b, r) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// Returns true if any of the line from a to b is inside the rectangle, by clipping it to each side in turn
+func segmentInRect(a [2]float64, b [2]float64, r labelRect) bool {
+	t0, t1 := 0.0, 1.0
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	for _, c := range [][2]float64{{-dx, a[0] - r.x1}, {dx, r.x2 - a[0]}, {-dy, a[1] - r.y1}, {dy, r.y2 - a[1]}} {
+		p, q := c[0], c[1]
+		if p == 0 {
+			if q < 0 {
+				return false
+			}
+			continue
+		}
+		t := q / p
+		if p < 0 {
+			t0 = math.Max(t0, t)
+		} else {
+			t1 = math.Min(t1, t)
+		}
+		if t0 > t1 {
+			return false
+		}
+	}
+	return true
+}
+
+// Returns the estimated width of some text in a CSS font, in pixels
+func TextWidth(text string, font string) float64 {
+	return float64(utf8.RuneCountInString(text)) * fontSize(font) * labelCharWidth
+}
@@ -0,0 +1,124 @@
+package render
+
+import (
+	"math"
+	"strconv"
+)
+
+// Shapes gathered up as SVG path data, so a whole object can be handed to the canvas as one Path2D and drawn with a
+// single call.  Co-ordinates are kept to 2 decimal places, as in the SVG output.  Points which aren't finite are
+// skipped, the same as the canvas ignores them, as one in the path data would make the browser drop the rest of it
+type Path struct {
+	b    []byte
+	open bool // Set once the current subpath has a starting point
+}
+
+// Adds a command and its numbers to the path data
+func (p *Path) add(cmd byte, v ...float64) {
+	if len(p.b) > 0 {
+		p.b = append(p.b, ' ')
+	}
+	p.b = append(p.b, cmd)
+	p.nums(v...)
+}
+
+// Adds a half circle arc of the given radius, ending at a point.  The arc flags need to be plain 0 or 1, so aren't
+// written as numbers
+func (p *Path) arc(r float64, x float64, y float64) {
+	p.add('A', r, r)
+	p.b = append(p.b, " 0 1 0"...)
+	p.nums(x, y)
+}
+
+// Closes the current subpath back to its start
+func (p *Path) Close() {
+	if p.open {
+		p.add('Z')
+		p.open = false
+	}
+}
+
+// Adds a filled dot, as a circle made of two arcs
+func (p *Path) Dot(x float64, y float64, r float64) {
+	if !finite(x) || !finite(y) {
+		return
+	}
+	p.add('M', x+r, y)
+	p.arc(r, x-r, y)
+	p.arc(r, x+r, y)
+	p.add('Z')
+	p.open = false
+}
+
+// Returns true if nothing's been added yet
+func (p *Path) Empty() bool {
+	return len(p.b) == 0
+}
+
+// Returns true for numbers which aren't NaN or infinite
+func finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// Adds a separate straight line
+func (p *Path) Line(x1 float64, y1 float64, x2 float64, y2 float64) {
+	if !finite(x1) || !finite(y1) || !finite(x2) || !finite(y2) {
+		return
+	}
+	p.add('M', x1, y1)
+	p.add('L', x2, y2)
+	p.open = true
+}
+
+// Continues the current subpath to a point, or starts a new one there if there isn't one yet
+func (p *Path) LineTo(x float64, y float64) {
+	if !finite(x) || !finite(y) {
+		return
+	}
+	if !p.open {
+		p.MoveTo(x, y)
+		return
+	}
+	p.add('L', x, y)
+}
+
+// Starts a new subpath at a point
+func (p *Path) MoveTo(x float64, y float64) {
+	if !finite(x) || !finite(y) {
+		return
+	}
+	p.add('M', x, y)
+	p.open = true
+}
+
+// Adds numbers to the path data, each after a space
+func (p *Path) nums(v ...float64) {
+	for _, f := range v {
+		p.b = append(p.b, ' ')
+		p.b = strconv.AppendFloat(p.b, f, 'f', 2, 64)
+	}
+}
+
+// Adds a closed polygon.  The points are always added going the same way round, so overlapping polygons filled
+// together using the nonzero rule don't cancel each other out
+func (p *Path) Polygon(pts [][2]float64) {
+	area := 0.0
+	for i, a := range pts {
+		b := pts[(i+1)%len(pts)]
+		area += a[0]*b[1] - b[0]*a[1]
+	}
+	p.open = false
+	for i := range pts {
+		j := i
+		if area < 0 {
+			j = len(pts) - 1 - i
+		}
+		p.LineTo(pts[j][0], pts[j][1])
+	}
+	p.Close()
+}
+
+// Returns the SVG path data
+func (p *Path) String() string {
+	return string(p.b)
+}
@@ -0,0 +1,59 @@
+package render
+
+import (
+	"math"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	PathLabelOffset  = 5   // Gap between a curve and its name label, in pixels
+	pathLabelStart   = 60  // Distance along the visible part of a curve before its first name label, in pixels
+	pathLabelSpacing = 300 // Distance between repeats of a curves' name along its path, in pixels
+)
+
+// A curve name label placed along its path, in screen co-ordinates
+type PathLabel struct {
+	X, Y  float64
+	Angle float64 // Rotation in radians, following the direction of the curve
+}
+
+// Works out where to place the name labels along a curve, given its points in screen co-ordinates.  Only the parts of
+// the curve inside the given area are counted, so the labels stay visible wherever the curve is scrolled or zoomed to.
+// The angles follow the local direction of the curve, flipped where needed to keep the text the right way up
+func PathLabelPositions(pts [][2]float64, minX float64, minY float64, maxX float64, maxY float64) (l []PathLabel) {
+	inside := func(p [2]float64) bool {
+		return p[0] >= minX && p[0] <= maxX && p[1] >= minY && p[1] <= maxY
+	}
+	dist, next := 0.0, float64(pathLabelStart)
+	for i := 1; i < len(pts); i++ {
+		a, b := pts[i-1], pts[i]
+		if !inside(a) || !inside(b) {
+			continue
+		}
+		dx, dy := b[0]-a[0], b[1]-a[1]
+		segLen := math.Hypot(dx, dy)
+		for segLen > 0 && next <= dist+segLen {
+			t := (next - dist) / segLen
+			angle := math.Atan2(dy, dx)
+			if angle > math.Pi/2 {
+				angle -= math.Pi
+			} else if angle < -math.Pi/2 {
+				angle += math.Pi
+			}
+			l = append(l, PathLabel{X: a[0] + t*dx, Y: a[1] + t*dy, Angle: angle})
+			next += pathLabelSpacing
+		}
+		dist += segLen
+	}
+	return
+}
+
+// Returns the screen co-ordinates of an objects' points
+func ScreenPoints(o scene.Object, cX float64, cY float64, unit float64) [][2]float64 {
+	pts := make([][2]float64, len(o.P))
+	for i, p := range o.P {
+		pts[i] = [2]float64{cX + (p.X * unit), cY + ((p.Y * unit) * -1)}
+	}
+	return pts
+}
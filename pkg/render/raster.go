@@ -0,0 +1,275 @@
+package render
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"sort"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	rasterScale = 2 // Shapes are drawn at this multiple of the image size, then scaled down to smooth their edges
+)
+
+// An image being drawn on, at rasterScale times its final size.  Co-ordinates given to the drawing functions are in
+// final image pixels
+type raster struct {
+	img  *image.RGBA
+	clip image.Rectangle // Area drawing is limited to, in the scaled up pixels
+}
+
+// Draws the graph area as an image, the same way SVG lays it out.  Text can't be drawn without a font renderer, so
+// point labels, tick numbers, and curve names along their paths are left out
+func Image(objects []scene.Object, m geometry.Matrix, th Theme, w float64, h float64, cX float64, cY float64,
+	unit float64) *image.RGBA {
+	colour := func(s string, def string) color.RGBA {
+		if c, ok := parseColour(s); ok {
+			return c
+		}
+		c, _ := parseColour(def)
+		return c
+	}
+	fg := colour(th.Foreground, "black")
+	bg := colour(th.Background, "white")
+	iw, ih := int(math.Ceil(w)), int(math.Ceil(h))
+	r := &raster{img: image.NewRGBA(image.Rect(0, 0, iw*rasterScale, ih*rasterScale))}
+	r.clip = r.img.Bounds()
+	r.fill([][2]float64{{0, 0}, {w, 0}, {w, h}, {0, h}}, bg)
+
+	// Grid lines, clipped to the graph area
+	border := float64(2)
+	left := border + 3
+	top := border + 3
+	major, minor := geometry.Grid(m, cX, cY, unit, w, h, scene.TickInterval(unit*geometry.Zoom(m)))
+	r.clip = image.Rect(int(left*rasterScale), int(top*rasterScale), iw*rasterScale, ih*rasterScale)
+	for _, g := range []struct {
+		colour string
+		lines  []geometry.GridLine
+	}{{th.GridMinor, minor}, {th.GridMajor, major}} {
+		c := colour(g.colour, th.Foreground)
+		for _, l := range g.lines {
+			r.dashed(l.X1, l.Y1, l.X2, l.Y2, 1, 3, c)
+		}
+	}
+	r.clip = r.img.Bounds()
+
+	// Surfaces and edges
+	xy := func(p scene.Point) (float64, float64) {
+		return cX + (p.X * unit), cY + ((p.Y * unit) * -1)
+	}
+	for _, i := range scene.SurfaceOrder(objects) {
+		o := objects[i]
+		for _, f := range scene.Faces(o) {
+			var pts [][2]float64
+			for _, n := range f.S {
+				px, py := xy(o.P[n])
+				pts = append(pts, [2]float64{px, py})
+			}
+			r.fill(pts, colour(FaceColour(o, f), th.Foreground))
+		}
+		for _, l := range o.E {
+			x1, y1 := xy(o.P[l[0]])
+			x2, y2 := xy(o.P[l[1]])
+			r.polyline([][2]float64{{x1, y1}, {x2, y2}}, scene.LineWidth(o, 1), o.DashPattern, fade(fg, o))
+		}
+	}
+
+	// The graph and derivatives, as lines between the points with dots on top.  Scattered points are just dots
+	for _, d := range drawOrder(objects) {
+		o := objects[d]
+		c := fade(colour(o.C, th.Foreground), o)
+		if o.Scatter {
+			for _, l := range o.P {
+				px, py := xy(l)
+				shape := scene.MarkerPoints(o.Marker, px, py)
+				if shape == nil {
+					r.dot(px, py, scene.PointRadius(o, 2), c)
+					continue
+				}
+				r.fill(shape, c)
+				for k := range shape {
+					a, b := shape[k], shape[(k+1)%len(shape)]
+					r.line(a[0], a[1], b[0], b[1], 1, fade(bg, o))
+				}
+			}
+			continue
+		}
+		if !scene.IsCurve(o) {
+			continue
+		}
+		pts := make([][2]float64, len(o.P))
+		for k, l := range o.P {
+			pts[k][0], pts[k][1] = xy(l)
+		}
+		if scene.HasGradient(o) {
+			for k, g := range GradientColours(o.Values) {
+				if g != "" {
+					r.polyline(pts[k:k+2], scene.LineWidth(o, 2), o.DashPattern, fade(colour(g, o.C), o))
+				}
+			}
+		} else {
+			r.polyline(pts, scene.LineWidth(o, 2), o.DashPattern, c)
+		}
+		for _, p := range pts {
+			r.dot(p[0], p[1], scene.PointRadius(o, 1), fade(fg, o))
+		}
+	}
+
+	// Border around the graph area
+	for _, l := range [][4]float64{
+		{border, border, w, border}, {w, border, w, h}, {w, h, border, h}, {border, h, border, border},
+	} {
+		r.line(l[0], l[1], l[2], l[3], 2, fg)
+	}
+	return r.scaleDown(iw, ih)
+}
+
+// Mixes a colour into a pixel, using the colours' alpha value.  The colours from parseColour aren't premultiplied
+func (r *raster) blend(x int, y int, c color.RGBA) {
+	if !(image.Point{X: x, Y: y}).In(r.clip) {
+		return
+	}
+	i := r.img.PixOffset(x, y)
+	p := r.img.Pix[i : i+4 : i+4]
+	a := uint32(c.A)
+	p[0] = uint8((uint32(c.R)*a + uint32(p[0])*(255-a)) / 255)
+	p[1] = uint8((uint32(c.G)*a + uint32(p[1])*(255-a)) / 255)
+	p[2] = uint8((uint32(c.B)*a + uint32(p[2])*(255-a)) / 255)
+	p[3] = uint8(a + uint32(p[3])*(255-a)/255)
+}
+
+// Draws a dashed line, with dashes and gaps of the given lengths
+func (r *raster) dashed(x1 float64, y1 float64, x2 float64, y2 float64, on float64, off float64, c color.RGBA) {
+	l := math.Hypot(x2-x1, y2-y1)
+	if l == 0 {
+		return
+	}
+	dx, dy := (x2-x1)/l, (y2-y1)/l
+	for d := 0.0; d < l; d += on + off {
+		e := math.Min(d+on, l)
+		r.line(x1+dx*d, y1+dy*d, x1+dx*e, y1+dy*e, 1, c)
+	}
+}
+
+// Draws a filled circle
+func (r *raster) dot(x float64, y float64, radius float64, c color.RGBA) {
+	var pts [][2]float64
+	for i := 0; i < 16; i++ {
+		a := float64(i) * math.Pi / 8
+		pts = append(pts, [2]float64{x + radius*math.Cos(a), y + radius*math.Sin(a)})
+	}
+	r.fill(pts, c)
+}
+
+// Returns a colour made as translucent as an object is
+func fade(c color.RGBA, o scene.Object) color.RGBA {
+	c.A = uint8(float64(c.A)*scene.Opacity(o) + 0.5)
+	return c
+}
+
+// Fills a polygon, using the even-odd rule.  Each pixel row is scanned for where it crosses the polygon's edges
+func (r *raster) fill(pts [][2]float64, c color.RGBA) {
+	if len(pts) < 3 {
+		return
+	}
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, p := range pts {
+		minY, maxY = math.Min(minY, p[1]*rasterScale), math.Max(maxY, p[1]*rasterScale)
+	}
+	y0 := int(math.Max(math.Floor(minY), float64(r.clip.Min.Y)))
+	y1 := int(math.Min(math.Ceil(maxY), float64(r.clip.Max.Y)))
+	var xs []float64
+	for y := y0; y < y1; y++ {
+		sy := float64(y) + 0.5
+		xs = xs[:0]
+		for i := range pts {
+			a, b := pts[i], pts[(i+1)%len(pts)]
+			ay, by := a[1]*rasterScale, b[1]*rasterScale
+			if (ay > sy) != (by > sy) {
+				xs = append(xs, (a[0]+(b[0]-a[0])*(sy-ay)/(by-ay))*rasterScale)
+			}
+		}
+		sort.Float64s(xs)
+		for i := 0; i+1 < len(xs); i += 2 {
+			start := int(math.Max(math.Floor(xs[i]+0.5), float64(r.clip.Min.X)))
+			end := int(math.Min(math.Floor(xs[i+1]+0.5), float64(r.clip.Max.X)))
+			for x := start; x < end; x++ {
+				r.blend(x, y, c)
+			}
+		}
+	}
+}
+
+// Draws a straight line of the given width, as a thin rectangle
+func (r *raster) line(x1 float64, y1 float64, x2 float64, y2 float64, width float64, c color.RGBA) {
+	l := math.Hypot(x2-x1, y2-y1)
+	if l == 0 {
+		return
+	}
+	nx, ny := (y1-y2)/l*width/2, (x2-x1)/l*width/2
+	r.fill([][2]float64{{x1 + nx, y1 + ny}, {x2 + nx, y2 + ny}, {x2 - nx, y2 - ny}, {x1 - nx, y1 - ny}}, c)
+}
+
+// Draws lines joining up the points, with the joins rounded off.  A dash pattern, as for the canvas setLineDash(), is
+// carried on from each line to the next
+func (r *raster) polyline(pts [][2]float64, width float64, dash []float64, c color.RGBA) {
+	total := 0.0
+	for _, d := range dash {
+		total += d
+	}
+	if total <= 0 {
+		for k := 1; k < len(pts); k++ {
+			r.line(pts[k-1][0], pts[k-1][1], pts[k][0], pts[k][1], width, c)
+			r.dot(pts[k][0], pts[k][1], width/2, c)
+		}
+		return
+	}
+	if len(dash)%2 == 1 {
+		// An odd number of lengths is repeated, the same as the canvas does
+		dash = append(append([]float64(nil), dash...), dash...)
+	}
+	i, left := 0, dash[0]
+	for k := 1; k < len(pts); k++ {
+		a, b := pts[k-1], pts[k]
+		l := math.Hypot(b[0]-a[0], b[1]-a[1])
+		for d := 0.0; d < l; {
+			step := math.Min(left, l-d)
+			if i%2 == 0 && step > 0 {
+				s, e := d/l, (d+step)/l
+				r.line(a[0]+(b[0]-a[0])*s, a[1]+(b[1]-a[1])*s, a[0]+(b[0]-a[0])*e, a[1]+(b[1]-a[1])*e, width, c)
+			}
+			d, left = d+step, left-step
+			if left <= 0 {
+				i = (i + 1) % len(dash)
+				left = dash[i]
+			}
+		}
+	}
+}
+
+// Returns the drawing at its final size, averaging each block of the scaled up pixels
+func (r *raster) scaleDown(w int, h int) *image.RGBA {
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	n := uint32(rasterScale * rasterScale)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sum [4]uint32
+			for sy := 0; sy < rasterScale; sy++ {
+				for sx := 0; sx < rasterScale; sx++ {
+					i := r.img.PixOffset(x*rasterScale+sx, y*rasterScale+sy)
+					for k := range sum {
+						sum[k] += uint32(r.img.Pix[i+k])
+					}
+				}
+			}
+			i := out.PixOffset(x, y)
+			for k := range sum {
+				out.Pix[i+k] = uint8(sum[k] / n)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,298 @@
+package render
+
+import (
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	recorderFontSize  = 14.0 // Font size in pixels assumed by a Recorder until it's given a font
+	recorderCharWidth = 0.55 // Average width of a character as a fraction of the font size, for measuring text
+)
+
+// Something to draw on, with the same calls as a canvas 2D context.  The page draws through the canvas one, while
+// drawing code can be run without a browser against a Recorder, and what it drew checked afterwards.  Co-ordinates
+// are in CSS pixels
+type Renderer interface {
+	Arc(x float64, y float64, r float64, start float64, end float64)
+	BeginPath()
+	Clip()
+	ClosePath()
+	Dots(pts [][2]float64, r float64, outline bool) // Fills a dot at each point, outlining them too if asked
+	Ellipse(x float64, y float64, rx float64, ry float64, rotation float64, start float64, end float64)
+	Fill()
+	FillPath(p *Path) // Fills the shapes gathered in a path, all at once
+	FillRect(x float64, y float64, w float64, h float64)
+	FillText(text string, x float64, y float64)
+	LineTo(x float64, y float64)
+	MeasureText(text string) float64 // Returns the width of the text in the current font
+	MoveTo(x float64, y float64)
+	Polyline(pts [][2]float64) // Strokes a line joining up the points
+	Rect(x float64, y float64, w float64, h float64)
+	Restore()
+	Rotate(angle float64)
+	Save()
+	SetFillStyle(style string)
+	SetFont(font string)
+	SetGlobalAlpha(alpha float64)
+	SetLineDash(segments ...float64)
+	SetLineJoin(join string)
+	SetLineWidth(width float64)
+	SetStrokeStyle(style string)
+	SetTextAlign(align string)
+	SetTextBaseline(baseline string)
+	SetTransform(a float64, b float64, c float64, d float64, e float64, f float64)
+	Stroke()
+	StrokePath(p *Path) // Strokes the lines gathered in a path, all at once
+	StrokeRect(x float64, y float64, w float64, h float64)
+	StrokeText(text string, x float64, y float64)
+	Translate(x float64, y float64)
+}
+
+// A drawing call made to a Recorder.  Name is the canvas method or property it stands for, such as "fillText" or
+// "lineWidth", with its arguments.  Paths are recorded as their SVG path data
+type Command struct {
+	Name string
+	Args []interface{}
+}
+
+// A Renderer which draws nothing, just keeping a list of the calls made to it, for running drawing code without a
+// browser.  One made with Discard doesn't even keep the list.  Text is measured from an average character width, so
+// is only roughly the size a browser would make it
+type Recorder struct {
+	Commands []Command
+
+	discard bool
+	font    string
+	fonts   []string // The fonts put aside by Save, for Restore to bring back
+}
+
+// Returns a Recorder which doesn't keep the calls made to it
+func Discard() *Recorder {
+	return &Recorder{discard: true}
+}
+
+// Returns a Recorder which keeps a list of the calls made to it
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+// Adds a call to the list
+func (r *Recorder) add(name string, args ...interface{}) {
+	if !r.discard {
+		r.Commands = append(r.Commands, Command{Name: name, Args: args})
+	}
+}
+
+// Records a call to arc()
+func (r *Recorder) Arc(x float64, y float64, rad float64, start float64, end float64) {
+	r.add("arc", x, y, rad, start, end)
+}
+
+// Records a call to beginPath()
+func (r *Recorder) BeginPath() {
+	r.add("beginPath")
+}
+
+// Records a call to clip()
+func (r *Recorder) Clip() {
+	r.add("clip")
+}
+
+// Records a call to closePath()
+func (r *Recorder) ClosePath() {
+	r.add("closePath")
+}
+
+// Returns the number of calls made with the given name
+func (r *Recorder) Count(name string) (n int) {
+	for _, c := range r.Commands {
+		if c.Name == name {
+			n++
+		}
+	}
+	return
+}
+
+// Records the dots as the path data filling them, as a canvas would draw them
+func (r *Recorder) Dots(pts [][2]float64, rad float64, outline bool) {
+	var p Path
+	for _, d := range pts {
+		p.Dot(d[0], d[1], rad)
+	}
+	r.FillPath(&p)
+	if outline {
+		r.StrokePath(&p)
+	}
+}
+
+// Records a call to ellipse()
+func (r *Recorder) Ellipse(x float64, y float64, rx float64, ry float64, rotation float64, start float64, end float64) {
+	r.add("ellipse", x, y, rx, ry, rotation, start, end)
+}
+
+// Records a call to fill()
+func (r *Recorder) Fill() {
+	r.add("fill")
+}
+
+// Records filling a path, with its path data
+func (r *Recorder) FillPath(p *Path) {
+	if !p.Empty() {
+		r.add("fill", p.String())
+	}
+}
+
+// Records a call to fillRect()
+func (r *Recorder) FillRect(x float64, y float64, w float64, h float64) {
+	r.add("fillRect", x, y, w, h)
+}
+
+// Records a call to fillText()
+func (r *Recorder) FillText(text string, x float64, y float64) {
+	r.add("fillText", text, x, y)
+}
+
+// Records a call to lineTo()
+func (r *Recorder) LineTo(x float64, y float64) {
+	r.add("lineTo", x, y)
+}
+
+// Returns a rough width for the text, from the size of the current font
+func (r *Recorder) MeasureText(text string) float64 {
+	size := recorderFontSize
+	for _, f := range strings.Fields(r.font) {
+		if v, err := strconv.ParseFloat(strings.TrimSuffix(f, "px"), 64); err == nil && strings.HasSuffix(f, "px") {
+			size = v
+			break
+		}
+	}
+	return float64(utf8.RuneCountInString(text)) * size * recorderCharWidth
+}
+
+// Records a call to moveTo()
+func (r *Recorder) MoveTo(x float64, y float64) {
+	r.add("moveTo", x, y)
+}
+
+// Records the line as the path data stroking it, as a canvas would draw it
+func (r *Recorder) Polyline(pts [][2]float64) {
+	var p Path
+	for _, l := range pts {
+		p.LineTo(l[0], l[1])
+	}
+	r.StrokePath(&p)
+}
+
+// Records a call to rect()
+func (r *Recorder) Rect(x float64, y float64, w float64, h float64) {
+	r.add("rect", x, y, w, h)
+}
+
+// Records a call to restore()
+func (r *Recorder) Restore() {
+	if n := len(r.fonts); n > 0 {
+		r.font, r.fonts = r.fonts[n-1], r.fonts[:n-1]
+	}
+	r.add("restore")
+}
+
+// Records a call to rotate()
+func (r *Recorder) Rotate(angle float64) {
+	r.add("rotate", angle)
+}
+
+// Records a call to save()
+func (r *Recorder) Save() {
+	r.fonts = append(r.fonts, r.font)
+	r.add("save")
+}
+
+// Records setting fillStyle
+func (r *Recorder) SetFillStyle(style string) {
+	r.add("fillStyle", style)
+}
+
+// Records setting font
+func (r *Recorder) SetFont(font string) {
+	r.font = font
+	r.add("font", font)
+}
+
+// Records setting globalAlpha
+func (r *Recorder) SetGlobalAlpha(alpha float64) {
+	r.add("globalAlpha", alpha)
+}
+
+// Records a call to setLineDash()
+func (r *Recorder) SetLineDash(segments ...float64) {
+	r.add("setLineDash", append([]float64(nil), segments...))
+}
+
+// Records setting lineJoin
+func (r *Recorder) SetLineJoin(join string) {
+	r.add("lineJoin", join)
+}
+
+// Records setting lineWidth
+func (r *Recorder) SetLineWidth(width float64) {
+	r.add("lineWidth", width)
+}
+
+// Records setting strokeStyle
+func (r *Recorder) SetStrokeStyle(style string) {
+	r.add("strokeStyle", style)
+}
+
+// Records setting textAlign
+func (r *Recorder) SetTextAlign(align string) {
+	r.add("textAlign", align)
+}
+
+// Records setting textBaseline
+func (r *Recorder) SetTextBaseline(baseline string) {
+	r.add("textBaseline", baseline)
+}
+
+// Records a call to setTransform()
+func (r *Recorder) SetTransform(a float64, b float64, c float64, d float64, e float64, f float64) {
+	r.add("setTransform", a, b, c, d, e, f)
+}
+
+// Records a call to stroke()
+func (r *Recorder) Stroke() {
+	r.add("stroke")
+}
+
+// Records stroking a path, with its path data
+func (r *Recorder) StrokePath(p *Path) {
+	if !p.Empty() {
+		r.add("stroke", p.String())
+	}
+}
+
+// Records a call to strokeRect()
+func (r *Recorder) StrokeRect(x float64, y float64, w float64, h float64) {
+	r.add("strokeRect", x, y, w, h)
+}
+
+// Records a call to strokeText()
+func (r *Recorder) StrokeText(text string, x float64, y float64) {
+	r.add("strokeText", text, x, y)
+}
+
+// Returns the text drawn with FillText or StrokeText, in the order it was drawn
+func (r *Recorder) Text() (l []string) {
+	for _, c := range r.Commands {
+		if c.Name == "fillText" || c.Name == "strokeText" {
+			l = append(l, c.Args[0].(string))
+		}
+	}
+	return
+}
+
+// Records a call to translate()
+func (r *Recorder) Translate(x float64, y float64) {
+	r.add("translate", x, y)
+}
@@ -0,0 +1,49 @@
+package render
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+const (
+	lightAmbient = 0.35 // Brightness of shaded surfaces the light doesn't fall on at all, as a fraction of their colour
+)
+
+var (
+	// Direction the light shining on shaded surfaces comes from, relative to the viewer: x to the right, y up, and z
+	// towards them.  The default is from above and to the left, over the viewer's shoulder
+	DefaultLight = [3]float64{-1, 1, 2}
+
+	// The current light direction, used by all the renderers
+	Light = DefaultLight
+)
+
+// Returns how brightly the light falls on the side of a face the viewer sees, from lightAmbient when it's edge on or
+// lit from behind to 1 when it's facing the light
+func lightLevel(o scene.Object, f scene.Face) float64 {
+	x, y, z := scene.Normal(o, f.S)
+	if z < 0 {
+		x, y, z = -x, -y, -z
+	}
+	n := math.Sqrt(x*x + y*y + z*z)
+	l := math.Sqrt(Light[0]*Light[0] + Light[1]*Light[1] + Light[2]*Light[2])
+	if n == 0 || l == 0 {
+		return 1
+	}
+	d := (x*Light[0] + y*Light[1] + z*Light[2]) / (n * l)
+	return lightAmbient + (1-lightAmbient)*math.Max(0, d)
+}
+
+// Sets the direction the light comes from, relative to the viewer.  It can be any length, but not zero
+func SetLight(x float64, y float64, z float64) error {
+	if !scene.Finite(x) || !scene.Finite(y) || !scene.Finite(z) {
+		return fmt.Errorf("the light direction needs to be finite numbers")
+	}
+	if x == 0 && y == 0 && z == 0 {
+		return fmt.Errorf("the light needs to come from somewhere, so can't be (0, 0, 0)")
+	}
+	Light = [3]float64{x, y, z}
+	return nil
+}
@@ -0,0 +1,224 @@
+// Package render draws scenes without the browser, as SVG documents or PNG images
+package render
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+	"github.com/justinclift/wasmGraph4/pkg/scene"
+)
+
+// Returns the indexes of the objects in the order their curves and markers are drawn.  Objects with the same draw
+// order keep their order in the world space
+func drawOrder(objects []scene.Object) []int {
+	order := make([]int, len(objects))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return objects[order[i]].DrawOrder < objects[order[j]].DrawOrder })
+	return order
+}
+
+// Returns the graph area as a standalone SVG document.  This walks the world space objects the same way the page's
+// frame renderer does, but emits vector paths instead of canvas calls, so the output stays sharp at any size.  The
+// world transform is used for positioning the grid lines, and the theme for the colours.  When pathLabels is set,
+// curves are labelled along their paths instead of at their first points.  Hidden objects are left out, as is the grid
+// unless grid is set
+func SVG(objects []scene.Object, m geometry.Matrix, th Theme, w float64, h float64, cX float64, cY float64,
+	unit float64, pathLabels bool, grid bool) string {
+	objects = scene.Visible(objects)
+	var b strings.Builder
+	fmt.Fprintf(&b, `<?xml version="1.0" encoding="UTF-8"?>`+"\n")
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f" viewBox="0 0 %.0f %.0f">`+"\n",
+		w, h, w, h)
+	fmt.Fprintf(&b, `<rect x="0" y="0" width="%.0f" height="%.0f" fill="%s"/>`+"\n", w, h, th.Background)
+
+	// Converts a world space X/Y co-ordinate into an SVG one
+	svgXY := func(x, y float64) (float64, float64) {
+		return cX + (x * unit), cY + ((y * unit) * -1)
+	}
+
+	// Grid lines, clipped to the graph area
+	border := float64(2)
+	left := border + 3
+	top := border + 3
+	major, minor := geometry.Grid(m, cX, cY, unit, w, h, scene.TickInterval(unit*geometry.Zoom(m)))
+	if !grid {
+		major, minor = nil, nil
+	}
+	fmt.Fprintf(&b, `<clipPath id="graph"><rect x="%.2f" y="%.2f" width="%.2f" height="%.2f"/></clipPath>`+"\n",
+		left, top, w-left, h-top)
+	b.WriteString(`<g clip-path="url(#graph)" stroke-dasharray="1 3">` + "\n")
+	for _, g := range []struct {
+		colour string
+		lines  []geometry.GridLine
+	}{{th.GridMinor, minor}, {th.GridMajor, major}} {
+		fmt.Fprintf(&b, `<g stroke="%s">`+"\n", g.colour)
+		for _, l := range g.lines {
+			fmt.Fprintf(&b, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f"/>`+"\n", l.X1, l.Y1, l.X2, l.Y2)
+		}
+		b.WriteString("</g>\n")
+	}
+	b.WriteString("</g>\n")
+
+	// Surfaces and edges.  Translucent objects are drawn after the opaque ones
+	inv, _ := geometry.Invert(m)
+	for _, i := range scene.SurfaceOrder(objects) {
+		o := objects[i]
+		for _, f := range scene.Faces(o) {
+			var d strings.Builder
+			for m, n := range f.S {
+				px, py := svgXY(o.P[n].X, o.P[n].Y)
+				if m == 0 {
+					fmt.Fprintf(&d, "M%.2f %.2f", px, py)
+				} else {
+					fmt.Fprintf(&d, " L%.2f %.2f", px, py)
+				}
+			}
+			fmt.Fprintf(&b, `<path d="%s Z" fill="%s"/>`+"\n", d.String(), html.EscapeString(FaceColour(o, f)))
+		}
+		for _, l := range o.E {
+			x1, y1 := svgXY(o.P[l[0]].X, o.P[l[0]].Y)
+			x2, y2 := svgXY(o.P[l[1]].X, o.P[l[1]].Y)
+			fmt.Fprintf(&b, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%s" stroke-width="%g"%s%s/>`+"\n",
+				x1, y1, x2, y2, th.Foreground, scene.LineWidth(o, 1), svgDash(o), svgOpacity(o))
+		}
+	}
+
+	// The graph and derivatives, as lines between the points with dots on top.  Scattered points are just dots
+	for _, d := range drawOrder(objects) {
+		o := objects[d]
+		if o.Scatter {
+			fmt.Fprintf(&b, `<g fill="%s" stroke="%s"%s>`+"\n", html.EscapeString(o.C), th.Background, svgOpacity(o))
+			for _, l := range o.P {
+				px, py := svgXY(l.X, l.Y)
+				shape := scene.MarkerPoints(o.Marker, px, py)
+				if shape == nil {
+					fmt.Fprintf(&b, `<circle cx="%.2f" cy="%.2f" r="%g" stroke="none"/>`+"\n", px, py,
+						scene.PointRadius(o, 2))
+					continue
+				}
+				var pts []string
+				for _, c := range shape {
+					pts = append(pts, fmt.Sprintf("%.2f,%.2f", c[0], c[1]))
+				}
+				fmt.Fprintf(&b, `<polygon points="%s"/>`+"\n", strings.Join(pts, " "))
+			}
+			b.WriteString("</g>\n")
+			continue
+		}
+		if !scene.IsCurve(o) || len(o.P) == 0 {
+			continue
+		}
+		if scene.HasGradient(o) {
+			// Each line in its own colour
+			for k, c := range GradientColours(o.Values) {
+				if c == "" {
+					continue
+				}
+				x1, y1 := svgXY(o.P[k].X, o.P[k].Y)
+				x2, y2 := svgXY(o.P[k+1].X, o.P[k+1].Y)
+				fmt.Fprintf(&b,
+					`<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%s" stroke-width="%g"%s%s/>`+"\n",
+					x1, y1, x2, y2, c, scene.LineWidth(o, 2), svgDash(o), svgOpacity(o))
+			}
+		} else {
+			var p strings.Builder
+			for k, l := range o.P {
+				px, py := svgXY(l.X, l.Y)
+				if k == 0 {
+					fmt.Fprintf(&p, "M%.2f %.2f", px, py)
+				} else {
+					fmt.Fprintf(&p, " L%.2f %.2f", px, py)
+				}
+			}
+			fmt.Fprintf(&b, `<path d="%s" fill="none" stroke="%s" stroke-width="%g"%s%s/>`+"\n", p.String(),
+				html.EscapeString(o.C), scene.LineWidth(o, 2), svgDash(o), svgOpacity(o))
+		}
+		fmt.Fprintf(&b, `<g fill="%s"%s>`+"\n", th.Foreground, svgOpacity(o))
+		for _, l := range o.P {
+			px, py := svgXY(l.X, l.Y)
+			fmt.Fprintf(&b, `<circle cx="%.2f" cy="%.2f" r="%g"/>`+"\n", px, py, scene.PointRadius(o, 1))
+		}
+		b.WriteString("</g>\n")
+	}
+
+	// Point labels on top, laid out the same way as on the page, with leader lines back to the points of any moved well
+	// away from them
+	labels := PointLabels(objects, inv, cX, cY, unit, pathLabels)
+	placed, _ := LayoutLabels(labels, CurveLines(objects, cX, cY, unit), w, h)
+	for _, l := range placed {
+		if l.Leader {
+			fmt.Fprintf(&b, `<line x1="%.2f" y1="%.2f" x2="%.2f" y2="%.2f" stroke="%s" stroke-width="1"/>`+"\n",
+				l.X, l.Y, l.LX, l.LY, th.Muted)
+		}
+	}
+	for _, l := range placed {
+		if l.Box {
+			fmt.Fprintf(&b, `<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="%s" stroke="%s"/>`+"\n",
+				l.X1, l.Y1, l.X2-l.X1, l.Y2-l.Y1, th.Background, th.Muted)
+		}
+		fmt.Fprintf(&b,
+			`<text x="%.2f" y="%.2f" text-anchor="%s" style="font: %s" fill="%s" xml:space="preserve">%s</text>`+"\n",
+			l.TX, l.TY, svgAnchor(l.Align), html.EscapeString(l.Font), th.Foreground, html.EscapeString(l.Text))
+	}
+
+	// Curve names along their paths
+	if pathLabels {
+		for _, o := range objects {
+			if !scene.IsCurve(o) || o.Name == "" {
+				continue
+			}
+			for _, l := range PathLabelPositions(ScreenPoints(o, cX, cY, unit), left, top, w, h) {
+				fmt.Fprintf(&b, `<text transform="translate(%.2f %.2f) rotate(%.2f)" y="%d" text-anchor="middle" `+
+					`style="font: bold 12px sans-serif" fill="%s" stroke="%s" stroke-width="3" paint-order="stroke">`+
+					`%s</text>`+"\n", l.X, l.Y, l.Angle*180/math.Pi, -PathLabelOffset, html.EscapeString(o.C),
+					th.Background, html.EscapeString(o.Name))
+			}
+		}
+	}
+
+	// Border around the graph area
+	fmt.Fprintf(&b,
+		`<rect x="%.2f" y="%.2f" width="%.2f" height="%.2f" fill="none" stroke="%s" stroke-width="2"/>`+"\n",
+		border, border, w-border, h-border, th.Foreground)
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// Converts a canvas textAlign value into the SVG text-anchor equivalent
+func svgAnchor(align string) string {
+	switch align {
+	case "center":
+		return "middle"
+	case "right", "end":
+		return "end"
+	}
+	return "start"
+}
+
+// Returns the dash pattern attribute for drawing the lines of an object, or nothing when they're solid
+func svgDash(o scene.Object) string {
+	if len(o.DashPattern) == 0 {
+		return ""
+	}
+	var d []string
+	for _, l := range o.DashPattern {
+		d = append(d, strconv.FormatFloat(l, 'g', -1, 64))
+	}
+	return fmt.Sprintf(` stroke-dasharray="%s"`, strings.Join(d, " "))
+}
+
+// Returns the opacity attribute for drawing the lines and dots of an object, or nothing when it's opaque.  Its
+// surfaces have the opacity in their fill colour instead
+func svgOpacity(o scene.Object) string {
+	if a := scene.Opacity(o); a < 1 {
+		return fmt.Sprintf(` opacity="%g"`, a)
+	}
+	return ""
+}
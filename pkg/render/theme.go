@@ -0,0 +1,46 @@
+package render
+
+// The colours used for drawing everything other than the objects themselves
+type Theme struct {
+	Name       string
+	Background string // Graph and info panel background
+	GridMajor  string // Grid lines matching the axis tick marks
+	GridMinor  string // Grid lines in between
+	Foreground string // Edges, point labels, point dots, and the graph border
+	Text       string // Info panel text
+	Help       string // Help text in the info panel
+	Link       string // The source code link
+	Muted      string // Less important things, such as the scroll bar and "paused" indicator
+	Alert      string // Things needing attention, such as the recording indicator
+}
+
+var (
+	LightTheme = Theme{
+		Name:       "light",
+		Background: "white",
+		GridMajor:  "rgb(210, 210, 210)",
+		GridMinor:  "rgb(238, 238, 238)",
+		Foreground: "black",
+		Text:       "black",
+		Help:       "blue",
+		Link:       "blue",
+		Muted:      "rgb(160, 160, 160)",
+		Alert:      "red",
+	}
+
+	DarkTheme = Theme{
+		Name:       "dark",
+		Background: "rgb(30, 30, 34)",
+		GridMajor:  "rgb(75, 75, 82)",
+		GridMinor:  "rgb(48, 48, 54)",
+		Foreground: "rgb(225, 225, 225)",
+		Text:       "rgb(225, 225, 225)",
+		Help:       "rgb(120, 170, 255)",
+		Link:       "rgb(120, 170, 255)",
+		Muted:      "rgb(110, 110, 118)",
+		Alert:      "rgb(255, 90, 90)",
+	}
+
+	// The available themes, in the order the toggle key cycles through them
+	Themes = []Theme{LightTheme, DarkTheme}
+)
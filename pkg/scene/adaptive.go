@@ -0,0 +1,87 @@
+package scene
+
+import (
+	"math"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+)
+
+const (
+	AdaptiveStart = 32 // Number of even intervals adaptive sampling starts from
+	AdaptiveDepth = 10 // Most times each of those intervals is halved
+)
+
+// Returns how far a point is from the straight line through a and b
+func chordError(a Point, b Point, p Point) float64 {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	l := math.Hypot(dx, dy)
+	if l == 0 {
+		return math.Hypot(p.X-a.X, p.Y-a.Y)
+	}
+	return math.Abs(dx*(p.Y-a.Y)-dy*(p.X-a.X)) / l
+}
+
+// Samples an expression of x over the given range, with the points closer together where the curve bends and further
+// apart where it's nearly straight.  It starts from AdaptiveStart even intervals, then halves each for as long as the
+// curve strays more than tol from the straight line across it, checked at the middle and quarter points.  Where the
+// expression stops being defined, the intervals are halved too, so the curve runs right up to the edge.  Points where
+// it isn't defined are left out, as with SampleCurve
+func SampleAdaptive(n expr.Node, minX float64, maxX float64, tol float64) (o Object) {
+	vars := map[string]float64{}
+	at := func(x float64) Point {
+		vars["x"] = x
+		return Point{X: x, Y: n.Eval(vars)}
+	}
+	add := func(p Point) {
+		if Finite(p.Y) {
+			o.P = append(o.P, p)
+		}
+	}
+
+	// Adds the points strictly between a and b, in order
+	var split func(a Point, b Point, depth int)
+	split = func(a Point, b Point, depth int) {
+		if depth >= AdaptiveDepth {
+			return
+		}
+		w := b.X - a.X
+		q1, m, q3 := at(a.X+w/4), at(a.X+w/2), at(a.X+3*w/4)
+		if straight(a, b, tol, q1, m, q3) {
+			return
+		}
+		split(a, m, depth+1)
+		add(m)
+		split(m, b, depth+1)
+	}
+
+	prev := at(minX)
+	add(prev)
+	for i := 1; i <= AdaptiveStart; i++ {
+		p := at(minX + (maxX-minX)*float64(i)/AdaptiveStart)
+		split(prev, p, 0)
+		add(p)
+		prev = p
+	}
+	return
+}
+
+// Returns true if the curve between a and b is close enough to a straight line, going by points along it.  Intervals
+// where the curve isn't defined at all count as straight, as there's nothing to draw, but ones where it's only
+// defined some of the way don't
+func straight(a Point, b Point, tol float64, pts ...Point) bool {
+	defined := Finite(a.Y) && Finite(b.Y)
+	for _, p := range pts {
+		if Finite(p.Y) != defined {
+			return false
+		}
+	}
+	if !defined {
+		return !Finite(a.Y) && !Finite(b.Y)
+	}
+	for _, p := range pts {
+		if chordError(a, b, p) > tol {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,214 @@
+package scene
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+)
+
+const (
+	AxisLength    = 10  // The axes run from -AxisLength to +AxisLength on each arm, unless the scene needs them longer
+	maxAxisLength = 1e6 // Longest the axes are made to reach things further out
+	TickSpacing   = 50  // Rough number of pixels wanted between tick marks
+	tickSize      = 5   // Length in pixels of each half of a tick mark
+	tickLabelGap  = 16  // Distance in pixels from an axis to its tick labels
+	maxTicks      = 200 // Most tick marks to generate on each side of each axis
+
+	// How long the Z arm of the axes looks on screen, compared with its real length, when it starts fading out and
+	// when it's gone.  It's faded as it turns to point toward (or away from) the viewer, so it doesn't end up as a
+	// jumble of tick labels on top of the origin
+	zAxisFadeLength = 0.5
+	zAxisHideLength = 0.15
+)
+
+var (
+	// The X/Y axes, at their usual length.  The Z arm is separate, see NewZAxis
+	Axes = NewAxes(AxisLength, AxisLength)
+)
+
+// Returns how long an arm of the axes needs to be to reach past the given distance from the origin.  That's
+// AxisLength, unless something is further out, when it's the next round number beyond it
+func axisLength(extent float64) float64 {
+	if !(extent > AxisLength) {
+		return AxisLength
+	}
+	if extent >= maxAxisLength {
+		return maxAxisLength
+	}
+	p := math.Pow(10, math.Floor(math.Log10(extent)))
+	for _, f := range []float64{1.2, 1.5, 2, 2.5, 3, 4, 5, 6, 8, 10} {
+		if f*p > extent {
+			return f * p
+		}
+	}
+	return 10 * p
+}
+
+// Returns how long the arms of the X, Y, and Z axes need to be to cover everything inside the given box, in graph
+// co-ordinates, so nothing runs off their ends.  They're never shorter than AxisLength
+func AxisLengths(b Bounds) (x float64, y float64, z float64) {
+	return axisLength(math.Max(-b.Min.X, b.Max.X)), axisLength(math.Max(-b.Min.Y, b.Max.Y)),
+		axisLength(math.Max(-b.Min.Z, b.Max.Z))
+}
+
+// Generates the tick marks and numeric labels for axes with arms of the given lengths, at the given interval.  Their
+// sizes are given in pixels, so the number of pixels per graph unit at the current zoom level is needed too
+func AxisTicks(interval float64, unit float64, xLen float64, yLen float64) (ticks Object) {
+	ticks.Name = "ticks"
+	ticks.Tags = []string{"axes"}
+	ticks.C = "black"
+	ticks.LabelFont = "12px sans-serif"
+
+	// Tick marks and labels are generated in graph units, so their sizes on screen depend on the zoom level
+	if unit <= 0 {
+		return
+	}
+	half := tickSize / unit
+	gap := tickLabelGap / unit
+	decimals := 0
+	if interval < 1 {
+		decimals = int(math.Ceil(-math.Log10(interval)))
+	}
+
+	n := int(math.Min(math.Floor(math.Max(xLen, yLen)/interval), maxTicks))
+	for i := -n; i <= n; i++ {
+		v := float64(i) * interval
+		if i == 0 {
+			continue // Leave room for the origin
+		}
+		label := localise(strconv.FormatFloat(v, 'f', decimals, 64))
+
+		// X axis, leaving room for the X labels at the ends
+		if math.Abs(v) < xLen {
+			p := len(ticks.P)
+			ticks.P = append(ticks.P,
+				Point{X: v, Y: -half},
+				Point{X: v, Y: half},
+				Point{X: v, Y: -gap, Label: label, LabelAlign: "center"},
+			)
+			ticks.E = append(ticks.E, Edge{p, p + 1})
+		}
+
+		// Y axis
+		if math.Abs(v) < yLen {
+			p := len(ticks.P)
+			ticks.P = append(ticks.P,
+				Point{X: -half, Y: v},
+				Point{X: half, Y: v},
+				Point{X: gap / 2, Y: v, Label: label, LabelAlign: "left"},
+			)
+			ticks.E = append(ticks.E, Edge{p, p + 1})
+		}
+	}
+	return
+}
+
+// Returns X/Y axes with arms of the given lengths, as thin bars joined at the origin.  Their labels are placed just
+// past the ends, at distances in proportion to the lengths
+func NewAxes(x float64, y float64) Object {
+	t := 0.1 // Half the thickness of the bars
+	return Object{
+		C:         "grey",
+		DrawOrder: 0,
+		Name:      "axes",
+		Tags:      []string{"axes"},
+		P: []Point{
+			{X: -t, Y: t},
+			{X: -t, Y: y},
+			{X: t, Y: y},
+			{X: t, Y: t},
+			{X: x, Y: t},
+			{X: x, Y: -t},
+			{X: t, Y: -t},
+			{X: t, Y: -y},
+			{X: -t, Y: -y},
+			{X: -t, Y: -t},
+			{X: -x, Y: -t},
+			{X: -x, Y: t},
+			{X: x, Y: -y / 10, Label: "X", LabelAlign: "center"},
+			{X: -x, Y: -y / 10, Label: "-X", LabelAlign: "center"},
+			{X: 0.0, Y: y * 1.05, Label: "Y", LabelAlign: "center"},
+			{X: 0.0, Y: -y * 1.1, Label: "-Y", LabelAlign: "center"},
+		},
+		E: []Edge{
+			{0, 1},
+			{1, 2},
+			{2, 3},
+			{3, 4},
+			{4, 5},
+			{5, 6},
+			{6, 7},
+			{7, 8},
+			{8, 9},
+			{9, 10},
+			{10, 11},
+			{11, 0},
+		},
+		S: []Surface{
+			{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+		},
+	}
+}
+
+// Returns the Z arm of the axes, with the given length, as a line through the origin with tick marks and labels at the
+// given interval.  The tick marks are small crosses, so they show whichever way the graph is turned.  Sizes are in
+// pixels, as for AxisTicks.  Labels are drawn at full strength whatever the object's opacity, so a faded arm leaves
+// out its tick labels.  An opacity of 0 gives an empty object, for when the arm is pointing at the viewer
+func NewZAxis(z float64, interval float64, unit float64, opacity float64) Object {
+	o := Object{
+		C:         "grey",
+		DrawOrder: 0,
+		Name:      "zaxis",
+		Tags:      []string{"axes"},
+		LabelFont: "12px sans-serif",
+	}
+	if opacity <= 0 || unit <= 0 {
+		return o
+	}
+	if opacity < 1 {
+		o.Alpha = opacity
+	}
+	o.P = []Point{
+		{Z: -z},
+		{Z: z},
+		{Z: z * 1.05, Label: "Z", LabelAlign: "center"},
+		{Z: -z * 1.1, Label: "-Z", LabelAlign: "center"},
+	}
+	o.E = []Edge{{0, 1}}
+
+	half := tickSize / unit
+	gap := tickLabelGap / unit
+	decimals := 0
+	if interval < 1 {
+		decimals = int(math.Ceil(-math.Log10(interval)))
+	}
+	n := int(math.Min(math.Floor(z/interval), maxTicks))
+	for i := -n; i <= n; i++ {
+		v := float64(i) * interval
+		if i == 0 || math.Abs(v) >= z {
+			continue // Leave room for the origin, and the labels at the ends
+		}
+		p := len(o.P)
+		o.P = append(o.P, Point{X: -half, Z: v}, Point{X: half, Z: v}, Point{Y: -half, Z: v}, Point{Y: half, Z: v})
+		o.E = append(o.E, Edge{p, p + 1}, Edge{p + 2, p + 3})
+		if opacity >= 1 {
+			label := localise(strconv.FormatFloat(v, 'f', decimals, 64))
+			o.P = append(o.P, Point{X: gap / 2, Z: v, Label: label, LabelAlign: "left"})
+		}
+	}
+	return o
+}
+
+// Returns the tick interval suiting the given number of pixels per graph unit
+func TickInterval(unit float64) float64 {
+	return geometry.NiceInterval(TickSpacing / unit)
+}
+
+// Returns how opaque the Z arm of the axes should be drawn when viewed through the given matrix, from 1 when it's
+// across the screen to 0 when it's pointing straight toward or away from the viewer.  Only the rotation counts
+func ZAxisOpacity(m geometry.Matrix) float64 {
+	r := geometry.Rotation(m)
+	l := math.Sqrt(r[2]*r[2] + r[6]*r[6]) // How long the Z arm looks on screen, for each unit of its length
+	return math.Max(0, math.Min(1, (l-zAxisHideLength)/(zAxisFadeLength-zAxisHideLength)))
+}
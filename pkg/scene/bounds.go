@@ -0,0 +1,109 @@
+package scene
+
+import (
+	"math"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+)
+
+// A box lined up with the axes, from its smallest co-ordinates to its largest
+type Bounds struct {
+	Min, Max Point
+}
+
+// Returns the smallest box holding all of the object's points, leaving out any which aren't finite.  Returns false
+// when it has none
+func (o Object) BoundingBox() (Bounds, bool) {
+	inf := math.Inf(1)
+	b := Bounds{Min: Point{X: inf, Y: inf, Z: inf}, Max: Point{X: -inf, Y: -inf, Z: -inf}}
+	ok := false
+	for _, p := range o.P {
+		if !Finite(p.X) || !Finite(p.Y) || !Finite(p.Z) {
+			continue
+		}
+		b.Min.X, b.Min.Y, b.Min.Z = math.Min(b.Min.X, p.X), math.Min(b.Min.Y, p.Y), math.Min(b.Min.Z, p.Z)
+		b.Max.X, b.Max.Y, b.Max.Z = math.Max(b.Max.X, p.X), math.Max(b.Max.Y, p.Y), math.Max(b.Max.Z, p.Z)
+		ok = true
+	}
+	return b, ok
+}
+
+// Returns the middle of the box
+func (b Bounds) Centre() Point {
+	return Point{X: (b.Min.X + b.Max.X) / 2, Y: (b.Min.Y + b.Max.Y) / 2, Z: (b.Min.Z + b.Max.Z) / 2}
+}
+
+// Returns the box around everything shown in a scene, leaving out the axes, grid planes, and hidden objects.  Returns
+// false when there's nothing else
+func Extent(objs []Object) (Bounds, bool) {
+	var all Bounds
+	found := false
+	for _, o := range objs {
+		if o.Hidden || HasTag(o, "axes") || HasTag(o, "grid") {
+			continue
+		}
+		b, ok := o.BoundingBox()
+		if !ok {
+			continue
+		}
+		if !found {
+			all, found = b, true
+			continue
+		}
+		all = all.Union(b)
+	}
+	return all, found
+}
+
+// Returns the box around everything under a node in the scene graph, in the co-ordinates of the root, leaving out the
+// axes, grid planes, and hidden objects.  Boxes of transformed nodes are moved by their transforms, so can be a little
+// larger than what's inside.  Returns false when there's nothing else
+func (n *Node) Extent() (Bounds, bool) {
+	var all Bounds
+	found := false
+	n.Walk(func(c *Node) {
+		if c.Object.Hidden || HasTag(c.Object, "axes") || HasTag(c.Object, "grid") {
+			return
+		}
+		b, ok := c.Object.BoundingBox()
+		if !ok {
+			return
+		}
+		b = b.Transform(c.World())
+		if !found {
+			all, found = b, true
+			return
+		}
+		all = all.Union(b)
+	})
+	return all, found
+}
+
+// Returns the box around the given one after it's moved by a transform, holding all eight of its moved corners
+func (b Bounds) Transform(m geometry.Matrix) Bounds {
+	inf := math.Inf(1)
+	t := Bounds{Min: Point{X: inf, Y: inf, Z: inf}, Max: Point{X: -inf, Y: -inf, Z: -inf}}
+	for i := 0; i < 8; i++ {
+		c := b.Min
+		if i&1 != 0 {
+			c.X = b.Max.X
+		}
+		if i&2 != 0 {
+			c.Y = b.Max.Y
+		}
+		if i&4 != 0 {
+			c.Z = b.Max.Z
+		}
+		x, y, z := geometry.Apply(m, c.X, c.Y, c.Z)
+		t = t.Union(Bounds{Min: Point{X: x, Y: y, Z: z}, Max: Point{X: x, Y: y, Z: z}})
+	}
+	return t
+}
+
+// Returns the smallest box holding both boxes
+func (b Bounds) Union(c Bounds) Bounds {
+	return Bounds{
+		Min: Point{X: math.Min(b.Min.X, c.Min.X), Y: math.Min(b.Min.Y, c.Min.Y), Z: math.Min(b.Min.Z, c.Min.Z)},
+		Max: Point{X: math.Max(b.Max.X, c.Max.X), Y: math.Max(b.Max.Y, c.Max.Y), Z: math.Max(b.Max.Z, c.Max.Z)},
+	}
+}
@@ -0,0 +1,78 @@
+package scene
+
+import (
+	"math"
+	"testing"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+)
+
+func TestBoundingBox(t *testing.T) {
+	tests := []struct {
+		name string
+		p    []Point
+		want Bounds
+		ok   bool
+	}{
+		{"none", nil, Bounds{}, false},
+		{"one", []Point{{X: 1, Y: 2, Z: 3}}, Bounds{Min: Point{X: 1, Y: 2, Z: 3}, Max: Point{X: 1, Y: 2, Z: 3}}, true},
+		{"several", []Point{{X: 1, Y: -2, Z: 0}, {X: -4, Y: 5, Z: 0.5}, {X: 0, Y: 0, Z: -1}},
+			Bounds{Min: Point{X: -4, Y: -2, Z: -1}, Max: Point{X: 1, Y: 5, Z: 0.5}}, true},
+		{"not finite", []Point{{X: math.NaN(), Y: 100}, {X: 2, Y: 3}, {X: 1, Y: math.Inf(1)}},
+			Bounds{Min: Point{X: 2, Y: 3}, Max: Point{X: 2, Y: 3}}, true},
+		{"only not finite", []Point{{X: math.NaN()}, {Y: math.Inf(-1)}}, Bounds{}, false},
+	}
+	for _, tc := range tests {
+		b, ok := Object{P: tc.p}.BoundingBox()
+		if ok != tc.ok || (ok && b != tc.want) {
+			t.Errorf("%s: BoundingBox = %v, %v, want %v, %v", tc.name, b, ok, tc.want, tc.ok)
+		}
+	}
+}
+
+func TestExtent(t *testing.T) {
+	objs := []Object{
+		Axes,
+		{Name: "grid", Tags: []string{"grid"}, P: []Point{{X: -50, Y: -50}, {X: 50, Y: 50}}},
+		{Name: "hidden", Hidden: true, P: []Point{{X: 1000}}},
+		{Name: "f1", P: []Point{{X: -2, Y: 1}, {X: 3, Y: 4}}},
+		{Name: "f2", P: []Point{{X: 0, Y: -6, Z: 2}}},
+	}
+	want := Bounds{Min: Point{X: -2, Y: -6}, Max: Point{X: 3, Y: 4, Z: 2}}
+	if b, ok := Extent(objs); !ok || b != want {
+		t.Errorf("Extent = %v, %v, want %v", b, ok, want)
+	}
+	if b, ok := Extent(objs[:3]); ok {
+		t.Errorf("Extent of just the axes, grid, and hidden objects = %v, want none", b)
+	}
+
+	// In the scene graph, the boxes are moved by the nodes' transforms
+	root := NewNode(Object{Name: "root"})
+	root.Add(NewNode(Axes))
+	n := NewNode(Object{Name: "f1", P: []Point{{X: -2, Y: 1}, {X: 3, Y: 4}}})
+	n.Local = geometry.Translate(geometry.Scale(geometry.Identity(), 2, 2, 2), 10, 0, 0)
+	root.Add(n)
+	want = Bounds{Min: Point{X: 6, Y: 2}, Max: Point{X: 16, Y: 8}}
+	if b, ok := root.Extent(); !ok || b != want {
+		t.Errorf("scene graph Extent = %v, %v, want %v", b, ok, want)
+	}
+}
+
+func TestAxisLengths(t *testing.T) {
+	tests := []struct {
+		b       Bounds
+		x, y, z float64
+	}{
+		{Bounds{}, AxisLength, AxisLength, AxisLength},
+		{Bounds{Min: Point{X: -3, Y: -2, Z: -1}, Max: Point{X: 3, Y: 10, Z: 1}}, AxisLength, AxisLength, AxisLength},
+		{Bounds{Min: Point{X: -3, Y: -40, Z: 0}, Max: Point{X: 25, Y: 2, Z: -1}}, 30, 50, AxisLength},
+		{Bounds{Min: Point{X: -11, Y: 0, Z: -99}, Max: Point{X: 0, Y: 100, Z: 0}}, 12, 120, 100},
+		{Bounds{Min: Point{X: -13, Y: 0, Z: 0}, Max: Point{X: 2e7, Y: 0, Z: 0}}, maxAxisLength, AxisLength, AxisLength},
+		{Bounds{Min: Point{X: math.NaN()}, Max: Point{X: math.NaN()}}, AxisLength, AxisLength, AxisLength},
+	}
+	for _, tc := range tests {
+		if x, y, z := AxisLengths(tc.b); x != tc.x || y != tc.y || z != tc.z {
+			t.Errorf("AxisLengths(%v) = %v, %v, %v, want %v, %v, %v", tc.b, x, y, z, tc.x, tc.y, tc.z)
+		}
+	}
+}
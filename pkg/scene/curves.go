@@ -0,0 +1,290 @@
+package scene
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+)
+
+const (
+	MaxSamples        = 10000000 // Most points SampleArrays will sample, to keep the arrays to a sensible size
+	ParametricSamples = 400      // Number of points sampled along a parametric curve
+	ParamDefault      = 1.0      // Value of an equation's parameters before they've been set
+)
+
+// A parametric space curve, with x, y, and z as functions of t
+type Parametric struct {
+	Src        string    // The curve, tidied up from how it was entered
+	X, Y, Z    expr.Node // The co-ordinates
+	MinT, MaxT float64   // The range of t the curve is plotted over
+}
+
+// Colour pairs for plotting equations, the first for the equation and the second for its derivative
+var Palette = [][2]string{
+	{"blue", "green"},
+	{"red", "orange"},
+	{"purple", "orchid"},
+	{"teal", "turquoise"},
+	{"saddlebrown", "peru"},
+	{"crimson", "hotpink"},
+	{"darkolivegreen", "yellowgreen"},
+	{"navy", "steelblue"},
+}
+
+// Reports whether a value is an ordinary number, rather than NaN or infinite
+func Finite(v float64) bool {
+	return !math.IsNaN(v) && !math.IsInf(v, 0)
+}
+
+// Reports whether an equation looks like a parametric curve, such as "x = cos(t); y = sin(t)"
+func IsParametric(src string) bool {
+	for _, p := range SplitParametric(src) {
+		i := strings.Index(p, "=")
+		if i < 0 {
+			continue
+		}
+		switch strings.Replace(p[:i], " ", "", -1) {
+		case "x", "x(t)", "z", "z(t)", "y(t)", "t":
+			return true
+		}
+	}
+	return false
+}
+
+// Names and colours a sampled equation curve (or derivative), labelling its first point with the equation
+func NameCurve(o Object, name string, colour string, equation string, order int) Object {
+	o.Name = name
+	o.C = colour
+	o.DrawOrder = order
+	o.Equation = equation
+	if len(o.P) > 0 {
+		o.P[0].Label = fmt.Sprintf(" %s: %s ", name, equation)
+		o.P[0].LabelAlign = "right"
+	}
+	return o
+}
+
+// Parses an equation the same as ParseFunction, but also allowing parameters, as in the family of curves
+// "y = a·x³ + b".  Any single letter other than x, y, and z can be a parameter.  Their names are returned sorted
+func ParseFamily(src string) (string, expr.Node, []string, error) {
+	src = strings.TrimSpace(src)
+	rhs := src
+	if i := strings.Index(src, "="); i >= 0 {
+		lhs := strings.Replace(src[:i], " ", "", -1)
+		if lhs != "y" && !strings.HasSuffix(lhs, "(x)") {
+			return "", nil, nil, fmt.Errorf("only equations of the form y = f(x) can be plotted")
+		}
+		rhs = src[i+1:]
+	}
+	if strings.TrimSpace(rhs) == "" {
+		return "", nil, nil, fmt.Errorf("the equation is empty")
+	}
+	n, err := expr.Parse(rhs)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	var params []string
+	for _, v := range expr.Vars(n) {
+		switch {
+		case v == "x":
+		case len(v) != 1 || v == "y" || v == "z":
+			return "", nil, nil, fmt.Errorf("unknown name '%s'", v)
+		default:
+			params = append(params, v)
+		}
+	}
+	return "y = " + strings.TrimSpace(rhs), n, params, nil
+}
+
+// Parses an equation such as "y = x^2", "f(x) = sin(x)", or just "x^2".  Returns the equation in the "y = ..." form,
+// and its parsed right hand side
+func ParseFunction(src string) (string, expr.Node, error) {
+	eq, n, params, err := ParseFamily(src)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(params) > 0 {
+		return "", nil, fmt.Errorf("unknown name '%s'", params[0])
+	}
+	return eq, n, nil
+}
+
+// Parses a parametric curve such as "x = cos(t); y = sin(t); z = t/5; t = 0..4pi".  Any co-ordinates left out are
+// zero, and the range of t defaults to 0..2pi
+func ParseParametric(src string) (*Parametric, error) {
+	c := &Parametric{MinT: 0, MaxT: 2 * math.Pi}
+	tRange := "0..2π"
+	for _, p := range SplitParametric(src) {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		i := strings.Index(p, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("expected 'x = ...', 'y = ...', 'z = ...', or 't = min..max', not '%s'",
+				strings.TrimSpace(p))
+		}
+		lhs := strings.Replace(p[:i], " ", "", -1)
+		rhs := strings.TrimSpace(p[i+1:])
+		if lhs == "t" {
+			var err error
+			c.MinT, c.MaxT, err = ParseRange(rhs)
+			if err != nil {
+				return nil, err
+			}
+			tRange = rhs
+			continue
+		}
+		n, err := expr.ParseVars(rhs, "t")
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", lhs, err)
+		}
+		switch lhs {
+		case "x", "x(t)":
+			c.X = n
+		case "y", "y(t)":
+			c.Y = n
+		case "z", "z(t)":
+			c.Z = n
+		default:
+			return nil, fmt.Errorf("unknown co-ordinate '%s'", lhs)
+		}
+	}
+	if c.X == nil && c.Y == nil && c.Z == nil {
+		return nil, fmt.Errorf("the curve needs at least one of x, y, or z")
+	}
+
+	// Missing co-ordinates stay on zero
+	var parts []string
+	for _, a := range []struct {
+		name string
+		n    *expr.Node
+	}{{"x", &c.X}, {"y", &c.Y}, {"z", &c.Z}} {
+		if *a.n == nil {
+			*a.n = expr.Num(0)
+			continue
+		}
+		parts = append(parts, a.name+" = "+(*a.n).String())
+	}
+	c.Src = strings.Join(parts, "; ") + "; t = " + tRange
+	return c, nil
+}
+
+// Parses a range such as "0..2pi", "-1 to 1", or "-0,5..1,5"
+func ParseRange(s string) (float64, float64, error) {
+	sep := ".."
+	if !strings.Contains(s, sep) {
+		sep = " to "
+	}
+	i := strings.Index(s, sep)
+	if i < 0 {
+		return 0, 0, fmt.Errorf("expected a range like '0..2pi'")
+	}
+	var v [2]float64
+	for j, b := range []string{s[:i], s[i+len(sep):]} {
+		// Plain numbers can use a comma for the decimal separator, which expressions can't
+		if f, err := ParseNumber(b); err == nil {
+			v[j] = f
+			continue
+		}
+		n, err := expr.ParseVars(b)
+		if err != nil {
+			return 0, 0, fmt.Errorf("range: %v", err)
+		}
+		v[j] = n.Eval(nil)
+		if !Finite(v[j]) {
+			return 0, 0, fmt.Errorf("range: '%s' isn't a number", strings.TrimSpace(b))
+		}
+	}
+	if v[0] >= v[1] {
+		return 0, 0, fmt.Errorf("the start of the range must be less than the end")
+	}
+	return v[0], v[1], nil
+}
+
+// Samples the curve evenly over its range of t, returning the points as a curve object.  Points where the curve isn't
+// defined are left out
+func (c *Parametric) Sample(n int) (o Object) {
+	vars := map[string]float64{}
+	for i := 0; i < n; i++ {
+		vars["t"] = c.MinT + (c.MaxT-c.MinT)*float64(i)/float64(n-1)
+		p := Point{X: c.X.Eval(vars), Y: c.Y.Eval(vars), Z: c.Z.Eval(vars)}
+		if !Finite(p.X) || !Finite(p.Y) || !Finite(p.Z) {
+			continue
+		}
+		o.P = append(o.P, p)
+	}
+	return
+}
+
+// Samples an equation or parametric curve at n evenly spaced values of x (or of t, for parametric curves) from min to
+// max, as arrays of co-ordinates ready for handing over to javascript.  z is only returned for parametric curves.
+// Unlike SampleCurve the points where it isn't defined are kept, as NaN, so the arrays stay evenly spaced
+func SampleArrays(src string, min float64, max float64, n int) (x []float64, y []float64, z []float64, err error) {
+	if n < 2 || n > MaxSamples {
+		return nil, nil, nil, fmt.Errorf("the number of samples must be from 2 to %d", MaxSamples)
+	}
+	if !Finite(min) || !Finite(max) || min >= max {
+		return nil, nil, nil, fmt.Errorf("the start of the range must be less than the end")
+	}
+	x, y = make([]float64, n), make([]float64, n)
+	vars := map[string]float64{}
+	if IsParametric(src) {
+		c, err := ParseParametric(src)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		z = make([]float64, n)
+		for i := range x {
+			vars["t"] = min + (max-min)*float64(i)/float64(n-1)
+			x[i], y[i], z[i] = c.X.Eval(vars), c.Y.Eval(vars), c.Z.Eval(vars)
+		}
+		return x, y, z, nil
+	}
+	_, f, err := ParseFunction(src)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for i := range x {
+		x[i] = min + (max-min)*float64(i)/float64(n-1)
+		vars["x"] = x[i]
+		y[i] = f.Eval(vars)
+	}
+	return x, y, nil, nil
+}
+
+// Samples an expression of x over the given range, returning the points as a curve object.  Points where the
+// expression isn't defined (eg the square root of a negative number) are left out
+func SampleCurve(n expr.Node, minX float64, maxX float64, step float64) (o Object) {
+	vars := map[string]float64{}
+	for x := minX; x <= maxX+step/2; x += step {
+		vars["x"] = x
+		y := n.Eval(vars)
+		if !Finite(y) {
+			continue
+		}
+		o.P = append(o.P, Point{X: x, Y: y})
+	}
+	return
+}
+
+// Splits a parametric curve definition into its parts, which are separated by semicolons or by commas outside of any
+// brackets
+func SplitParametric(src string) (parts []string) {
+	depth, start := 0, 0
+	for i, c := range src {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ';', ',':
+			if depth == 0 {
+				parts = append(parts, src[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, src[start:])
+}
@@ -0,0 +1,183 @@
+package scene
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+)
+
+const (
+	DerivedPoints = 1000 // Number of points on the common grid derived curves are sampled at
+	DerivedOrder  = 200  // Draw order of the first derived curve, putting them above the equations and data series
+)
+
+var (
+	// Colours for derived curves, chosen to stand apart from the equations
+	DerivedColours = []string{"black", "slategray", "maroon", "darkgreen", "darkorange", "mediumvioletred"}
+)
+
+// A curve a derived curve can be worked out from, giving its y value anywhere in its range of x.  The value is NaN
+// where the curve isn't defined
+type Source struct {
+	MinX, MaxX float64
+	At         func(x float64) float64
+}
+
+// Returns a source for an expression of x, over the range it's plotted over
+func ExprSource(n expr.Node, minX float64, maxX float64) Source {
+	vars := map[string]float64{}
+	return Source{MinX: minX, MaxX: maxX, At: func(x float64) float64 {
+		vars["x"] = x
+		return n.Eval(vars)
+	}}
+}
+
+// Returns true if the rune can be part of a name in an expression
+func identRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// Returns true if a name can start at position i, which it can't part way through another name.  It can straight
+// after a number though, as in "2f1"
+func nameStart(rs []rune, i int) bool {
+	j := i
+	for j > 0 && (unicode.IsDigit(rs[j-1]) || rs[j-1] == '.') {
+		j--
+	}
+	return j == 0 || !identRune(rs[j-1])
+}
+
+// Parses a derived curve such as "c3 = f1 - f2" or "abs(f1')", which works out a new curve from existing ones.  The
+// names of the existing curves are given, and can be used in the expression along with x.  Returns the new curve's
+// name (or "" if it wasn't given one), the parsed expression, and the curves it uses.  Names which aren't plain
+// identifiers, like the "f1'" of a derivative, are found by matching them against the given names, the longest
+// first, so they're swapped for placeholders before parsing
+func ParseDerived(src string, names []string) (string, expr.Node, []string, error) {
+	name, rhs := "", strings.TrimSpace(src)
+	if i := strings.Index(rhs, "="); i >= 0 {
+		name, rhs = strings.TrimSpace(rhs[:i]), strings.TrimSpace(rhs[i+1:])
+		if name == "" {
+			return "", nil, nil, fmt.Errorf("the new curve's name is missing")
+		}
+	}
+	if rhs == "" {
+		return "", nil, nil, fmt.Errorf("the expression is empty")
+	}
+	sorted := append([]string(nil), names...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	// Swap the curve names for placeholders, only matching whole names.  A number can come straight before one, as
+	// in "2f1"
+	var refs []string
+	placeholder := map[string]string{}
+	rs := []rune(rhs)
+	var b strings.Builder
+	for i := 0; i < len(rs); {
+		matched := ""
+		if nameStart(rs, i) {
+			for _, n := range sorted {
+				nr := []rune(n)
+				if n == "" || n == "x" || i+len(nr) > len(rs) || string(rs[i:i+len(nr)]) != n {
+					continue
+				}
+				if i+len(nr) < len(rs) && identRune(nr[len(nr)-1]) && identRune(rs[i+len(nr)]) {
+					continue
+				}
+				matched = n
+				break
+			}
+		}
+		if matched == "" {
+			b.WriteRune(rs[i])
+			i++
+			continue
+		}
+		p, ok := placeholder[matched]
+		if !ok {
+			refs = append(refs, matched)
+			p = fmt.Sprintf("_%d", len(refs))
+			placeholder[matched] = p
+		}
+		b.WriteString(" " + p + " ")
+		i += len([]rune(matched))
+	}
+	allowed := []string{"x"}
+	for _, p := range placeholder {
+		allowed = append(allowed, p)
+	}
+	n, err := expr.ParseVars(b.String(), allowed...)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return name, n, refs, nil
+}
+
+// Returns a source for a curve given as points, such as a data series, interpolating straight between them.  The
+// points don't need to be in order of x
+func PointSource(pts []Point) Source {
+	var p []Point
+	for _, j := range pts {
+		if Finite(j.X) && Finite(j.Y) {
+			p = append(p, j)
+		}
+	}
+	sort.SliceStable(p, func(i, j int) bool { return p[i].X < p[j].X })
+	if len(p) == 0 {
+		return Source{MinX: math.NaN(), MaxX: math.NaN(), At: func(x float64) float64 { return math.NaN() }}
+	}
+	return Source{MinX: p[0].X, MaxX: p[len(p)-1].X, At: func(x float64) float64 {
+		i := sort.Search(len(p), func(i int) bool { return p[i].X >= x })
+		switch {
+		case i == len(p) || (i == 0 && x < p[0].X):
+			return math.NaN()
+		case p[i].X == x || i == 0:
+			return p[i].Y
+		}
+		a, b := p[i-1], p[i]
+		return a.Y + (b.Y-a.Y)*(x-a.X)/(b.X-a.X)
+	}}
+}
+
+// Samples a derived curve on a common grid of evenly spaced x values, across the range where all of the curves it
+// uses are defined.  Curves which don't use any others are sampled from minX to maxX.  Points where any of them, or
+// the result, isn't defined are left out
+func SampleDerived(n expr.Node, refs []string, sources map[string]Source, minX float64,
+	maxX float64) (o Object, err error) {
+	var src []Source
+	for i, r := range refs {
+		s, ok := sources[r]
+		if !ok {
+			return o, fmt.Errorf("there's no curve called '%s'", r)
+		}
+		if i == 0 {
+			minX, maxX = s.MinX, s.MaxX
+		}
+		minX, maxX = math.Max(minX, s.MinX), math.Min(maxX, s.MaxX)
+		src = append(src, s)
+	}
+	if !(minX < maxX) {
+		return o, fmt.Errorf("the curves don't overlap along x")
+	}
+	vars := map[string]float64{}
+	for i := 0; i < DerivedPoints; i++ {
+		x := minX + (maxX-minX)*float64(i)/float64(DerivedPoints-1)
+		vars["x"] = x
+		ok := true
+		for j, s := range src {
+			v := s.At(x)
+			ok = ok && Finite(v)
+			vars[fmt.Sprintf("_%d", j+1)] = v
+		}
+		if !ok {
+			continue
+		}
+		if y := n.Eval(vars); Finite(y) {
+			o.P = append(o.P, Point{X: x, Y: y})
+		}
+	}
+	return o, nil
+}
@@ -0,0 +1,356 @@
+package scene
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+)
+
+const (
+	DistTailColour = "rgba(255, 140, 0, 0.5)" // Colour of the shaded tail regions
+	distSamples    = 200                      // Number of points sampled along a continuous distributions' curve
+	distBarWidth   = 0.8                      // Width of the bars for discrete distributions, in graph units
+)
+
+// A built in probability distribution
+type Distribution struct {
+	Name     string
+	Aliases  []string
+	Params   []string // Names of the parameters, for display
+	Discrete bool
+	Check    func(p []float64) error
+	PDF      func(x float64, p []float64) float64 // Probability density, or probability mass for discrete distributions
+	CDF      func(x float64, p []float64) float64 // Cumulative probability, for continuous distributions
+	Domain   func(p []float64) (float64, float64) // Range of x to plot over
+}
+
+// A plotted distribution, with an optional shaded tail region
+type DistPlot struct {
+	Name   string // Short name, used for the object names, eg "d1"
+	Dist   *Distribution
+	Params []float64
+	Colour string
+	Tail   bool    // Set when a tail region is shaded
+	A, B   float64 // Bounds of the tail region
+	Prob   float64 // Probability of a value in the tail region
+}
+
+var (
+	Distributions = []*Distribution{
+		{
+			Name: "normal", Aliases: []string{"gauss", "gaussian"}, Params: []string{"μ", "σ"},
+			Check: func(p []float64) error {
+				if p[1] <= 0 {
+					return fmt.Errorf("σ must be greater than 0")
+				}
+				return nil
+			},
+			PDF: func(x float64, p []float64) float64 {
+				z := (x - p[0]) / p[1]
+				return math.Exp(-z*z/2) / (p[1] * math.Sqrt(2*math.Pi))
+			},
+			CDF: func(x float64, p []float64) float64 {
+				return (1 + math.Erf((x-p[0])/(p[1]*math.Sqrt2))) / 2
+			},
+			Domain: func(p []float64) (float64, float64) {
+				return p[0] - 4*p[1], p[0] + 4*p[1]
+			},
+		},
+		{
+			Name: "binomial", Aliases: []string{"binom"}, Params: []string{"n", "p"}, Discrete: true,
+			Check: func(p []float64) error {
+				if p[0] < 0 || p[0] > 1000 || p[0] != math.Floor(p[0]) {
+					return fmt.Errorf("n must be a whole number from 0 to 1000")
+				}
+				if p[1] < 0 || p[1] > 1 {
+					return fmt.Errorf("p must be between 0 and 1")
+				}
+				return nil
+			},
+			PDF: func(k float64, p []float64) float64 {
+				if k < 0 || k > p[0] {
+					return 0
+				}
+				return math.Exp(lnChoose(p[0], k) + xLogY(k, p[1]) + xLogY(p[0]-k, 1-p[1]))
+			},
+			Domain: func(p []float64) (float64, float64) {
+				return 0, p[0]
+			},
+		},
+		{
+			Name: "poisson", Params: []string{"λ"}, Discrete: true,
+			Check: func(p []float64) error {
+				if p[0] <= 0 || p[0] > 1000 {
+					return fmt.Errorf("λ must be greater than 0, and at most 1000")
+				}
+				return nil
+			},
+			PDF: func(k float64, p []float64) float64 {
+				if k < 0 {
+					return 0
+				}
+				lg, _ := math.Lgamma(k + 1)
+				return math.Exp(k*math.Log(p[0]) - p[0] - lg)
+			},
+			Domain: func(p []float64) (float64, float64) {
+				return 0, math.Ceil(p[0] + 4*math.Sqrt(p[0]) + 4)
+			},
+		},
+		{
+			Name: "chisq", Aliases: []string{"chi2", "chisquare", "chisquared"}, Params: []string{"k"},
+			Check: func(p []float64) error {
+				if p[0] <= 0 || p[0] > 1000 {
+					return fmt.Errorf("k must be greater than 0, and at most 1000")
+				}
+				return nil
+			},
+			PDF: func(x float64, p []float64) float64 {
+				if x < 0 {
+					return 0
+				}
+				h := p[0] / 2
+				lg, _ := math.Lgamma(h)
+				return math.Exp((h-1)*math.Log(x) - x/2 - h*math.Ln2 - lg)
+			},
+			CDF: func(x float64, p []float64) float64 {
+				if x <= 0 {
+					return 0
+				}
+				return regGammaP(p[0]/2, x/2)
+			},
+			Domain: func(p []float64) (float64, float64) {
+				return 0, p[0] + 5*math.Sqrt(2*p[0])
+			},
+		},
+	}
+
+	// Colours for plotting distributions
+	DistColours = []string{"darkviolet", "darkcyan", "darkgoldenrod", "indigo", "firebrick", "olive"}
+)
+
+// Adds a bar to a bar chart object, centred on x
+func addBar(o *Object, x float64, height float64) {
+	n := len(o.P)
+	w := distBarWidth / 2
+	o.P = append(o.P, Point{X: x - w}, Point{X: x - w, Y: height}, Point{X: x + w, Y: height}, Point{X: x + w})
+	o.S = append(o.S, Surface{n, n + 1, n + 2, n + 3})
+}
+
+// Returns a description of the distribution, such as "normal(μ = 0, σ = 1)"
+func (d *DistPlot) Describe() string {
+	var p []string
+	for i, n := range d.Dist.Params {
+		p = append(p, n+" = "+FormatCoord(d.Params[i]))
+	}
+	return d.Dist.Name + "(" + strings.Join(p, ", ") + ")"
+}
+
+// Returns the built in distribution with the given name
+func FindDistribution(name string) (*Distribution, bool) {
+	name = strings.ToLower(name)
+	for _, d := range Distributions {
+		if d.Name == name {
+			return d, true
+		}
+		for _, a := range d.Aliases {
+			if a == name {
+				return d, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// Returns the log of the binomial coefficient "n choose k"
+func lnChoose(n float64, k float64) float64 {
+	a, _ := math.Lgamma(n + 1)
+	b, _ := math.Lgamma(k + 1)
+	c, _ := math.Lgamma(n - k + 1)
+	return a - b - c
+}
+
+// Generates the objects for a distribution and its tail region.  Continuous distributions are drawn as curves, and
+// discrete ones as bar charts.  The tail region has no points when there isn't one
+func (d *DistPlot) Objects() (o Object, t Object) {
+	minX, maxX := d.Dist.Domain(d.Params)
+	o = Object{Name: d.Name, C: d.Colour, Equation: d.Describe()}
+	t = Object{Name: d.Name + " tail", C: DistTailColour, Equation: "tail region"}
+	if d.Dist.Discrete {
+		for k := minX; k <= maxX; k++ {
+			p := d.Dist.PDF(k, d.Params)
+			addBar(&o, k, p)
+			if d.Tail && k >= d.A && k <= d.B {
+				addBar(&t, k, p)
+			}
+		}
+	} else {
+		step := (maxX - minX) / distSamples
+		for x := minX; x <= maxX+step/2; x += step {
+			if y := d.Dist.PDF(x, d.Params); Finite(y) {
+				o.P = append(o.P, Point{X: x, Y: y})
+			}
+		}
+
+		// The tail region follows the curve between the bounds, then comes back along the X axis
+		if d.Tail {
+			a, b := math.Max(d.A, minX), math.Min(d.B, maxX)
+			if a < b {
+				t.P = append(t.P, Point{X: a})
+				for i := 0; i <= distSamples; i++ {
+					x := a + (b-a)*float64(i)/distSamples
+					if y := d.Dist.PDF(x, d.Params); Finite(y) {
+						t.P = append(t.P, Point{X: x, Y: y})
+					}
+				}
+				t.P = append(t.P, Point{X: b})
+				var s Surface
+				for i := range t.P {
+					s = append(s, i)
+				}
+				t.S = []Surface{s}
+			}
+		}
+	}
+	if len(o.P) > 0 {
+		o.P[0].Label = fmt.Sprintf(" %s: %s ", d.Name, o.Equation)
+		o.P[0].LabelAlign = "right"
+	}
+	return
+}
+
+// Parses a distribution, with its parameters and an optional tail range
+func ParseDistribution(src string) (*DistPlot, error) {
+	src = strings.TrimSpace(src)
+	var tail string
+	if i := strings.Index(src, ";"); i >= 0 {
+		src, tail = strings.TrimSpace(src[:i]), strings.TrimSpace(src[i+1:])
+	}
+	open := strings.Index(src, "(")
+	if open < 0 || !strings.HasSuffix(src, ")") {
+		return nil, fmt.Errorf("expected a distribution like 'normal(0, 1)'")
+	}
+	dist, ok := FindDistribution(strings.TrimSpace(src[:open]))
+	if !ok {
+		var names []string
+		for _, d := range Distributions {
+			names = append(names, d.Name)
+		}
+		return nil, fmt.Errorf("unknown distribution '%s' (the built in ones are %s)", strings.TrimSpace(src[:open]),
+			strings.Join(names, ", "))
+	}
+	args := SplitParametric(src[open+1 : len(src)-1])
+	if len(args) != len(dist.Params) {
+		return nil, fmt.Errorf("%s needs %d parameters (%s)", dist.Name, len(dist.Params),
+			strings.Join(dist.Params, ", "))
+	}
+	d := &DistPlot{Dist: dist}
+	for i, a := range args {
+		n, err := expr.ParseVars(a)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", dist.Params[i], err)
+		}
+		v := n.Eval(nil)
+		if !Finite(v) {
+			return nil, fmt.Errorf("%s isn't a number", dist.Params[i])
+		}
+		d.Params = append(d.Params, v)
+	}
+	err := dist.Check(d.Params)
+	if err != nil {
+		return nil, err
+	}
+	if tail != "" {
+		d.A, d.B, err = ParseRange(tail)
+		if err != nil {
+			return nil, err
+		}
+		d.Tail = true
+		d.Prob = d.TailProbability()
+	}
+	return d, nil
+}
+
+// Works out the regularised lower incomplete gamma function P(a, x).  Its series expansion is used below x = a + 1,
+// and the continued fraction for the upper function Q(a, x) = 1 - P(a, x) above, as the series' terms grow too large
+// to add up there before they start shrinking
+func regGammaP(a float64, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	lg, _ := math.Lgamma(a)
+	scale := math.Exp(-x + a*math.Log(x) - lg)
+	if x < a+1 {
+		term := 1 / a
+		sum := term
+		for n := 1; n < 100000; n++ {
+			term *= x / (a + float64(n))
+			sum += term
+			if term < sum*1e-15 {
+				break
+			}
+		}
+		return math.Min(1, sum*scale)
+	}
+
+	// Modified Lentz's method, with tiny standing in for zero so nothing's divided by it
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for n := 1; n < 100000; n++ {
+		an := -float64(n) * (float64(n) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < 1e-15 {
+			break
+		}
+	}
+	return math.Max(0, 1-scale*h)
+}
+
+// Returns the text for the distribution, which can be parsed again when loading a scene
+func (d *DistPlot) Source() string {
+	var p []string
+	for _, v := range d.Params {
+		p = append(p, strconv.FormatFloat(v, 'f', -1, 64))
+	}
+	src := d.Dist.Name + "(" + strings.Join(p, ", ") + ")"
+	if d.Tail {
+		src += "; " + strconv.FormatFloat(d.A, 'f', -1, 64) + ".." + strconv.FormatFloat(d.B, 'f', -1, 64)
+	}
+	return src
+}
+
+// Returns the probability of a value from the distribution falling within its tail range
+func (d *DistPlot) TailProbability() float64 {
+	if !d.Dist.Discrete {
+		return d.Dist.CDF(d.B, d.Params) - d.Dist.CDF(d.A, d.Params)
+	}
+	p := 0.0
+	for k := math.Max(0, math.Ceil(d.A)); k <= math.Floor(d.B); k++ {
+		p += d.Dist.PDF(k, d.Params)
+	}
+	return math.Min(1, p)
+}
+
+// Returns x * log(y), taking 0 * log(0) as 0
+func xLogY(x float64, y float64) float64 {
+	if x == 0 {
+		return 0
+	}
+	return x * math.Log(y)
+}
@@ -0,0 +1,38 @@
+package scene
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRegGammaP(t *testing.T) {
+	tests := []struct {
+		a, x, want float64
+	}{
+		{1, 0, 0},
+		{1, 0.5, 1 - math.Exp(-0.5)},
+		{1, 3, 1 - math.Exp(-3)},
+		{0.5, 2, math.Erf(math.Sqrt(2))},
+		{0.5, 9, math.Erf(3)},
+		{2, 1, 1 - 2*math.Exp(-1)},
+		{2, 5, 1 - 6*math.Exp(-5)},
+		{2, 1000, 1},
+		{50, 1e5, 1},
+	}
+	for _, tc := range tests {
+		got := regGammaP(tc.a, tc.x)
+		if math.IsNaN(got) || math.Abs(got-tc.want) > 1e-12 {
+			t.Errorf("regGammaP(%v, %v) = %v, want %v", tc.a, tc.x, got, tc.want)
+		}
+	}
+}
+
+func TestChiSquaredTail(t *testing.T) {
+	d, err := ParseDistribution("chisq(4); 0..2000")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.IsNaN(d.Prob) || math.Abs(d.Prob-1) > 1e-12 {
+		t.Errorf("P(0..2000) = %v, want 1", d.Prob)
+	}
+}
@@ -0,0 +1,187 @@
+package scene
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Ways of reducing a large series of points to fewer, for drawing
+const (
+	DownsampleNone     = "none"     // Keep every point
+	DownsampleNth      = "nth"      // Keep every Nth point
+	DownsampleMinMax   = "minmax"   // Keep the lowest and highest points in each bucket of X, so spikes aren't lost
+	DownsampleLTTB     = "lttb"     // Largest Triangle Three Buckets, which keeps the points making the most difference
+	DownsampleEnvelope = "envelope" // Draw the mean of each bucket of X, in a shaded band from its lowest to highest
+)
+
+// The downsampling strategies, in the order clicking a series cycles through them
+var DownsampleModes = []string{DownsampleEnvelope, DownsampleMinMax, DownsampleLTTB, DownsampleNth, DownsampleNone}
+
+// Reduces a series of points to at most n, using the given strategy.  Series already no longer than n are returned
+// as they are
+func Downsample(pts []Point, mode string, n int) []Point {
+	if len(pts) <= n || n < 3 {
+		return pts
+	}
+	switch mode {
+	case DownsampleNth:
+		return downsampleNth(pts, n)
+	case DownsampleMinMax:
+		return downsampleMinMax(pts, n)
+	case DownsampleLTTB:
+		return downsampleLTTB(pts, n)
+	case DownsampleEnvelope:
+		_, _, mean := Envelope(pts, n)
+		return mean
+	}
+	return pts
+}
+
+// Reduces a series using Largest Triangle Three Buckets.  The first and last points are kept, and the rest are split
+// into n-2 buckets.  From each bucket the point forming the largest triangle with the point kept from the bucket
+// before and the average of the bucket after is kept
+func downsampleLTTB(pts []Point, n int) []Point {
+	out := make([]Point, 0, n)
+	out = append(out, pts[0])
+	size := float64(len(pts)-2) / float64(n-2)
+	a := 0
+	for i := 0; i < n-2; i++ {
+		// Average of the next bucket, or the last point for the final bucket
+		start, end := int(float64(i+1)*size)+1, int(float64(i+2)*size)+1
+		if end > len(pts) {
+			end = len(pts)
+		}
+		var avgX, avgY float64
+		for j := start; j < end; j++ {
+			avgX += pts[j].X
+			avgY += pts[j].Y
+		}
+		if c := float64(end - start); c > 0 {
+			avgX, avgY = avgX/c, avgY/c
+		} else {
+			avgX, avgY = pts[len(pts)-1].X, pts[len(pts)-1].Y
+		}
+
+		// The point in this bucket making the largest triangle
+		from, to := int(float64(i)*size)+1, int(float64(i+1)*size)+1
+		best, bestArea := from, -1.0
+		for j := from; j < to; j++ {
+			area := math.Abs((pts[a].X-avgX)*(pts[j].Y-pts[a].Y) - (pts[a].X-pts[j].X)*(avgY-pts[a].Y))
+			if area > bestArea {
+				best, bestArea = j, area
+			}
+		}
+		out = append(out, pts[best])
+		a = best
+	}
+	return append(out, pts[len(pts)-1])
+}
+
+// Reduces a series to the lowest and highest points in each of n/2 equal ranges of X, in their original order.  With
+// one bucket per pixel across the graph, this draws the same as the full series would
+func downsampleMinMax(pts []Point, n int) []Point {
+	minX, maxX := xRange(pts)
+	buckets := n / 2
+	lo, hi := make([]int, buckets), make([]int, buckets)
+	for i := range lo {
+		lo[i], hi[i] = -1, -1
+	}
+	for i, p := range pts {
+		b := xBucket(p.X, minX, maxX, buckets)
+		if lo[b] < 0 || p.Y < pts[lo[b]].Y {
+			lo[b] = i
+		}
+		if hi[b] < 0 || p.Y > pts[hi[b]].Y {
+			hi[b] = i
+		}
+	}
+	out := make([]Point, 0, n)
+	for b := range lo {
+		switch {
+		case lo[b] < 0:
+		case lo[b] == hi[b]:
+			out = append(out, pts[lo[b]])
+		case lo[b] < hi[b]:
+			out = append(out, pts[lo[b]], pts[hi[b]])
+		default:
+			out = append(out, pts[hi[b]], pts[lo[b]])
+		}
+	}
+	return out
+}
+
+// Reduces a series by keeping every Nth point, picking N so there are at most n.  The last point is always kept
+func downsampleNth(pts []Point, n int) []Point {
+	every := (len(pts) + n - 3) / (n - 1)
+	out := make([]Point, 0, n)
+	for i := 0; i < len(pts)-1; i += every {
+		out = append(out, pts[i])
+	}
+	return append(out, pts[len(pts)-1])
+}
+
+// Splits a series into n equal ranges of X, returning the lowest, highest, and mean Y of each.  Each is placed at the
+// mean X of its bucket, and empty buckets are left out
+func Envelope(pts []Point, n int) (lo []Point, hi []Point, mean []Point) {
+	minX, maxX := xRange(pts)
+	type bucket struct {
+		n                int
+		x, y, minY, maxY float64
+	}
+	b := make([]bucket, n)
+	for _, p := range pts {
+		i := xBucket(p.X, minX, maxX, n)
+		if b[i].n == 0 || p.Y < b[i].minY {
+			b[i].minY = p.Y
+		}
+		if b[i].n == 0 || p.Y > b[i].maxY {
+			b[i].maxY = p.Y
+		}
+		b[i].n++
+		b[i].x += p.X
+		b[i].y += p.Y
+	}
+	for _, k := range b {
+		if k.n == 0 {
+			continue
+		}
+		x := k.x / float64(k.n)
+		lo = append(lo, Point{X: x, Y: k.minY})
+		hi = append(hi, Point{X: x, Y: k.maxY})
+		mean = append(mean, Point{X: x, Y: k.y / float64(k.n)})
+	}
+	return
+}
+
+// Parses the name of a downsampling strategy
+func ParseDownsample(s string) (string, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	for _, m := range DownsampleModes {
+		if s == m {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("unknown downsampling '%s' (the choices are %s)", s, strings.Join(DownsampleModes, ", "))
+}
+
+// Returns which of n equal ranges from minX to maxX an X value falls in
+func xBucket(x float64, minX float64, maxX float64, n int) int {
+	b := 0
+	if maxX > minX {
+		b = int(float64(n) * (x - minX) / (maxX - minX))
+	}
+	if b >= n {
+		b = n - 1
+	}
+	return b
+}
+
+// Returns the lowest and highest X of a series
+func xRange(pts []Point) (float64, float64) {
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	for _, p := range pts {
+		minX, maxX = math.Min(minX, p.X), math.Max(maxX, p.X)
+	}
+	return minX, maxX
+}
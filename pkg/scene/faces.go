@@ -0,0 +1,84 @@
+package scene
+
+import (
+	"sort"
+)
+
+// A surface to draw, and whether it's turned away from the viewer
+type Face struct {
+	S    Surface
+	Back bool
+
+	depth float64 // Average Z of its points, for sorting
+}
+
+// Returns the surfaces of an object to draw, in the order to draw them.  Objects which care which way their surfaces
+// face (see Object.Backface) have those turned away from the viewer marked or left out.  Those, and translucent
+// objects, have the rest sorted from the furthest away to the nearest, so nearer ones cover (or tint) the ones
+// behind.  The points are expected to be transformed already, with the viewer looking down the Z axis
+func Faces(o Object) []Face {
+	faces := make([]Face, 0, len(o.S))
+	if o.Backface != "cull" && o.Backface != "shade" && Opacity(o) == 1 {
+		for _, s := range o.S {
+			faces = append(faces, Face{S: s})
+		}
+		return faces
+	}
+	for _, s := range o.S {
+		if len(s) == 0 {
+			continue
+		}
+		back := !FacesViewer(o, s)
+		if back && o.Backface == "cull" {
+			continue
+		}
+		var z float64
+		for _, n := range s {
+			z += o.P[n].Z
+		}
+		faces = append(faces, Face{S: s, Back: back, depth: z / float64(len(s))})
+	}
+	sort.SliceStable(faces, func(i, j int) bool { return faces[i].depth < faces[j].depth })
+	return faces
+}
+
+// Returns true if the points of a surface go anticlockwise as the viewer sees them, so its front faces them
+func FacesViewer(o Object, s Surface) bool {
+	_, _, z := Normal(o, s)
+	return z > 0
+}
+
+// Returns the normal of a surface, pointing out of its front, with a length of twice its area.  This is worked out
+// using Newell's method, so surfaces which aren't quite flat still work
+func Normal(o Object, s Surface) (x float64, y float64, z float64) {
+	for i, n := range s {
+		p, q := o.P[n], o.P[s[(i+1)%len(s)]]
+		x += (p.Y - q.Y) * (p.Z + q.Z)
+		y += (p.Z - q.Z) * (p.X + q.X)
+		z += (p.X - q.X) * (p.Y + q.Y)
+	}
+	return
+}
+
+// Returns the order to draw the surfaces of the objects in, as indexes into them.  Opaque objects come first, in the
+// order they're given, then translucent ones from the furthest away to the nearest, so whatever is behind them has
+// been drawn by the time they're blended over it
+func SurfaceOrder(objs []Object) []int {
+	var order, clear []int
+	depth := make([]float64, len(objs))
+	for i, o := range objs {
+		if Opacity(o) == 1 {
+			order = append(order, i)
+			continue
+		}
+		for _, p := range o.P {
+			depth[i] += p.Z
+		}
+		if len(o.P) > 0 {
+			depth[i] /= float64(len(o.P))
+		}
+		clear = append(clear, i)
+	}
+	sort.SliceStable(clear, func(i, j int) bool { return depth[clear[i]] < depth[clear[j]] })
+	return append(order, clear...)
+}
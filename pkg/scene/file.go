@@ -0,0 +1,246 @@
+package scene
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+)
+
+const (
+	Version = 1 // Version of the scene format written when saving
+
+	// The range of X values equations are plotted over, and the spacing of their points
+	DefaultMinX = -2.1
+	DefaultMaxX = 2.2
+	DefaultStep = 0.05
+)
+
+// A saved scene.  Equations and distributions are kept as the text they were entered as, and are plotted again when
+// the scene is loaded.  Objects added through the API are kept in graph co-ordinates
+type File struct {
+	Version       int
+	View          geometry.Matrix `json:",omitempty"` // The world transform, for the rotation, zoom, and position
+	Equations     []string        `json:",omitempty"`
+	Distributions []string        `json:",omitempty"`
+	Objects       []Object        `json:",omitempty"`
+
+	Domain []float64 `json:",omitempty"` // The range of x the equations are plotted over, when it isn't the default
+	Step   float64   `json:",omitempty"` // The step along x they're sampled at, or 0 for adaptive sampling
+
+	// The values of each equation's parameters, in the same order as the equations.  Parameters left out have their
+	// default value
+	Params []map[string]float64 `json:",omitempty"`
+
+	// Curves worked out from the equations and each other, as "name = expression"
+	Derived []string `json:",omitempty"`
+
+	// Direction the light shining on shaded surfaces comes from, as x, y, and z relative to the viewer, when it isn't
+	// the default
+	Light []float64 `json:",omitempty"`
+
+	// The render mode everything but the axes is drawn in, when it isn't each object's own.  See ApplyModes
+	Mode string `json:",omitempty"`
+
+	// The grid planes drawn along with the background grid, eg "xz".  See GridPlanes
+	GridPlanes []string `json:",omitempty"`
+}
+
+var (
+	// Migrations upgrading the raw JSON of older scenes, indexed by the version they upgrade from.  Each one only
+	// needs to handle the changes to the version after it, as they're applied in turn
+	migrations = map[int]func(raw map[string]interface{}) (map[string]interface{}, error){
+		0: migrate0,
+	}
+)
+
+// Generates the objects for a scene without the page, the same way the page plots them: the axes and their tick
+// marks (long enough to reach everything else), then the equations with their derivatives and the curves derived from
+// them, the distributions, the objects, and any grid planes, all moved into the saved view and drawn in their render
+// modes.  The number of pixels per graph unit before any zooming is needed for sizing the tick marks.  The scene's own
+// range of x and step are used instead of the ones given, when it has them.  Anything which can't be plotted is
+// reported, with the rest of the scene still generated
+func (s *File) Build(minX float64, maxX float64, step float64, unit float64) ([]Object, error) {
+	if len(s.Domain) == 2 && s.Domain[0] < s.Domain[1] {
+		minX, maxX = s.Domain[0], s.Domain[1]
+	}
+	if s.Step > 0 && (maxX-minX)/s.Step < MaxSamples {
+		step = s.Step
+	}
+	view := s.ViewMatrix()
+	unit *= geometry.Zoom(view)
+	objs := []Object{Axes, {}, {}} // The axes, tick marks, and Z arm are filled in at the end, to reach everything
+	var problems []string
+	sources := map[string]Source{}
+	for i, src := range s.Equations {
+		name, c, num := fmt.Sprintf("f%d", i+1), Palette[i%len(Palette)], i+1
+		if IsParametric(src) {
+			p, err := ParseParametric(src)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("equation '%s': %v", src, err))
+				continue
+			}
+			objs = append(objs, NameCurve(p.Sample(ParametricSamples), name, c[0], p.Src, num*2))
+			continue
+		}
+		_, family, params, err := ParseFamily(src)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("equation '%s': %v", src, err))
+			continue
+		}
+		n := expr.Substitute(family, s.ParamValues(i, params))
+		d := n.Deriv("x")
+		sources[name], sources[name+"'"] = ExprSource(n, minX, maxX), ExprSource(d, minX, maxX)
+		objs = append(objs, NameCurve(SampleCurve(n, minX, maxX, step), name, c[0], FormatEquation(family), num*2),
+			NameCurve(SampleCurve(d, minX, maxX, step), name+"'", c[1], FormatEquation(d), num*2+1))
+	}
+	for i, src := range s.Derived {
+		var names []string
+		for n := range sources {
+			names = append(names, n)
+		}
+		name, n, refs, err := ParseDerived(src, names)
+		var o Object
+		if err == nil {
+			o, err = SampleDerived(n, refs, sources, minX, maxX)
+		}
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("derived curve '%s': %v", src, err))
+			continue
+		}
+		if name == "" {
+			name = fmt.Sprintf("c%d", i+1)
+		}
+		sources[name] = PointSource(o.P)
+		objs = append(objs, NameCurve(o, name, DerivedColours[i%len(DerivedColours)], src, DerivedOrder+i+1))
+	}
+	for i, src := range s.Distributions {
+		d, err := ParseDistribution(src)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("distribution '%s': %v", src, err))
+			continue
+		}
+		d.Name, d.Colour = fmt.Sprintf("d%d", i+1), DistColours[i%len(DistColours)]
+		o, t := d.Objects()
+		objs = append(objs, o)
+		if len(t.P) > 0 {
+			objs = append(objs, t)
+		}
+	}
+	for _, o := range s.Objects {
+		if err := Validate(o); err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		objs = append(objs, o)
+	}
+	mode := s.Mode
+	if !ValidMode(mode) {
+		problems = append(problems, fmt.Sprintf("unknown mode '%s'", mode))
+		mode = ""
+	}
+	xLen, yLen, zLen := float64(AxisLength), float64(AxisLength), float64(AxisLength)
+	if b, ok := Extent(objs); ok {
+		xLen, yLen, zLen = AxisLengths(b)
+	}
+	objs[0], objs[1] = NewAxes(xLen, yLen), AxisTicks(TickInterval(unit), unit, xLen, yLen)
+	objs[2] = NewZAxis(zLen, TickInterval(unit), unit, ZAxisOpacity(view))
+	for _, p := range s.GridPlanes {
+		a, b := GridPlaneLengths(p, xLen, yLen, zLen)
+		g, err := GridPlane(p, a, b, TickInterval(unit))
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		objs = append(objs, g)
+	}
+	objs = ApplyModes(TransformObjects(objs, view), mode)
+	if len(problems) > 0 {
+		return objs, fmt.Errorf("some of the scene couldn't be plotted: %s", strings.Join(problems, "; "))
+	}
+	return objs, nil
+}
+
+// Upgrades scenes from before the format was versioned.  These were just the object JSON accepted by
+// wasmGraph.addObject, either a single object or a list of them
+func migrate0(raw map[string]interface{}) (map[string]interface{}, error) {
+	if _, ok := raw["Objects"]; ok {
+		return raw, nil
+	}
+	if _, ok := raw["P"]; ok {
+		return map[string]interface{}{"Objects": []interface{}{raw}}, nil
+	}
+	return nil, fmt.Errorf("it doesn't look like a scene or an object")
+}
+
+// Parses a saved scene, upgrading it to the current version of the format first if it was saved by an older one
+func Parse(data []byte) (*File, error) {
+	data = bytes.TrimSpace(data)
+	var raw map[string]interface{}
+	if bytes.HasPrefix(data, []byte("[")) {
+		// A bare list of objects, from before the format was versioned
+		var objs []interface{}
+		if err := json.Unmarshal(data, &objs); err != nil {
+			return nil, err
+		}
+		raw = map[string]interface{}{"Objects": objs}
+	} else if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	v := 0
+	if f, ok := raw["Version"].(float64); ok {
+		v = int(f)
+	}
+	if v > Version {
+		return nil, fmt.Errorf("the scene was saved by a newer version (format %d, this one reads up to %d)", v,
+			Version)
+	}
+	for ; v < Version; v++ {
+		m, ok := migrations[v]
+		if !ok {
+			return nil, fmt.Errorf("there's no way to upgrade scenes from format %d", v)
+		}
+		var err error
+		if raw, err = m(raw); err != nil {
+			return nil, fmt.Errorf("upgrading from format %d: %v", v, err)
+		}
+	}
+	raw["Version"] = Version
+
+	// Go through JSON again to fill in the struct
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, err
+	}
+	var s File
+	if err = json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// Returns the values of the named parameters of the i'th equation, using the default for any which weren't saved
+func (s *File) ParamValues(i int, names []string) map[string]float64 {
+	vals := map[string]float64{}
+	for _, p := range names {
+		vals[p] = ParamDefault
+		if i < len(s.Params) {
+			if v, ok := s.Params[i][p]; ok && Finite(v) {
+				vals[p] = v
+			}
+		}
+	}
+	return vals
+}
+
+// Returns the saved world transform, or the identity matrix for scenes saved without one
+func (s *File) ViewMatrix() geometry.Matrix {
+	if len(s.View) == 16 {
+		return s.View
+	}
+	return geometry.Identity()
+}
@@ -0,0 +1,135 @@
+package scene
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// A ready made graph in the gallery.  Each is plotted from scratch, using only the fields it has
+type GalleryEntry struct {
+	Name      string
+	Category  string
+	About     string         // A line saying what's interesting about it
+	Equations []string       `json:",omitempty"`
+	Surfaces  []MapParams    `json:",omitempty"` // Parameters for the surface generator, one set per surface
+	Domain    []float64      `json:",omitempty"` // The range of x the equations are plotted over
+	Labels    []GalleryLabel `json:",omitempty"` // Notes put over points of interest
+	View      string         `json:",omitempty"` // The view to turn to, such as "front" or "isometric"
+	Zoom      float64        `json:",omitempty"` // The zoom level which fits it on the graph, where it isn't 1
+}
+
+// A note over a point of a gallery graph, in graph co-ordinates
+type GalleryLabel struct {
+	X, Y, Z float64
+	Text    string
+}
+
+// Named parameters decoded from JSON, for handing to a generator.  Missing numbers are NaN and missing strings are
+// empty, the same as the javascript API gives them
+type MapParams map[string]interface{}
+
+// Entries added to the gallery after the built in ones, such as from a deployment's own library
+var galleryExtra []GalleryEntry
+
+// The gallery, grouped by category.  It's kept as data, so entries can be added without touching the code which
+// plots them
+const galleryJSON = `[
+	{"Name": "Sine and cosine", "Category": "Trigonometry", "About": "The same wave, a quarter turn apart",
+		"Equations": ["y = sin(x)", "y = cos(x)"], "Domain": [-6.3, 6.3], "View": "front", "Zoom": 0.35,
+		"Labels": [{"X": 1.5708, "Y": 1, "Text": "sin peaks at π/2"}, {"X": 0, "Y": 1, "Text": "cos peaks at 0"}]},
+	{"Name": "Tangent", "Category": "Trigonometry", "About": "sin(x)/cos(x), with asymptotes where cos(x) is 0",
+		"Equations": ["y = tan(x)"], "Domain": [-4.5, 4.5], "View": "front", "Zoom": 0.5,
+		"Labels": [{"X": 1.5708, "Y": 0, "Text": "x = π/2"}, {"X": -1.5708, "Y": 0, "Text": "x = -π/2"}]},
+	{"Name": "Beats", "Category": "Trigonometry", "About": "Two close frequencies adding up, and their envelope",
+		"Equations": ["y = sin(6x) + sin(7x)", "y = 2cos(x/2)"], "Domain": [-12.6, 12.6], "View": "front",
+		"Zoom": 0.17},
+	{"Name": "Growth and decay", "Category": "Exponentials", "About": "e^x and e^-x, mirror images through the y axis",
+		"Equations": ["y = exp(x)", "y = exp(-x)"], "Domain": [-2, 2], "View": "front", "Zoom": 0.8,
+		"Labels": [{"X": 0, "Y": 1, "Text": "both pass through (0, 1)"}]},
+	{"Name": "Logistic curve", "Category": "Exponentials", "About": "Growth which levels off, as in populations",
+		"Equations": ["y = 1/(1 + exp(-x))"], "Domain": [-6, 6], "View": "front", "Zoom": 0.37,
+		"Labels": [{"X": 0, "Y": 0.5, "Text": "steepest at the midpoint"}]},
+	{"Name": "Gaussian", "Category": "Exponentials", "About": "The bell curve e^-x², and its derivative",
+		"Equations": ["y = exp(-x^2)"], "Domain": [-3, 3], "View": "front", "Zoom": 0.7},
+	{"Name": "Damped oscillation", "Category": "Oscillations", "About": "A spring losing energy, inside its envelope",
+		"Equations": ["y = exp(-x/4)cos(3x)", "y = exp(-x/4)", "y = -exp(-x/4)"], "Domain": [0, 12], "View": "front",
+		"Zoom": 0.18},
+	{"Name": "Sinc", "Category": "Oscillations", "About": "sin(x)/x, which is 1 at x = 0 despite dividing by 0",
+		"Equations": ["y = sin(x)/x"], "Domain": [-15, 15], "View": "front", "Zoom": 0.15,
+		"Labels": [{"X": 0, "Y": 1, "Text": "limit of 1"}]},
+	{"Name": "Chirp", "Category": "Oscillations", "About": "A wave whose frequency rises as it goes",
+		"Equations": ["y = sin(x^2)"], "Domain": [0, 6], "View": "front", "Zoom": 0.37},
+	{"Name": "Saddle", "Category": "Surfaces", "About": "z = x² - y², curving up one way and down the other",
+		"Surfaces": [{"src": "x^2 - y^2", "min": -1.5, "max": 1.5, "n": 20}], "View": "isometric",
+		"Labels": [{"X": 0, "Y": 0, "Z": 0, "Text": "saddle point"}]},
+	{"Name": "Monkey saddle", "Category": "Surfaces", "About": "z = x³ - 3xy², with room for a tail",
+		"Surfaces": [{"src": "x^3 - 3x*y^2", "min": -1.2, "max": 1.2, "n": 24}], "View": "isometric"},
+	{"Name": "Paraboloid", "Category": "Surfaces", "About": "A bowl, the same curve turned around the z axis",
+		"Surfaces": [{"src": "(x^2 + y^2)/2", "min": -1.5, "max": 1.5, "n": 20}], "View": "isometric"},
+	{"Name": "Ripple", "Category": "Surfaces", "About": "Waves spreading out from a stone dropped in a pond",
+		"Surfaces": [{"src": "sin(4sqrt(x^2 + y^2))/4", "min": -2, "max": 2, "n": 40}], "View": "isometric",
+		"Zoom": 0.9}
+]`
+
+// Adds entries to the end of the gallery, from a JSON list in the same form as the built in ones.  Returns the number
+// added
+func AddGallery(data []byte) (int, error) {
+	var g []GalleryEntry
+	if err := json.Unmarshal(data, &g); err != nil {
+		return 0, fmt.Errorf("the gallery entries couldn't be read: %v", err)
+	}
+	for i, e := range g {
+		if strings.TrimSpace(e.Name) == "" {
+			return 0, fmt.Errorf("gallery entry %d needs a name", i+1)
+		}
+		if len(e.Equations) == 0 && len(e.Surfaces) == 0 {
+			return 0, fmt.Errorf("the gallery entry '%s' has nothing to plot", e.Name)
+		}
+	}
+	galleryExtra = append(galleryExtra, g...)
+	return len(g), nil
+}
+
+// Returns the gallery entry with the given name, ignoring case
+func FindGallery(name string) (GalleryEntry, error) {
+	g, err := Gallery()
+	if err != nil {
+		return GalleryEntry{}, err
+	}
+	var names []string
+	for _, e := range g {
+		if strings.EqualFold(e.Name, strings.TrimSpace(name)) {
+			return e, nil
+		}
+		names = append(names, e.Name)
+	}
+	return GalleryEntry{}, fmt.Errorf("there's nothing called '%s' in the gallery (the choices are %s)", name,
+		strings.Join(names, ", "))
+}
+
+// Returns a number parameter, or NaN when it's missing or isn't a number
+func (p MapParams) Float(name string) float64 {
+	if v, ok := p[name].(float64); ok {
+		return v
+	}
+	return math.NaN()
+}
+
+// Returns the entries in the gallery, in order, followed by any added to it
+func Gallery() ([]GalleryEntry, error) {
+	var g []GalleryEntry
+	if err := json.Unmarshal([]byte(galleryJSON), &g); err != nil {
+		return nil, fmt.Errorf("the gallery couldn't be read: %v", err)
+	}
+	return append(g, galleryExtra...), nil
+}
+
+// Returns a string parameter, or an empty string when it's missing or isn't a string
+func (p MapParams) String(name string) string {
+	if v, ok := p[name].(string); ok {
+		return v
+	}
+	return ""
+}
@@ -0,0 +1,272 @@
+package scene
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+)
+
+const (
+	generatorRange = 5  // Default extent of generated grids either side of the origin
+	generatorGrid  = 20 // Default number of grid cells along each side
+	generatorBins  = 10 // Default number of histogram bars
+)
+
+// Named parameters, as given through the javascript API.  Missing numbers are NaN and missing strings are empty, so
+// defaults can be filled in
+type Params interface {
+	Float(name string) float64
+	String(name string) string
+}
+
+// Something which makes a new kind of object from its parameters, such as a surface or a histogram.  New plot types
+// can be added by registering a Generator, without changing the rest of the scene code
+type Generator interface {
+	Generate(p Params) (Object, error)
+}
+
+// Lets an ordinary function be used as a Generator
+type GeneratorFunc func(p Params) (Object, error)
+
+var (
+	// The registered generators, by name
+	generators = map[string]Generator{}
+)
+
+func init() {
+	Register("field", GeneratorFunc(generateField))
+	Register("histogram", GeneratorFunc(generateHistogram))
+	Register("isosurface", GeneratorFunc(generateIsosurface))
+	Register("surface", GeneratorFunc(generateSurface))
+}
+
+// Returns the value of a number parameter, or the default when it's missing
+func floatParam(p Params, name string, def float64) float64 {
+	if v := p.Float(name); Finite(v) {
+		return v
+	}
+	return def
+}
+
+// Makes an object using the named generator
+func Generate(name string, p Params) (Object, error) {
+	g, ok := generators[strings.ToLower(name)]
+	if !ok {
+		return Object{}, fmt.Errorf("unknown generator '%s' (the choices are %s)", name,
+			strings.Join(GeneratorNames(), ", "))
+	}
+	return g.Generate(p)
+}
+
+// Calls the function
+func (f GeneratorFunc) Generate(p Params) (Object, error) {
+	return f(p)
+}
+
+// Draws a vector field as arrows on a grid.  "dx" and "dy" give the vector at each point as expressions of x and y,
+// over "min" to "max" in both directions, with "n" arrows along each side.  The arrows are scaled so the longest just
+// fills its grid cell
+func generateField(p Params) (o Object, err error) {
+	dx, err := expr.ParseVars(p.String("dx"), "x", "y")
+	if err != nil {
+		return o, fmt.Errorf("dx: %v", err)
+	}
+	dy, err := expr.ParseVars(p.String("dy"), "x", "y")
+	if err != nil {
+		return o, fmt.Errorf("dy: %v", err)
+	}
+	min, max, n, err := gridParams(p)
+	if err != nil {
+		return o, err
+	}
+	cell := (max - min) / float64(n)
+	type arrow struct{ x, y, vx, vy float64 }
+	var arrows []arrow
+	longest := 0.0
+	vars := map[string]float64{}
+	for i := 0; i <= n; i++ {
+		for j := 0; j <= n; j++ {
+			vars["x"], vars["y"] = min+float64(i)*cell, min+float64(j)*cell
+			a := arrow{x: vars["x"], y: vars["y"], vx: dx.Eval(vars), vy: dy.Eval(vars)}
+			if !Finite(a.vx) || !Finite(a.vy) {
+				continue
+			}
+			arrows = append(arrows, a)
+			longest = math.Max(longest, math.Hypot(a.vx, a.vy))
+		}
+	}
+	if longest == 0 {
+		return o, fmt.Errorf("the field is zero everywhere")
+	}
+	scale := 0.9 * cell / longest
+	for _, a := range arrows {
+		ex, ey := a.x+a.vx*scale, a.y+a.vy*scale
+		l := math.Hypot(ex-a.x, ey-a.y)
+		if l == 0 {
+			continue
+		}
+
+		// The shaft, then two short lines back from the tip for the head
+		ux, uy := (ex-a.x)/l, (ey-a.y)/l
+		head := math.Min(l/3, cell/4)
+		k := len(o.P)
+		o.P = append(o.P, Point{X: a.x, Y: a.y}, Point{X: ex, Y: ey},
+			Point{X: ex - head*(ux-uy/2), Y: ey - head*(uy+ux/2)},
+			Point{X: ex - head*(ux+uy/2), Y: ey - head*(uy-ux/2)})
+		o.E = append(o.E, Edge{k, k + 1}, Edge{k + 1, k + 2}, Edge{k + 1, k + 3})
+	}
+	o.Equation = fmt.Sprintf("field (%s, %s)", dx, dy)
+	return o, nil
+}
+
+// Draws a histogram of the numbers in "values" (separated by commas or spaces), with "bins" bars.  The bars' heights
+// are the number of values in each
+func generateHistogram(p Params) (o Object, err error) {
+	var vals []float64
+	for _, f := range strings.FieldsFunc(p.String("values"), func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n'
+	}) {
+		v, err := strconv.ParseFloat(f, 64)
+		if err != nil || !Finite(v) {
+			return o, fmt.Errorf("'%s' isn't a number", f)
+		}
+		vals = append(vals, v)
+	}
+	if len(vals) == 0 {
+		return o, fmt.Errorf("there are no values to count")
+	}
+	bins := int(floatParam(p, "bins", generatorBins))
+	if bins < 1 || bins > 1000 {
+		return o, fmt.Errorf("the number of bins needs to be from 1 to 1000")
+	}
+	lo, hi := vals[0], vals[0]
+	for _, v := range vals {
+		lo, hi = math.Min(lo, v), math.Max(hi, v)
+	}
+	if hi == lo {
+		lo, hi = lo-0.5, hi+0.5
+	}
+	counts := make([]int, bins)
+	for _, v := range vals {
+		b := int(float64(bins) * (v - lo) / (hi - lo))
+		if b >= bins {
+			b = bins - 1
+		}
+		counts[b]++
+	}
+	w := (hi - lo) / float64(bins)
+	for i, c := range counts {
+		if c == 0 {
+			continue
+		}
+		x, k := lo+float64(i)*w, len(o.P)
+		o.P = append(o.P, Point{X: x}, Point{X: x, Y: float64(c)}, Point{X: x + w, Y: float64(c)}, Point{X: x + w})
+		o.S = append(o.S, Surface{k, k + 1, k + 2, k + 3})
+	}
+	o.Equation = fmt.Sprintf("histogram of %d values", len(vals))
+	return o, nil
+}
+
+// Draws the surface z = f(x, y) as a wireframe grid.  "src" is the expression (with or without the "z ="), over "min"
+// to "max" in both directions, with "n" grid cells along each side.  Giving "faces" fills the grid cells in too, shaded
+// by the light, with it saying what to do with their backs: "show", "shade", or "cull"
+func generateSurface(p Params) (o Object, err error) {
+	faces := strings.ToLower(p.String("faces"))
+	if faces != "" && faces != "show" && faces != "shade" && faces != "cull" {
+		return o, fmt.Errorf("unknown faces '%s' (the choices are show, shade, and cull)", faces)
+	}
+	src := strings.TrimSpace(p.String("src"))
+	if i := strings.Index(src, "="); i >= 0 {
+		if strings.TrimSpace(src[:i]) != "z" {
+			return o, fmt.Errorf("surfaces are given as z = f(x, y)")
+		}
+		src = src[i+1:]
+	}
+	f, err := expr.ParseVars(src, "x", "y")
+	if err != nil {
+		return o, err
+	}
+	min, max, n, err := gridParams(p)
+	if err != nil {
+		return o, err
+	}
+	cell := (max - min) / float64(n)
+	index := make([]int, (n+1)*(n+1)) // Index of the point at each grid position, or -1 where it's undefined
+	vars := map[string]float64{}
+	for i := 0; i <= n; i++ {
+		for j := 0; j <= n; j++ {
+			vars["x"], vars["y"] = min+float64(i)*cell, min+float64(j)*cell
+			z := f.Eval(vars)
+			index[i*(n+1)+j] = -1
+			if Finite(z) {
+				index[i*(n+1)+j] = len(o.P)
+				o.P = append(o.P, Point{X: vars["x"], Y: vars["y"], Z: z})
+			}
+		}
+	}
+	if len(o.P) == 0 {
+		return o, fmt.Errorf("the surface isn't defined anywhere in the range")
+	}
+	for i := 0; i <= n; i++ {
+		for j := 0; j <= n; j++ {
+			a := index[i*(n+1)+j]
+			if a < 0 {
+				continue
+			}
+			if i < n && index[(i+1)*(n+1)+j] >= 0 {
+				o.E = append(o.E, Edge{a, index[(i+1)*(n+1)+j]})
+			}
+			if j < n && index[i*(n+1)+j+1] >= 0 {
+				o.E = append(o.E, Edge{a, index[i*(n+1)+j+1]})
+			}
+
+			// The cells go anticlockwise seen from above, so their fronts face up
+			if faces != "" && i < n && j < n {
+				b, c, d := index[(i+1)*(n+1)+j], index[(i+1)*(n+1)+j+1], index[i*(n+1)+j+1]
+				if b >= 0 && c >= 0 && d >= 0 {
+					o.S = append(o.S, Surface{a, b, c, d})
+				}
+			}
+		}
+	}
+	if faces != "" {
+		o.Shading = "flat"
+	}
+	if faces != "show" {
+		o.Backface = faces
+	}
+	o.Equation = "z = " + f.String()
+	return o, nil
+}
+
+// Returns the names of the registered generators, in alphabetical order
+func GeneratorNames() []string {
+	var names []string
+	for n := range generators {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Returns the "min", "max", and "n" parameters of a generated grid, filling in the defaults
+func gridParams(p Params) (min float64, max float64, n int, err error) {
+	min, max = floatParam(p, "min", -generatorRange), floatParam(p, "max", generatorRange)
+	if min >= max {
+		return 0, 0, 0, fmt.Errorf("the minimum needs to be less than the maximum")
+	}
+	n = int(floatParam(p, "n", generatorGrid))
+	if n < 1 || n > 200 {
+		return 0, 0, 0, fmt.Errorf("the grid size needs to be from 1 to 200")
+	}
+	return
+}
+
+// Adds a generator, replacing any already registered with the same name
+func Register(name string, g Generator) {
+	generators[strings.ToLower(name)] = g
+}
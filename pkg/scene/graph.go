@@ -0,0 +1,118 @@
+package scene
+
+import (
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+)
+
+// A node of the scene graph.  Its object's points are in the node's own co-ordinates, which its Local transform places
+// within its parent's, so transforming a node carries all of its children along with it.  Nodes which just group
+// others together have an object with a name but no points
+type Node struct {
+	Object   Object
+	Local    geometry.Matrix
+	Children []*Node
+	parent   *Node
+}
+
+// Adds a child node, taking it away from any parent it had before
+func (n *Node) Add(c *Node) {
+	if c.parent != nil {
+		c.parent.Remove(c)
+	}
+	c.parent = n
+	n.Children = append(n.Children, c)
+}
+
+// Returns the number of objects with points in the node and everything under it, and the number of points they have
+// between them
+func (n *Node) size() (objects int, points int) {
+	if len(n.Object.P) > 0 {
+		objects, points = 1, len(n.Object.P)
+	}
+	for _, c := range n.Children {
+		o, p := c.size()
+		objects, points = objects+o, points+p
+	}
+	return
+}
+
+// Returns the node with the given object name, searching the children depth first.  Returns nil if there isn't one
+func (n *Node) Find(name string) *Node {
+	if n.Object.Name == name {
+		return n
+	}
+	for _, c := range n.Children {
+		if f := c.Find(name); f != nil {
+			return f
+		}
+	}
+	return nil
+}
+
+// Returns the objects of the node and everything under it, in depth first order, with their points transformed by
+// m composed with the Local transforms down the tree.  Objects without any points are left out
+func (n *Node) Flatten(m geometry.Matrix) []Object {
+	// The points for all of the objects share one allocation, as this is done for every frame of an animation
+	objects, points := n.size()
+	if objects == 0 {
+		return nil
+	}
+	objs, _ := n.flatten(m, make([]Object, 0, objects), make([]Point, points))
+	return objs
+}
+
+// Appends the transformed objects of the node and everything under it to objs, putting their points in the start of
+// pts.  Returns the objects, and what's left of pts
+func (n *Node) flatten(m geometry.Matrix, objs []Object, pts []Point) ([]Object, []Point) {
+	m = geometry.Multiply(m, n.Local)
+	if l := len(n.Object.P); l > 0 {
+		o := n.Object
+		o.P = pts[:l:l] // Capped, so appending to one object's points can't overwrite the next object's
+		transformPoints(m, n.Object.P, o.P)
+		objs, pts = append(objs, o), pts[l:]
+	}
+	for _, c := range n.Children {
+		objs, pts = c.flatten(m, objs, pts)
+	}
+	return objs, pts
+}
+
+// Returns a new node for an object, with no transform of its own
+func NewNode(o Object) *Node {
+	return &Node{Object: o, Local: geometry.Identity()}
+}
+
+// Returns the node's parent, or nil for the root
+func (n *Node) Parent() *Node {
+	return n.parent
+}
+
+// Removes a child node, along with everything under it.  Returns false if it isn't a child of this node
+func (n *Node) Remove(c *Node) bool {
+	for i, j := range n.Children {
+		if j == c {
+			n.Children = append(n.Children[:i], n.Children[i+1:]...)
+			c.parent = nil
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the transform from the node's own co-ordinates to those of the root, composed from its Local transform and
+// those of its parents
+func (n *Node) World() geometry.Matrix {
+	m := n.Local
+	for p := n.parent; p != nil; p = p.parent {
+		m = geometry.Multiply(p.Local, m)
+	}
+	return m
+}
+
+// Calls f for the node and everything under it, in depth first order
+func (n *Node) Walk(f func(*Node)) {
+	f(n)
+	for _, c := range n.Children {
+		c.Walk(f)
+	}
+}
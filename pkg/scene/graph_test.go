@@ -0,0 +1,94 @@
+package scene
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+)
+
+// Returns a scene like the page plots: the axes, with curves grouped in pairs under them so there's some depth to
+// the scene graph, sharing the points out between the curves
+func benchScene(points int, curves int) *Node {
+	root := NewNode(Object{})
+	axes := NewNode(Axes)
+	root.Add(axes)
+	n, _ := expr.Parse("sin(x)*x")
+	per := points / curves
+	var parent *Node
+	for i := 0; i < curves; i++ {
+		o := SampleCurve(n, -10, 10, 20/float64(per))
+		o.Name = fmt.Sprintf("f%d", i+1)
+		node := NewNode(o)
+		node.Local = geometry.Translate(geometry.Identity(), 0, float64(i), 0)
+		if i%2 == 0 {
+			axes.Add(node)
+			parent = node
+		} else {
+			parent.Add(node)
+		}
+	}
+	return root
+}
+
+func TestFlatten(t *testing.T) {
+	root := NewNode(Object{Name: "root"})
+	a := NewNode(Object{Name: "a", P: []Point{{X: 1, Y: 0, Z: 0, Label: "one"}}})
+	a.Local = geometry.Translate(geometry.Identity(), 0, 2, 0)
+	group := NewNode(Object{Name: "group"})
+	group.Local = geometry.Scale(geometry.Identity(), 3, 3, 3)
+	b := NewNode(Object{Name: "b", P: []Point{{X: 1, Y: 1, Z: 1}, {X: -1, Y: 0, Z: 2}}})
+	b.Local = geometry.Translate(geometry.Identity(), 1, 0, 0)
+	root.Add(a)
+	root.Add(group)
+	group.Add(b)
+
+	m := geometry.Translate(geometry.Identity(), 0, 0, 10)
+	objs := root.Flatten(m)
+
+	// Nodes without points are left out, and the rest come depth first with every transform above them applied
+	if len(objs) != 2 || objs[0].Name != "a" || objs[1].Name != "b" {
+		t.Fatalf("got %v, want objects a and b", objs)
+	}
+	want := [][]Point{
+		{{X: 1, Y: 2, Z: 10, Label: "one"}},
+		{{X: 6, Y: 3, Z: 13}, {X: 0, Y: 0, Z: 16}},
+	}
+	for i, o := range objs {
+		if len(o.P) != len(want[i]) {
+			t.Fatalf("%s has %d points, want %d", o.Name, len(o.P), len(want[i]))
+		}
+		for j, p := range o.P {
+			w := want[i][j]
+			near := math.Abs(p.X-w.X) < 1e-12 && math.Abs(p.Y-w.Y) < 1e-12 && math.Abs(p.Z-w.Z) < 1e-12
+			if !near || p.Label != w.Label {
+				t.Errorf("%s point %d = %v, want %v", o.Name, j, p, w)
+			}
+		}
+	}
+
+	// The scene's own points are left alone, and appending to one flattened object doesn't touch the next
+	if b.Object.P[0] != (Point{X: 1, Y: 1, Z: 1}) {
+		t.Errorf("flattening changed b's own point to %v", b.Object.P[0])
+	}
+	_ = append(objs[0].P, Point{X: 99})
+	if objs[1].P[0].X != 6 {
+		t.Errorf("appending to a's points overwrote b's, giving %v", objs[1].P[0])
+	}
+
+	if objs := NewNode(Object{Name: "empty"}).Flatten(m); objs != nil {
+		t.Errorf("flattening a node with no points gave %v", objs)
+	}
+}
+
+func BenchmarkFlatten(b *testing.B) {
+	root := benchScene(100000, 10)
+	m := geometry.RotateAroundY(geometry.RotateAroundX(geometry.Identity(), 30), 45)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		root.Flatten(m)
+	}
+}
@@ -0,0 +1,78 @@
+package scene
+
+import (
+	"fmt"
+	"math"
+)
+
+var (
+	// The grid planes which can be drawn in the world space, named after the axes they lie along.  Unlike the
+	// background grid, the XY one reaches only as far as the axes, and is drawn and exported with the scene
+	GridPlanes = []string{"xy", "xz", "yz"}
+)
+
+// Returns a grid on one of the planes through the origin, as lines spaced at the given interval so they meet the axis
+// tick marks.  The lines run the length of the axes along the plane, a along its first axis and b along its second,
+// so the grid turns with the scene and shows where things are in 3D.  It's named "grid" and the plane, eg "gridxz"
+func GridPlane(plane string, a float64, b float64, interval float64) (o Object, err error) {
+	if !ValidGridPlane(plane) {
+		return o, fmt.Errorf("unknown grid plane '%s' (the choices are xy, xz, and yz)", plane)
+	}
+	o = Object{
+		C:           "grey",
+		DrawOrder:   0,
+		Name:        "grid" + plane,
+		Tags:        []string{"grid"},
+		Alpha:       0.35,
+		LineWidth:   1,
+		DashPattern: []float64{1, 3},
+	}
+	if !(interval > 0) {
+		return o, nil
+	}
+
+	// Points on the plane, from its two co-ordinates
+	at := func(u, v float64) Point {
+		switch plane {
+		case "xy":
+			return Point{X: u, Y: v}
+		case "xz":
+			return Point{X: u, Z: v}
+		}
+		return Point{Y: u, Z: v}
+	}
+	line := func(p, q Point) {
+		o.P = append(o.P, p, q)
+		o.E = append(o.E, Edge{len(o.P) - 2, len(o.P) - 1})
+	}
+	n := int(math.Min(math.Floor(a/interval), maxTicks))
+	for i := -n; i <= n; i++ {
+		line(at(float64(i)*interval, -b), at(float64(i)*interval, b))
+	}
+	n = int(math.Min(math.Floor(b/interval), maxTicks))
+	for i := -n; i <= n; i++ {
+		line(at(-a, float64(i)*interval), at(a, float64(i)*interval))
+	}
+	return o, nil
+}
+
+// Returns the lengths of a grid plane along its first and second axes, given the lengths of the X, Y, and Z axes
+func GridPlaneLengths(plane string, x float64, y float64, z float64) (a float64, b float64) {
+	switch plane {
+	case "xy":
+		return x, y
+	case "yz":
+		return y, z
+	}
+	return x, z
+}
+
+// Returns true if a grid plane is one of the ones which can be drawn
+func ValidGridPlane(plane string) bool {
+	for _, p := range GridPlanes {
+		if p == plane {
+			return true
+		}
+	}
+	return false
+}
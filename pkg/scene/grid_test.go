@@ -0,0 +1,40 @@
+package scene
+
+import "testing"
+
+func TestGridPlane(t *testing.T) {
+	tests := []struct {
+		plane   string
+		x, y, z float64
+		flat    func(p Point) bool // True for the points lying on the plane
+	}{
+		{"xy", 4, 2, 3, func(p Point) bool { return p.Z == 0 && p.X >= -4 && p.X <= 4 && p.Y >= -2 && p.Y <= 2 }},
+		{"xz", 4, 2, 3, func(p Point) bool { return p.Y == 0 && p.X >= -4 && p.X <= 4 && p.Z >= -3 && p.Z <= 3 }},
+		{"yz", 4, 2, 3, func(p Point) bool { return p.X == 0 && p.Y >= -2 && p.Y <= 2 && p.Z >= -3 && p.Z <= 3 }},
+	}
+	for _, tc := range tests {
+		a, b := GridPlaneLengths(tc.plane, tc.x, tc.y, tc.z)
+		o, err := GridPlane(tc.plane, a, b, 1)
+		if err != nil {
+			t.Errorf("%s: %v", tc.plane, err)
+			continue
+		}
+		if o.Name != "grid"+tc.plane || !HasTag(o, "grid") {
+			t.Errorf("%s: named %q with tags %v", tc.plane, o.Name, o.Tags)
+		}
+
+		// A line for every tick mark along each axis, from one end of the plane to the other
+		if want := int(2*a+1) + int(2*b+1); len(o.E) != want {
+			t.Errorf("%s: %d lines, want %d", tc.plane, len(o.E), want)
+		}
+		for _, p := range o.P {
+			if !tc.flat(p) {
+				t.Errorf("%s: point %+v is off the plane", tc.plane, p)
+				break
+			}
+		}
+	}
+	if _, err := GridPlane("xw", 1, 1, 1); err == nil {
+		t.Error("an unknown plane gave no error")
+	}
+}
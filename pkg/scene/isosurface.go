@@ -0,0 +1,179 @@
+package scene
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+)
+
+const (
+	maxIsoTriangles = 500000 // Most triangles an isosurface can have, to keep it to a size which can be drawn
+)
+
+var (
+	// The six tetrahedra each grid cell is split into, by the indexes of their corners, which count 1 along X, 2 along
+	// Y, and 4 along Z.  They all share the diagonal from corner 0 to corner 7, so neighbouring cells' faces match up
+	cellTetrahedra = [6][4]int{{0, 1, 3, 7}, {0, 3, 2, 7}, {0, 2, 6, 7}, {0, 6, 4, 7}, {0, 4, 5, 7}, {0, 5, 1, 7}}
+)
+
+// Draws the surface where an expression of x, y, and z equals "level" (0 by default), eg "x^2 + y^2 + z^2 - 9".  It
+// can also be given as an equation, eg "x^2 + y^2 + z^2 = 9".  It's worked out over a cube from "min" to "max" along
+// each axis, with "n" grid cells along each side
+func generateIsosurface(p Params) (o Object, err error) {
+	f, level, min, max, n, err := IsosurfaceParams(p)
+	if err != nil {
+		return o, err
+	}
+	x, y, z, err := Isosurface(f, level, min, max, n)
+	if err != nil {
+		return o, err
+	}
+	return Triangles(x, y, z)
+}
+
+// Works out the surface where f(x, y, z) = level, over a cube from min to max along each axis split into n grid cells
+// per side.  It uses marching tetrahedra, the variant of marching cubes which splits each cell into six tetrahedra,
+// so it needs no lookup tables and leaves no holes where cells could be split more than one way.  Returns the
+// corners of the triangles, three at a time.  Each triangle's corners go anticlockwise seen from the side where f is
+// larger than the level
+func Isosurface(f expr.Node, level float64, min float64, max float64, n int) (x []float64, y []float64, z []float64,
+	err error) {
+	cell := (max - min) / float64(n)
+	side := n + 1
+	vals := make([]float64, side*side*side)
+	vars := map[string]float64{}
+	for i := 0; i <= n; i++ {
+		for j := 0; j <= n; j++ {
+			for k := 0; k <= n; k++ {
+				vars["x"], vars["y"], vars["z"] = min+float64(i)*cell, min+float64(j)*cell, min+float64(k)*cell
+				vals[(i*side+j)*side+k] = f.Eval(vars) - level
+			}
+		}
+	}
+
+	var corner [8]Point
+	var v [8]float64
+	for i := 0; i < n; i++ {
+		for j := 0; j < n; j++ {
+			for k := 0; k < n; k++ {
+				for c := 0; c < 8; c++ {
+					ci, cj, ck := i+(c&1), j+(c>>1&1), k+(c>>2&1)
+					corner[c] = Point{X: min + float64(ci)*cell, Y: min + float64(cj)*cell, Z: min + float64(ck)*cell}
+					v[c] = vals[(ci*side+cj)*side+ck]
+				}
+				for _, t := range cellTetrahedra {
+					var tp [4]Point
+					var tv [4]float64
+					for m, c := range t {
+						tp[m], tv[m] = corner[c], v[c]
+					}
+					for _, tri := range tetrahedronTriangles(tp, tv) {
+						for _, q := range tri {
+							x, y, z = append(x, q.X), append(y, q.Y), append(z, q.Z)
+						}
+					}
+				}
+				if len(x) > maxIsoTriangles*3 {
+					return nil, nil, nil, fmt.Errorf("the surface has more than %d triangles, so needs a smaller grid",
+						maxIsoTriangles)
+				}
+			}
+		}
+	}
+	return x, y, z, nil
+}
+
+// Reads the parameters for an isosurface, the same way the generator does: the expression or equation "src", the
+// "level" it's drawn at, and the grid
+func IsosurfaceParams(p Params) (f expr.Node, level float64, min float64, max float64, n int, err error) {
+	src := p.String("src")
+	if i := strings.Index(src, "="); i >= 0 {
+		src = "(" + src[:i] + ") - (" + src[i+1:] + ")"
+	}
+	if f, err = expr.ParseVars(src, "x", "y", "z"); err != nil {
+		return
+	}
+	level = floatParam(p, "level", 0)
+	min, max, n, err = gridParams(p)
+	return
+}
+
+// Returns the triangles where the surface crosses a tetrahedron, from the values at its corners less the level.  It
+// crosses every edge joining a corner below the level to one above, giving a triangle when one corner is on its own
+// side, or two making up a quad when they're split two and two.  The triangles face the corners above the level
+func tetrahedronTriangles(p [4]Point, v [4]float64) (tris [][3]Point) {
+	var below, above []int
+	for m := range v {
+		if !Finite(v[m]) {
+			return nil
+		}
+		if v[m] < 0 {
+			below = append(below, m)
+		} else {
+			above = append(above, m)
+		}
+	}
+	if len(below) == 0 || len(above) == 0 {
+		return nil
+	}
+
+	// The point on the edge from corner a to b where the value crosses the level
+	cross := func(a, b int) Point {
+		t := v[a] / (v[a] - v[b])
+		return Point{X: p[a].X + t*(p[b].X-p[a].X), Y: p[a].Y + t*(p[b].Y-p[a].Y), Z: p[a].Z + t*(p[b].Z-p[a].Z)}
+	}
+	switch {
+	case len(below) == 1:
+		a := below[0]
+		tris = append(tris, [3]Point{cross(a, above[0]), cross(a, above[1]), cross(a, above[2])})
+	case len(above) == 1:
+		a := above[0]
+		tris = append(tris, [3]Point{cross(a, below[0]), cross(a, below[1]), cross(a, below[2])})
+	default:
+		a, b, c, d := below[0], below[1], above[0], above[1]
+		ac, ad, bd, bc := cross(a, c), cross(a, d), cross(b, d), cross(b, c)
+		tris = append(tris, [3]Point{ac, ad, bd}, [3]Point{ac, bd, bc})
+	}
+
+	// Turn the triangles to face the corners above the level
+	var gx, gy, gz float64
+	for _, m := range above {
+		gx, gy, gz = gx+p[m].X/float64(len(above)), gy+p[m].Y/float64(len(above)), gz+p[m].Z/float64(len(above))
+	}
+	for _, m := range below {
+		gx, gy, gz = gx-p[m].X/float64(len(below)), gy-p[m].Y/float64(len(below)), gz-p[m].Z/float64(len(below))
+	}
+	for i, t := range tris {
+		ux, uy, uz := t[1].X-t[0].X, t[1].Y-t[0].Y, t[1].Z-t[0].Z
+		wx, wy, wz := t[2].X-t[0].X, t[2].Y-t[0].Y, t[2].Z-t[0].Z
+		nx, ny, nz := uy*wz-uz*wy, uz*wx-ux*wz, ux*wy-uy*wx
+		if nx*gx+ny*gy+nz*gz < 0 {
+			tris[i][1], tris[i][2] = t[2], t[1]
+		}
+	}
+	return tris
+}
+
+// Returns an object made of triangles, from the co-ordinates of their corners three at a time, such as an isosurface
+// worked out by the compute worker.  They're flat shaded, so the shape shows
+func Triangles(x []float64, y []float64, z []float64) (o Object, err error) {
+	if len(x) != len(y) || len(x) != len(z) || len(x)%3 != 0 {
+		return o, fmt.Errorf("the triangles need three corners each, with x, y, and z for every corner")
+	}
+	if len(x) == 0 {
+		return o, fmt.Errorf("the surface doesn't pass through the range")
+	}
+	o.Shading = "flat"
+	o.P = make([]Point, len(x))
+	for i := range x {
+		if !Finite(x[i]) || !Finite(y[i]) || !Finite(z[i]) {
+			return Object{}, fmt.Errorf("the triangles' corners need to be numbers")
+		}
+		o.P[i] = Point{X: x[i], Y: y[i], Z: z[i]}
+	}
+	for i := 0; i < len(x); i += 3 {
+		o.S = append(o.S, Surface{i, i + 1, i + 2})
+	}
+	return o, nil
+}
@@ -0,0 +1,92 @@
+package scene
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/justinclift/wasmGraph4/pkg/expr"
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+)
+
+const (
+	MarkerSize = 6 // Distance from the centre of a marker shape to its corners, in pixels
+)
+
+// Expands a label template, replacing %x, %y, and %z with the given co-ordinates.  %% gives a literal percent sign
+func ExpandLabel(tmpl string, x float64, y float64, z float64) string {
+	if !strings.Contains(tmpl, "%") {
+		return tmpl
+	}
+	var b strings.Builder
+	for i := 0; i < len(tmpl); i++ {
+		if tmpl[i] != '%' || i == len(tmpl)-1 {
+			b.WriteByte(tmpl[i])
+			continue
+		}
+		switch tmpl[i+1] {
+		case 'x':
+			b.WriteString(FormatCoord(x))
+		case 'y':
+			b.WriteString(FormatCoord(y))
+		case 'z':
+			b.WriteString(FormatCoord(z))
+		case '%':
+			b.WriteByte('%')
+		default:
+			b.WriteByte('%')
+			continue
+		}
+		i++
+	}
+	return b.String()
+}
+
+// Formats a co-ordinate for display, to at most 3 decimal places, with the current locale's decimal separator
+func FormatCoord(v float64) string {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return "undefined"
+	}
+	v = math.Round(v*1000) / 1000
+	if v == 0 {
+		v = 0 // Avoid showing "-0"
+	}
+	return localise(strconv.FormatFloat(v, 'f', -1, 64))
+}
+
+// Returns an equation in the y = f(x) form, typeset for display as in "y = 3x² − 1"
+func FormatEquation(n expr.Node) string {
+	return "y = " + expr.Pretty(n)
+}
+
+// Returns the corners of a marker shape centred on the given screen co-ordinates.  Returns nothing for plain dots
+func MarkerPoints(shape string, x float64, y float64) [][2]float64 {
+	s := float64(MarkerSize)
+	switch shape {
+	case "up":
+		return [][2]float64{{x, y - s}, {x + s, y + s*0.7}, {x - s, y + s*0.7}}
+	case "down":
+		return [][2]float64{{x, y + s}, {x + s, y - s*0.7}, {x - s, y - s*0.7}}
+	case "diamond":
+		return [][2]float64{{x, y - s}, {x + s, y}, {x, y + s}, {x - s, y}}
+	case "square":
+		h := s * math.Sqrt2 / 2
+		return [][2]float64{{x - h, y - h}, {x + h, y - h}, {x + h, y + h}, {x - h, y + h}}
+	}
+	return nil
+}
+
+// Returns the label to show for a point, expanding any template with the points' graph co-ordinates.  Points without
+// their own label use the objects' label template, if it has one.  The inverse world transform is used to recover the
+// graph co-ordinates from the world space ones
+func PointLabel(o Object, p Point, inv geometry.Matrix) string {
+	l := p.Label
+	if l == "" {
+		l = o.LabelTemplate
+	}
+	if !strings.Contains(l, "%") {
+		return l
+	}
+	g := Transform(inv, p)
+	return ExpandLabel(l, g.X, g.Y, g.Z)
+}
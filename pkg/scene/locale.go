@@ -0,0 +1,106 @@
+package scene
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A way of writing numbers, for a language
+type Locale struct {
+	Code    string // Language code, as in the browser's navigator.language
+	Name    string // The language's own name for itself
+	Decimal string // Decimal separator
+}
+
+var (
+	// The locales numbers can be shown in
+	Locales = []Locale{
+		{Code: "en", Name: "English", Decimal: "."},
+		{Code: "de", Name: "Deutsch", Decimal: ","},
+		{Code: "es", Name: "Español", Decimal: ","},
+		{Code: "fr", Name: "Français", Decimal: ","},
+		{Code: "it", Name: "Italiano", Decimal: ","},
+		{Code: "nl", Name: "Nederlands", Decimal: ","},
+		{Code: "pl", Name: "Polski", Decimal: ","},
+		{Code: "pt", Name: "Português", Decimal: ","},
+		{Code: "ru", Name: "Русский", Decimal: ","},
+	}
+
+	locale = Locales[0] // The locale numbers are currently shown in
+)
+
+// Returns the locale numbers are currently shown in
+func CurrentLocale() Locale {
+	return locale
+}
+
+// Returns the locale for a language code such as "de" or "de-AT", ignoring case and any region
+func FindLocale(code string) (Locale, bool) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-_"); i >= 0 {
+		code = code[:i]
+	}
+	for _, l := range Locales {
+		if l.Code == code {
+			return l, true
+		}
+	}
+	return Locale{}, false
+}
+
+// Formats a number in full, with the current locale's decimal separator
+func FormatNumber(v float64) string {
+	return localise(strconv.FormatFloat(v, 'g', -1, 64))
+}
+
+// Formats co-ordinates as a point, eg "(1.5, 2)".  Where the decimal separator is a comma, the co-ordinates are
+// separated by semicolons instead, eg "(1,5; 2)"
+func FormatPoint(v ...float64) string {
+	sep := ", "
+	if locale.Decimal == "," {
+		sep = "; "
+	}
+	var c []string
+	for _, j := range v {
+		c = append(c, FormatCoord(j))
+	}
+	return "(" + strings.Join(c, sep) + ")"
+}
+
+// Swaps the decimal point in a formatted number for the current locale's decimal separator
+func localise(s string) string {
+	if locale.Decimal == "." {
+		return s
+	}
+	return strings.Replace(s, ".", locale.Decimal, 1)
+}
+
+// Parses a number entered by the user, accepting either a dot or a comma as the decimal separator whatever the
+// locale, eg "1.5" or "1,5"
+func ParseNumber(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return v, nil
+	}
+	if strings.Count(s, ",") == 1 && !strings.Contains(s, ".") {
+		if v, err := strconv.ParseFloat(strings.Replace(s, ",", ".", 1), 64); err == nil {
+			return v, nil
+		}
+	}
+	return 0, fmt.Errorf("'%s' isn't a number", s)
+}
+
+// Sets the locale numbers are shown in, from its language code
+func SetLocale(code string) error {
+	l, ok := FindLocale(code)
+	if !ok {
+		var codes []string
+		for _, j := range Locales {
+			codes = append(codes, j.Code)
+		}
+		return fmt.Errorf("unknown locale '%s' (the choices are %s)", code, strings.Join(codes, ", "))
+	}
+	locale = l
+	return nil
+}
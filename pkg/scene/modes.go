@@ -0,0 +1,61 @@
+package scene
+
+var (
+	// The render modes, in the order the app cycles through them
+	Modes = []string{"points", "wireframe", "solid"}
+)
+
+// Returns the objects changed to be drawn in their render modes.  A mode given here is used for every object instead
+// of its own, except for the axes, their tick marks, and the grid planes, which are always drawn as they are
+func ApplyModes(objs []Object, mode string) []Object {
+	for i, o := range objs {
+		m := o.Mode
+		if mode != "" && !HasTag(o, "axes") && !HasTag(o, "grid") {
+			m = mode
+		}
+		if m != "" {
+			objs[i] = WithMode(o, m)
+		}
+	}
+	return objs
+}
+
+// Returns true if a render mode is one of the known ones, or empty for drawing the object as it is
+func ValidMode(mode string) bool {
+	if mode == "" {
+		return true
+	}
+	for _, m := range Modes {
+		if m == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns an object changed to be drawn in a render mode: "points" as a dot at each of its points, "wireframe" as its
+// edges (or the outlines of its surfaces, when it has no edges), and "solid" as its filled surfaces alone.  Curves and
+// objects without surfaces are the same in the last two.  The object's slices are shared, not changed
+func WithMode(o Object, mode string) Object {
+	switch mode {
+	case "points":
+		o.E, o.S, o.Scatter, o.Marker = nil, nil, true, ""
+	case "wireframe":
+		if len(o.S) == 0 {
+			break
+		}
+		if len(o.E) == 0 {
+			for _, s := range o.S {
+				for i, n := range s {
+					o.E = append(o.E, Edge{n, s[(i+1)%len(s)]})
+				}
+			}
+		}
+		o.S = nil
+	case "solid":
+		if len(o.S) > 0 {
+			o.E = nil
+		}
+	}
+	return o
+}
@@ -0,0 +1,252 @@
+// Package scene holds the objects drawn on the graph, and the saved scene format.  It doesn't depend on the browser,
+// so scenes can be built and rendered headlessly too
+package scene
+
+import (
+	"fmt"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+)
+
+const (
+	maxLineWidth = 50  // Widest line, and largest point radius, an object can be drawn with, in pixels
+	maxDash      = 500 // Longest dash or gap in a dash pattern, in pixels
+)
+
+// A point of an object, with an optional label
+type Point struct {
+	Label      string
+	LabelAlign string
+	X          float64
+	Y          float64
+	Z          float64
+}
+
+// The indexes of the two points joined by an edge
+type Edge []int
+
+// The indexes of the points around a filled surface, in order
+type Surface []int
+
+// Something drawn on the graph: the axes, a curve, a set of markers, or an object added through the API
+type Object struct {
+	C             string // Colour of the object
+	P             []Point
+	E             []Edge    // List of points to connect by edges
+	S             []Surface // List of points to connect in order, to create a surface
+	DrawOrder     int       // Draw order for the object
+	Name          string
+	LabelFont     string // Font for the point labels.  Defaults to "bold 14px serif"
+	Equation      string // Equation the object was generated from, if any.  Shown in the legend
+	LabelTemplate string // Label template for points without their own label, eg "(%x, %y)"
+	Scatter       bool   // Draw the points as separate dots, rather than as a curve through them
+	Marker        string // Shape for scattered points: "up" or "down" triangles, "diamond", "square", or a dot
+
+	Tags   []string          `json:",omitempty"` // Kinds of object it is, eg "derivatives", for filtering by
+	Meta   map[string]string `json:",omitempty"` // Anything else worth knowing about it, shown when it's selected
+	Hidden bool              `json:",omitempty"` // Kept in the scene, but not drawn
+
+	// What to do with surfaces turned away from the viewer: "cull" leaves them out, "shade" draws them darker, and
+	// anything else draws them like the rest.  Surfaces face the viewer when their points go anticlockwise on screen
+	Backface string `json:",omitempty"`
+
+	// How surfaces are coloured: "flat" shades each one by how directly the light falls on it, and anything else fills
+	// them all in the object's colour
+	Shading string `json:",omitempty"`
+
+	// How the object is drawn: "points" for just its points, "wireframe" for its edges, "solid" for its filled
+	// surfaces, and anything else for all of them.  See WithMode
+	Mode string `json:",omitempty"`
+
+	// How opaque the object is, from 0 to 1, so things behind it show through.  Missing (or 0) is fully opaque, as
+	// Hidden is there for leaving an object out
+	Alpha float64 `json:",omitempty"`
+
+	// How its lines and points are drawn, in pixels.  Missing (or 0) uses the usual sizes, and no dash pattern draws
+	// solid lines.  The dash pattern alternates the lengths of the dashes and the gaps between them, as for the
+	// canvas setLineDash()
+	LineWidth   float64   `json:",omitempty"`
+	DashPattern []float64 `json:",omitempty"`
+	PointRadius float64   `json:",omitempty"`
+
+	// A number for each point, such as the slope of the curve there.  When there's one for every point, curves are
+	// coloured along their length by them instead of in C, and ValuesLabel says what they are for the colour bar
+	Values      []float64 `json:",omitempty"`
+	ValuesLabel string    `json:",omitempty"`
+
+	// Draw the point labels on boxes in the background colour, so they stand out from what's behind them
+	LabelBox bool `json:",omitempty"`
+}
+
+// Returns true if the object has the given tag
+func HasTag(o Object, tag string) bool {
+	for _, t := range o.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the objects which aren't hidden
+func Visible(objs []Object) (v []Object) {
+	for _, o := range objs {
+		if !o.Hidden {
+			v = append(v, o)
+		}
+	}
+	return
+}
+
+// Returns how opaque an object is drawn, from 0 to 1
+func Opacity(o Object) float64 {
+	if o.Alpha > 0 && o.Alpha < 1 {
+		return o.Alpha
+	}
+	return 1
+}
+
+// Returns true for objects drawn as a line through their points, such as the graph and its derivatives.  Objects
+// with edges or surfaces (like the axes) are drawn using those instead, and scattered points as separate dots
+func IsCurve(o Object) bool {
+	return len(o.E) == 0 && len(o.S) == 0 && !o.Scatter
+}
+
+// Checks a line width, point radius, and dash pattern are ones which can be drawn.  0 and an empty pattern are fine,
+// meaning the usual
+func CheckLineStyle(width float64, radius float64, dash []float64) error {
+	if !(width >= 0 && width <= maxLineWidth) || !(radius >= 0 && radius <= maxLineWidth) {
+		return fmt.Errorf("the line width and point radius need to be from 0 to %d", maxLineWidth)
+	}
+	total := 0.0
+	for _, d := range dash {
+		if !(d >= 0 && d <= maxDash) {
+			return fmt.Errorf("the dash pattern needs lengths from 0 to %d", maxDash)
+		}
+		total += d
+	}
+	if len(dash) > 0 && total == 0 {
+		return fmt.Errorf("the dash pattern needs some lengths which aren't 0")
+	}
+	return nil
+}
+
+// Returns true if a curve is coloured by its values, rather than in its own colour
+func HasGradient(o Object) bool {
+	return len(o.Values) > 0 && len(o.Values) == len(o.P)
+}
+
+// Returns the width to draw the lines of an object, or the given default when it doesn't have one
+func LineWidth(o Object, def float64) float64 {
+	if o.LineWidth > 0 {
+		return o.LineWidth
+	}
+	return def
+}
+
+// Returns the radius to draw the points of an object, or the given default when it doesn't have one
+func PointRadius(o Object, def float64) float64 {
+	if o.PointRadius > 0 {
+		return o.PointRadius
+	}
+	return def
+}
+
+// Returns the font to use for the point labels of an object
+func LabelFont(o Object) string {
+	if o.LabelFont != "" {
+		return o.LabelFont
+	}
+	return "bold 14px serif"
+}
+
+// Transform the XYZ co-ordinates using the values from the transformation matrix
+func Transform(m geometry.Matrix, p Point) (t Point) {
+	top0 := m[0]
+	top1 := m[1]
+	top2 := m[2]
+	top3 := m[3]
+	upperMid0 := m[4]
+	upperMid1 := m[5]
+	upperMid2 := m[6]
+	upperMid3 := m[7]
+	lowerMid0 := m[8]
+	lowerMid1 := m[9]
+	lowerMid2 := m[10]
+	lowerMid3 := m[11]
+	//bot0 := m[12] // The fourth row values can be ignored for 3D matrices
+	//bot1 := m[13]
+	//bot2 := m[14]
+	//bot3 := m[15]
+
+	t.Label = p.Label
+	t.LabelAlign = p.LabelAlign
+	t.X = (top0 * p.X) + (top1 * p.Y) + (top2 * p.Z) + top3
+	t.Y = (upperMid0 * p.X) + (upperMid1 * p.Y) + (upperMid2 * p.Z) + upperMid3
+	t.Z = (lowerMid0 * p.X) + (lowerMid1 * p.Y) + (lowerMid2 * p.Z) + lowerMid3
+	return
+}
+
+// Returns copies of the given objects, with their points transformed by the matrix
+func TransformObjects(objs []Object, m geometry.Matrix) []Object {
+	t := make([]Object, len(objs))
+	for i, o := range objs {
+		t[i] = o
+		t[i].P = make([]Point, len(o.P))
+		transformPoints(m, o.P, t[i].P)
+	}
+	return t
+}
+
+// Transforms the points in src by the matrix, putting the results in dst, which needs to be at least as long.  This
+// is Transform for a whole object at once, reading the matrix just the once and not copying the points in and out
+func transformPoints(m geometry.Matrix, src []Point, dst []Point) {
+	m0, m1, m2, m3 := m[0], m[1], m[2], m[3]
+	m4, m5, m6, m7 := m[4], m[5], m[6], m[7]
+	m8, m9, m10, m11 := m[8], m[9], m[10], m[11]
+	for j := range src {
+		p, t := &src[j], &dst[j]
+		t.Label, t.LabelAlign = p.Label, p.LabelAlign
+		t.X = m0*p.X + m1*p.Y + m2*p.Z + m3
+		t.Y = m4*p.X + m5*p.Y + m6*p.Z + m7
+		t.Z = m8*p.X + m9*p.Y + m10*p.Z + m11
+	}
+}
+
+// Checks an object for problems that would break rendering, such as edges referring to points that don't exist
+func Validate(ob Object) error {
+	if ob.Name == "" {
+		return fmt.Errorf("the object needs a name")
+	}
+	if len(ob.P) == 0 {
+		return fmt.Errorf("object '%s' has no points", ob.Name)
+	}
+	for i, e := range ob.E {
+		if len(e) != 2 {
+			return fmt.Errorf("edge %d of object '%s' needs exactly 2 points", i, ob.Name)
+		}
+		for _, n := range e {
+			if n < 0 || n >= len(ob.P) {
+				return fmt.Errorf("edge %d of object '%s' refers to missing point %d", i, ob.Name, n)
+			}
+		}
+	}
+	if !(ob.Alpha >= 0 && ob.Alpha <= 1) {
+		return fmt.Errorf("the alpha of object '%s' needs to be from 0 to 1", ob.Name)
+	}
+	if err := CheckLineStyle(ob.LineWidth, ob.PointRadius, ob.DashPattern); err != nil {
+		return fmt.Errorf("object '%s': %v", ob.Name, err)
+	}
+	if !ValidMode(ob.Mode) {
+		return fmt.Errorf("object '%s' has an unknown mode '%s' (the choices are points, wireframe, and solid)",
+			ob.Name, ob.Mode)
+	}
+	for i, f := range ob.S {
+		for _, n := range f {
+			if n < 0 || n >= len(ob.P) {
+				return fmt.Errorf("surface %d of object '%s' refers to missing point %d", i, ob.Name, n)
+			}
+		}
+	}
+	return nil
+}
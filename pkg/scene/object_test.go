@@ -0,0 +1,52 @@
+package scene
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/justinclift/wasmGraph4/pkg/geometry"
+)
+
+func TestValidate(t *testing.T) {
+	tri := []Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 0, Y: 1}}
+	tests := []struct {
+		name string
+		o    Object
+		err  string // Part of the error wanted, or "" for none
+	}{
+		{"valid", Object{Name: "tri", P: tri, E: []Edge{{0, 1}, {1, 2}}, S: []Surface{{0, 1, 2}}}, ""},
+		{"styled", Object{Name: "tri", P: tri, Alpha: 0.5, LineWidth: 3, DashPattern: []float64{4, 2}, Mode: "solid"},
+			""},
+		{"no name", Object{P: tri}, "needs a name"},
+		{"no points", Object{Name: "empty"}, "has no points"},
+		{"short edge", Object{Name: "tri", P: tri, E: []Edge{{0}}}, "exactly 2 points"},
+		{"missing edge point", Object{Name: "tri", P: tri, E: []Edge{{0, 3}}}, "missing point 3"},
+		{"negative edge point", Object{Name: "tri", P: tri, E: []Edge{{-1, 0}}}, "missing point -1"},
+		{"missing surface point", Object{Name: "tri", P: tri, S: []Surface{{0, 1, 5}}}, "missing point 5"},
+		{"alpha", Object{Name: "tri", P: tri, Alpha: 1.5}, "alpha"},
+		{"line width", Object{Name: "tri", P: tri, LineWidth: -1}, "line width"},
+		{"dash", Object{Name: "tri", P: tri, DashPattern: []float64{0, 0}}, "dash pattern"},
+		{"mode", Object{Name: "tri", P: tri, Mode: "sketch"}, "unknown mode"},
+	}
+	for _, tc := range tests {
+		err := Validate(tc.o)
+		switch {
+		case tc.err == "" && err != nil:
+			t.Errorf("%s: %v", tc.name, err)
+		case tc.err != "" && err == nil:
+			t.Errorf("%s: no error, want one about %q", tc.name, tc.err)
+		case tc.err != "" && !strings.Contains(err.Error(), tc.err):
+			t.Errorf("%s: %q, want one about %q", tc.name, err, tc.err)
+		}
+	}
+}
+
+func BenchmarkTransformObjects(b *testing.B) {
+	objs := benchScene(100000, 10).Flatten(geometry.Identity())
+	m := geometry.RotateAroundY(geometry.RotateAroundX(geometry.Identity(), 30), 45)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		TransformObjects(objs, m)
+	}
+}
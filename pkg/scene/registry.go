@@ -0,0 +1,61 @@
+package scene
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// The flattened objects of a scene, kept sorted into draw order and looked up by name.  Names are unique, so an
+// object can be found without knowing where it's got to in the list
+type Registry struct {
+	objs  []Object       // In draw order
+	index map[string]int // Position of each named object in objs
+}
+
+// Returns the object with the given name
+func (r *Registry) Get(name string) (Object, bool) {
+	if i, ok := r.index[name]; ok {
+		return r.objs[i], true
+	}
+	return Object{}, false
+}
+
+// Returns the number of objects
+func (r *Registry) Len() int {
+	return len(r.objs)
+}
+
+// Returns a registry of objects, sorted into draw order.  Objects with the same draw order stay in the order they're
+// given in, so they don't flicker as they overwrite each other.  If two objects have the same name, the first is kept
+// and an error returned along with the registry
+func NewRegistry(objs []Object) (*Registry, error) {
+	r := &Registry{index: make(map[string]int, len(objs))}
+	var dup []string
+	seen := make(map[string]bool, len(objs))
+	for _, o := range objs {
+		if o.Name != "" && seen[o.Name] {
+			dup = append(dup, o.Name)
+			continue
+		}
+		seen[o.Name] = true
+		r.objs = append(r.objs, o)
+	}
+	sort.SliceStable(r.objs, func(i, j int) bool {
+		return r.objs[i].DrawOrder < r.objs[j].DrawOrder
+	})
+	for i, o := range r.objs {
+		if o.Name != "" {
+			r.index[o.Name] = i
+		}
+	}
+	if len(dup) > 0 {
+		return r, fmt.Errorf("there's more than one object called '%s'", strings.Join(dup, "', '"))
+	}
+	return r, nil
+}
+
+// Returns the objects in draw order.  The slice is the registry's own, so mustn't be added to or reordered
+func (r *Registry) Objects() []Object {
+	return r.objs
+}
@@ -0,0 +1,66 @@
+package scene
+
+import (
+	"testing"
+)
+
+func TestNewRegistry(t *testing.T) {
+	r, err := NewRegistry([]Object{
+		{Name: "c", DrawOrder: 2},
+		{Name: "a", DrawOrder: 1},
+		{DrawOrder: 1},
+		{Name: "b", DrawOrder: 1},
+		{DrawOrder: 0},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, o := range r.Objects() {
+		names = append(names, o.Name)
+	}
+
+	// Sorted by draw order, keeping the order they were given in for the same draw order.  Unnamed objects don't
+	// clash with each other
+	want := []string{"", "a", "", "b", "c"}
+	if len(names) != len(want) {
+		t.Fatalf("got objects %q, want %q", names, want)
+	}
+	for i := range want {
+		if names[i] != want[i] {
+			t.Fatalf("got objects %q, want %q", names, want)
+		}
+	}
+	if r.Len() != 5 {
+		t.Errorf("Len = %d, want 5", r.Len())
+	}
+	if o, ok := r.Get("b"); !ok || o.Name != "b" {
+		t.Errorf(`Get("b") = %v, %v`, o, ok)
+	}
+	if _, ok := r.Get("missing"); ok {
+		t.Error(`Get("missing") found an object`)
+	}
+}
+
+func TestNewRegistryDuplicates(t *testing.T) {
+	r, err := NewRegistry([]Object{
+		{Name: "f1", C: "red"},
+		{Name: "f2"},
+		{Name: "f1", C: "blue"},
+		{Name: "f2"},
+	})
+	if err == nil {
+		t.Fatal("duplicate names gave no error")
+	}
+	if want := "there's more than one object called 'f1', 'f2'"; err.Error() != want {
+		t.Errorf("error %q, want %q", err, want)
+	}
+
+	// The first of each name is kept
+	if r.Len() != 2 {
+		t.Errorf("Len = %d, want 2", r.Len())
+	}
+	if o, ok := r.Get("f1"); !ok || o.C != "red" {
+		t.Errorf(`Get("f1") = %v, %v, want the first one`, o, ok)
+	}
+}
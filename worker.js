@@ -0,0 +1,12 @@
+// Starts the compute worker's wasm.  Tasks posted before it's ready are queued for it to pick up
+importScripts("wasm_exec.js");
+self.pending = [];
+self.onmessage = function(e) {
+    self.pending.push(e.data);
+};
+const go = new Go();
+WebAssembly.instantiateStreaming(fetch("worker.wasm"), go.importObject).then(res => {
+    go.run(res.instance);
+}).catch(err => {
+    self.postMessage({failed: String(err)});
+});